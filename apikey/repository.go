@@ -0,0 +1,95 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/sqlc"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx pgx.Tx, key *models.APIKey) error
+	GetByPrefix(ctx context.Context, tx pgx.Tx, prefix string) (*models.APIKey, error)
+	Revoke(ctx context.Context, tx pgx.Tx, prefix string) (bool, error)
+	RecordRequest(ctx context.Context, tx pgx.Tx, request *models.APIKeyRequest) error
+}
+
+type repository struct {
+	conn driver.PostgresPool
+}
+
+func NewRepository(conn driver.PostgresPool) Repository {
+	return &repository{conn: conn}
+}
+
+// Create 保存新的 API key，並以資料庫產生的 ID 與建立時間更新 key
+func (r *repository) Create(ctx context.Context, tx pgx.Tx, key *models.APIKey) error {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	var tenantID *string
+	if key.TenantID != "" {
+		tenantID = &key.TenantID
+	}
+
+	sqlcAPIKey, err := sqlc.New(r.conn).WithTx(tx).CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
+		Name:     key.Name,
+		Prefix:   key.Prefix,
+		Hash:     key.Hash,
+		Scopes:   scopes,
+		TenantID: tenantID,
+	})
+	if err != nil {
+		return err
+	}
+
+	key.ConvertFromSQLCAPIKey(sqlcAPIKey)
+	return nil
+}
+
+// GetByPrefix 以 key 的前綴查詢，不存在時回傳 nil
+func (r *repository) GetByPrefix(ctx context.Context, tx pgx.Tx, prefix string) (*models.APIKey, error) {
+	sqlcAPIKey, err := sqlc.New(r.conn).WithTx(tx).GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return new(models.APIKey).ConvertFromSQLCAPIKey(sqlcAPIKey), nil
+}
+
+// Revoke 撤銷 key，key 不存在或已撤銷時回傳 false
+func (r *repository) Revoke(ctx context.Context, tx pgx.Tx, prefix string) (bool, error) {
+	rows, err := sqlc.New(r.conn).WithTx(tx).RevokeAPIKey(ctx, prefix)
+	return rows > 0, err
+}
+
+// RecordRequest 寫入一筆請求紀錄並更新 key 的最後使用時間
+func (r *repository) RecordRequest(ctx context.Context, tx pgx.Tx, request *models.APIKeyRequest) error {
+	queries := sqlc.New(r.conn).WithTx(tx)
+	createdAt := pgtype.Timestamptz{Time: request.CreatedAt, Valid: !request.CreatedAt.IsZero()}
+
+	if err := queries.CreateAPIKeyRequest(ctx, sqlc.CreateAPIKeyRequestParams{
+		ApiKeyID:  request.APIKeyID,
+		Method:    request.Method,
+		Path:      request.Path,
+		Status:    int32(request.Status),
+		CreatedAt: createdAt,
+	}); err != nil {
+		return err
+	}
+
+	return queries.TouchAPIKey(ctx, sqlc.TouchAPIKeyParams{
+		ID:         request.APIKeyID,
+		LastUsedAt: createdAt,
+	})
+}
@@ -0,0 +1,152 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+const (
+	// keyPrefix 標示本服務發行的 key，方便在設定檔或紀錄中辨識
+	keyPrefix = "pay_"
+
+	prefixRandomBytes = 6
+	secretRandomBytes = 32
+)
+
+// ErrInvalidAPIKey 代表 key 格式錯誤、不存在、雜湊不符或已被撤銷，不區分原因以免洩漏 key 是否存在
+var ErrInvalidAPIKey = domainerr.New(domainerr.CodeUnauthenticated, "invalid api key")
+
+// ErrAPIKeyNotFound 代表要撤銷的 key 不存在或已撤銷
+var ErrAPIKeyNotFound = domainerr.New(domainerr.CodeNotFound, "api key not found")
+
+type Service interface {
+	Issue(ctx context.Context, name, tenantID string, scopes []models.APIKeyScope) (*models.APIKey, string, error)
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+	Revoke(ctx context.Context, prefix string) error
+	RecordRequest(ctx context.Context, request *models.APIKeyRequest) error
+}
+
+type service struct {
+	repo               Repository
+	transactionManager *driver.TransactionManager
+}
+
+func NewService(repo Repository, tm *driver.TransactionManager) Service {
+	return &service{
+		repo:               repo,
+		transactionManager: tm,
+	}
+}
+
+// Issue 發行新的 API key 並回傳完整的 key
+// key 的格式為 pay_<前綴>_<秘密>，資料庫只保存雜湊，完整的 key 只有這一次能取得
+// tenantID 為空時發行平台 key
+func (s *service) Issue(ctx context.Context, name, tenantID string, scopes []models.APIKeyScope) (*models.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", models.ErrInvalidAPIKeyScope)
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, "", fmt.Errorf("%w: %s", models.ErrInvalidAPIKeyScope, scope)
+		}
+	}
+
+	prefix, err := randomHex(prefixRandomBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(secretRandomBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		Name:     name,
+		Prefix:   keyPrefix + prefix,
+		Scopes:   scopes,
+		TenantID: tenantID,
+	}
+	plaintext := key.Prefix + "_" + secret
+	key.Hash = hashKey(plaintext)
+
+	if err = s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Create(ctx, tx, key)
+	}); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// Authenticate 驗證呼叫端提供的 key，以前綴查詢後用固定時間比較雜湊
+func (s *service) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	sep := strings.LastIndexByte(key, '_')
+	if !strings.HasPrefix(key, keyPrefix) || sep <= len(keyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey *models.APIKey
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		apiKey, err = s.repo.GetByPrefix(ctx, tx, key[:sep])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil || apiKey.Revoked() {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hashKey(key))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return apiKey, nil
+}
+
+// Revoke 撤銷 key，之後以該 key 認證的請求會被拒絕
+func (s *service) Revoke(ctx context.Context, prefix string) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		revoked, err := s.repo.Revoke(ctx, tx, prefix)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return ErrAPIKeyNotFound
+		}
+		return nil
+	})
+}
+
+// RecordRequest 記錄以 key 認證的請求並更新最後使用時間
+func (s *service) RecordRequest(ctx context.Context, request *models.APIKeyRequest) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.RecordRequest(ctx, tx, request)
+	})
+}
+
+// hashKey 計算完整 key 的 SHA-256
+// key 含 256 位元的亂數，不需要 bcrypt 之類的慢雜湊，每個請求驗證的成本也較低
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
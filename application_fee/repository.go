@@ -0,0 +1,80 @@
+package application_fee
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/sqlc"
+)
+
+type Repository interface {
+	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.ApplicationFee, error)
+	Upsert(ctx context.Context, tx pgx.Tx, fee *models.PartialApplicationFee) error
+}
+
+type repository struct {
+	conn driver.PostgresPool
+}
+
+func NewRepository(conn driver.PostgresPool) Repository {
+	return &repository{conn: conn}
+}
+
+func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.ApplicationFee, error) {
+	sqlcFee, err := sqlc.New(r.conn).WithTx(tx).GetApplicationFee(ctx, sqlc.GetApplicationFeeParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application fee: %w", err)
+	}
+
+	return models.NewApplicationFee().ConvertFromSQLCApplicationFee(sqlcFee), nil
+}
+
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, fee *models.PartialApplicationFee) error {
+	const query = `
+    INSERT INTO application_fees (id, account_id, charge_id, originating_transaction_id, amount, amount_refunded, currency, refunded, created_at, updated_at, last_event_at)
+    VALUES (@id, @account_id, @charge_id, @originating_transaction_id, @amount, COALESCE(@amount_refunded, 0), @currency, COALESCE(@refunded, FALSE), COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
+    ON CONFLICT (id) DO UPDATE SET
+        account_id = COALESCE(@account_id, application_fees.account_id),
+        charge_id = COALESCE(@charge_id, application_fees.charge_id),
+        originating_transaction_id = COALESCE(@originating_transaction_id, application_fees.originating_transaction_id),
+        amount = COALESCE(@amount, application_fees.amount),
+        amount_refunded = COALESCE(@amount_refunded, application_fees.amount_refunded),
+        currency = COALESCE(@currency, application_fees.currency),
+        refunded = COALESCE(@refunded, application_fees.refunded),
+        last_event_at = COALESCE(@last_event_at, application_fees.last_event_at),
+        updated_at = @updated_at
+    WHERE application_fees.id = @id
+      AND application_fees.tenant_id = @tenant_id
+      AND (application_fees.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR application_fees.last_event_at <= @last_event_at)
+    `
+
+	now := time.Now()
+	args := pgx.NamedArgs{
+		"id":                         fee.ID,
+		"account_id":                 fee.AccountID,
+		"charge_id":                  fee.ChargeID,
+		"originating_transaction_id": fee.OriginatingTransactionID,
+		"amount":                     fee.Amount,
+		"amount_refunded":            fee.AmountRefunded,
+		"currency":                   fee.Currency,
+		"refunded":                   fee.Refunded,
+		"created_at":                 fee.CreatedAt,
+		"updated_at":                 now,
+		"last_event_at":              fee.LastEventAt,
+		"tenant_id":                  driver.TenantID(ctx),
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("failed to upsert application fee: %w", err)
+	}
+
+	return nil
+}
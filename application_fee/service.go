@@ -0,0 +1,43 @@
+package application_fee
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+type Service interface {
+	GetByID(ctx context.Context, id string) (*models.ApplicationFee, error)
+	Upsert(ctx context.Context, fee *models.PartialApplicationFee) error
+}
+
+type service struct {
+	repo               Repository
+	transactionManager *driver.TransactionManager
+}
+
+func NewService(repo Repository, tm *driver.TransactionManager) Service {
+	return &service{
+		repo:               repo,
+		transactionManager: tm,
+	}
+}
+
+func (s *service) GetByID(ctx context.Context, id string) (*models.ApplicationFee, error) {
+	var fee *models.ApplicationFee
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		fee, err = s.repo.GetByID(ctx, tx, id)
+		return err
+	})
+	return fee, err
+}
+
+func (s *service) Upsert(ctx context.Context, fee *models.PartialApplicationFee) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Upsert(ctx, tx, fee)
+	})
+}
@@ -0,0 +1,510 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"github.com/stripe/stripe-go/v79/client"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/event"
+	"goflare.io/payment/models"
+)
+
+const (
+	// defaultBackfillRateLimit 為每秒請求數的預設上限，遠低於 Stripe 正式環境的 100 req/s，保留額度給線上流量
+	defaultBackfillRateLimit = 20
+	defaultBackfillPageSize  = 100
+)
+
+// backfillRetryPolicy 決定 Stripe 回應 429 時重新取得同一頁前的等待時間
+var backfillRetryPolicy = event.RetryPolicy{
+	MaxAttempts: 8,
+	BaseDelay:   time.Second,
+	MaxDelay:    time.Minute,
+}
+
+// BackfillResource 為可從 Stripe 回填的資源類型
+type BackfillResource string
+
+const (
+	BackfillProducts       BackfillResource = "products"
+	BackfillPrices         BackfillResource = "prices"
+	BackfillCustomers      BackfillResource = "customers"
+	BackfillPaymentMethods BackfillResource = "payment_methods"
+	BackfillSubscriptions  BackfillResource = "subscriptions"
+	BackfillInvoices       BackfillResource = "invoices"
+	BackfillCharges        BackfillResource = "charges"
+	BackfillRefunds        BackfillResource = "refunds"
+	BackfillDisputes       BackfillResource = "disputes"
+	BackfillCoupons        BackfillResource = "coupons"
+	BackfillPromotionCodes BackfillResource = "promotion_codes"
+)
+
+// BackfillResources 依 init_schema 的外鍵相依順序列出所有可回填的資源
+// 價格參照商品；支付方式、訂閱與發票參照客戶；退款與爭議參照扣款；促銷代碼參照優惠券
+var BackfillResources = []BackfillResource{
+	BackfillProducts,
+	BackfillPrices,
+	BackfillCustomers,
+	BackfillPaymentMethods,
+	BackfillSubscriptions,
+	BackfillInvoices,
+	BackfillCharges,
+	BackfillRefunds,
+	BackfillDisputes,
+	BackfillCoupons,
+	BackfillPromotionCodes,
+}
+
+// BackfillOptions 設定一次回填的範圍
+// Resources 為空時回填所有資源；Reset 先刪除這些資源的進度再從頭回填；RateLimit 大於 0 時覆蓋設定檔的每秒請求數
+type BackfillOptions struct {
+	Resources []BackfillResource
+	Reset     bool
+	RateLimit float64
+}
+
+// BackfillResult 為單一資源的回填結果
+// Imported 為這次寫入的筆數，Total 為包含先前中斷前已寫入的累計筆數
+type BackfillResult struct {
+	Resource    BackfillResource `json:"resource"`
+	ResumedFrom string           `json:"resumed_from,omitempty"`
+	Imported    int64            `json:"imported"`
+	Total       int64            `json:"total"`
+	Completed   bool             `json:"completed"`
+}
+
+// BackfillReport 為一次回填的結果
+type BackfillReport struct {
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Results    []BackfillResult `json:"results"`
+}
+
+// Backfill 依外鍵相依順序從 Stripe 匯入既有資料
+// 每頁資料以 UpsertBatch 寫入，並在同一個交易內更新進度，中斷後再次執行會從最後完成的一頁之後繼續，已完成的資源會略過
+// Stripe 請求經過獨立的限流器，回應 429 時等待後重新取得同一頁
+// 寫入以取得該頁之前的時間作為 last_event_at，回填期間收到的 webhook 事件不會被較舊的資料覆蓋
+// 回填 ctx 所屬租戶的 Stripe 帳號，資料與進度都寫入該租戶
+func (sp *StripePayment) Backfill(ctx context.Context, opts BackfillOptions) (*BackfillReport, error) {
+	t, err := sp.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := opts.Resources
+	if len(resources) == 0 {
+		resources = BackfillResources
+	}
+	for _, resource := range resources {
+		if !slices.Contains(BackfillResources, resource) {
+			return nil, fmt.Errorf("unknown backfill resource: %s", resource)
+		}
+	}
+
+	if opts.Reset {
+		names := make([]string, len(resources))
+		for i, resource := range resources {
+			names[i] = string(resource)
+		}
+		if err := sp.checkpoints.Reset(ctx, names...); err != nil {
+			return nil, fmt.Errorf("failed to reset backfill checkpoints: %w", err)
+		}
+	}
+
+	rateLimit := opts.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = sp.backfillConfig.RateLimit
+	}
+	if rateLimit <= 0 {
+		rateLimit = defaultBackfillRateLimit
+	}
+	pageSize := sp.backfillConfig.PageSize
+	if pageSize <= 0 || pageSize > defaultBackfillPageSize {
+		pageSize = defaultBackfillPageSize
+	}
+
+	sc := newBackfillClient(t.stripeConfig, rateLimit)
+	report := &BackfillReport{StartedAt: time.Now()}
+
+	// 依 BackfillResources 的順序執行，與呼叫端傳入的順序無關
+	for _, resource := range BackfillResources {
+		if !slices.Contains(resources, resource) {
+			continue
+		}
+
+		result := BackfillResult{Resource: resource}
+		var err error
+		switch resource {
+		case BackfillProducts:
+			err = runBackfill(ctx, sp, sp.productBackfiller(sc), pageSize, &result)
+		case BackfillPrices:
+			err = runBackfill(ctx, sp, sp.priceBackfiller(sc), pageSize, &result)
+		case BackfillCustomers:
+			err = runBackfill(ctx, sp, sp.customerBackfiller(sc), pageSize, &result)
+		case BackfillPaymentMethods:
+			err = runBackfill(ctx, sp, sp.paymentMethodBackfiller(sc, pageSize), pageSize, &result)
+		case BackfillSubscriptions:
+			err = runBackfill(ctx, sp, sp.subscriptionBackfiller(sc), pageSize, &result)
+		case BackfillInvoices:
+			err = runBackfill(ctx, sp, sp.invoiceBackfiller(sc), pageSize, &result)
+		case BackfillCharges:
+			err = runBackfill(ctx, sp, sp.chargeBackfiller(sc), pageSize, &result)
+		case BackfillRefunds:
+			err = runBackfill(ctx, sp, sp.refundBackfiller(sc), pageSize, &result)
+		case BackfillDisputes:
+			err = runBackfill(ctx, sp, sp.disputeBackfiller(sc), pageSize, &result)
+		case BackfillCoupons:
+			err = runBackfill(ctx, sp, sp.couponBackfiller(sc), pageSize, &result)
+		case BackfillPromotionCodes:
+			err = runBackfill(ctx, sp, sp.promotionCodeBackfiller(sc), pageSize, &result)
+		}
+		report.Results = append(report.Results, result)
+		if err != nil {
+			report.FinishedAt = time.Now()
+			return report, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// backfiller 描述一種資源如何從 Stripe 分頁讀取並寫入本地，S 為分頁的 Stripe 物件，P 為寫入的部分欄位
+// 進度以分頁物件的 ID 記錄；支付方式依客戶分頁，因此 S 與寫入的資料不一定是同一種物件
+type backfiller[S, P any] struct {
+	resource BackfillResource
+	stripeID func(S) string
+	list     func(params *stripe.ListParams) (*stripe.Iter, func() S)
+	collect  func(ctx context.Context, page []S, observedAt *time.Time) ([]P, error)
+	upsert   func(ctx context.Context, items []P) error
+}
+
+// runBackfill 從進度記錄的位置逐頁讀取 Stripe，每頁的寫入與進度在同一個交易內提交
+func runBackfill[S, P any](ctx context.Context, sp *StripePayment, b backfiller[S, P], pageSize int64, result *BackfillResult) error {
+	checkpoint, err := sp.checkpoints.Get(ctx, string(b.resource))
+	if err != nil {
+		return fmt.Errorf("failed to load %s backfill checkpoint: %w", b.resource, err)
+	}
+	if checkpoint == nil {
+		checkpoint = &models.BackfillCheckpoint{Resource: string(b.resource)}
+	}
+	result.ResumedFrom = checkpoint.LastID
+	result.Total = checkpoint.Imported
+	if checkpoint.CompletedAt != nil {
+		result.Completed = true
+		return nil
+	}
+
+	for {
+		observedAt := time.Now()
+
+		var page []S
+		var hasMore bool
+		err = sp.retryRateLimited(ctx, b.resource, func() error {
+			var fetchErr error
+			page, hasMore, fetchErr = b.fetchPage(ctx, checkpoint.LastID, pageSize)
+			return fetchErr
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list %s from stripe: %w", b.resource, err)
+		}
+
+		var items []P
+		items, err = b.collect(ctx, page, &observedAt)
+		if err != nil {
+			return fmt.Errorf("failed to list %s from stripe: %w", b.resource, err)
+		}
+
+		next := *checkpoint
+		next.Imported += int64(len(items))
+		if len(page) > 0 {
+			next.LastID = b.stripeID(page[len(page)-1])
+		}
+		if !hasMore || len(page) == 0 {
+			completedAt := time.Now()
+			next.CompletedAt = &completedAt
+		}
+
+		err = sp.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := b.upsert(ctx, items); err != nil {
+				return err
+			}
+			return sp.checkpoints.Save(ctx, &next)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", b.resource, err)
+		}
+
+		checkpoint = &next
+		result.Imported += int64(len(items))
+		result.Total = checkpoint.Imported
+
+		sp.logger.Info("backfilled page",
+			zap.String("resource", string(b.resource)),
+			zap.String("last_id", checkpoint.LastID),
+			zap.Int64("total", checkpoint.Imported))
+
+		if checkpoint.CompletedAt != nil {
+			result.Completed = true
+			return nil
+		}
+	}
+}
+
+// fetchPage 取得 startingAfter 之後的一頁，Stripe 由新到舊排序
+func (b backfiller[S, P]) fetchPage(ctx context.Context, startingAfter string, pageSize int64) ([]S, bool, error) {
+	params := &stripe.ListParams{Limit: stripe.Int64(pageSize), Single: true}
+	params.Context = ctx
+	if startingAfter != "" {
+		params.StartingAfter = stripe.String(startingAfter)
+	}
+
+	iter, current := b.list(params)
+	page := make([]S, 0, pageSize)
+	for iter.Next() {
+		page = append(page, current())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return page, iter.Meta().HasMore, nil
+}
+
+// retryRateLimited 執行 fn，Stripe 回應 429 時依 backfillRetryPolicy 等待後重試
+func (sp *StripePayment) retryRateLimited(ctx context.Context, resource BackfillResource, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		var stripeErr *stripe.Error
+		if err == nil || !errors.As(err, &stripeErr) || stripeErr.HTTPStatusCode != http.StatusTooManyRequests || attempt >= backfillRetryPolicy.MaxAttempts {
+			return err
+		}
+
+		delay := backfillRetryPolicy.Backoff(attempt)
+		sp.logger.Warn("stripe rate limited backfill, retrying",
+			zap.String("resource", string(resource)),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// newBackfillClient 建立回填專用的 Stripe client，所有請求先經過限流器，不影響 API 服務共用的 client
+func newBackfillClient(cfg config.StripeConfig, rateLimit float64) *client.API {
+	backendConfig := newStripeBackendConfig(cfg, &rateLimitedTransport{
+		limiter: rate.NewLimiter(rate.Limit(rateLimit), max(int(rateLimit), 1)),
+		base:    http.DefaultTransport,
+	})
+	return client.New(cfg.SecretKey, stripe.NewBackendsWithConfig(backendConfig))
+}
+
+// rateLimitedTransport 在送出每個 HTTP 請求前等待限流器
+type rateLimitedTransport struct {
+	limiter *rate.Limiter
+	base    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// convertPage 將一頁 Stripe 物件轉為 UpsertBatch 使用的部分欄位
+func convertPage[S, P any](convert func(S, *time.Time) P) func(context.Context, []S, *time.Time) ([]P, error) {
+	return func(_ context.Context, page []S, observedAt *time.Time) ([]P, error) {
+		items := make([]P, len(page))
+		for i, item := range page {
+			items[i] = convert(item, observedAt)
+		}
+		return items, nil
+	}
+}
+
+func (sp *StripePayment) productBackfiller(sc *client.API) backfiller[*stripe.Product, *models.PartialProduct] {
+	return backfiller[*stripe.Product, *models.PartialProduct]{
+		resource: BackfillProducts,
+		stripeID: func(p *stripe.Product) string { return p.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Product) {
+			iter := sc.Products.List(&stripe.ProductListParams{ListParams: *params})
+			return iter.Iter, iter.Product
+		},
+		collect: convertPage(partialProductFromStripe),
+		upsert:  sp.product.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) priceBackfiller(sc *client.API) backfiller[*stripe.Price, *models.PartialPrice] {
+	return backfiller[*stripe.Price, *models.PartialPrice]{
+		resource: BackfillPrices,
+		stripeID: func(p *stripe.Price) string { return p.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Price) {
+			iter := sc.Prices.List(&stripe.PriceListParams{ListParams: *params})
+			return iter.Iter, iter.Price
+		},
+		collect: convertPage(partialPriceFromStripe),
+		upsert:  sp.price.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) customerBackfiller(sc *client.API) backfiller[*stripe.Customer, *models.PartialCustomer] {
+	return backfiller[*stripe.Customer, *models.PartialCustomer]{
+		resource: BackfillCustomers,
+		stripeID: func(c *stripe.Customer) string { return c.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Customer) {
+			iter := sc.Customers.List(&stripe.CustomerListParams{ListParams: *params})
+			return iter.Iter, iter.Customer
+		},
+		collect: convertPage(partialCustomerFromStripe),
+		upsert:  sp.customer.UpsertBatch,
+	}
+}
+
+// paymentMethodBackfiller 依客戶分頁，逐一列出每位客戶的支付方式
+// Stripe 沒有列出所有支付方式的 API，未附加到客戶的支付方式不會回填
+func (sp *StripePayment) paymentMethodBackfiller(sc *client.API, pageSize int64) backfiller[*stripe.Customer, *models.PartialPaymentMethod] {
+	return backfiller[*stripe.Customer, *models.PartialPaymentMethod]{
+		resource: BackfillPaymentMethods,
+		stripeID: func(c *stripe.Customer) string { return c.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Customer) {
+			iter := sc.Customers.List(&stripe.CustomerListParams{ListParams: *params})
+			return iter.Iter, iter.Customer
+		},
+		collect: func(ctx context.Context, customers []*stripe.Customer, observedAt *time.Time) ([]*models.PartialPaymentMethod, error) {
+			var items []*models.PartialPaymentMethod
+			for _, c := range customers {
+				err := sp.retryRateLimited(ctx, BackfillPaymentMethods, func() error {
+					params := &stripe.CustomerListPaymentMethodsParams{Customer: stripe.String(c.ID)}
+					params.Context = ctx
+					params.Limit = stripe.Int64(pageSize)
+
+					var customerItems []*models.PartialPaymentMethod
+					iter := sc.Customers.ListPaymentMethods(params)
+					for iter.Next() {
+						partial := partialPaymentMethodFromStripe(iter.PaymentMethod(), observedAt)
+						if partial.CustomerID == nil {
+							partial.CustomerID = &c.ID
+						}
+						customerItems = append(customerItems, partial)
+					}
+					if err := iter.Err(); err != nil {
+						return err
+					}
+					items = append(items, customerItems...)
+					return nil
+				})
+				if err != nil {
+					return nil, fmt.Errorf("customer %s: %w", c.ID, err)
+				}
+			}
+			return items, nil
+		},
+		upsert: sp.paymentMethod.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) subscriptionBackfiller(sc *client.API) backfiller[*stripe.Subscription, *models.PartialSubscription] {
+	return backfiller[*stripe.Subscription, *models.PartialSubscription]{
+		resource: BackfillSubscriptions,
+		stripeID: func(s *stripe.Subscription) string { return s.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Subscription) {
+			iter := sc.Subscriptions.List(&stripe.SubscriptionListParams{ListParams: *params, Status: stripe.String("all")})
+			return iter.Iter, iter.Subscription
+		},
+		collect: convertPage(partialSubscriptionFromStripe),
+		upsert:  sp.subscription.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) invoiceBackfiller(sc *client.API) backfiller[*stripe.Invoice, *models.PartialInvoice] {
+	return backfiller[*stripe.Invoice, *models.PartialInvoice]{
+		resource: BackfillInvoices,
+		stripeID: func(i *stripe.Invoice) string { return i.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Invoice) {
+			iter := sc.Invoices.List(&stripe.InvoiceListParams{ListParams: *params})
+			return iter.Iter, iter.Invoice
+		},
+		collect: convertPage(partialInvoiceFromStripe),
+		upsert:  sp.invoice.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) chargeBackfiller(sc *client.API) backfiller[*stripe.Charge, *models.PartialCharge] {
+	return backfiller[*stripe.Charge, *models.PartialCharge]{
+		resource: BackfillCharges,
+		stripeID: func(c *stripe.Charge) string { return c.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Charge) {
+			iter := sc.Charges.List(&stripe.ChargeListParams{ListParams: *params})
+			return iter.Iter, iter.Charge
+		},
+		collect: convertPage(partialChargeFromStripe),
+		upsert:  sp.charge.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) refundBackfiller(sc *client.API) backfiller[*stripe.Refund, *models.PartialRefund] {
+	return backfiller[*stripe.Refund, *models.PartialRefund]{
+		resource: BackfillRefunds,
+		stripeID: func(r *stripe.Refund) string { return r.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Refund) {
+			iter := sc.Refunds.List(&stripe.RefundListParams{ListParams: *params})
+			return iter.Iter, iter.Refund
+		},
+		collect: convertPage(partialRefundFromStripe),
+		upsert:  sp.refund.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) disputeBackfiller(sc *client.API) backfiller[*stripe.Dispute, *models.PartialDispute] {
+	return backfiller[*stripe.Dispute, *models.PartialDispute]{
+		resource: BackfillDisputes,
+		stripeID: func(d *stripe.Dispute) string { return d.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Dispute) {
+			iter := sc.Disputes.List(&stripe.DisputeListParams{ListParams: *params})
+			return iter.Iter, iter.Dispute
+		},
+		collect: convertPage(partialDisputeFromStripe),
+		upsert:  sp.dispute.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) couponBackfiller(sc *client.API) backfiller[*stripe.Coupon, *models.PartialCoupon] {
+	return backfiller[*stripe.Coupon, *models.PartialCoupon]{
+		resource: BackfillCoupons,
+		stripeID: func(c *stripe.Coupon) string { return c.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.Coupon) {
+			iter := sc.Coupons.List(&stripe.CouponListParams{ListParams: *params})
+			return iter.Iter, iter.Coupon
+		},
+		collect: convertPage(partialCouponFromStripe),
+		upsert:  sp.coupon.UpsertBatch,
+	}
+}
+
+func (sp *StripePayment) promotionCodeBackfiller(sc *client.API) backfiller[*stripe.PromotionCode, *models.PartialPromotionCode] {
+	return backfiller[*stripe.PromotionCode, *models.PartialPromotionCode]{
+		resource: BackfillPromotionCodes,
+		stripeID: func(p *stripe.PromotionCode) string { return p.ID },
+		list: func(params *stripe.ListParams) (*stripe.Iter, func() *stripe.PromotionCode) {
+			iter := sc.PromotionCodes.List(&stripe.PromotionCodeListParams{ListParams: *params})
+			return iter.Iter, iter.PromotionCode
+		},
+		collect: convertPage(partialPromotionCodeFromStripe),
+		upsert:  sp.promotionCode.UpsertBatch,
+	}
+}
@@ -0,0 +1,71 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/sqlc"
+)
+
+type Repository interface {
+	Get(ctx context.Context, tx pgx.Tx, resource string) (*models.BackfillCheckpoint, error)
+	Save(ctx context.Context, tx pgx.Tx, checkpoint *models.BackfillCheckpoint) error
+	Delete(ctx context.Context, tx pgx.Tx, resources []string) error
+}
+
+type repository struct {
+	conn driver.PostgresPool
+}
+
+func NewRepository(conn driver.PostgresPool) Repository {
+	return &repository{conn: conn}
+}
+
+// Get 取得資源的回填進度，尚未開始回填時回傳 nil
+func (r *repository) Get(ctx context.Context, tx pgx.Tx, resource string) (*models.BackfillCheckpoint, error) {
+	sqlcCheckpoint, err := sqlc.New(r.conn).WithTx(tx).GetBackfillCheckpoint(ctx, sqlc.GetBackfillCheckpointParams{
+		Resource: resource,
+		TenantID: driver.TenantID(ctx),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return new(models.BackfillCheckpoint).ConvertFromSQLCBackfillCheckpoint(sqlcCheckpoint), nil
+}
+
+func (r *repository) Save(ctx context.Context, tx pgx.Tx, checkpoint *models.BackfillCheckpoint) error {
+	var lastID *string
+	if checkpoint.LastID != "" {
+		lastID = &checkpoint.LastID
+	}
+
+	var completedAt pgtype.Timestamptz
+	if checkpoint.CompletedAt != nil {
+		completedAt = pgtype.Timestamptz{Time: *checkpoint.CompletedAt, Valid: true}
+	}
+
+	return sqlc.New(r.conn).WithTx(tx).SaveBackfillCheckpoint(ctx, sqlc.SaveBackfillCheckpointParams{
+		Resource:    checkpoint.Resource,
+		LastID:      lastID,
+		Imported:    checkpoint.Imported,
+		CompletedAt: completedAt,
+		UpdatedAt:   pgtype.Timestamptz{Time: checkpoint.UpdatedAt, Valid: true},
+		TenantID:    driver.TenantID(ctx),
+	})
+}
+
+func (r *repository) Delete(ctx context.Context, tx pgx.Tx, resources []string) error {
+	return sqlc.New(r.conn).WithTx(tx).DeleteBackfillCheckpoints(ctx, sqlc.DeleteBackfillCheckpointsParams{
+		Column1:  resources,
+		TenantID: driver.TenantID(ctx),
+	})
+}
@@ -0,0 +1,55 @@
+package backfill
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+type Service interface {
+	Get(ctx context.Context, resource string) (*models.BackfillCheckpoint, error)
+	Save(ctx context.Context, checkpoint *models.BackfillCheckpoint) error
+	Reset(ctx context.Context, resources ...string) error
+}
+
+type service struct {
+	repo               Repository
+	transactionManager *driver.TransactionManager
+}
+
+func NewService(repo Repository, tm *driver.TransactionManager) Service {
+	return &service{
+		repo:               repo,
+		transactionManager: tm,
+	}
+}
+
+func (s *service) Get(ctx context.Context, resource string) (*models.BackfillCheckpoint, error) {
+	var checkpoint *models.BackfillCheckpoint
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		checkpoint, err = s.repo.Get(ctx, tx, resource)
+		return err
+	})
+	return checkpoint, err
+}
+
+// Save 更新回填進度
+// 在 TransactionManager.WithinTransaction 內呼叫時會與該頁資料一起提交，中斷後不會略過或重複計算
+func (s *service) Save(ctx context.Context, checkpoint *models.BackfillCheckpoint) error {
+	checkpoint.UpdatedAt = time.Now()
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Save(ctx, tx, checkpoint)
+	})
+}
+
+// Reset 刪除資源的回填進度，下一次回填會從頭開始
+func (s *service) Reset(ctx context.Context, resources ...string) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Delete(ctx, tx, resources)
+	})
+}
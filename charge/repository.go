@@ -13,6 +13,7 @@ import (
 
 type Repository interface {
 	Upsert(ctx context.Context, tx pgx.Tx, charge *models.PartialCharge) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, charges []*models.PartialCharge) error
 }
 
 type repository struct {
@@ -24,9 +25,34 @@ func NewRepository(conn driver.PostgresPool) Repository {
 }
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, charge *models.PartialCharge) error {
-	const query = `
-    INSERT INTO charges (id, customer_id, payment_intent_id, amount, currency, status, paid, refunded, failure_code, failure_message, created_at, updated_at)
-    VALUES (@id, @customer_id, @payment_intent_id, @amount, @currency, @status, @paid, @refunded, @failure_code, @failure_message, COALESCE(@created_at, NOW()), @updated_at)
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, charge)); err != nil {
+		return fmt.Errorf("failed to upsert charge: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, charges []*models.PartialCharge) error {
+	if len(charges) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, charge := range charges {
+		batch.Queue(upsertQuery, upsertArgs(ctx, charge))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert charges: %w", err)
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO charges (id, customer_id, payment_intent_id, amount, currency, status, paid, refunded, failure_code, failure_message, created_at, updated_at, last_event_at)
+    VALUES (@id, @customer_id, @payment_intent_id, @amount, @currency, @status, @paid, @refunded, @failure_code, @failure_message, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, charges.customer_id),
         payment_intent_id = COALESCE(@payment_intent_id, charges.payment_intent_id),
@@ -37,12 +63,17 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, charge *models.Parti
         refunded = COALESCE(@refunded, charges.refunded),
         failure_code = COALESCE(@failure_code, charges.failure_code),
         failure_message = COALESCE(@failure_message, charges.failure_message),
+        last_event_at = COALESCE(@last_event_at, charges.last_event_at),
         updated_at = @updated_at
     WHERE charges.id = @id
+      AND charges.tenant_id = @tenant_id
+      AND (charges.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR charges.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, charge *models.PartialCharge) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":                charge.ID,
 		"customer_id":       charge.CustomerID,
 		"payment_intent_id": charge.PaymentIntentID,
@@ -55,11 +86,7 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, charge *models.Parti
 		"failure_message":   charge.FailureMessage,
 		"created_at":        charge.CreatedAt,
 		"updated_at":        now,
+		"last_event_at":     charge.LastEventAt,
+		"tenant_id":         driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert charge: %w", err)
-	}
-
-	return nil
 }
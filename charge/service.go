@@ -11,6 +11,7 @@ import (
 
 type Service interface {
 	Upsert(ctx context.Context, charge *models.PartialCharge) error
+	UpsertBatch(ctx context.Context, charges []*models.PartialCharge) error
 }
 
 type service struct {
@@ -30,3 +31,9 @@ func (s *service) Upsert(ctx context.Context, charge *models.PartialCharge) erro
 		return s.repo.Upsert(ctx, tx, charge)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, charges []*models.PartialCharge) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, charges)
+	})
+}
@@ -27,8 +27,8 @@ func NewRepository(conn driver.PostgresPool) Repository {
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, session *models.PartialCheckoutSession) error {
 	const query = `
-    INSERT INTO checkout_sessions (id, customer_id, payment_intent_id, status, mode, success_url, cancel_url, amount_total, currency, created_at, updated_at)
-    VALUES (@id, @customer_id, @payment_intent_id, @status, @mode, @success_url, @cancel_url, @amount_total, @currency, COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO checkout_sessions (id, customer_id, payment_intent_id, status, mode, success_url, cancel_url, amount_total, currency, created_at, updated_at, last_event_at)
+    VALUES (@id, @customer_id, @payment_intent_id, @status, @mode, @success_url, @cancel_url, @amount_total, @currency, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, checkout_sessions.customer_id),
         payment_intent_id = COALESCE(@payment_intent_id, checkout_sessions.payment_intent_id),
@@ -38,8 +38,11 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, session *models.Part
         cancel_url = COALESCE(@cancel_url, checkout_sessions.cancel_url),
         amount_total = COALESCE(@amount_total, checkout_sessions.amount_total),
         currency = COALESCE(@currency, checkout_sessions.currency),
+        last_event_at = COALESCE(@last_event_at, checkout_sessions.last_event_at),
         updated_at = @updated_at
     WHERE checkout_sessions.id = @id
+      AND checkout_sessions.tenant_id = @tenant_id
+      AND (checkout_sessions.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR checkout_sessions.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
@@ -55,6 +58,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, session *models.Part
 		"currency":          session.Currency,
 		"created_at":        session.CreatedAt,
 		"updated_at":        now,
+		"last_event_at":     session.LastEventAt,
+		"tenant_id":         driver.TenantID(ctx),
 	}
 
 	if _, err := tx.Exec(ctx, query, args); err != nil {
@@ -65,5 +70,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, session *models.Part
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteCheckOutSession(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeleteCheckOutSession(ctx, sqlc.DeleteCheckOutSessionParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
@@ -1,16 +1,24 @@
 package main
 
-import "log"
+import (
+	"flag"
+	"log"
+
+	"goflare.io/payment/config"
+)
 
 func main() {
 
-	server, err := InitializePaymentService()
+	configFile := flag.String("config", string(config.DefaultFile), "path to the configuration file")
+	flag.Parse()
+
+	server, err := InitializePaymentService(config.File(*configFile))
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 
-	if err = server.Run(":8080"); err != nil {
+	if err = server.Run(); err != nil {
 		log.Fatal(err.Error())
 	}
 
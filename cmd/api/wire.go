@@ -7,17 +7,23 @@ import (
 	"github.com/google/wire"
 
 	"goflare.io/payment"
+	"goflare.io/payment/apikey"
+	"goflare.io/payment/application_fee"
+	"goflare.io/payment/backfill"
 	"goflare.io/payment/charge"
 	"goflare.io/payment/checkout_session"
 	"goflare.io/payment/config"
+	"goflare.io/payment/connected_account"
 	"goflare.io/payment/coupon"
 	"goflare.io/payment/customer"
 	"goflare.io/payment/discount"
 	"goflare.io/payment/disputes"
-	"goflare.io/payment/driver"
 	"goflare.io/payment/event"
+	grpcserver "goflare.io/payment/grpc"
 	"goflare.io/payment/handlers"
+	"goflare.io/payment/health"
 	"goflare.io/payment/invoice"
+	"goflare.io/payment/outbox"
 	"goflare.io/payment/payment_intent"
 	"goflare.io/payment/payment_link"
 	"goflare.io/payment/payment_method"
@@ -25,22 +31,27 @@ import (
 	"goflare.io/payment/product"
 	"goflare.io/payment/promotion_code"
 	"goflare.io/payment/quote"
+	"goflare.io/payment/ratelimit"
 	"goflare.io/payment/refund"
 	"goflare.io/payment/review"
 	"goflare.io/payment/server"
 	"goflare.io/payment/subscription"
 	"goflare.io/payment/tax_rate"
+	"goflare.io/payment/tracing"
+	"goflare.io/payment/transfer"
 )
 
-func InitializePaymentService() (*server.Server, error) {
+func InitializePaymentService(file config.File) (*server.Server, error) {
 
 	wire.Build(
 		config.ProvideApplicationConfig,
 		config.NewLogger,
 		config.ProvidePostgresConn,
+		config.ProvideRedis,
 		config.ProvideEmber,
 		config.ProvideIgnite,
-		driver.NewTransactionManager,
+		tracing.NewProvider,
+		config.ProvideTransactionManager,
 		customer.NewRepository,
 		customer.NewService,
 		checkout_session.NewRepository,
@@ -77,14 +88,40 @@ func InitializePaymentService() (*server.Server, error) {
 		subscription.NewService,
 		tax_rate.NewRepository,
 		tax_rate.NewService,
+		connected_account.NewRepository,
+		connected_account.NewService,
+		transfer.NewRepository,
+		transfer.NewService,
+		application_fee.NewRepository,
+		application_fee.NewService,
 		quote.NewRepository,
 		quote.NewService,
+		outbox.NewRepository,
+		outbox.NewService,
+		backfill.NewRepository,
+		backfill.NewService,
+		apikey.NewRepository,
+		apikey.NewService,
 		payment.NewStripePayment,
 		handlers.NewCustomerHandler,
 		handlers.NewProductHandler,
 		handlers.NewPriceHandler,
 		handlers.NewPaymentIntentHandler,
+		handlers.NewPaymentMethodHandler,
+		handlers.NewSubscriptionHandler,
+		handlers.NewInvoiceHandler,
+		handlers.NewRefundHandler,
+		handlers.NewConnectHandler,
 		handlers.NewWebhookHandler,
+		handlers.NewEventHandler,
+		health.NewChecker,
+		handlers.NewHealthHandler,
+		ratelimit.NewLimiter,
+		grpcserver.NewServer,
+		server.NewIdempotency,
+		server.NewAuth,
+		server.NewTenancy,
+		server.NewRateLimit,
 		server.NewServer,
 	)
 
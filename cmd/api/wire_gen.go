@@ -8,17 +8,23 @@ package main
 
 import (
 	"goflare.io/payment"
+	"goflare.io/payment/apikey"
+	"goflare.io/payment/application_fee"
+	"goflare.io/payment/backfill"
 	"goflare.io/payment/charge"
 	"goflare.io/payment/checkout_session"
 	"goflare.io/payment/config"
+	"goflare.io/payment/connected_account"
 	"goflare.io/payment/coupon"
 	"goflare.io/payment/customer"
 	"goflare.io/payment/discount"
 	"goflare.io/payment/disputes"
-	"goflare.io/payment/driver"
 	"goflare.io/payment/event"
+	"goflare.io/payment/grpc"
 	"goflare.io/payment/handlers"
+	"goflare.io/payment/health"
 	"goflare.io/payment/invoice"
+	"goflare.io/payment/outbox"
 	"goflare.io/payment/payment_intent"
 	"goflare.io/payment/payment_link"
 	"goflare.io/payment/payment_method"
@@ -26,17 +32,20 @@ import (
 	"goflare.io/payment/product"
 	"goflare.io/payment/promotion_code"
 	"goflare.io/payment/quote"
+	"goflare.io/payment/ratelimit"
 	"goflare.io/payment/refund"
 	"goflare.io/payment/review"
 	"goflare.io/payment/server"
 	"goflare.io/payment/subscription"
 	"goflare.io/payment/tax_rate"
+	"goflare.io/payment/tracing"
+	"goflare.io/payment/transfer"
 )
 
 // Injectors from wire.go:
 
-func InitializePaymentService() (*server.Server, error) {
-	configConfig, err := config.ProvideApplicationConfig()
+func InitializePaymentService(file config.File) (*server.Server, error) {
+	configConfig, err := config.ProvideApplicationConfig(file)
 	if err != nil {
 		return nil, err
 	}
@@ -44,8 +53,15 @@ func InitializePaymentService() (*server.Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	logger := config.NewLogger()
-	multiCache, err := config.ProvideEmber(configConfig)
+	logger, err := config.NewLogger(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := config.ProvideRedis(configConfig)
+	if err != nil {
+		return nil, err
+	}
+	multiCache, err := config.ProvideEmber(client)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +70,7 @@ func InitializePaymentService() (*server.Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	transactionManager := driver.NewTransactionManager(postgresPool, logger)
+	transactionManager := config.ProvideTransactionManager(postgresPool, configConfig, logger)
 	service := customer.NewService(repository, transactionManager, logger)
 	chargeRepository := charge.NewRepository(postgresPool)
 	chargeService := charge.NewService(chargeRepository, transactionManager)
@@ -116,12 +132,48 @@ func InitializePaymentService() (*server.Server, error) {
 	tax_rateService := tax_rate.NewService(tax_rateRepository, transactionManager)
 	quoteRepository := quote.NewRepository(postgresPool)
 	quoteService := quote.NewService(quoteRepository, transactionManager)
-	paymentPayment := payment.NewStripePayment(configConfig, service, chargeService, couponService, checkout_sessionService, discountService, disputesService, eventService, productService, priceService, subscriptionService, invoiceService, payment_methodService, payment_linkService, payment_intentService, promotion_codeService, refundService, reviewService, tax_rateService, quoteService, logger)
+	connected_accountRepository := connected_account.NewRepository(postgresPool)
+	connected_accountService := connected_account.NewService(connected_accountRepository, transactionManager)
+	transferRepository := transfer.NewRepository(postgresPool)
+	transferService := transfer.NewService(transferRepository, transactionManager)
+	application_feeRepository := application_fee.NewRepository(postgresPool)
+	application_feeService := application_fee.NewService(application_feeRepository, transactionManager)
+	outboxRepository := outbox.NewRepository(postgresPool)
+	outboxService := outbox.NewService(outboxRepository, transactionManager)
+	backfillRepository := backfill.NewRepository(postgresPool)
+	backfillService := backfill.NewService(backfillRepository, transactionManager)
+	paymentPayment, err := payment.NewStripePayment(configConfig, service, chargeService, couponService, checkout_sessionService, discountService, disputesService, eventService, productService, priceService, subscriptionService, invoiceService, payment_methodService, payment_linkService, payment_intentService, promotion_codeService, refundService, reviewService, tax_rateService, quoteService, connected_accountService, transferService, application_feeService, outboxService, backfillService, transactionManager, logger)
+	if err != nil {
+		return nil, err
+	}
 	customerHandler := handlers.NewCustomerHandler(paymentPayment)
 	productHandler := handlers.NewProductHandler(paymentPayment, logger)
 	priceHandler := handlers.NewPriceHandler(paymentPayment, logger)
 	paymentIntentHandler := handlers.NewPaymentIntentHandler(paymentPayment)
+	paymentMethodHandler := handlers.NewPaymentMethodHandler(paymentPayment)
+	subscriptionHandler := handlers.NewSubscriptionHandler(paymentPayment)
+	invoiceHandler := handlers.NewInvoiceHandler(paymentPayment, logger)
+	refundHandler := handlers.NewRefundHandler(paymentPayment)
+	connectHandler := handlers.NewConnectHandler(paymentPayment)
 	webhookHandler := handlers.NewWebhookHandler(paymentPayment, logger)
-	serverServer := server.NewServer(customerHandler, productHandler, priceHandler, paymentIntentHandler, webhookHandler)
+	eventHandler := handlers.NewEventHandler(paymentPayment, logger)
+	checker, err := health.NewChecker(postgresPool, client, paymentPayment, configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	healthHandler := handlers.NewHealthHandler(checker)
+	apikeyRepository := apikey.NewRepository(postgresPool)
+	apikeyService := apikey.NewService(apikeyRepository, transactionManager)
+	limiter := ratelimit.NewLimiter(client, configConfig, logger)
+	grpcServer := grpc.NewServer(paymentPayment, apikeyService, limiter, checker, logger)
+	idempotency := server.NewIdempotency(client, configConfig, logger)
+	auth := server.NewAuth(apikeyService, logger)
+	tenancy := server.NewTenancy(paymentPayment)
+	rateLimit := server.NewRateLimit(limiter)
+	provider, err := tracing.NewProvider(configConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	serverServer := server.NewServer(customerHandler, productHandler, priceHandler, paymentIntentHandler, paymentMethodHandler, subscriptionHandler, invoiceHandler, refundHandler, connectHandler, webhookHandler, eventHandler, healthHandler, grpcServer, idempotency, auth, tenancy, rateLimit, provider, configConfig)
 	return serverServer, nil
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"goflare.io/payment"
+	"goflare.io/payment/driver"
+)
+
+const backfillUsage = `usage: paymentctl backfill [flags]
+
+flags:
+  -resource    comma-separated resources to import: products, prices, customers, payment_methods, subscriptions,
+               invoices, charges, refunds, disputes, coupons, promotion_codes (default all)
+  -reset       discard saved progress for these resources and import from the beginning
+  -rate        maximum Stripe requests per second (default backfill.rate_limit, or 20)
+  -json        print the report as JSON
+  -tenant      tenant whose Stripe account and data to use (default "default")
+`
+
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, backfillUsage) }
+
+	tenant := fs.String("tenant", driver.DefaultTenantID, "")
+	resource := fs.String("resource", "", "")
+	reset := fs.Bool("reset", false, "")
+	rate := fs.Float64("rate", 0, "")
+	asJSON := fs.Bool("json", false, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := payment.BackfillOptions{Reset: *reset, RateLimit: *rate}
+	if *resource != "" {
+		for _, r := range strings.Split(*resource, ",") {
+			opts.Resources = append(opts.Resources, payment.BackfillResource(strings.TrimSpace(r)))
+		}
+	}
+
+	processor, err := InitializeEventProcessor(configFile)
+	if err != nil {
+		return err
+	}
+	defer processor.Close()
+
+	if !processor.HasTenant(*tenant) {
+		return fmt.Errorf("%w: %s", payment.ErrUnknownTenant, *tenant)
+	}
+
+	ctx, stop := signal.NotifyContext(driver.WithTenant(context.Background(), *tenant), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := processor.Backfill(ctx, opts)
+	if report == nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if encodeErr := encoder.Encode(report); encodeErr != nil {
+			return encodeErr
+		}
+	} else {
+		printBackfillReport(report)
+	}
+
+	return err
+}
+
+func printBackfillReport(report *payment.BackfillReport) {
+	for _, result := range report.Results {
+		status := "incomplete"
+		if result.Completed {
+			status = "completed"
+		}
+
+		line := fmt.Sprintf("%s: imported %d, total %d, %s", result.Resource, result.Imported, result.Total, status)
+		if result.ResumedFrom != "" {
+			line += fmt.Sprintf(" (resumed after %s)", result.ResumedFrom)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("finished in %s\n", report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+}
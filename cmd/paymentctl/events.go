@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/event"
+)
+
+const eventsUsage = `usage: paymentctl events replay [flags]
+
+flags:
+  -type        only replay events of this type, e.g. customer.subscription.updated
+  -object-id   only replay events whose data.object.id matches
+  -from        only replay events created by Stripe at or after this RFC 3339 time
+  -to          only replay events created by Stripe before this RFC 3339 time
+  -limit       maximum number of events to replay (default 1000)
+  -dry-run     list the matching events without processing them
+  -tenant      tenant whose Stripe account and data to use (default "default")
+`
+
+func runEvents(args []string) error {
+	if len(args) < 1 || args[0] != "replay" {
+		fmt.Fprint(os.Stderr, eventsUsage)
+		os.Exit(2)
+	}
+
+	return runEventsReplay(args[1:])
+}
+
+func runEventsReplay(args []string) error {
+	fs := flag.NewFlagSet("events replay", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, eventsUsage) }
+
+	tenant := fs.String("tenant", driver.DefaultTenantID, "")
+	eventType := fs.String("type", "", "")
+	objectID := fs.String("object-id", "", "")
+	from := fs.String("from", "", "")
+	to := fs.String("to", "", "")
+	limit := fs.Int("limit", 0, "")
+	dryRun := fs.Bool("dry-run", false, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := event.ReplayFilter{
+		Type:     stripe.EventType(*eventType),
+		ObjectID: *objectID,
+		Limit:    *limit,
+	}
+
+	var err error
+	if filter.From, err = parseTime(*from); err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	if filter.To, err = parseTime(*to); err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return errors.New("-from must be before -to")
+	}
+
+	processor, err := InitializeEventProcessor(configFile)
+	if err != nil {
+		return err
+	}
+	defer processor.Close()
+
+	if !processor.HasTenant(*tenant) {
+		return fmt.Errorf("%w: %s", payment.ErrUnknownTenant, *tenant)
+	}
+
+	ctx, stop := signal.NotifyContext(driver.WithTenant(context.Background(), *tenant), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *dryRun {
+		events, err := processor.ListReplayableEvents(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			fmt.Printf("%s\t%s\t%s\t%s\n", e.ID, e.Type, e.ObjectID, e.StripeCreatedAt.Format(time.RFC3339))
+		}
+		fmt.Printf("%d events would be replayed\n", len(events))
+		return nil
+	}
+
+	result, err := processor.ReplayEvents(ctx, filter)
+	if err != nil && result == nil {
+		return err
+	}
+
+	for _, failure := range result.Failures {
+		fmt.Fprintf(os.Stderr, "%s\t%v\n", failure.EventID, failure.Err)
+	}
+	fmt.Printf("matched %d, replayed %d, failed %d\n", result.Matched, result.Replayed, len(result.Failures))
+
+	if err != nil {
+		return err
+	}
+	if len(result.Failures) > 0 {
+		return fmt.Errorf("%d events failed to replay", len(result.Failures))
+	}
+
+	return nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
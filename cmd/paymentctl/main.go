@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"goflare.io/payment/config"
+)
+
+const usage = `usage: paymentctl [-config file] <command> [arguments]
+
+commands:
+  events replay    re-dispatch stored Stripe events through the event handlers
+  backfill         import existing Stripe objects into the local database, resuming from saved progress
+  reconcile        compare local customers, subscriptions, invoices and payment intents with Stripe
+  keys issue       create an API key for the HTTP API and print it once
+  keys revoke      revoke an API key by its prefix
+  migrate up       apply pending database migrations embedded in the binary
+  migrate down     roll back the most recent database migrations
+  migrate status   print the database schema version and pending migrations
+
+flags:
+  -config          path to the configuration file (default "./config.yaml")
+`
+
+// configFile 為所有子命令共用的設定檔，必須在子命令之前指定
+var configFile = config.DefaultFile
+
+func main() {
+
+	flag.StringVar((*string)(&configFile), "config", string(config.DefaultFile), "")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "events":
+		err = runEvents(args[1:])
+	case "reconcile":
+		err = runReconcile(args[1:])
+	case "backfill":
+		err = runBackfill(args[1:])
+	case "keys":
+		err = runKeys(args[1:])
+	case "migrate":
+		err = runMigrate(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
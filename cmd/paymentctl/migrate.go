@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"goflare.io/payment/migrate"
+)
+
+const migrateUsage = `usage: paymentctl migrate <up|down|status> [flags]
+
+up flags:
+  -steps       apply at most this many pending migrations (default all)
+
+down flags:
+  -steps       number of migrations to roll back (default 1)
+  -all         roll back every migration
+`
+
+func runMigrate(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "up":
+		return runMigrateUp(args[1:])
+	case "down":
+		return runMigrateDown(args[1:])
+	case "status":
+		return runMigrateStatus(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	return nil
+}
+
+func runMigrateUp(args []string) error {
+	fs := flag.NewFlagSet("migrate up", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+
+	steps := fs.Int("steps", 0, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	migrator, err := InitializeMigrator(configFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	applied, err := migrator.Up(ctx, *steps)
+	printMigrations("applied", applied)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("no pending migrations, schema is at version %d\n", migrator.Latest())
+	}
+	return nil
+}
+
+func runMigrateDown(args []string) error {
+	fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+
+	steps := fs.Int("steps", 1, "")
+	all := fs.Bool("all", false, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *all {
+		*steps = 0
+	} else if *steps < 1 {
+		return fmt.Errorf("-steps must be at least 1, use -all to roll back every migration")
+	}
+
+	migrator, err := InitializeMigrator(configFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reverted, err := migrator.Down(ctx, *steps)
+	printMigrations("reverted", reverted)
+	return err
+}
+
+func runMigrateStatus(args []string) error {
+	fs := flag.NewFlagSet("migrate status", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	migrator, err := InitializeMigrator(configFile)
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version %d, expected %d", status.Version, status.Latest)
+	if status.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+	for _, m := range status.Applied {
+		fmt.Printf("  applied  %d_%s\n", m.Version, m.Name)
+	}
+	for _, m := range status.Pending {
+		fmt.Printf("  pending  %d_%s\n", m.Version, m.Name)
+	}
+	return nil
+}
+
+func printMigrations(action string, migrations []migrate.Migration) {
+	for _, m := range migrations {
+		fmt.Printf("%s %d_%s\n", action, m.Version, m.Name)
+	}
+}
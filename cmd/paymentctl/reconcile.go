@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"goflare.io/payment"
+	"goflare.io/payment/driver"
+)
+
+const reconcileUsage = `usage: paymentctl reconcile [flags]
+
+flags:
+  -resource    comma-separated resources to check: customers, subscriptions, invoices, payment_intents (default all)
+  -since       only check Stripe objects created at or after this RFC 3339 time
+  -lookback    only check Stripe objects created within this duration, e.g. 72h (ignored when -since is set)
+  -repair      overwrite drifted local rows with the Stripe data
+  -json        print the full report as JSON
+  -tenant      tenant whose Stripe account and data to use (default "default")
+`
+
+func runReconcile(args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, reconcileUsage) }
+
+	tenant := fs.String("tenant", driver.DefaultTenantID, "")
+	resource := fs.String("resource", "", "")
+	since := fs.String("since", "", "")
+	lookback := fs.Duration("lookback", 0, "")
+	repair := fs.Bool("repair", false, "")
+	asJSON := fs.Bool("json", false, "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := payment.ReconcileOptions{Repair: *repair}
+	if *resource != "" {
+		for _, r := range strings.Split(*resource, ",") {
+			opts.Resources = append(opts.Resources, payment.ReconcileResource(strings.TrimSpace(r)))
+		}
+	}
+
+	var err error
+	if opts.CreatedFrom, err = parseTime(*since); err != nil {
+		return fmt.Errorf("invalid -since: %w", err)
+	}
+	if opts.CreatedFrom.IsZero() && *lookback > 0 {
+		opts.CreatedFrom = time.Now().Add(-*lookback)
+	}
+
+	processor, err := InitializeEventProcessor(configFile)
+	if err != nil {
+		return err
+	}
+	defer processor.Close()
+
+	if !processor.HasTenant(*tenant) {
+		return fmt.Errorf("%w: %s", payment.ErrUnknownTenant, *tenant)
+	}
+
+	ctx, stop := signal.NotifyContext(driver.WithTenant(context.Background(), *tenant), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := processor.Reconcile(ctx, opts)
+	if report == nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if encodeErr := encoder.Encode(report); encodeErr != nil {
+			return encodeErr
+		}
+	} else {
+		printReconcileReport(report)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var unrepaired int
+	for _, drift := range report.Drifts {
+		if !drift.Repaired {
+			unrepaired++
+		}
+	}
+	if unrepaired > 0 {
+		return fmt.Errorf("%d objects drifted from Stripe", unrepaired)
+	}
+
+	return nil
+}
+
+func printReconcileReport(report *payment.ReconcileReport) {
+	for _, drift := range report.Drifts {
+		status := "drift"
+		switch {
+		case drift.Repaired:
+			status = "repaired"
+		case drift.RepairError != "":
+			status = "repair failed: " + drift.RepairError
+		}
+
+		fmt.Printf("%s\t%s\t%s\t%s\n", drift.Resource, drift.ID, drift.Kind, status)
+		for _, field := range drift.Fields {
+			fmt.Printf("\t%s\tlocal=%v\tstripe=%v\n", field.Field, field.Local, field.Stripe)
+		}
+	}
+
+	for _, resource := range payment.ReconcileResources {
+		if checked, ok := report.Checked[resource]; ok {
+			fmt.Printf("%s: checked %d\n", resource, checked)
+		}
+	}
+	fmt.Printf("%d drifted objects in %s\n", len(report.Drifts), report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+}
@@ -0,0 +1,128 @@
+//go:build wireinject
+// +build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+
+	"goflare.io/payment"
+	"goflare.io/payment/apikey"
+	"goflare.io/payment/application_fee"
+	"goflare.io/payment/backfill"
+	"goflare.io/payment/charge"
+	"goflare.io/payment/checkout_session"
+	"goflare.io/payment/config"
+	"goflare.io/payment/connected_account"
+	"goflare.io/payment/coupon"
+	"goflare.io/payment/customer"
+	"goflare.io/payment/discount"
+	"goflare.io/payment/disputes"
+	"goflare.io/payment/event"
+	"goflare.io/payment/invoice"
+	"goflare.io/payment/migrate"
+	"goflare.io/payment/outbox"
+	"goflare.io/payment/payment_intent"
+	"goflare.io/payment/payment_link"
+	"goflare.io/payment/payment_method"
+	"goflare.io/payment/price"
+	"goflare.io/payment/product"
+	"goflare.io/payment/promotion_code"
+	"goflare.io/payment/quote"
+	"goflare.io/payment/refund"
+	"goflare.io/payment/review"
+	"goflare.io/payment/subscription"
+	"goflare.io/payment/tax_rate"
+	"goflare.io/payment/transfer"
+)
+
+// InitializeEventProcessor 建立不訂閱 NATS 的 StripePayment，供離線重新處理事件
+func InitializeEventProcessor(file config.File) (*payment.StripePayment, error) {
+
+	wire.Build(
+		config.ProvideApplicationConfig,
+		config.NewLogger,
+		config.ProvidePostgresConn,
+		config.ProvideRedis,
+		config.ProvideEmber,
+		config.ProvideIgnite,
+		config.ProvideTransactionManager,
+		customer.NewRepository,
+		customer.NewService,
+		checkout_session.NewRepository,
+		checkout_session.NewService,
+		coupon.NewRepository,
+		coupon.NewService,
+		charge.NewRepository,
+		charge.NewService,
+		discount.NewRepository,
+		discount.NewService,
+		disputes.NewRepository,
+		disputes.NewService,
+		event.NewRepository,
+		event.NewService,
+		invoice.NewRepository,
+		invoice.NewService,
+		payment_method.NewRepository,
+		payment_method.NewService,
+		payment_link.NewRepository,
+		payment_link.NewService,
+		payment_intent.NewRepository,
+		payment_intent.NewService,
+		price.NewRepository,
+		price.NewService,
+		promotion_code.NewRepository,
+		promotion_code.NewService,
+		product.NewRepository,
+		product.NewService,
+		review.NewRepository,
+		review.NewService,
+		refund.NewRepository,
+		refund.NewService,
+		subscription.NewRepository,
+		subscription.NewService,
+		tax_rate.NewRepository,
+		tax_rate.NewService,
+		connected_account.NewRepository,
+		connected_account.NewService,
+		transfer.NewRepository,
+		transfer.NewService,
+		application_fee.NewRepository,
+		application_fee.NewService,
+		quote.NewRepository,
+		quote.NewService,
+		outbox.NewRepository,
+		outbox.NewService,
+		backfill.NewRepository,
+		backfill.NewService,
+		payment.NewEventProcessor,
+	)
+
+	return &payment.StripePayment{}, nil
+}
+
+// InitializeAPIKeyService 建立發行與撤銷 API key 所需的服務，只需要 Postgres
+func InitializeAPIKeyService(file config.File) (apikey.Service, error) {
+
+	wire.Build(
+		config.ProvideApplicationConfig,
+		config.NewLogger,
+		config.ProvidePostgresConn,
+		config.ProvideTransactionManager,
+		apikey.NewRepository,
+		apikey.NewService,
+	)
+
+	return nil, nil
+}
+
+// InitializeMigrator 建立執行嵌入 migration 的 Migrator，不檢查資料庫的 schema 版本
+func InitializeMigrator(file config.File) (*migrate.Migrator, error) {
+
+	wire.Build(
+		config.ProvideApplicationConfig,
+		config.ProvideMigrator,
+	)
+
+	return nil, nil
+}
@@ -4,28 +4,221 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
-	"github.com/spf13/viper"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"goflare.io/ember"
 	emberConfig "goflare.io/ember/config"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/migrate"
+	"goflare.io/payment/migrations"
 )
 
-const (
-	ServerStartPort = ":8080"
-)
+// schemaCheckTimeout 為啟動時讀取 schema 版本的逾時
+const schemaCheckTimeout = 10 * time.Second
 
 type Config struct {
-	Stripe   StripeConfig
-	Postgres PostgresConfig
-	Redis    RedisConfig
+	Server       ServerConfig
+	Logging      LoggingConfig
+	Stripe       StripeConfig
+	Postgres     PostgresConfig
+	Redis        RedisConfig
+	Webhook      WebhookConfig
+	NATS         NATSConfig
+	Workers      WorkerConfig
+	Transactions TransactionConfig
+	Retry        RetryConfig
+	Outbox       OutboxConfig
+	Reconcile    ReconcileConfig
+	Backfill     BackfillConfig
+	Idempotency  IdempotencyConfig
+	RateLimit    RateLimitConfig `mapstructure:"rate_limit"`
+	Tracing      TracingConfig
+	Health       HealthConfig
+	Tenants      []TenantConfig
+
+	// reload 為設定檔變更時通知的對象，見 OnReload
+	reload *reloader
 }
 
+// ServerConfig 設定 HTTP 與 gRPC 服務的監聽位址，ShutdownTimeout 為關閉時等待處理中請求的上限
+type ServerConfig struct {
+	HTTPAddress     string        `mapstructure:"http_address"`
+	GRPCAddress     string        `mapstructure:"grpc_address"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+}
+
+// LoggingConfig 設定 zap logger，Level 為 debug、info、warn 或 error，Format 為 json 或 console
+type LoggingConfig struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+}
+
+// TransactionConfig 設定 serializable 交易發生序列化衝突時的重試
+// MaxRetries 為最多執行的次數，RetryBackoff 為每次重試前增加的等待時間
+type TransactionConfig struct {
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+// StripeConfig 設定 Stripe API
+// APIBase 留空時使用 Stripe 的正式端點，設定後改連到該位址，例如本機的 stripe-mock（http://localhost:12111）
+// Timeout 為每個 Stripe HTTP 請求的逾時，stripe-go 重試時每次請求各自計算；呼叫端的 ctx 另外限制整個呼叫，0 表示使用預設值
 type StripeConfig struct {
-	SecretKey string `mapstructure:"secret_key"`
+	SecretKey string        `mapstructure:"secret_key"`
+	APIBase   string        `mapstructure:"api_base"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// WebhookConfig 設定 Stripe webhook 的簽章驗證
+// Secrets 可同時包含多組 endpoint secret，輪替期間新舊 secret 皆可通過驗證
+type WebhookConfig struct {
+	Secrets   []string      `mapstructure:"secrets"`
+	Tolerance time.Duration `mapstructure:"tolerance"`
+}
+
+// TenantConfig 設定一個租戶（品牌）自己的 Stripe 帳號與 webhook endpoint
+// 頂層的 Stripe 與 Webhook 屬於 driver.DefaultTenantID 租戶；租戶未設定 APIBase、Timeout、Tolerance 時沿用頂層的值
+type TenantConfig struct {
+	ID      string        `mapstructure:"id"`
+	Stripe  StripeConfig  `mapstructure:"stripe"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+}
+
+// TenantConfigs 回傳包含預設租戶在內的所有租戶設定，並補上沿用頂層設定的欄位
+func (c *Config) TenantConfigs() []TenantConfig {
+	tenants := make([]TenantConfig, 0, len(c.Tenants)+1)
+	tenants = append(tenants, TenantConfig{
+		ID:      driver.DefaultTenantID,
+		Stripe:  c.Stripe,
+		Webhook: c.Webhook,
+	})
+
+	for _, tenant := range c.Tenants {
+		if tenant.Stripe.APIBase == "" {
+			tenant.Stripe.APIBase = c.Stripe.APIBase
+		}
+		if tenant.Stripe.Timeout <= 0 {
+			tenant.Stripe.Timeout = c.Stripe.Timeout
+		}
+		if tenant.Webhook.Tolerance <= 0 {
+			tenant.Webhook.Tolerance = c.Webhook.Tolerance
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants
+}
+
+// NATSConfig 設定事件管線使用的 NATS，URL 可以逗號分隔多個伺服器
+type NATSConfig struct {
+	URL       string          `mapstructure:"url"`
+	JetStream JetStreamConfig `mapstructure:"jetstream"`
+}
+
+// JetStreamConfig 設定以 JetStream 持久化事件管線，未啟用時使用 core NATS
+// 未設定的欄位會使用 payment 套件中的預設值
+type JetStreamConfig struct {
+	Enabled          bool            `mapstructure:"enabled"`
+	Stream           string          `mapstructure:"stream"`
+	Consumer         string          `mapstructure:"consumer"`
+	DeadLetterStream string          `mapstructure:"dead_letter_stream"`
+	MaxDeliver       int             `mapstructure:"max_deliver"`
+	AckWait          time.Duration   `mapstructure:"ack_wait"`
+	Backoff          []time.Duration `mapstructure:"backoff"`
+}
+
+// WorkerConfig 設定事件處理的 worker pool
+// 同一物件的事件固定由同一個 shard 依序處理，QueueSize 為每個 shard 的佇列上限
+type WorkerConfig struct {
+	Shards    int `mapstructure:"shards"`
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// RetryConfig 設定事件處理失敗後的重試
+// MaxAttempts、BaseDelay、MaxDelay 決定退避策略，Interval 與 BatchSize 控制排程器多久取出一批到期事件
+// 啟用 JetStream 時改由 JetStream 重送，排程器不會啟動
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+	Interval    time.Duration `mapstructure:"interval"`
+	BatchSize   int           `mapstructure:"batch_size"`
+}
+
+// OutboxConfig 設定將 outbox 中的領域事件發佈到 NATS 的 relay
+// 啟用 JetStream 時領域事件寫入 Stream，並以訊息 ID 在 DuplicateWindow 內去重
+type OutboxConfig struct {
+	Interval        time.Duration `mapstructure:"interval"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	Stream          string        `mapstructure:"stream"`
+	DuplicateWindow time.Duration `mapstructure:"duplicate_window"`
+}
+
+// ReconcileConfig 設定與 Stripe 對帳的排程
+// Interval 為 0 時不啟動排程，仍可透過 paymentctl reconcile 手動執行
+// Lookback 只比對這段時間內建立的 Stripe 物件，0 表示全部；Repair 為 true 時以 Stripe 的資料修正本地
+type ReconcileConfig struct {
+	Interval  time.Duration `mapstructure:"interval"`
+	Lookback  time.Duration `mapstructure:"lookback"`
+	Resources []string      `mapstructure:"resources"`
+	Repair    bool          `mapstructure:"repair"`
+}
+
+// BackfillConfig 設定從 Stripe 匯入既有資料
+// RateLimit 為每秒向 Stripe 發出的請求上限，應低於帳號的限制並保留額度給線上流量；PageSize 為每頁筆數，上限為 100
+type BackfillConfig struct {
+	RateLimit float64 `mapstructure:"rate_limit"`
+	PageSize  int64   `mapstructure:"page_size"`
+}
+
+// IdempotencyConfig 設定 Idempotency-Key 的保存時間
+// TTL 為完成的回應保存多久，期間內以同一個 key 重送會取得相同的回應；LockTTL 為處理中的請求佔用 key 的上限，處理程序中斷時逾時後可重試
+type IdempotencyConfig struct {
+	TTL     time.Duration `mapstructure:"ttl"`
+	LockTTL time.Duration `mapstructure:"lock_ttl"`
+}
+
+// RateLimitConfig 設定以 Redis token bucket 實作的限流，多個 API 實例共用同一個 bucket
+// 每個 API key 在每個路由群組各有一個 bucket，群組為路由權限的資源（例如 customers、payment_intents）；
+// Groups 覆寫個別群組的限制，APIKeys 以 key 的前綴覆寫該 key 在所有群組的限制，兩者皆未設定時使用 Default
+// Customer 為建立付款的端點對同一個客戶的限制，不論使用哪個 API key，用來減緩盜刷卡片的測試
+type RateLimitConfig struct {
+	Enabled  bool                     `mapstructure:"enabled"`
+	Default  RateLimitRule            `mapstructure:"default"`
+	Groups   map[string]RateLimitRule `mapstructure:"groups"`
+	APIKeys  map[string]RateLimitRule `mapstructure:"api_keys"`
+	Customer RateLimitRule            `mapstructure:"customer"`
+}
+
+// RateLimitRule 為一個 token bucket，每秒補充 Rate 個 token，最多累積 Burst 個；Rate 為 0 表示不限制
+type RateLimitRule struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// TracingConfig 設定 OpenTelemetry tracing
+// Exporter 為 otlp（以 gRPC 送到 Endpoint 的 collector）、stdout 或 memory（保留在記憶體中，供測試檢查），留空時不記錄 trace
+// SampleRatio 為沒有上游 trace 時的取樣比例，0 表示全部取樣；上游已決定是否取樣時沿用上游的決定
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// HealthConfig 設定啟動時的依賴檢查與 readiness
+// Degraded 為 true 時 Postgres、Redis 或 NATS 在啟動時無法連線仍繼續啟動，由 /readyz 回報，連線恢復後自動復原；預設為啟動失敗
+// WorkerSaturation 為任一 shard 的佇列使用率達到此比例時視為未就緒，預設為 0.9；Timeout 為每項檢查的逾時，預設為 2 秒
+type HealthConfig struct {
+	Degraded         bool          `mapstructure:"degraded"`
+	WorkerSaturation float64       `mapstructure:"worker_saturation"`
+	Timeout          time.Duration `mapstructure:"timeout"`
 }
 
 type PostgresConfig struct {
@@ -35,42 +228,88 @@ type PostgresConfig struct {
 type RedisConfig struct {
 	Addr     string `mapstructure:"addr"`
 	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
 }
 
-func ProvideApplicationConfig() (*Config, error) {
+// ProvideApplicationConfig 讀取設定檔並以 PAYMENT_ 開頭的環境變數覆寫，驗證失敗時回傳所有錯誤
+// 之後設定檔變更時重新讀取，驗證通過後通知以 OnReload 註冊的對象
+func ProvideApplicationConfig(file File) (*Config, error) {
 
-	viper.SetConfigFile("./config.yaml")
-	viper.SetConfigType("yaml")
-
-	if err := viper.ReadInConfig(); err != nil {
+	v := newViper(file)
+	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	config, err := load(v)
+	if err != nil {
+		return nil, err
 	}
 
-	return &config, nil
+	config.reload = &reloader{current: config}
+	config.reload.watch(v)
+
+	return config, nil
 }
 
+// ProvidePostgresConn 連線到 Postgres，並確認資料庫的 schema 版本與執行檔嵌入的 migration 相同、連線的角色受 row level security 限制
+// 以降級模式啟動時若無法連線，則改由 readiness 檢查在連上後確認
 func ProvidePostgresConn(appConfig *Config) (driver.PostgresPool, error) {
 
 	conn, err := driver.ConnectSQL(appConfig.Postgres.URL)
 	if err != nil {
+		if conn == nil || !appConfig.Health.Degraded {
+			return nil, err
+		}
+		// 連線池會在之後的請求重新連線
+		log.Println(fmt.Errorf("postgres is unavailable, starting in degraded mode, the schema version is checked once readiness can connect: %w", err))
+		return conn.Pool, nil
+	}
+
+	migrator, err := migrate.New(conn.Pool, migrations.FS)
+	if err != nil {
+		conn.Pool.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaCheckTimeout)
+	defer cancel()
+	if err = migrator.CheckVersion(ctx); err != nil {
+		conn.Pool.Close()
+		return nil, err
+	}
+	if err = driver.CheckRowLevelSecurity(ctx, conn.Pool); err != nil {
+		conn.Pool.Close()
 		return nil, err
 	}
 
 	return conn.Pool, nil
 }
 
-func ProvideEmber(appConfig *Config) (*ember.MultiCache, error) {
+// ProvideMigrator 連線到 Postgres 並讀取嵌入的 migration，不檢查 schema 版本，供 paymentctl migrate 使用
+func ProvideMigrator(appConfig *Config) (*migrate.Migrator, error) {
 
-	conn, err := driver.ConnectRedis(appConfig.Redis.Addr, appConfig.Redis.Password, 0)
+	conn, err := driver.ConnectSQL(appConfig.Postgres.URL)
 	if err != nil {
 		return nil, err
 	}
 
+	return migrate.New(conn.Pool, migrations.FS)
+}
+
+func ProvideRedis(appConfig *Config) (*redis.Client, error) {
+	client, err := driver.ConnectRedis(appConfig.Redis.Addr, appConfig.Redis.Password, appConfig.Redis.DB)
+	if err != nil {
+		if client == nil || !appConfig.Health.Degraded {
+			return nil, err
+		}
+		// client 會在之後的指令重新連線
+		log.Println(fmt.Errorf("redis is unavailable, starting in degraded mode: %w", err))
+	}
+	return client, nil
+}
+
+func ProvideEmber(conn *redis.Client) (*ember.MultiCache, error) {
+
 	config := emberConfig.NewConfig()
 	cache, err := ember.NewMultiCache(context.Background(), &config, conn)
 	if err != nil {
@@ -85,8 +324,41 @@ func ProvideIgnite() ignite.Manager {
 	return ignite.NewManager()
 }
 
-func NewLogger() *zap.Logger {
+// ProvideTransactionManager 建立 TransactionManager，重試次數與間隔會隨設定檔更新
+func ProvideTransactionManager(conn driver.PostgresPool, appConfig *Config, logger *zap.Logger) *driver.TransactionManager {
+	tm := driver.NewTransactionManager(conn, logger)
+	tm.SetRetryPolicy(appConfig.Transactions.MaxRetries, appConfig.Transactions.RetryBackoff)
+	appConfig.OnReload(func(next *Config) {
+		tm.SetRetryPolicy(next.Transactions.MaxRetries, next.Transactions.RetryBackoff)
+	})
+	return tm
+}
+
+// NewLogger 依 Logging 建立 logger，level 會隨設定檔更新
+func NewLogger(appConfig *Config) (*zap.Logger, error) {
+
+	zapConfig := zap.NewProductionConfig()
+	if appConfig.Logging.Format == "console" {
+		zapConfig = zap.NewDevelopmentConfig()
+	}
+
+	level, err := zap.ParseAtomicLevel(appConfig.Logging.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging.level: %w", err)
+	}
+	zapConfig.Level = level
+
+	logger, err := zapConfig.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %w", err)
+	}
+
+	appConfig.OnReload(func(next *Config) {
+		if next, err := zapcore.ParseLevel(next.Logging.Level); err == nil && next != level.Level() {
+			level.SetLevel(next)
+			logger.Info("log level changed", zap.Stringer("level", next))
+		}
+	})
 
-	logger, _ := zap.NewProduction()
-	return logger
+	return logger, nil
 }
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// File 為設定檔的路徑，由 --config 指定
+type File string
+
+const (
+	DefaultFile File = "./config.yaml"
+
+	// EnvPrefix 為覆寫設定的環境變數前綴，例如 PAYMENT_POSTGRES_URL 覆寫 postgres.url、PAYMENT_RATE_LIMIT_ENABLED 覆寫 rate_limit.enabled
+	EnvPrefix = "PAYMENT"
+)
+
+// newViper 建立讀取 file 的 viper，並設定預設值與環境變數覆寫
+func newViper(file File) *viper.Viper {
+	if file == "" {
+		file = DefaultFile
+	}
+
+	v := viper.New()
+	v.SetConfigFile(string(file))
+	v.SetConfigType("yaml")
+
+	v.SetDefault("server.http_address", ":8080")
+	v.SetDefault("server.grpc_address", ":50051")
+	v.SetDefault("server.shutdown_timeout", 5*time.Second)
+	v.SetDefault("logging.level", "info")
+	v.SetDefault("logging.format", "json")
+	v.SetDefault("nats.url", "nats://127.0.0.1:4222")
+	v.SetDefault("transactions.max_retries", 3)
+	v.SetDefault("transactions.retry_backoff", 100*time.Millisecond)
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	bindEnv(v, "", reflect.TypeOf(Config{}))
+
+	return v
+}
+
+// bindEnv 為 Config 的每個欄位綁定環境變數；viper 的 AutomaticEnv 只對設定檔中已有的 key 生效，Unmarshal 時會遺漏其他欄位
+// Tenants 等 struct 的 slice 與 map 無法以單一環境變數表示，不綁定；字串 slice 以逗號分隔
+func bindEnv(v *viper.Viper, prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Tag.Get("mapstructure")
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			bindEnv(v, key, field.Type)
+		case field.Type.Kind() == reflect.Map,
+			field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+		default:
+			_ = v.BindEnv(key)
+		}
+	}
+}
+
+// load 把 viper 目前的設定解析為 Config 並驗證
+func load(v *viper.Viper) (*Config, error) {
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", v.ConfigFileUsed(), err)
+	}
+
+	return &config, nil
+}
+
+// OnReload 註冊設定檔變更時的 callback，傳入驗證通過的新設定
+// 只有不含密鑰、可以在執行期間套用的設定會重新載入：logging.level、rate_limit、idempotency、health 與 transactions；
+// 其他設定（連線、密鑰、租戶、worker 數量等）需要重新啟動才會生效
+func (c *Config) OnReload(fn func(next *Config)) {
+	if c.reload == nil {
+		return
+	}
+
+	c.reload.mu.Lock()
+	defer c.reload.mu.Unlock()
+	c.reload.listeners = append(c.reload.listeners, fn)
+}
+
+// reloader 監看設定檔，變更時通知以 OnReload 註冊的 callback
+type reloader struct {
+	mu        sync.Mutex
+	current   *Config
+	listeners []func(next *Config)
+}
+
+func (r *reloader) watch(v *viper.Viper) {
+	v.OnConfigChange(func(e fsnotify.Event) {
+		next, err := load(v)
+		if err != nil {
+			log.Println(fmt.Errorf("ignoring config change: %w", err))
+			return
+		}
+		r.apply(next)
+	})
+	v.WatchConfig()
+}
+
+func (r *reloader) apply(next *Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if changed := restartRequired(r.current, next); len(changed) > 0 {
+		log.Printf("config changes to %s take effect after a restart", strings.Join(changed, ", "))
+	}
+
+	r.current = next
+	for _, fn := range r.listeners {
+		fn(next)
+	}
+}
+
+// restartRequired 回傳無法在執行期間套用且有變更的設定區塊
+func restartRequired(current, next *Config) []string {
+	sections := []struct {
+		name          string
+		current, next any
+	}{
+		{"server", current.Server, next.Server},
+		{"logging.format", current.Logging.Format, next.Logging.Format},
+		{"stripe", current.Stripe, next.Stripe},
+		{"postgres", current.Postgres, next.Postgres},
+		{"redis", current.Redis, next.Redis},
+		{"webhook", current.Webhook, next.Webhook},
+		{"nats", current.NATS, next.NATS},
+		{"workers", current.Workers, next.Workers},
+		{"retry", current.Retry, next.Retry},
+		{"outbox", current.Outbox, next.Outbox},
+		{"reconcile", current.Reconcile, next.Reconcile},
+		{"backfill", current.Backfill, next.Backfill},
+		{"tracing", current.Tracing, next.Tracing},
+		{"health.degraded", current.Health.Degraded, next.Health.Degraded},
+		{"tenants", current.Tenants, next.Tenants},
+	}
+
+	var changed []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.current, section.next) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"goflare.io/payment/driver"
+)
+
+// Validate 檢查必填欄位與數值範圍，回傳所有不合法的設定，而不是只回傳第一個
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.HTTPAddress != "", "server.http_address is required")
+	check(c.Server.GRPCAddress != "", "server.grpc_address is required")
+	check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout must not be negative")
+
+	if _, err := zapcore.ParseLevel(c.Logging.Level); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %w", err))
+	}
+	check(c.Logging.Format == "json" || c.Logging.Format == "console", "logging.format must be json or console, got %q", c.Logging.Format)
+
+	check(c.Stripe.SecretKey != "", "stripe.secret_key is required")
+	check(c.Stripe.Timeout >= 0, "stripe.timeout must not be negative")
+	if c.Stripe.APIBase != "" {
+		if u, err := url.Parse(c.Stripe.APIBase); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("stripe.api_base must be an absolute URL, got %q", c.Stripe.APIBase))
+		}
+	}
+	check(c.Webhook.Tolerance >= 0, "webhook.tolerance must not be negative")
+
+	check(c.Postgres.URL != "", "postgres.url is required")
+	check(c.Redis.Addr != "", "redis.addr is required")
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+
+	check(c.NATS.URL != "", "nats.url is required")
+	for _, server := range strings.Split(c.NATS.URL, ",") {
+		if u, err := url.Parse(strings.TrimSpace(server)); err != nil || u.Host == "" {
+			errs = append(errs, fmt.Errorf("nats.url contains an invalid server %q", server))
+		}
+	}
+	check(c.NATS.JetStream.MaxDeliver >= 0, "nats.jetstream.max_deliver must not be negative")
+
+	check(c.Workers.Shards >= 0, "workers.shards must not be negative")
+	check(c.Workers.QueueSize >= 0, "workers.queue_size must not be negative")
+	check(c.Transactions.MaxRetries >= 1, "transactions.max_retries must be at least 1")
+	check(c.Transactions.RetryBackoff >= 0, "transactions.retry_backoff must not be negative")
+	check(c.Retry.MaxAttempts >= 0, "retry.max_attempts must not be negative")
+	check(c.Retry.MaxDelay == 0 || c.Retry.MaxDelay >= c.Retry.BaseDelay, "retry.max_delay must not be less than retry.base_delay")
+
+	check(c.Backfill.RateLimit >= 0, "backfill.rate_limit must not be negative")
+	check(c.Backfill.PageSize >= 0 && c.Backfill.PageSize <= 100, "backfill.page_size must be between 0 and 100")
+	check(c.Idempotency.TTL >= 0 && c.Idempotency.LockTTL >= 0, "idempotency.ttl and idempotency.lock_ttl must not be negative")
+
+	rules := map[string]RateLimitRule{"rate_limit.default": c.RateLimit.Default, "rate_limit.customer": c.RateLimit.Customer}
+	for group, rule := range c.RateLimit.Groups {
+		rules["rate_limit.groups."+group] = rule
+	}
+	for prefix, rule := range c.RateLimit.APIKeys {
+		rules["rate_limit.api_keys."+prefix] = rule
+	}
+	for name, rule := range rules {
+		check(rule.Rate >= 0 && rule.Burst >= 0, "%s: rate and burst must not be negative", name)
+	}
+
+	switch c.Tracing.Exporter {
+	case "", "otlp", "stdout", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be otlp, stdout or memory, got %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	check(c.Health.WorkerSaturation >= 0 && c.Health.WorkerSaturation <= 1, "health.worker_saturation must be between 0 and 1")
+	check(c.Health.Timeout >= 0, "health.timeout must not be negative")
+
+	seen := map[string]bool{driver.DefaultTenantID: true}
+	for i, tenant := range c.Tenants {
+		switch {
+		case tenant.ID == "":
+			errs = append(errs, fmt.Errorf("tenants[%d].id is required", i))
+		case seen[tenant.ID]:
+			errs = append(errs, fmt.Errorf("tenants[%d].id %q is reserved or duplicated", i, tenant.ID))
+		case strings.ContainsAny(tenant.ID, ".*> \t"):
+			// 租戶 ID 是 dead-letter subject 的一個 token
+			errs = append(errs, fmt.Errorf("tenants[%d].id %q must not contain '.', '*', '>' or whitespace", i, tenant.ID))
+		}
+		seen[tenant.ID] = true
+		check(tenant.Stripe.SecretKey != "", "tenants[%d].stripe.secret_key is required", i)
+	}
+
+	return errors.Join(errs...)
+}
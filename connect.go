@@ -0,0 +1,240 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/models"
+)
+
+// CreateConnectedAccount 建立 Stripe Connect 連結帳號並申請 card_payments 與 transfers capability
+// 帳號建立後需以 CreateAccountLink 讓商家完成 onboarding，capability 的狀態之後由 account.updated 同步
+func (sp *StripePayment) CreateConnectedAccount(ctx context.Context, idempotencyKey string, req models.ConnectedAccountParams) (*models.ConnectedAccount, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accountType := req.Type
+	if accountType == "" {
+		accountType = stripe.AccountTypeExpress
+	}
+
+	params := &stripe.AccountParams{
+		Type: stripe.String(string(accountType)),
+		Capabilities: &stripe.AccountCapabilitiesParams{
+			CardPayments: &stripe.AccountCapabilitiesCardPaymentsParams{Requested: stripe.Bool(true)},
+			Transfers:    &stripe.AccountCapabilitiesTransfersParams{Requested: stripe.Bool(true)},
+		},
+	}
+	if req.Country != "" {
+		params.Country = stripe.String(req.Country)
+	}
+	if req.Email != "" {
+		params.Email = stripe.String(req.Email)
+	}
+
+	stripeAccount, err := sc.Accounts.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe connected account: %w", err)
+	}
+
+	if err = sp.connectedAccount.Upsert(ctx, partialConnectedAccountFromStripe(stripeAccount, objectCreatedAt(stripeAccount.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local connected account record: %w", err)
+	}
+
+	return sp.connectedAccount.GetByID(ctx, stripeAccount.ID)
+}
+
+// GetConnectedAccount retrieves a connected account from the local database
+func (sp *StripePayment) GetConnectedAccount(ctx context.Context, accountID string) (*models.ConnectedAccount, error) {
+	return sp.connectedAccount.GetByID(ctx, accountID)
+}
+
+// CreateAccountLink 建立連結帳號的 onboarding 網址
+// 網址過期或已使用時 Stripe 會把商家導向 refreshURL，應在該頁面重新呼叫本方法；完成後導向 returnURL
+func (sp *StripePayment) CreateAccountLink(ctx context.Context, idempotencyKey, accountID, refreshURL, returnURL string) (*models.AccountLink, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.AccountLinkParams{
+		Account:    stripe.String(accountID),
+		RefreshURL: stripe.String(refreshURL),
+		ReturnURL:  stripe.String(returnURL),
+		Type:       stripe.String(string(stripe.AccountLinkTypeAccountOnboarding)),
+	}
+
+	link, err := sc.AccountLinks.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe account link: %w", err)
+	}
+
+	return &models.AccountLink{
+		URL:       link.URL,
+		ExpiresAt: time.Unix(link.ExpiresAt, 0),
+	}, nil
+}
+
+// CreateTransfer 從平台餘額轉帳給連結帳號（separate charges and transfers）
+// sourceTransactionID 為款項來源的 charge，指定後轉帳會等該筆款項可用時才執行；transferGroup 用於關聯同一筆訂單的付款與轉帳
+func (sp *StripePayment) CreateTransfer(ctx context.Context, idempotencyKey, destinationAccountID string, amount models.Money, sourceTransactionID, transferGroup string) (*models.Transfer, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.TransferParams{
+		Amount:      stripe.Int64(amount.Amount),
+		Currency:    stripe.String(string(amount.Currency)),
+		Destination: stripe.String(destinationAccountID),
+	}
+	if sourceTransactionID != "" {
+		params.SourceTransaction = stripe.String(sourceTransactionID)
+	}
+	if transferGroup != "" {
+		params.TransferGroup = stripe.String(transferGroup)
+	}
+
+	stripeTransfer, err := sc.Transfers.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe transfer: %w", err)
+	}
+
+	if err = sp.transfer.Upsert(ctx, partialTransferFromStripe(stripeTransfer, objectCreatedAt(stripeTransfer.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local transfer record: %w", err)
+	}
+
+	return sp.transfer.GetByID(ctx, stripeTransfer.ID)
+}
+
+// GetTransfer retrieves a transfer from the local database
+func (sp *StripePayment) GetTransfer(ctx context.Context, transferID string) (*models.Transfer, error) {
+	return sp.transfer.GetByID(ctx, transferID)
+}
+
+// ReverseTransfer 從連結帳號取回轉帳金額，amount 為 0 時取回尚未取回的全部金額
+// 取回後重新讀取轉帳以更新本地的 amount_reversed 與 reversed
+func (sp *StripePayment) ReverseTransfer(ctx context.Context, idempotencyKey, transferID string, amount int64) (*models.TransferReversal, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.TransferReversalParams{
+		ID: stripe.String(transferID),
+	}
+	if amount > 0 {
+		params.Amount = stripe.Int64(amount)
+	}
+
+	reversal, err := sc.TransferReversals.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reverse Stripe transfer: %w", err)
+	}
+
+	stripeTransfer, err := sc.Transfers.Get(transferID, stripeParams(ctx, &stripe.TransferParams{}, ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Stripe transfer: %w", err)
+	}
+
+	if err = sp.transfer.Upsert(ctx, partialTransferFromStripe(stripeTransfer, objectCreatedAt(stripeTransfer.Created))); err != nil {
+		return nil, fmt.Errorf("failed to update local transfer record: %w", err)
+	}
+
+	return &models.TransferReversal{
+		ID:         reversal.ID,
+		TransferID: transferID,
+		Amount:     models.NewMoney(reversal.Amount, reversal.Currency),
+		CreatedAt:  time.Unix(reversal.Created, 0),
+	}, nil
+}
+
+// GetApplicationFee retrieves an application fee from the local database
+func (sp *StripePayment) GetApplicationFee(ctx context.Context, feeID string) (*models.ApplicationFee, error) {
+	return sp.applicationFee.GetByID(ctx, feeID)
+}
+
+func (sp *StripePayment) handleAccountEvent(ctx context.Context, stripeEvent *stripe.Event) error {
+
+	sp.logger.Info("Stripe account event", zap.String("event_id", stripeEvent.ID))
+
+	var err error
+	switch stripeEvent.Type {
+	case stripe.EventTypeAccountUpdated:
+		// 平台自己的帳號也會送出 account.updated，只有 Connect endpoint 送出的事件帶有 event.account
+		if stripeEvent.Account == "" {
+			sp.logger.Info("Skipping account.updated for the platform account", zap.String("event_id", stripeEvent.ID))
+			return nil
+		}
+		account := new(stripe.Account)
+		if err = json.Unmarshal(stripeEvent.Data.Raw, &account); err != nil {
+			sp.logger.Error("Failed to unmarshal account event", zap.Error(err))
+			return err
+		}
+		err = sp.connectedAccount.Upsert(ctx, partialConnectedAccountFromStripe(account, eventCreatedAt(stripeEvent)))
+	case stripe.EventTypeAccountApplicationDeauthorized:
+		// 事件內容為平台的 application，被撤銷授權的連結帳號在 event.account
+		if stripeEvent.Account == "" {
+			sp.logger.Warn("account.application.deauthorized without account", zap.String("event_id", stripeEvent.ID))
+			return nil
+		}
+		err = sp.connectedAccount.Deauthorize(ctx, stripeEvent.Account, *eventCreatedAt(stripeEvent))
+	default:
+		sp.logger.Error(fmt.Sprintf("unexpected account event type: %s", stripeEvent.Type))
+	}
+	if err != nil {
+		sp.logger.Error("Failed to update connected account", zap.Error(err))
+		return err
+	}
+
+	sp.logger.Info("Stripe account event processed", zap.String("event_id", stripeEvent.ID))
+
+	return nil
+}
+
+func (sp *StripePayment) handleTransferEvent(ctx context.Context, stripeEvent *stripe.Event) error {
+
+	sp.logger.Info("Stripe transfer event", zap.String("event_id", stripeEvent.ID))
+
+	transfer := new(stripe.Transfer)
+	if err := json.Unmarshal(stripeEvent.Data.Raw, &transfer); err != nil {
+		sp.logger.Error("Failed to unmarshal transfer event", zap.Error(err))
+		return err
+	}
+
+	if err := sp.transfer.Upsert(ctx, partialTransferFromStripe(transfer, eventCreatedAt(stripeEvent))); err != nil {
+		sp.logger.Error("Failed to upsert transfer", zap.Error(err))
+		return err
+	}
+
+	sp.logger.Info("Stripe transfer event processed", zap.String("event_id", stripeEvent.ID))
+
+	return nil
+}
+
+func (sp *StripePayment) handleApplicationFeeEvent(ctx context.Context, stripeEvent *stripe.Event) error {
+
+	sp.logger.Info("Stripe application fee event", zap.String("event_id", stripeEvent.ID))
+
+	fee := new(stripe.ApplicationFee)
+	if err := json.Unmarshal(stripeEvent.Data.Raw, &fee); err != nil {
+		sp.logger.Error("Failed to unmarshal application fee event", zap.Error(err))
+		return err
+	}
+
+	if err := sp.applicationFee.Upsert(ctx, partialApplicationFeeFromStripe(fee, eventCreatedAt(stripeEvent))); err != nil {
+		sp.logger.Error("Failed to upsert application fee", zap.Error(err))
+		return err
+	}
+
+	sp.logger.Info("Stripe application fee event processed", zap.String("event_id", stripeEvent.ID))
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package connected_account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/sqlc"
+)
+
+type Repository interface {
+	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.ConnectedAccount, error)
+	Upsert(ctx context.Context, tx pgx.Tx, account *models.PartialConnectedAccount) error
+	Deauthorize(ctx context.Context, tx pgx.Tx, id string, deauthorizedAt time.Time) error
+}
+
+type repository struct {
+	conn driver.PostgresPool
+}
+
+func NewRepository(conn driver.PostgresPool) Repository {
+	return &repository{conn: conn}
+}
+
+func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.ConnectedAccount, error) {
+	sqlcAccount, err := sqlc.New(r.conn).WithTx(tx).GetConnectedAccount(ctx, sqlc.GetConnectedAccountParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connected account: %w", err)
+	}
+
+	return models.NewConnectedAccount().ConvertFromSQLCConnectedAccount(sqlcAccount), nil
+}
+
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, account *models.PartialConnectedAccount) error {
+	const query = `
+    INSERT INTO connected_accounts (id, type, email, country, charges_enabled, payouts_enabled, details_submitted, capabilities, requirements_due, disabled_reason, created_at, updated_at, last_event_at)
+    VALUES (@id, @type, @email, @country, COALESCE(@charges_enabled, FALSE), COALESCE(@payouts_enabled, FALSE), COALESCE(@details_submitted, FALSE),
+            COALESCE(@capabilities, '{}'::jsonb), COALESCE(@requirements_due, '{}'::text[]), @disabled_reason, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
+    ON CONFLICT (id) DO UPDATE SET
+        type = COALESCE(@type, connected_accounts.type),
+        email = COALESCE(@email, connected_accounts.email),
+        country = COALESCE(@country, connected_accounts.country),
+        charges_enabled = COALESCE(@charges_enabled, connected_accounts.charges_enabled),
+        payouts_enabled = COALESCE(@payouts_enabled, connected_accounts.payouts_enabled),
+        details_submitted = COALESCE(@details_submitted, connected_accounts.details_submitted),
+        capabilities = COALESCE(@capabilities, connected_accounts.capabilities),
+        requirements_due = COALESCE(@requirements_due, connected_accounts.requirements_due),
+        disabled_reason = COALESCE(@disabled_reason, connected_accounts.disabled_reason),
+        last_event_at = COALESCE(@last_event_at, connected_accounts.last_event_at),
+        updated_at = @updated_at
+    WHERE connected_accounts.id = @id
+      AND connected_accounts.tenant_id = @tenant_id
+      AND (connected_accounts.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR connected_accounts.last_event_at <= @last_event_at)
+    `
+
+	now := time.Now()
+	args := pgx.NamedArgs{
+		"id":                account.ID,
+		"type":              account.Type,
+		"email":             account.Email,
+		"country":           account.Country,
+		"charges_enabled":   account.ChargesEnabled,
+		"payouts_enabled":   account.PayoutsEnabled,
+		"details_submitted": account.DetailsSubmitted,
+		"capabilities":      account.Capabilities,
+		"requirements_due":  account.RequirementsDue,
+		"disabled_reason":   account.DisabledReason,
+		"created_at":        account.CreatedAt,
+		"updated_at":        now,
+		"last_event_at":     account.LastEventAt,
+		"tenant_id":         driver.TenantID(ctx),
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("failed to upsert connected account: %w", err)
+	}
+
+	return nil
+}
+
+// Deauthorize 記錄連結帳號撤銷平台授權的時間，撤銷後平台無法再以該帳號收款或轉帳
+func (r *repository) Deauthorize(ctx context.Context, tx pgx.Tx, id string, deauthorizedAt time.Time) error {
+	return sqlc.New(r.conn).WithTx(tx).DeauthorizeConnectedAccount(ctx, sqlc.DeauthorizeConnectedAccountParams{
+		ID:             id,
+		DeauthorizedAt: pgtype.Timestamptz{Time: deauthorizedAt, Valid: true},
+		TenantID:       driver.TenantID(ctx),
+	})
+}
@@ -0,0 +1,51 @@
+package connected_account
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+type Service interface {
+	GetByID(ctx context.Context, id string) (*models.ConnectedAccount, error)
+	Upsert(ctx context.Context, account *models.PartialConnectedAccount) error
+	Deauthorize(ctx context.Context, id string, deauthorizedAt time.Time) error
+}
+
+type service struct {
+	repo               Repository
+	transactionManager *driver.TransactionManager
+}
+
+func NewService(repo Repository, tm *driver.TransactionManager) Service {
+	return &service{
+		repo:               repo,
+		transactionManager: tm,
+	}
+}
+
+func (s *service) GetByID(ctx context.Context, id string) (*models.ConnectedAccount, error) {
+	var account *models.ConnectedAccount
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		account, err = s.repo.GetByID(ctx, tx, id)
+		return err
+	})
+	return account, err
+}
+
+func (s *service) Upsert(ctx context.Context, account *models.PartialConnectedAccount) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Upsert(ctx, tx, account)
+	})
+}
+
+func (s *service) Deauthorize(ctx context.Context, id string, deauthorizedAt time.Time) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Deauthorize(ctx, tx, id, deauthorizedAt)
+	})
+}
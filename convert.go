@@ -0,0 +1,562 @@
+package payment
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/models"
+)
+
+// partialCustomerFromStripe 將 Stripe 客戶轉為 Upsert 使用的部分欄位，webhook 與對帳修復共用
+// lastEventAt 為 nil 時不受 last_event_at 的先後檢查限制
+func partialCustomerFromStripe(customer *stripe.Customer, lastEventAt *time.Time) *models.PartialCustomer {
+	partialCustomer := &models.PartialCustomer{
+		ID:          customer.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if customer.Email != "" {
+		partialCustomer.Email = &customer.Email
+	}
+
+	// Stripe 一律回傳 balance，歸零時也要寫回，否則本地會停留在舊的餘額
+	balance := customer.Balance
+	partialCustomer.Balance = &balance
+	if customer.Created > 0 {
+		createdAt := time.Unix(customer.Created, 0)
+		partialCustomer.CreatedAt = &createdAt
+	}
+
+	return partialCustomer
+}
+
+// partialSubscriptionFromStripe 將 Stripe 訂閱轉為 Upsert 使用的部分欄位
+// lastEventAt 為 nil 時不受 last_event_at 的先後檢查限制
+func partialSubscriptionFromStripe(subscription *stripe.Subscription, lastEventAt *time.Time) *models.PartialSubscription {
+	partialSubscription := &models.PartialSubscription{
+		ID:          subscription.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if subscription.Customer != nil {
+		partialSubscription.CustomerID = &subscription.Customer.ID
+	}
+	if subscription.Items != nil && len(subscription.Items.Data) > 0 && subscription.Items.Data[0].Price != nil {
+		partialSubscription.PriceID = &subscription.Items.Data[0].Price.ID
+	}
+	if subscription.Status != "" {
+		partialSubscription.Status = &subscription.Status
+	}
+	if subscription.CurrentPeriodStart > 0 {
+		start := time.Unix(subscription.CurrentPeriodStart, 0)
+		partialSubscription.CurrentPeriodStart = &start
+	}
+	if subscription.CurrentPeriodEnd > 0 {
+		end := time.Unix(subscription.CurrentPeriodEnd, 0)
+		partialSubscription.CurrentPeriodEnd = &end
+	}
+	partialSubscription.CancelAtPeriodEnd = &subscription.CancelAtPeriodEnd
+	if subscription.CanceledAt > 0 {
+		canceledAt := time.Unix(subscription.CanceledAt, 0)
+		partialSubscription.CanceledAt = &canceledAt
+	}
+
+	return partialSubscription
+}
+
+// partialInvoiceFromStripe 將 Stripe 發票轉為 Upsert 使用的部分欄位
+// lastEventAt 為 nil 時不受 last_event_at 的先後檢查限制
+func partialInvoiceFromStripe(invoice *stripe.Invoice, lastEventAt *time.Time) *models.PartialInvoice {
+	partialInvoice := &models.PartialInvoice{
+		ID:          invoice.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if invoice.Customer != nil {
+		partialInvoice.CustomerID = &invoice.Customer.ID
+	}
+	if invoice.Subscription != nil {
+		partialInvoice.SubscriptionID = &invoice.Subscription.ID
+	}
+	if invoice.Status != "" {
+		partialInvoice.Status = &invoice.Status
+	}
+	if invoice.Currency != "" {
+		partialInvoice.Currency = &invoice.Currency
+	}
+
+	partialInvoice.AmountDue = &invoice.AmountDue
+	partialInvoice.AmountPaid = &invoice.AmountPaid
+	partialInvoice.AmountRemaining = &invoice.AmountRemaining
+
+	if invoice.DueDate > 0 {
+		dueDate := time.Unix(invoice.DueDate, 0)
+		partialInvoice.DueDate = &dueDate
+	}
+	if invoice.Created > 0 {
+		createdAt := time.Unix(invoice.Created, 0)
+		partialInvoice.CreatedAt = &createdAt
+	}
+
+	// 優先使用 Stripe 記錄的付款時間，對帳修復時才不會以修復當下作為付款時間
+	if invoice.StatusTransitions != nil && invoice.StatusTransitions.PaidAt > 0 {
+		paidAt := time.Unix(invoice.StatusTransitions.PaidAt, 0)
+		partialInvoice.PaidAt = &paidAt
+	} else if invoice.Status == stripe.InvoiceStatusPaid {
+		now := time.Now()
+		partialInvoice.PaidAt = &now
+	}
+
+	return partialInvoice
+}
+
+// partialPaymentIntentFromStripe 將 Stripe 支付意圖轉為 Upsert 使用的部分欄位
+// lastEventAt 為 nil 時不受 last_event_at 的先後檢查限制
+func partialPaymentIntentFromStripe(paymentIntent *stripe.PaymentIntent, lastEventAt *time.Time) *models.PartialPaymentIntent {
+	partialPaymentIntent := &models.PartialPaymentIntent{
+		ID:          paymentIntent.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if paymentIntent.Customer != nil {
+		partialPaymentIntent.CustomerID = &paymentIntent.Customer.ID
+	}
+	if paymentIntent.Amount > 0 {
+		partialPaymentIntent.Amount = &paymentIntent.Amount
+	}
+	if paymentIntent.Currency != "" {
+		partialPaymentIntent.Currency = &paymentIntent.Currency
+	}
+	if paymentIntent.Status != "" {
+		partialPaymentIntent.Status = &paymentIntent.Status
+	}
+	if paymentIntent.PaymentMethod != nil {
+		partialPaymentIntent.PaymentMethodID = &paymentIntent.PaymentMethod.ID
+	}
+	if paymentIntent.SetupFutureUsage != "" {
+		partialPaymentIntent.SetupFutureUsage = &paymentIntent.SetupFutureUsage
+	}
+	if paymentIntent.ClientSecret != "" {
+		partialPaymentIntent.ClientSecret = &paymentIntent.ClientSecret
+	}
+	if paymentIntent.CaptureMethod != "" {
+		partialPaymentIntent.CaptureMethod = &paymentIntent.CaptureMethod
+	}
+	if paymentIntent.OnBehalfOf != nil {
+		partialPaymentIntent.OnBehalfOf = &paymentIntent.OnBehalfOf.ID
+	}
+	if paymentIntent.TransferData != nil && paymentIntent.TransferData.Destination != nil {
+		partialPaymentIntent.TransferDestination = &paymentIntent.TransferData.Destination.ID
+	}
+	if paymentIntent.ApplicationFeeAmount > 0 {
+		partialPaymentIntent.ApplicationFeeAmount = &paymentIntent.ApplicationFeeAmount
+	}
+	if paymentIntent.Created > 0 {
+		createdAt := time.Unix(paymentIntent.Created, 0)
+		partialPaymentIntent.CreatedAt = &createdAt
+	}
+
+	return partialPaymentIntent
+}
+
+// partialProductFromStripe 將 Stripe 商品轉為 Upsert 使用的部分欄位
+func partialProductFromStripe(product *stripe.Product, lastEventAt *time.Time) *models.PartialProduct {
+	partialProduct := &models.PartialProduct{
+		ID:          product.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if product.Name != "" {
+		partialProduct.Name = &product.Name
+	}
+	if product.Description != "" {
+		partialProduct.Description = &product.Description
+	}
+	partialProduct.Active = &product.Active
+	if product.Metadata != nil {
+		partialProduct.Metadata = &product.Metadata
+	}
+
+	return partialProduct
+}
+
+// partialPriceFromStripe 將 Stripe 價格轉為 Upsert 使用的部分欄位
+func partialPriceFromStripe(price *stripe.Price, lastEventAt *time.Time) *models.PartialPrice {
+	partialPrice := &models.PartialPrice{
+		ID:          price.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if price.Product != nil {
+		partialPrice.ProductID = &price.Product.ID
+	}
+	partialPrice.Active = &price.Active
+	if price.Currency != "" {
+		partialPrice.Currency = &price.Currency
+	}
+	if price.UnitAmount > 0 {
+		partialPrice.UnitAmount = &price.UnitAmount
+	}
+	if price.Type != "" {
+		partialPrice.Type = &price.Type
+	}
+	if price.Recurring != nil {
+		if price.Recurring.Interval != "" {
+			partialPrice.RecurringInterval = &price.Recurring.Interval
+		}
+		if price.Recurring.IntervalCount > 0 {
+			intervalCount := int32(price.Recurring.IntervalCount)
+			partialPrice.RecurringIntervalCount = &intervalCount
+		}
+	}
+
+	return partialPrice
+}
+
+// partialPaymentMethodFromStripe 將 Stripe 支付方式轉為 Upsert 使用的部分欄位
+func partialPaymentMethodFromStripe(paymentMethod *stripe.PaymentMethod, lastEventAt *time.Time) *models.PartialPaymentMethod {
+	partialPaymentMethod := &models.PartialPaymentMethod{
+		ID:          paymentMethod.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if paymentMethod.Customer != nil {
+		partialPaymentMethod.CustomerID = &paymentMethod.Customer.ID
+	}
+	if paymentMethod.Type != "" {
+		pmType := paymentMethod.Type
+		partialPaymentMethod.Type = &pmType
+	}
+	if paymentMethod.Created > 0 {
+		createdAt := time.Unix(paymentMethod.Created, 0)
+		partialPaymentMethod.CreatedAt = &createdAt
+	}
+
+	switch paymentMethod.Type {
+	case stripe.PaymentMethodTypeCard:
+		if paymentMethod.Card != nil {
+			if paymentMethod.Card.Last4 != "" {
+				partialPaymentMethod.CardLast4 = &paymentMethod.Card.Last4
+			}
+			if paymentMethod.Card.Brand != "" {
+				partialPaymentMethod.CardBrand = &paymentMethod.Card.Brand
+			}
+			if paymentMethod.Card.ExpMonth > 0 {
+				expMonth := int32(paymentMethod.Card.ExpMonth)
+				partialPaymentMethod.CardExpMonth = &expMonth
+			}
+			if paymentMethod.Card.ExpYear > 0 {
+				expYear := int32(paymentMethod.Card.ExpYear)
+				partialPaymentMethod.CardExpYear = &expYear
+			}
+		}
+	case stripe.PaymentMethodTypeUSBankAccount:
+		if paymentMethod.USBankAccount != nil {
+			if paymentMethod.USBankAccount.Last4 != "" {
+				partialPaymentMethod.BankAccountLast4 = &paymentMethod.USBankAccount.Last4
+			}
+			if paymentMethod.USBankAccount.BankName != "" {
+				partialPaymentMethod.BankAccountBankName = &paymentMethod.USBankAccount.BankName
+			}
+		}
+	}
+
+	return partialPaymentMethod
+}
+
+// partialChargeFromStripe 將 Stripe 扣款轉為 Upsert 使用的部分欄位
+func partialChargeFromStripe(charge *stripe.Charge, lastEventAt *time.Time) *models.PartialCharge {
+	partialCharge := &models.PartialCharge{
+		ID:          charge.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if charge.Customer != nil {
+		partialCharge.CustomerID = &charge.Customer.ID
+	}
+	if charge.PaymentIntent != nil {
+		partialCharge.PaymentIntentID = &charge.PaymentIntent.ID
+	}
+	if charge.Amount > 0 {
+		partialCharge.Amount = &charge.Amount
+	}
+	if charge.Currency != "" {
+		partialCharge.Currency = &charge.Currency
+	}
+	if charge.Status != "" {
+		partialCharge.Status = &charge.Status
+	}
+	partialCharge.Paid = &charge.Paid
+	partialCharge.Refunded = &charge.Refunded
+	if charge.FailureCode != "" {
+		partialCharge.FailureCode = &charge.FailureCode
+	}
+	if charge.FailureMessage != "" {
+		partialCharge.FailureMessage = &charge.FailureMessage
+	}
+	if charge.Created > 0 {
+		createdAt := time.Unix(charge.Created, 0)
+		partialCharge.CreatedAt = &createdAt
+	}
+
+	return partialCharge
+}
+
+// partialRefundFromStripe 將 Stripe 退款轉為 Upsert 使用的部分欄位
+func partialRefundFromStripe(refund *stripe.Refund, lastEventAt *time.Time) *models.PartialRefund {
+	partialRefund := &models.PartialRefund{
+		ID:          refund.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if refund.Charge != nil {
+		partialRefund.ChargeID = &refund.Charge.ID
+	}
+	if refund.Amount > 0 {
+		partialRefund.Amount = &refund.Amount
+	}
+	if refund.Currency != "" {
+		partialRefund.Currency = &refund.Currency
+	}
+	if refund.Status != "" {
+		partialRefund.Status = &refund.Status
+	}
+	if refund.Reason != "" {
+		partialRefund.Reason = &refund.Reason
+	}
+	if refund.Created > 0 {
+		createdAt := time.Unix(refund.Created, 0)
+		partialRefund.CreatedAt = &createdAt
+	}
+
+	return partialRefund
+}
+
+// partialDisputeFromStripe 將 Stripe 爭議轉為 Upsert 使用的部分欄位
+func partialDisputeFromStripe(dispute *stripe.Dispute, lastEventAt *time.Time) *models.PartialDispute {
+	partialDispute := &models.PartialDispute{
+		ID:          dispute.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if dispute.Charge != nil {
+		partialDispute.ChargeID = &dispute.Charge.ID
+	}
+	if dispute.Amount > 0 {
+		partialDispute.Amount = &dispute.Amount
+	}
+	if dispute.Status != "" {
+		partialDispute.Status = &dispute.Status
+	}
+	if dispute.Reason != "" {
+		partialDispute.Reason = &dispute.Reason
+	}
+	if dispute.Created > 0 {
+		createdAt := time.Unix(dispute.Created, 0)
+		partialDispute.CreatedAt = &createdAt
+	}
+
+	return partialDispute
+}
+
+// partialCouponFromStripe 將 Stripe 優惠券轉為 Upsert 使用的部分欄位
+func partialCouponFromStripe(coupon *stripe.Coupon, lastEventAt *time.Time) *models.PartialCoupon {
+	partialCoupon := &models.PartialCoupon{
+		ID:          coupon.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if coupon.Name != "" {
+		partialCoupon.Name = &coupon.Name
+	}
+	if coupon.Currency != "" {
+		partialCoupon.Currency = &coupon.Currency
+	}
+	if coupon.Duration != "" {
+		partialCoupon.Duration = &coupon.Duration
+	}
+	timesRedeemed := int32(coupon.TimesRedeemed)
+	partialCoupon.TimesRedeemed = &timesRedeemed
+	partialCoupon.Valid = &coupon.Valid
+	if coupon.Created > 0 {
+		createdAt := time.Unix(coupon.Created, 0)
+		partialCoupon.CreatedAt = &createdAt
+	}
+
+	if coupon.AmountOff > 0 {
+		partialCoupon.AmountOff = &coupon.AmountOff
+	}
+	if coupon.PercentOff > 0 {
+		partialCoupon.PercentOff = &coupon.PercentOff
+	}
+	if coupon.DurationInMonths > 0 {
+		durationInMonths := int(coupon.DurationInMonths)
+		partialCoupon.DurationInMonths = &durationInMonths
+	}
+	if coupon.MaxRedemptions > 0 {
+		maxRedemptions := int(coupon.MaxRedemptions)
+		partialCoupon.MaxRedemptions = &maxRedemptions
+	}
+	if coupon.RedeemBy > 0 {
+		redeemBy := time.Unix(coupon.RedeemBy, 0)
+		partialCoupon.RedeemBy = &redeemBy
+	}
+
+	return partialCoupon
+}
+
+// partialPromotionCodeFromStripe 將 Stripe 促銷代碼轉為 Upsert 使用的部分欄位
+func partialPromotionCodeFromStripe(promotionCode *stripe.PromotionCode, lastEventAt *time.Time) *models.PartialPromotionCode {
+	partialPromotionCode := &models.PartialPromotionCode{
+		ID:          promotionCode.ID,
+		LastEventAt: lastEventAt,
+	}
+
+	if promotionCode.Code != "" {
+		partialPromotionCode.Code = &promotionCode.Code
+	}
+	if promotionCode.Coupon != nil {
+		partialPromotionCode.CouponID = &promotionCode.Coupon.ID
+	}
+	if promotionCode.Customer != nil {
+		partialPromotionCode.CustomerID = &promotionCode.Customer.ID
+	}
+	partialPromotionCode.Active = &promotionCode.Active
+	if promotionCode.MaxRedemptions > 0 {
+		maxRedemptions := int(promotionCode.MaxRedemptions)
+		partialPromotionCode.MaxRedemptions = &maxRedemptions
+	}
+	timesRedeemed := int(promotionCode.TimesRedeemed)
+	partialPromotionCode.TimesRedeemed = &timesRedeemed
+	if promotionCode.ExpiresAt > 0 {
+		expiresAt := time.Unix(promotionCode.ExpiresAt, 0)
+		partialPromotionCode.ExpiresAt = &expiresAt
+	}
+	if promotionCode.Created > 0 {
+		createdAt := time.Unix(promotionCode.Created, 0)
+		partialPromotionCode.CreatedAt = &createdAt
+	}
+
+	return partialPromotionCode
+}
+
+// partialConnectedAccountFromStripe 將 Stripe 連結帳號轉為 Upsert 使用的部分欄位
+// account.updated 帶有完整的帳號，布林欄位與 requirements 一律以事件內容覆寫
+func partialConnectedAccountFromStripe(account *stripe.Account, lastEventAt *time.Time) *models.PartialConnectedAccount {
+	partialAccount := &models.PartialConnectedAccount{
+		ID:               account.ID,
+		ChargesEnabled:   &account.ChargesEnabled,
+		PayoutsEnabled:   &account.PayoutsEnabled,
+		DetailsSubmitted: &account.DetailsSubmitted,
+		LastEventAt:      lastEventAt,
+	}
+
+	if account.Type != "" {
+		partialAccount.Type = &account.Type
+	}
+	if account.Email != "" {
+		partialAccount.Email = &account.Email
+	}
+	if account.Country != "" {
+		partialAccount.Country = &account.Country
+	}
+	if account.Capabilities != nil {
+		partialAccount.Capabilities = accountCapabilities(account.Capabilities)
+	}
+	if account.Requirements != nil {
+		partialAccount.RequirementsDue = append([]string{}, account.Requirements.CurrentlyDue...)
+		disabledReason := string(account.Requirements.DisabledReason)
+		partialAccount.DisabledReason = &disabledReason
+	}
+	if account.Created > 0 {
+		createdAt := time.Unix(account.Created, 0)
+		partialAccount.CreatedAt = &createdAt
+	}
+
+	return partialAccount
+}
+
+// accountCapabilities 將 Stripe 的 capability 欄位轉為名稱對應狀態的 map，只保留帳號有申請的 capability
+func accountCapabilities(capabilities *stripe.AccountCapabilities) map[string]string {
+	raw, err := json.Marshal(capabilities)
+	if err != nil {
+		return nil
+	}
+
+	var all map[string]string
+	if err = json.Unmarshal(raw, &all); err != nil {
+		return nil
+	}
+
+	requested := make(map[string]string, len(all))
+	for name, status := range all {
+		if status != "" {
+			requested[name] = status
+		}
+	}
+	return requested
+}
+
+// partialTransferFromStripe 將 Stripe 轉帳轉為 Upsert 使用的部分欄位
+func partialTransferFromStripe(transfer *stripe.Transfer, lastEventAt *time.Time) *models.PartialTransfer {
+	partialTransfer := &models.PartialTransfer{
+		ID:             transfer.ID,
+		AmountReversed: &transfer.AmountReversed,
+		Reversed:       &transfer.Reversed,
+		LastEventAt:    lastEventAt,
+	}
+
+	if transfer.Destination != nil {
+		partialTransfer.DestinationAccountID = &transfer.Destination.ID
+	}
+	if transfer.Amount > 0 {
+		partialTransfer.Amount = &transfer.Amount
+	}
+	if transfer.Currency != "" {
+		partialTransfer.Currency = &transfer.Currency
+	}
+	if transfer.SourceTransaction != nil {
+		partialTransfer.SourceTransactionID = &transfer.SourceTransaction.ID
+	}
+	if transfer.TransferGroup != "" {
+		partialTransfer.TransferGroup = &transfer.TransferGroup
+	}
+	if transfer.Created > 0 {
+		createdAt := time.Unix(transfer.Created, 0)
+		partialTransfer.CreatedAt = &createdAt
+	}
+
+	return partialTransfer
+}
+
+// partialApplicationFeeFromStripe 將 Stripe 平台手續費轉為 Upsert 使用的部分欄位
+func partialApplicationFeeFromStripe(fee *stripe.ApplicationFee, lastEventAt *time.Time) *models.PartialApplicationFee {
+	partialFee := &models.PartialApplicationFee{
+		ID:             fee.ID,
+		Amount:         &fee.Amount,
+		AmountRefunded: &fee.AmountRefunded,
+		Refunded:       &fee.Refunded,
+		LastEventAt:    lastEventAt,
+	}
+
+	if fee.Account != nil {
+		partialFee.AccountID = &fee.Account.ID
+	}
+	if fee.Charge != nil {
+		partialFee.ChargeID = &fee.Charge.ID
+	}
+	if fee.OriginatingTransaction != nil {
+		partialFee.OriginatingTransactionID = &fee.OriginatingTransaction.ID
+	}
+	if fee.Currency != "" {
+		partialFee.Currency = &fee.Currency
+	}
+	if fee.Created > 0 {
+		createdAt := time.Unix(fee.Created, 0)
+		partialFee.CreatedAt = &createdAt
+	}
+
+	return partialFee
+}
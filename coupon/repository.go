@@ -9,12 +9,11 @@ import (
 
 	"goflare.io/payment/driver"
 	"goflare.io/payment/models"
-	"goflare.io/payment/sqlc"
 )
 
 type Repository interface {
 	Upsert(ctx context.Context, tx pgx.Tx, coupon *models.PartialCoupon) error
-	Delete(ctx context.Context, tx pgx.Tx, id string) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, coupons []*models.PartialCoupon) error
 }
 
 type repository struct {
@@ -26,9 +25,34 @@ func NewRepository(conn driver.PostgresPool) Repository {
 }
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, coupon *models.PartialCoupon) error {
-	const query = `
-    INSERT INTO coupons (id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by)
-    VALUES (@id, @name, @amount_off, @percent_off, @currency, @duration, @duration_in_months, @max_redemptions, @times_redeemed, @valid, COALESCE(@created_at, NOW()), @updated_at, @redeem_by)
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, coupon)); err != nil {
+		return fmt.Errorf("failed to upsert coupon: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, coupons []*models.PartialCoupon) error {
+	if len(coupons) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, coupon := range coupons {
+		batch.Queue(upsertQuery, upsertArgs(ctx, coupon))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert coupons: %w", err)
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO coupons (id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by, last_event_at, deleted_at)
+    VALUES (@id, @name, @amount_off, @percent_off, @currency, @duration, @duration_in_months, @max_redemptions, @times_redeemed, @valid, COALESCE(@created_at, NOW()), @updated_at, @redeem_by, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         name = COALESCE(@name, coupons.name),
         amount_off = COALESCE(@amount_off, coupons.amount_off),
@@ -39,13 +63,20 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, coupon *models.Parti
         max_redemptions = COALESCE(@max_redemptions, coupons.max_redemptions),
         times_redeemed = COALESCE(@times_redeemed, coupons.times_redeemed),
         valid = COALESCE(@valid, coupons.valid),
+        last_event_at = COALESCE(@last_event_at, coupons.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at,
         redeem_by = COALESCE(@redeem_by, coupons.redeem_by)
     WHERE coupons.id = @id
+      AND coupons.tenant_id = @tenant_id
+      AND coupons.deleted_at IS NULL
+      AND (coupons.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR coupons.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, coupon *models.PartialCoupon) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":                 coupon.ID,
 		"name":               coupon.Name,
 		"amount_off":         coupon.AmountOff,
@@ -58,16 +89,9 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, coupon *models.Parti
 		"valid":              coupon.Valid,
 		"created_at":         coupon.CreatedAt,
 		"updated_at":         now,
+		"last_event_at":      coupon.LastEventAt,
 		"redeem_by":          coupon.RedeemBy,
+		"deleted_at":         coupon.DeletedAt,
+		"tenant_id":          driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert coupon: %w", err)
-	}
-
-	return nil
-}
-
-func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteCoupon(ctx, id)
 }
@@ -0,0 +1,75 @@
+package coupon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/pgtest"
+)
+
+// TestStaleUpdateDoesNotReviveDeletedCoupon 依序寫入 created、deleted 與較晚送達的舊 updated，優惠券應維持刪除
+func TestStaleUpdateDoesNotReviveDeletedCoupon(t *testing.T) {
+	pool := pgtest.Migrate(t)
+	svc := NewService(NewRepository(pool), driver.NewTransactionManager(pool, zap.NewNop()))
+	ctx := driver.WithTenant(context.Background(), driver.DefaultTenantID)
+
+	created := time.Unix(1726000000, 0)
+	updated := created.Add(time.Minute)
+	deleted := created.Add(2 * time.Minute)
+
+	partial := func(name string, lastEventAt time.Time) *models.PartialCoupon {
+		var (
+			amountOff        int64
+			percentOff       = 10.0
+			currency         = stripe.CurrencyUSD
+			duration         = stripe.CouponDurationOnce
+			durationInMonths int
+			maxRedemptions   int
+			timesRedeemed    int32
+			valid            = true
+		)
+		return &models.PartialCoupon{
+			ID:               "co_Ordering0001",
+			Name:             &name,
+			AmountOff:        &amountOff,
+			PercentOff:       &percentOff,
+			Currency:         &currency,
+			Duration:         &duration,
+			DurationInMonths: &durationInMonths,
+			MaxRedemptions:   &maxRedemptions,
+			TimesRedeemed:    &timesRedeemed,
+			Valid:            &valid,
+			LastEventAt:      &lastEventAt,
+		}
+	}
+
+	if err := svc.Upsert(ctx, partial("created", created)); err != nil {
+		t.Fatalf("created: %v", err)
+	}
+	tombstone := partial("created", deleted)
+	tombstone.DeletedAt = &deleted
+	if err := svc.Upsert(ctx, tombstone); err != nil {
+		t.Fatalf("deleted: %v", err)
+	}
+	if err := svc.Upsert(ctx, partial("stale", updated)); err != nil {
+		t.Fatalf("stale updated: %v", err)
+	}
+
+	var (
+		name      string
+		deletedAt *time.Time
+	)
+	err := pool.QueryRow(ctx, "SELECT name, deleted_at FROM coupons WHERE id = 'co_Ordering0001'").Scan(&name, &deletedAt)
+	if err != nil {
+		t.Fatalf("failed to read coupon: %v", err)
+	}
+	if deletedAt == nil || !deletedAt.Equal(deleted) || name != "created" {
+		t.Fatalf("coupon = %q deleted at %v, want tombstone from the deleted event", name, deletedAt)
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 type Service interface {
 	Upsert(ctx context.Context, coupon *models.PartialCoupon) error
-	Delete(ctx context.Context, id string) error
+	UpsertBatch(ctx context.Context, coupons []*models.PartialCoupon) error
 }
 
 type service struct {
@@ -32,8 +32,8 @@ func (s *service) Upsert(ctx context.Context, coupon *models.PartialCoupon) erro
 	})
 }
 
-func (s *service) Delete(ctx context.Context, id string) error {
+func (s *service) UpsertBatch(ctx context.Context, coupons []*models.PartialCoupon) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		return s.repo.Delete(ctx, tx, id)
+		return s.repo.UpsertBatch(ctx, tx, coupons)
 	})
 }
@@ -12,6 +12,7 @@ import (
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -23,6 +24,8 @@ type Repository interface {
 	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Customer, error)
 	Update(ctx context.Context, tx pgx.Tx, customer *models.Customer) error
 	Upsert(ctx context.Context, tx pgx.Tx, customer *models.PartialCustomer) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, customers []*models.PartialCustomer) error
+	ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.Customer, error)
 	Delete(ctx context.Context, tx pgx.Tx, id string) error
 	List(ctx context.Context, tx pgx.Tx, limit, offset uint64) ([]*models.Customer, error)
 	UpdateBalance(ctx context.Context, tx pgx.Tx, id string, amount uint64) error
@@ -94,7 +97,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, customer *models.Cus
 	customer.ID = sqlcCustomer.ID
 	customer.CreatedAt = sqlcCustomer.CreatedAt.Time
 	customer.UpdatedAt = sqlcCustomer.UpdatedAt.Time
-	cacheKey := fmt.Sprintf("customer:%s", customer.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", customer.ID))
 	if err = r.cache.Set(ctx, cacheKey, &customer); err != nil {
 		r.logger.Warn("Failed to cache new customer", zap.Error(err), zap.String("id", customer.ID))
 	}
@@ -103,11 +106,12 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, customer *models.Cus
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Customer, error) {
-	cacheKey := fmt.Sprintf("customer:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", id))
 
 	// 嘗試從緩存中獲取
 	var cachedCustomer models.Customer
 	found, err := r.cache.Get(ctx, cacheKey, &cachedCustomer)
+	metrics.ObserveCache("customer", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get customer from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
@@ -115,7 +119,10 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	}
 
 	// 如果緩存中沒有，從數據庫獲取
-	sqlcCustomer, err := sqlc.New(r.conn).WithTx(tx).GetCustomer(ctx, &id)
+	sqlcCustomer, err := sqlc.New(r.conn).WithTx(tx).GetCustomer(ctx, sqlc.GetCustomerParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		r.logger.Error("error getting customer", zap.Error(err))
 		return nil, err
@@ -139,14 +146,15 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 
 func (r *repository) Update(ctx context.Context, tx pgx.Tx, customer *models.Customer) error {
 	if err := sqlc.New(r.conn).WithTx(tx).UpdateCustomer(ctx, sqlc.UpdateCustomerParams{
-		ID:      customer.ID,
-		Balance: customer.Balance,
+		ID:       customer.ID,
+		Balance:  customer.Balance,
+		TenantID: driver.TenantID(ctx),
 	}); err != nil {
 		return fmt.Errorf("failed to update customer: %w", err)
 	}
 
 	// 更新緩存
-	cacheKey := fmt.Sprintf("customer:%s", customer.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", customer.ID))
 	if err := r.cache.Set(ctx, cacheKey, customer); err != nil {
 		r.logger.Warn("Failed to update customer in cache", zap.Error(err), zap.String("id", customer.ID))
 	}
@@ -155,12 +163,15 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, customer *models.Cus
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	if err := sqlc.New(r.conn).WithTx(tx).DeleteCustomer(ctx, id); err != nil {
+	if err := sqlc.New(r.conn).WithTx(tx).DeleteCustomer(ctx, sqlc.DeleteCustomerParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	}); err != nil {
 		return fmt.Errorf("failed to delete customer: %w", err)
 	}
 
 	// 從緩存中刪除
-	cacheKey := fmt.Sprintf("customer:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", id))
 	if err := r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete customer from cache", zap.Error(err), zap.String("id", id))
 	}
@@ -173,8 +184,9 @@ func (r *repository) List(ctx context.Context, tx pgx.Tx, limit, offset uint64)
 	column1 := int64(limit)
 	column2 := int64(offset)
 	sqlcCustomers, err := sqlc.New(r.conn).WithTx(tx).ListCustomers(ctx, sqlc.ListCustomersParams{
-		Column1: &column1,
-		Column2: &column2,
+		Column1:  &column1,
+		Column2:  &column2,
+		TenantID: driver.TenantID(ctx),
 	})
 	if err != nil {
 		r.logger.Error("error listing customers", zap.Error(err))
@@ -193,7 +205,7 @@ func (r *repository) List(ctx context.Context, tx pgx.Tx, limit, offset uint64)
 		customers = append(customers, customer)
 
 		// 更新每個客戶的緩存
-		cacheKey := fmt.Sprintf("customer:%s", customer.ID)
+		cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", customer.ID))
 		if err := r.cache.Set(ctx, cacheKey, customer); err != nil {
 			r.logger.Warn("Failed to cache customer during list", zap.Error(err), zap.String("id", customer.ID))
 		}
@@ -205,16 +217,18 @@ func (r *repository) List(ctx context.Context, tx pgx.Tx, limit, offset uint64)
 
 func (r *repository) UpdateBalance(ctx context.Context, tx pgx.Tx, id string, balance uint64) error {
 	if err := sqlc.New(r.conn).WithTx(tx).UpdateCustomerBalance(ctx, sqlc.UpdateCustomerBalanceParams{
-		ID:      id,
-		Balance: int64(balance),
+		ID:       id,
+		Balance:  int64(balance),
+		TenantID: driver.TenantID(ctx),
 	}); err != nil {
 		return fmt.Errorf("failed to update customer balance: %w", err)
 	}
 
 	// 更新緩存中的餘額
-	cacheKey := fmt.Sprintf("customer:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", id))
 	var cachedCustomer models.Customer
 	found, err := r.cache.Get(ctx, cacheKey, &cachedCustomer)
+	metrics.ObserveCache("customer", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get customer from cache for balance update", zap.Error(err), zap.String("id", id))
 	} else if found {
@@ -228,38 +242,92 @@ func (r *repository) UpdateBalance(ctx context.Context, tx pgx.Tx, id string, ba
 }
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, customer *models.PartialCustomer) error {
-	const query = `
-    INSERT INTO customers (id, user_email, balance, created_at, updated_at)
-    VALUES (@id, @user_email, @balance, COALESCE(@created_at, NOW()), @updated_at)
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, customer)); err != nil {
+		return fmt.Errorf("failed to upsert customer: %w", err)
+	}
+
+	// 更新或清除緩存
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", customer.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete customer from cache", zap.Error(err), zap.String("id", customer.ID))
+	}
+
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, customers []*models.PartialCustomer) error {
+	if len(customers) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, customer := range customers {
+		batch.Queue(upsertQuery, upsertArgs(ctx, customer))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert customers: %w", err)
+	}
+
+	for _, customer := range customers {
+		cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("customer:%s", customer.ID))
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warn("Failed to delete customer from cache", zap.Error(err), zap.String("id", customer.ID))
+		}
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO customers (id, user_email, balance, created_at, updated_at, last_event_at, deleted_at)
+    VALUES (@id, @user_email, @balance, COALESCE(@created_at, NOW()), @updated_at, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         balance = COALESCE(@balance, customers.balance),
+        last_event_at = COALESCE(@last_event_at, customers.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at
     WHERE customers.id = @id
+      AND customers.tenant_id = @tenant_id
+      AND customers.deleted_at IS NULL
+      AND (customers.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR customers.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, customer *models.PartialCustomer) pgx.NamedArgs {
 	var balance int64
 	if customer.Balance != nil {
 		balance = *customer.Balance
 	}
 
 	now := time.Now()
-	args := pgx.NamedArgs{
-		"id":         customer.ID,
-		"user_email": customer.Email,
-		"balance":    balance,
-		"created_at": customer.CreatedAt,
-		"updated_at": now,
+	return pgx.NamedArgs{
+		"id":            customer.ID,
+		"user_email":    customer.Email,
+		"balance":       balance,
+		"created_at":    customer.CreatedAt,
+		"updated_at":    now,
+		"last_event_at": customer.LastEventAt,
+		"deleted_at":    customer.DeletedAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
+}
 
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert customer: %w", err)
+// ListByIDs 直接從資料庫讀取指定 ID 的客戶，不經過緩存，供對帳比對使用
+func (r *repository) ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.Customer, error) {
+	sqlcCustomers, err := sqlc.New(r.conn).WithTx(tx).ListCustomersByIDs(ctx, sqlc.ListCustomersByIDsParams{
+		Ids:      ids,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers by ids: %w", err)
 	}
 
-	// 更新或清除緩存
-	cacheKey := fmt.Sprintf("customer:%s", customer.ID)
-	if err := r.cache.Delete(ctx, cacheKey); err != nil {
-		r.logger.Warn("Failed to delete customer from cache", zap.Error(err), zap.String("id", customer.ID))
+	customers := make([]*models.Customer, 0, len(sqlcCustomers))
+	for _, sqlcCustomer := range sqlcCustomers {
+		customers = append(customers, models.NewCustomer().ConvertFromSQLCCustomer(sqlcCustomer))
 	}
 
-	return nil
+	return customers, nil
 }
@@ -0,0 +1,125 @@
+package customer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"goflare.io/ember"
+	emberConfig "goflare.io/ember/config"
+	"goflare.io/ignite"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/pgtest"
+)
+
+func newTestService(t *testing.T, pool driver.PostgresPool) Service {
+	t.Helper()
+
+	logger := zap.NewNop()
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() {
+		_ = rdb.Close()
+	})
+
+	cacheConfig := emberConfig.NewConfig()
+	cache, err := ember.NewMultiCache(context.Background(), &cacheConfig, rdb)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	repo, err := NewRepository(pool, logger, cache, ignite.NewManager())
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	return NewService(repo, driver.NewTransactionManager(pool, logger), logger)
+}
+
+// TestTenantIsolation 確認租戶 B 無法讀取、修改或刪除租戶 A 的客戶，包含以相同 ID upsert
+func TestTenantIsolation(t *testing.T) {
+	pool := pgtest.Migrate(t)
+	svc := newTestService(t, pool)
+
+	brandA := driver.WithTenant(context.Background(), "brand-a")
+	brandB := driver.WithTenant(context.Background(), "brand-b")
+
+	if err := svc.Create(brandA, &models.Customer{ID: "cus_BrandA0001", Email: "jane@brand-a.example", Balance: 500}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := svc.GetByID(brandB, "cus_BrandA0001"); err == nil {
+		t.Fatal("tenant B read tenant A's customer")
+	}
+	if customers, err := svc.ListByIDs(brandB, []string{"cus_BrandA0001"}); err != nil || len(customers) != 0 {
+		t.Fatalf("ListByIDs from tenant B = %v, %v", customers, err)
+	}
+	if customers, err := svc.List(brandB, 10, 0); err != nil || len(customers) != 0 {
+		t.Fatalf("List from tenant B = %v, %v", customers, err)
+	}
+
+	if err := svc.Update(brandB, &models.Customer{ID: "cus_BrandA0001", Balance: 1}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := svc.UpdateBalance(brandB, "cus_BrandA0001", 2); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+	// 受 row level security 限制的角色會拒絕 upsert 衝突到其他租戶的資料列，superuser 則由 tenant_id 條件略過，兩者都不得修改
+	email, balance := "jane@brand-b.example", int64(3)
+	_ = svc.Upsert(brandB, &models.PartialCustomer{ID: "cus_BrandA0001", Email: &email, Balance: &balance})
+	if err := svc.Delete(brandB, "cus_BrandA0001"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	var (
+		tenantID   string
+		gotBalance int64
+	)
+	err := pool.QueryRow(driver.WithAllTenants(context.Background()),
+		"SELECT tenant_id, balance FROM customers WHERE id = 'cus_BrandA0001'").Scan(&tenantID, &gotBalance)
+	if err != nil {
+		t.Fatalf("tenant A's customer is gone: %v", err)
+	}
+	if tenantID != "brand-a" || gotBalance != 500 {
+		t.Fatalf("customer = (%s, %d), want (brand-a, 500)", tenantID, gotBalance)
+	}
+
+	got, err := svc.GetByID(brandA, "cus_BrandA0001")
+	if err != nil || got.Balance != 500 {
+		t.Fatalf("GetByID from tenant A = %v, %v", got, err)
+	}
+}
+
+// TestRowLevelSecurity 確認沒有 tenant_id 條件的查詢也只看得到目前租戶的資料，連線角色可略過 row level security 時略過
+func TestRowLevelSecurity(t *testing.T) {
+	pool := pgtest.Migrate(t)
+	if err := driver.CheckRowLevelSecurity(context.Background(), pool); err != nil {
+		t.Skipf("%s connects as a role that bypasses row level security", pgtest.URLEnv)
+	}
+
+	svc := newTestService(t, pool)
+	brandA := driver.WithTenant(context.Background(), "brand-a")
+	brandB := driver.WithTenant(context.Background(), "brand-b")
+
+	if err := svc.Create(brandA, &models.Customer{ID: "cus_BrandA0001", Email: "jane@brand-a.example"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var count int
+	if err := pool.QueryRow(brandB, "SELECT count(*) FROM customers").Scan(&count); err != nil || count != 0 {
+		t.Fatalf("tenant B sees %d customers (%v)", count, err)
+	}
+	tag, err := pool.Exec(brandB, "UPDATE customers SET balance = 1")
+	if err != nil || tag.RowsAffected() != 0 {
+		t.Fatalf("tenant B updated %d rows (%v)", tag.RowsAffected(), err)
+	}
+	if _, err = pool.Exec(brandB, "INSERT INTO customers (id, user_email, tenant_id) VALUES ('cus_Spoofed0001', 'x@brand-a.example', 'brand-a')"); err == nil {
+		t.Fatal("tenant B inserted a row into tenant A")
+	}
+	// 沒有租戶的 ctx 看不到任何資料
+	if err = pool.QueryRow(context.Background(), "SELECT count(*) FROM customers").Scan(&count); err != nil || count != 0 {
+		t.Fatalf("no tenant sees %d customers (%v)", count, err)
+	}
+}
@@ -18,6 +18,8 @@ type Service interface {
 	List(ctx context.Context, limit, offset uint64) ([]*models.Customer, error)
 	UpdateBalance(ctx context.Context, id string, amount uint64) error
 	Upsert(ctx context.Context, customer *models.PartialCustomer) error
+	UpsertBatch(ctx context.Context, customers []*models.PartialCustomer) error
+	ListByIDs(ctx context.Context, ids []string) ([]*models.Customer, error)
 }
 
 type service struct {
@@ -84,3 +86,19 @@ func (s *service) Upsert(ctx context.Context, customer *models.PartialCustomer)
 		return s.repo.Upsert(ctx, tx, customer)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, customers []*models.PartialCustomer) error {
+	return s.transactionManager.ExecuteSerializableTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, customers)
+	})
+}
+
+func (s *service) ListByIDs(ctx context.Context, ids []string) ([]*models.Customer, error) {
+	var customers []*models.Customer
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		customers, err = s.repo.ListByIDs(ctx, tx, ids)
+		return err
+	})
+	return customers, err
+}
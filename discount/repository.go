@@ -11,12 +11,10 @@ import (
 
 	"goflare.io/payment/driver"
 	"goflare.io/payment/models"
-	"goflare.io/payment/sqlc"
 )
 
 type Repository interface {
 	Upsert(ctx context.Context, tx pgx.Tx, discount *models.PartialDiscount) error
-	Delete(ctx context.Context, tx pgx.Tx, id string) error
 }
 
 type repository struct {
@@ -29,26 +27,34 @@ func NewRepository(conn driver.PostgresPool) Repository {
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, discount *models.PartialDiscount) error {
 	const query = `
-    INSERT INTO discounts (id, customer_id, coupon_id, start, "end", created_at, updated_at)
-    VALUES (@id, @customer_id, @coupon_id, @start, @"end", COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO discounts (id, customer_id, coupon_id, start, "end", created_at, updated_at, last_event_at, deleted_at)
+    VALUES (@id, @customer_id, @coupon_id, @start, @"end", COALESCE(@created_at, NOW()), @updated_at, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, discounts.customer_id),
         coupon_id = COALESCE(@coupon_id, discounts.coupon_id),
         start = COALESCE(@start, discounts.start),
         "end" = COALESCE(@"end", discounts."end"),
+        last_event_at = COALESCE(@last_event_at, discounts.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at
     WHERE discounts.id = @id
+      AND discounts.tenant_id = @tenant_id
+      AND discounts.deleted_at IS NULL
+      AND (discounts.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR discounts.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
 	args := pgx.NamedArgs{
-		"id":          discount.ID,
-		"customer_id": discount.CustomerID,
-		"coupon_id":   discount.CouponID,
-		"start":       discount.Start,
-		"end":         discount.End,
-		"created_at":  discount.CreatedAt,
-		"updated_at":  now,
+		"id":            discount.ID,
+		"customer_id":   discount.CustomerID,
+		"coupon_id":     discount.CouponID,
+		"start":         discount.Start,
+		"end":           discount.End,
+		"created_at":    discount.CreatedAt,
+		"updated_at":    now,
+		"last_event_at": discount.LastEventAt,
+		"deleted_at":    discount.DeletedAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
 
 	if _, err := tx.Exec(ctx, query, args); err != nil {
@@ -57,7 +63,3 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, discount *models.Par
 
 	return nil
 }
-
-func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteDiscount(ctx, id)
-}
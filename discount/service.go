@@ -13,7 +13,6 @@ import (
 
 type Service interface {
 	Upsert(ctx context.Context, discount *models.PartialDiscount) error
-	Delete(ctx context.Context, id string) error
 }
 
 type service struct {
@@ -33,9 +32,3 @@ func (s *service) Upsert(ctx context.Context, discount *models.PartialDiscount)
 		return s.repo.Upsert(ctx, tx, discount)
 	})
 }
-
-func (s *service) Delete(ctx context.Context, id string) error {
-	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		return s.repo.Delete(ctx, tx, id)
-	})
-}
@@ -20,8 +20,8 @@ type Repository interface {
 	Create(ctx context.Context, dispute *models.Dispute) error
 	GetByID(ctx context.Context, id string) (*models.Dispute, error)
 	Update(ctx context.Context, dispute *models.Dispute) error
-	Close(ctx context.Context, id string) error
 	Upsert(ctx context.Context, tx pgx.Tx, dispute *models.PartialDispute) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, disputes []*models.PartialDispute) error
 }
 
 type repository struct {
@@ -41,7 +41,7 @@ func (r *repository) Create(ctx context.Context, dispute *models.Dispute) error
 	if err := sqlc.New(r.conn).CreateDispute(ctx, sqlc.CreateDisputeParams{
 		ID:            dispute.ID,
 		ChargeID:      dispute.ChargeID,
-		Amount:        float64(dispute.Amount),
+		Amount:        dispute.Amount.Amount,
 		Currency:      sqlc.Currency(dispute.Currency),
 		Status:        sqlc.DisputeStatus(dispute.Status),
 		Reason:        sqlc.DisputeReason(dispute.Reason),
@@ -55,7 +55,10 @@ func (r *repository) Create(ctx context.Context, dispute *models.Dispute) error
 
 func (r *repository) GetByID(ctx context.Context, id string) (*models.Dispute, error) {
 
-	dispute, err := sqlc.New(r.conn).GetDisputeByID(ctx, id)
+	dispute, err := sqlc.New(r.conn).GetDisputeByID(ctx, sqlc.GetDisputeByIDParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("dispute not found: %w", err)
@@ -73,11 +76,12 @@ func (r *repository) Update(ctx context.Context, dispute *models.Dispute) error
 	err := q.UpdateDispute(ctx, sqlc.UpdateDisputeParams{
 		ID:            dispute.ID,
 		ChargeID:      dispute.ChargeID,
-		Amount:        float64(dispute.Amount),
+		Amount:        dispute.Amount.Amount,
 		Currency:      sqlc.Currency(dispute.Currency),
 		Status:        sqlc.DisputeStatus(dispute.Status),
 		Reason:        sqlc.DisputeReason(dispute.Reason),
 		EvidenceDueBy: pgtype.Timestamptz{Time: dispute.EvidenceDueBy, Valid: true},
+		TenantID:      driver.TenantID(ctx),
 	})
 
 	if err != nil {
@@ -88,30 +92,53 @@ func (r *repository) Update(ctx context.Context, dispute *models.Dispute) error
 	return nil
 }
 
-func (r *repository) Close(ctx context.Context, id string) error {
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, dispute *models.PartialDispute) error {
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, dispute)); err != nil {
+		return fmt.Errorf("failed to upsert dispute: %w", err)
+	}
 
-	return sqlc.New(r.conn).CloseDispute(ctx, sqlc.CloseDisputeParams{
-		ID: id,
-	})
+	return nil
 }
 
-func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, dispute *models.PartialDispute) error {
-	const query = `
-    INSERT INTO disputes (id, charge_id, amount, status, reason, currency, evidence_due_by, created_at, updated_at)
-    VALUES (@id, @charge_id, @amount, @status, @reason, @currency, @evidence_due_by, COALESCE(@created_at, NOW()), @updated_at)
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, disputes []*models.PartialDispute) error {
+	if len(disputes) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, dispute := range disputes {
+		batch.Queue(upsertQuery, upsertArgs(ctx, dispute))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert disputes: %w", err)
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO disputes (id, charge_id, amount, status, reason, currency, evidence_due_by, created_at, updated_at, last_event_at)
+    VALUES (@id, @charge_id, @amount, @status, @reason, @currency, @evidence_due_by, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         charge_id = COALESCE(@charge_id, disputes.charge_id),
         amount = COALESCE(@amount, disputes.amount),
         status = COALESCE(@status, disputes.status),
         reason = COALESCE(@reason, disputes.reason),
         currency = COALESCE(@currency, disputes.currency),
-        evidence_due_by = COALESCE(@evidence_due_by, @evidence_due_by),
+        evidence_due_by = COALESCE(@evidence_due_by, disputes.evidence_due_by),
+        last_event_at = COALESCE(@last_event_at, disputes.last_event_at),
         updated_at = @updated_at
     WHERE disputes.id = @id
+      AND disputes.tenant_id = @tenant_id
+      AND (disputes.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR disputes.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, dispute *models.PartialDispute) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":              dispute.ID,
 		"charge_id":       dispute.ChargeID,
 		"amount":          dispute.Amount,
@@ -121,11 +148,7 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, dispute *models.Part
 		"evidence_due_by": dispute.EvidenceDueBy,
 		"created_at":      dispute.CreatedAt,
 		"updated_at":      now,
+		"last_event_at":   dispute.LastEventAt,
+		"tenant_id":       driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert dispute: %w", err)
-	}
-
-	return nil
 }
@@ -15,8 +15,8 @@ type Service interface {
 	Create(ctx context.Context, dispute *models.Dispute) error
 	GetByID(ctx context.Context, id string) (*models.Dispute, error)
 	Update(ctx context.Context, dispute *models.Dispute) error
-	Close(ctx context.Context, stripeID string) error
 	Upsert(ctx context.Context, dispute *models.PartialDispute) error
+	UpsertBatch(ctx context.Context, disputes []*models.PartialDispute) error
 }
 
 type service struct {
@@ -58,12 +58,14 @@ func (s *service) Update(ctx context.Context, dispute *models.Dispute) error {
 	return nil
 }
 
-func (s *service) Close(ctx context.Context, id string) error {
-	return s.repo.Close(ctx, id)
-}
-
 func (s *service) Upsert(ctx context.Context, dispute *models.PartialDispute) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		return s.repo.Upsert(ctx, tx, dispute)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, disputes []*models.PartialDispute) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, disputes)
+	})
+}
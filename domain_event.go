@@ -0,0 +1,132 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/models"
+)
+
+// writeWithDomainEvents 在同一個交易內執行寫入並將領域事件寫入 outbox
+// 寫入失敗時 outbox 一併回滾，不會發佈與資料庫狀態不一致的事件；write 回傳 false 代表事件過期而未寫入，此時也不發佈領域事件
+func (sp *StripePayment) writeWithDomainEvents(ctx context.Context, write func(ctx context.Context) (bool, error), events ...*models.DomainEvent) error {
+	if len(events) == 0 {
+		_, err := write(ctx)
+		return err
+	}
+
+	return sp.transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		applied, err := write(ctx)
+		if err != nil || !applied {
+			return err
+		}
+		return sp.outbox.Add(ctx, events...)
+	})
+}
+
+func paymentIntentDomainEvents(stripeEvent *stripe.Event, paymentIntent *stripe.PaymentIntent) []*models.DomainEvent {
+	var eventType models.DomainEventType
+	switch stripeEvent.Type {
+	case stripe.EventTypePaymentIntentSucceeded:
+		eventType = models.DomainEventPaymentIntentSucceeded
+	case stripe.EventTypePaymentIntentPaymentFailed:
+		eventType = models.DomainEventPaymentIntentFailed
+	case stripe.EventTypePaymentIntentCanceled:
+		eventType = models.DomainEventPaymentIntentCanceled
+	default:
+		return nil
+	}
+
+	data := models.PaymentIntentEventV1{
+		PaymentIntentID: paymentIntent.ID,
+		Amount:          paymentIntent.Amount,
+		Currency:        string(paymentIntent.Currency),
+		Status:          string(paymentIntent.Status),
+	}
+	if paymentIntent.Customer != nil {
+		data.CustomerID = paymentIntent.Customer.ID
+	}
+	if paymentIntent.LastPaymentError != nil {
+		data.FailureCode = string(paymentIntent.LastPaymentError.Code)
+		data.FailureMessage = paymentIntent.LastPaymentError.Msg
+	}
+
+	return []*models.DomainEvent{
+		models.NewDomainEvent(eventType, stripeEvent.ID, paymentIntent.ID, time.Unix(stripeEvent.Created, 0), data),
+	}
+}
+
+func subscriptionDomainEvents(stripeEvent *stripe.Event, subscription *stripe.Subscription) []*models.DomainEvent {
+	var eventType models.DomainEventType
+	switch stripeEvent.Type {
+	case stripe.EventTypeCustomerSubscriptionCreated:
+		eventType = models.DomainEventSubscriptionCreated
+	case stripe.EventTypeCustomerSubscriptionUpdated:
+		eventType = models.DomainEventSubscriptionUpdated
+	case stripe.EventTypeCustomerSubscriptionDeleted:
+		eventType = models.DomainEventSubscriptionCanceled
+	default:
+		return nil
+	}
+
+	data := models.SubscriptionEventV1{
+		SubscriptionID:     subscription.ID,
+		Status:             string(subscription.Status),
+		CurrentPeriodStart: time.Unix(subscription.CurrentPeriodStart, 0),
+		CurrentPeriodEnd:   time.Unix(subscription.CurrentPeriodEnd, 0),
+		CancelAtPeriodEnd:  subscription.CancelAtPeriodEnd,
+	}
+	if subscription.Customer != nil {
+		data.CustomerID = subscription.Customer.ID
+	}
+	if subscription.Items != nil && len(subscription.Items.Data) > 0 && subscription.Items.Data[0].Price != nil {
+		data.PriceID = subscription.Items.Data[0].Price.ID
+	}
+	if subscription.CanceledAt > 0 {
+		canceledAt := time.Unix(subscription.CanceledAt, 0)
+		data.CanceledAt = &canceledAt
+	}
+
+	return []*models.DomainEvent{
+		models.NewDomainEvent(eventType, stripeEvent.ID, subscription.ID, time.Unix(stripeEvent.Created, 0), data),
+	}
+}
+
+func invoiceDomainEvents(stripeEvent *stripe.Event, invoice *stripe.Invoice) []*models.DomainEvent {
+	var eventType models.DomainEventType
+	switch stripeEvent.Type {
+	case stripe.EventTypeInvoicePaid:
+		eventType = models.DomainEventInvoicePaid
+	case stripe.EventTypeInvoicePaymentFailed:
+		eventType = models.DomainEventInvoicePaymentFailed
+	case stripe.EventTypeInvoiceOverdue:
+		eventType = models.DomainEventInvoiceOverdue
+	default:
+		return nil
+	}
+
+	data := models.InvoiceEventV1{
+		InvoiceID:       invoice.ID,
+		Status:          string(invoice.Status),
+		Currency:        string(invoice.Currency),
+		AmountDue:       invoice.AmountDue,
+		AmountPaid:      invoice.AmountPaid,
+		AmountRemaining: invoice.AmountRemaining,
+	}
+	if invoice.Customer != nil {
+		data.CustomerID = invoice.Customer.ID
+	}
+	if invoice.Subscription != nil {
+		data.SubscriptionID = invoice.Subscription.ID
+	}
+	if invoice.DueDate > 0 {
+		dueDate := time.Unix(invoice.DueDate, 0)
+		data.DueDate = &dueDate
+	}
+
+	return []*models.DomainEvent{
+		models.NewDomainEvent(eventType, stripeEvent.ID, invoice.ID, time.Unix(stripeEvent.Created, 0), data),
+	}
+}
@@ -0,0 +1,118 @@
+package domainerr
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Code 為回傳給呼叫端的錯誤分類，HTTP 與 gRPC 的回應都以此決定狀態碼
+type Code string
+
+const (
+	CodeValidation          Code = "validation"
+	CodeNotFound            Code = "not_found"
+	CodeConflict            Code = "conflict"
+	CodeCardDeclined        Code = "card_declined"
+	CodeInsufficientFunds   Code = "insufficient_funds"
+	CodeRateLimited         Code = "rate_limited"
+	CodeUnauthenticated     Code = "unauthenticated"
+	CodePermissionDenied    Code = "permission_denied"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeTimeout             Code = "timeout"
+	CodeCanceled            Code = "canceled"
+	CodeNotImplemented      Code = "not_implemented"
+	CodeInternal            Code = "internal"
+)
+
+// httpStatus 與 grpcCode 為每個 Code 對應的狀態碼，未列出的 Code 視為 CodeInternal
+var (
+	httpStatus = map[Code]int{
+		CodeValidation:          http.StatusBadRequest,
+		CodeNotFound:            http.StatusNotFound,
+		CodeConflict:            http.StatusConflict,
+		CodeCardDeclined:        http.StatusPaymentRequired,
+		CodeInsufficientFunds:   http.StatusPaymentRequired,
+		CodeRateLimited:         http.StatusTooManyRequests,
+		CodeUnauthenticated:     http.StatusUnauthorized,
+		CodePermissionDenied:    http.StatusForbidden,
+		CodeUpstreamUnavailable: http.StatusServiceUnavailable,
+		CodeTimeout:             http.StatusGatewayTimeout,
+		CodeCanceled:            statusClientClosedRequest,
+		CodeNotImplemented:      http.StatusNotImplemented,
+		CodeInternal:            http.StatusInternalServerError,
+	}
+
+	grpcCode = map[Code]codes.Code{
+		CodeValidation:          codes.InvalidArgument,
+		CodeNotFound:            codes.NotFound,
+		CodeConflict:            codes.Aborted,
+		CodeCardDeclined:        codes.FailedPrecondition,
+		CodeInsufficientFunds:   codes.FailedPrecondition,
+		CodeRateLimited:         codes.ResourceExhausted,
+		CodeUnauthenticated:     codes.Unauthenticated,
+		CodePermissionDenied:    codes.PermissionDenied,
+		CodeUpstreamUnavailable: codes.Unavailable,
+		CodeTimeout:             codes.DeadlineExceeded,
+		CodeCanceled:            codes.Canceled,
+		CodeNotImplemented:      codes.Unimplemented,
+		CodeInternal:            codes.Internal,
+	}
+)
+
+// statusClientClosedRequest 為呼叫端在回應前中斷請求時的狀態碼（沿用 nginx 的 499）
+const statusClientClosedRequest = 499
+
+// HTTPStatus 回傳 Code 對應的 HTTP 狀態碼
+func (c Code) HTTPStatus() int {
+	if status, ok := httpStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode 回傳 Code 對應的 gRPC 狀態碼
+func (c Code) GRPCCode() codes.Code {
+	if code, ok := grpcCode[c]; ok {
+		return code
+	}
+	return codes.Internal
+}
+
+// Error 為可以回傳給呼叫端的錯誤
+// Message 為可以直接顯示給呼叫端的說明，為空時由呼叫端依情境提供；Err 為原始錯誤，只用於 log，不會回傳給呼叫端
+// DeclineCode 與 StripeCode 為 Stripe 回傳的拒絕原因與錯誤代碼（例如 insufficient_funds、expired_card），Param 為有問題的欄位
+type Error struct {
+	Code        Code
+	Message     string
+	Param       string
+	DeclineCode string
+	StripeCode  string
+	RequestID   string
+	Err         error
+}
+
+// New 建立 Error，通常用於定義 sentinel error，例如 var ErrUnknownTenant = domainerr.New(domainerr.CodeValidation, "unknown tenant")
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap 以 code 包裝 err，message 為回傳給呼叫端的說明
+func Wrap(err error, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	default:
+		return e.Message + ": " + e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
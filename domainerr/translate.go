@@ -0,0 +1,131 @@
+package domainerr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stripe/stripe-go/v79"
+)
+
+// PostgreSQL 的 SQLSTATE，見 https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgCheckViolation       = "23514"
+	pgNotNullViolation     = "23502"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgConnectionException  = "08"
+)
+
+// From 將錯誤轉換為 Error，err 為 nil 時回傳 nil
+//   - 已是 Error（包含被 %w 包裝的 sentinel error）：沿用其 Code；sentinel 被包裝時以整個錯誤訊息作為說明，包裝時只應加入可以回傳給呼叫端的內容
+//   - *stripe.Error：依 type、code 與 decline_code 分類，保留 Stripe 的訊息、decline_code 與 param
+//   - pgx：查無資料為 not_found，unique violation 與序列化衝突為 conflict，違反其他限制為 validation
+//   - ctx 取消與逾時、無法連線：canceled、timeout 與 upstream_unavailable
+//
+// 其他錯誤為 internal，Message 為空，不把內部錯誤回傳給呼叫端
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		if domainErr.Err == nil && err != domainErr {
+			translated := *domainErr
+			translated.Message = err.Error()
+			translated.Err = err
+			return &translated
+		}
+		return domainErr
+	}
+
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) {
+		return fromStripe(stripeErr, err)
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Wrap(err, CodeNotFound, "resource not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return fromPostgres(pgErr, err)
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Wrap(err, CodeCanceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, CodeTimeout, "request timed out")
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || pgconn.SafeToRetry(err) {
+		return Wrap(err, CodeUpstreamUnavailable, "upstream service unavailable")
+	}
+
+	return &Error{Code: CodeInternal, Err: err}
+}
+
+// fromStripe 分類 Stripe 的錯誤；卡片錯誤的訊息由 Stripe 提供，可以直接顯示給持卡人
+func fromStripe(stripeErr *stripe.Error, err error) *Error {
+	translated := &Error{
+		Code:        CodeInternal,
+		Message:     stripeErr.Msg,
+		Param:       stripeErr.Param,
+		DeclineCode: string(stripeErr.DeclineCode),
+		StripeCode:  string(stripeErr.Code),
+		RequestID:   stripeErr.RequestID,
+		Err:         err,
+	}
+
+	switch {
+	case stripeErr.HTTPStatusCode == http.StatusTooManyRequests || stripeErr.Code == stripe.ErrorCodeRateLimit:
+		translated.Code = CodeRateLimited
+	case stripeErr.DeclineCode == stripe.DeclineCodeInsufficientFunds || stripeErr.Code == stripe.ErrorCodeInsufficientFunds:
+		translated.Code = CodeInsufficientFunds
+	case stripeErr.Type == stripe.ErrorTypeCard:
+		translated.Code = CodeCardDeclined
+	case stripeErr.HTTPStatusCode == http.StatusNotFound || stripeErr.Code == stripe.ErrorCodeResourceMissing:
+		translated.Code = CodeNotFound
+	case stripeErr.Type == stripe.ErrorTypeIdempotency,
+		stripeErr.HTTPStatusCode == http.StatusConflict,
+		stripeErr.Code == stripe.ErrorCodeIdempotencyKeyInUse,
+		stripeErr.Code == stripe.ErrorCodeLockTimeout:
+		translated.Code = CodeConflict
+	case stripeErr.HTTPStatusCode == http.StatusUnauthorized || stripeErr.HTTPStatusCode == http.StatusForbidden:
+		// 本服務的 Stripe 金鑰無效或權限不足，不是呼叫端的問題，也不回傳 Stripe 的訊息
+		translated.Code = CodeInternal
+		translated.Message = ""
+	case stripeErr.Type == stripe.ErrorTypeInvalidRequest:
+		translated.Code = CodeValidation
+	case stripeErr.Type == stripe.ErrorTypeAPI, stripeErr.HTTPStatusCode >= http.StatusInternalServerError:
+		translated.Code = CodeUpstreamUnavailable
+	}
+
+	return translated
+}
+
+// fromPostgres 分類違反資料庫限制的錯誤；限制名稱與欄位屬於內部結構，不回傳給呼叫端
+func fromPostgres(pgErr *pgconn.PgError, err error) *Error {
+	switch {
+	case pgErr.Code == pgUniqueViolation:
+		return Wrap(err, CodeConflict, "resource already exists")
+	case pgErr.Code == pgSerializationFailure, pgErr.Code == pgDeadlockDetected:
+		return Wrap(err, CodeConflict, "concurrent update, retry the request")
+	case pgErr.Code == pgForeignKeyViolation:
+		return Wrap(err, CodeValidation, "referenced resource does not exist")
+	case pgErr.Code == pgCheckViolation, pgErr.Code == pgNotNullViolation:
+		return Wrap(err, CodeValidation, "invalid value")
+	case len(pgErr.Code) >= 2 && pgErr.Code[:2] == pgConnectionException:
+		return Wrap(err, CodeUpstreamUnavailable, "database unavailable")
+	}
+	return &Error{Code: CodeInternal, Err: err}
+}
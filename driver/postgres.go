@@ -27,6 +27,9 @@ type PostgresPool interface {
 	// QueryRow executes an SQL query and returns a single row.
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 
+	// Ping acquires a connection from the pool and checks that the server responds.
+	Ping(ctx context.Context) error
+
 	// SendBatch sends a batch of queries to the server. The batch is executed as a single transaction.
 	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
 
@@ -100,6 +103,9 @@ func ConnectSQL(dsn string) (*DB, error) {
 
 	config.MaxConns = int32(maxOpenDbConn)
 	config.MaxConnLifetime = maxDbLifetime
+	// 依 ctx 的租戶設定連線，交易與單一查詢都會套用 row level security
+	config.BeforeAcquire = setTenant
+	config.ConnConfig.Tracer = queryTracer{}
 
 	// create the pool
 	pool, err := pgxpool.NewWithConfig(context.Background(), config) // 使用ConnectConfig
@@ -110,8 +116,9 @@ func ConnectSQL(dsn string) (*DB, error) {
 	dbConn.Pool = pool
 	// Set transaction options to serializable
 
+	// 無法連線時仍回傳連線池，呼叫端可以選擇以降級模式啟動，連線池會在之後的請求重新連線
 	if err = testDB(pool); err != nil {
-		return nil, err
+		return dbConn, err
 	}
 
 	return dbConn, nil
@@ -35,9 +35,10 @@ func ConnectRedis(addr string, password string, db int) (*redis.Client, error) {
 	})
 
 	// Test the connection
+	// 無法連線時仍回傳 client，呼叫端可以選擇以降級模式啟動，client 會在之後的指令重新連線
 	if err := testRedis(client); err != nil {
 		log.Println(fmt.Sprintf("Redis connection error: %s", err))
-		return nil, err
+		return client, err
 	}
 
 	return client, nil
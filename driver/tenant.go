@@ -0,0 +1,77 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultTenantID 為頂層 Stripe 設定所屬的租戶，加入多租戶之前的資料都屬於它
+const DefaultTenantID = "default"
+
+// ErrBypassesRowLevelSecurity 表示連線使用的資料庫角色為 superuser 或有 BYPASSRLS，row level security 對它不會生效
+var ErrBypassesRowLevelSecurity = errors.New("database role bypasses row level security")
+
+type tenantContextKey struct{}
+
+type allTenantsContextKey struct{}
+
+// WithTenant 回傳帶有租戶的 ctx，以它取得的資料庫連線只能讀寫該租戶的資料
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext 回傳 ctx 中的租戶
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// TenantID 回傳 ctx 中的租戶，作為查詢的 tenant_id 條件；沒有租戶時為空字串，不會符合任何資料列
+// row level security 之外再以明確的條件限制租戶，資料庫角色可以略過 row level security 時也不會讀寫到其他租戶的資料
+func TenantID(ctx context.Context) string {
+	tenantID, _ := TenantFromContext(ctx)
+	return tenantID
+}
+
+// WithAllTenants 回傳可以跨租戶讀寫的 ctx，只用於 outbox relay、重試排程等不屬於單一租戶的系統工作
+// 取出資料後應以 WithTenant 切換到資料所屬的租戶再處理
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsContextKey{}, true)
+}
+
+// TenantCacheKey 在快取 key 前加上租戶，避免以其他租戶的物件 ID 讀到快取中的資料
+func TenantCacheKey(ctx context.Context, key string) string {
+	if tenantID, ok := TenantFromContext(ctx); ok {
+		return "tenant:" + tenantID + ":" + key
+	}
+	return key
+}
+
+// setTenant 在每次從 pool 取得連線時設定 app.tenant_id 與 app.all_tenants，供 row level security 使用
+// ctx 沒有租戶時兩者皆為空，查詢看不到任何資料列，寫入也會因 tenant_id 為 NULL 而失敗
+func setTenant(ctx context.Context, conn *pgx.Conn) bool {
+	tenantID, _ := TenantFromContext(ctx)
+	allTenants := "off"
+	if all, _ := ctx.Value(allTenantsContextKey{}).(bool); all {
+		allTenants = "on"
+	}
+
+	_, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false), set_config('app.all_tenants', $2, false)", tenantID, allTenants)
+	return err == nil
+}
+
+// CheckRowLevelSecurity 在啟動時確認目前的資料庫角色受 row level security 限制，否則回傳 ErrBypassesRowLevelSecurity
+// 應以一般角色連線，superuser 只用於 paymentctl migrate
+func CheckRowLevelSecurity(ctx context.Context, pool PostgresPool) error {
+	var bypass bool
+	if err := pool.QueryRow(ctx, "SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&bypass); err != nil {
+		return fmt.Errorf("failed to read database role: %w", err)
+	}
+	if bypass {
+		return fmt.Errorf("%w: connect as a role without SUPERUSER and BYPASSRLS", ErrBypassesRowLevelSecurity)
+	}
+	return nil
+}
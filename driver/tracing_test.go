@@ -0,0 +1,75 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/pgtest"
+	"goflare.io/payment/tracing"
+)
+
+// TestTransactionSpans 確認交易與其中每個查詢各有一個 span，查詢的 span 在交易之下，回滾時交易的 span 記錄錯誤
+func TestTransactionSpans(t *testing.T) {
+	pool := pgtest.Connect(t)
+	provider := tracing.NewInMemoryProvider()
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+
+	tm := driver.NewTransactionManager(pool, zap.NewNop())
+	ctx := driver.WithTenant(context.Background(), driver.DefaultTenantID)
+
+	provider.Reset()
+	err := tm.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var one int
+		return tx.QueryRow(ctx, "-- name: SelectOne :one\nSELECT 1").Scan(&one)
+	})
+	if err != nil {
+		t.Fatalf("ExecuteTransaction: %v", err)
+	}
+
+	spans := provider.Spans()
+	transaction := findSpan(t, spans, "transaction")
+	for _, name := range []string{"BEGIN", "SelectOne", "COMMIT"} {
+		query := findSpan(t, spans, name)
+		if query.Parent.SpanID() != transaction.SpanContext.SpanID() {
+			t.Fatalf("%s span is not a child of the transaction span", name)
+		}
+	}
+	// 只記錄 SQL，不記錄參數
+	for _, attr := range findSpan(t, spans, "SelectOne").Attributes {
+		if attr.Key == "db.statement" && attr.Value.AsString() != "-- name: SelectOne :one\nSELECT 1" {
+			t.Fatalf("db.statement = %q", attr.Value.AsString())
+		}
+	}
+
+	provider.Reset()
+	failure := errors.New("rollback")
+	if err = tm.ExecuteTransaction(ctx, func(pgx.Tx) error { return failure }); !errors.Is(err, failure) {
+		t.Fatalf("ExecuteTransaction = %v, want %v", err, failure)
+	}
+	spans = provider.Spans()
+	if span := findSpan(t, spans, "transaction"); span.Status.Code != codes.Error {
+		t.Fatalf("transaction span status = %s, want Error", span.Status.Code)
+	}
+	findSpan(t, spans, "ROLLBACK")
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no %q span", name)
+	return tracetest.SpanStub{}
+}
@@ -3,22 +3,53 @@ package driver
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"goflare.io/payment/metrics"
+)
+
+const (
+	defaultTxMaxRetries   = 3
+	defaultTxRetryBackoff = 100 * time.Millisecond
 )
 
+type txContextKey struct{}
+
 type TransactionManager struct {
 	conn   PostgresPool
 	logger *zap.Logger
+
+	// maxRetries 與 retryBackoff 為 ExecuteSerializableTransaction 的重試設定，設定檔更新時會在執行期間修改
+	maxRetries   atomic.Int64
+	retryBackoff atomic.Int64
 }
 
 func NewTransactionManager(conn PostgresPool, logger *zap.Logger) *TransactionManager {
-	return &TransactionManager{
+	m := &TransactionManager{
 		conn:   conn,
 		logger: logger,
 	}
+	m.SetRetryPolicy(defaultTxMaxRetries, defaultTxRetryBackoff)
+	return m
+}
+
+// SetRetryPolicy 設定 ExecuteSerializableTransaction 最多執行的次數與每次重試增加的等待時間，不合法的值使用預設值
+func (m *TransactionManager) SetRetryPolicy(maxRetries int, backoff time.Duration) {
+	if maxRetries < 1 {
+		maxRetries = defaultTxMaxRetries
+	}
+	if backoff < 0 {
+		backoff = defaultTxRetryBackoff
+	}
+	m.maxRetries.Store(int64(maxRetries))
+	m.retryBackoff.Store(int64(backoff))
 }
 
 func (m *TransactionManager) ExecuteTransaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
@@ -26,10 +57,25 @@ func (m *TransactionManager) ExecuteTransaction(ctx context.Context, fn func(tx
 }
 
 func (m *TransactionManager) ExecuteSerializableTransaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
-	return m.ExecuteTransactionWithRetry(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn, 3)
+	return m.ExecuteTransactionWithRetry(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn, int(m.maxRetries.Load()))
 }
 
-func (m *TransactionManager) ExecuteTransactionWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+func (m *TransactionManager) ExecuteTransactionWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) (err error) {
+	// ctx 已帶有 WithinTransaction 開啟的交易時直接加入，由外層負責提交或回滾
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+
+	// BEGIN、COMMIT 與 ROLLBACK 的查詢 span 都在交易的 span 之下
+	ctx, span := tracer.Start(ctx, "transaction", trace.WithAttributes(attribute.String("db.isolation_level", string(opts.IsoLevel))))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	dbTx, err := m.conn.BeginTx(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("begin transaction failed: %w", err)
@@ -37,22 +83,36 @@ func (m *TransactionManager) ExecuteTransactionWithOptions(ctx context.Context,
 
 	defer func() {
 		if p := recover(); p != nil {
+			metrics.TransactionRollbacks.WithLabelValues("panic").Inc()
 			m.rollback(ctx, dbTx)
 			m.logger.Error("panic in transaction", zap.Any("panic", p))
 			panic(p) // re-throw panic after Rollback
 		} else if err != nil {
+			metrics.TransactionRollbacks.WithLabelValues("error").Inc()
 			m.rollback(ctx, dbTx)
-		} else {
-			if err = dbTx.Commit(ctx); err != nil {
-				m.logger.Error("commit transaction failed", zap.Error(err))
-			}
+		} else if err = dbTx.Commit(ctx); err != nil {
+			m.logger.Error("commit transaction failed", zap.Error(err))
+			err = fmt.Errorf("commit transaction failed: %w", err)
 		}
 	}()
 
 	return fn(dbTx)
 }
 
+// WithinTransaction 開啟交易並將交易放入 ctx，fn 內以該 ctx 呼叫的 ExecuteTransaction 都會加入同一個交易
+// 讓不同 service 的寫入（例如 Upsert 與 outbox）一起提交或一起回滾
+func (m *TransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
 func (m *TransactionManager) ExecuteTransactionWithRetry(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error, maxRetries int) error {
+	// 加入外層交易時無法單獨重試，交由外層處理
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+
 	var err error
 	for i := 0; i < maxRetries; i++ {
 		if err = m.ExecuteTransactionWithOptions(ctx, opts, fn); err == nil {
@@ -61,8 +121,9 @@ func (m *TransactionManager) ExecuteTransactionWithRetry(ctx context.Context, op
 		if !m.isRetryableError(err) {
 			return err
 		}
+		metrics.TransactionRetries.Inc()
 		m.logger.Warn("Transaction failed, retrying", zap.Int("attempt", i+1), zap.Error(err))
-		time.Sleep(time.Duration(i*100) * time.Millisecond) // 簡單的退避策略
+		time.Sleep(time.Duration(i) * time.Duration(m.retryBackoff.Load())) // 簡單的退避策略
 	}
 	return fmt.Errorf("transaction failed after %d attempts: %w", maxRetries, err)
 }
@@ -78,3 +139,8 @@ func (m *TransactionManager) isRetryableError(err error) bool {
 	// 例如，可以檢查是否是由於並發衝突導致的錯誤
 	return true // 這裡簡化處理，實際使用時需要更精確的判斷
 }
+
+func txFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(pgx.Tx)
+	return tx, ok
+}
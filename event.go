@@ -4,26 +4,55 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/stripe/stripe-go/v79"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
 )
 
+// subscriptionDrainTimeout 為 Stop 等待訂閱中已收到的訊息交給 worker pool 的上限
+const subscriptionDrainTimeout = 30 * time.Second
+
 type EventHandler func(context.Context, *stripe.Event) error
 
 type EventManager struct {
 	natsConn *nats.Conn
+	js       jetstream.JetStream
+	jsConfig config.JetStreamConfig
 	handlers map[stripe.EventType]EventHandler
 	logger   *zap.Logger
+
+	// mu 保護訂閱，降級模式下訂閱可能在背景建立，同時 readiness 檢查正在讀取
+	mu           sync.Mutex
+	subscription *nats.Subscription
+	consumeCtx   jetstream.ConsumeContext
 }
 
-func NewEventManager(natsConn *nats.Conn, logger *zap.Logger) *EventManager {
-	return &EventManager{
+// NewEventManager 建立 EventManager，JetStream 啟用時事件會經由持久化的 stream 傳遞
+func NewEventManager(natsConn *nats.Conn, jsConfig config.JetStreamConfig, logger *zap.Logger) (*EventManager, error) {
+	em := &EventManager{
 		natsConn: natsConn,
 		handlers: make(map[stripe.EventType]EventHandler),
 		logger:   logger,
 	}
+
+	if jsConfig.Enabled {
+		js, err := jetstream.New(natsConn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+		em.js = js
+		em.jsConfig = withJetStreamDefaults(jsConfig)
+	}
+
+	return em, nil
 }
 
 func (em *EventManager) RegisterHandler(eventType stripe.EventType, handler EventHandler) {
@@ -35,28 +64,114 @@ func (em *EventManager) GetHandler(eventType stripe.EventType) (EventHandler, bo
 	return handler, exists
 }
 
-func (em *EventManager) PublishEvent(event *stripe.Event) error {
-	subject := fmt.Sprintf("stripe.event.%s", event.Type)
+// PublishEvent 發佈事件，並把 ctx 的 trace 放在 header，webhook 請求與之後處理事件的 span 屬於同一個 trace
+func (em *EventManager) PublishEvent(ctx context.Context, event *stripe.Event) (err error) {
+	subject := fmt.Sprintf("%s.%s", eventSubjectPrefix, event.Type)
+	ctx, span := startPublishSpan(ctx, subject)
+	span.SetAttributes(attribute.String("stripe.event_id", event.ID))
+	defer func() { endSpan(span, err) }()
+
 	data, err := json.Marshal(event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	return em.natsConn.Publish(subject, data)
+	// 事件所屬的租戶放在 header，consumer 以該租戶處理事件
+	msg := &nats.Msg{
+		Subject: subject,
+		Data:    data,
+		Header:  nats.Header{},
+	}
+	if tenantID, ok := driver.TenantFromContext(ctx); ok {
+		msg.Header.Set(headerTenantID, tenantID)
+	}
+	injectTraceContext(ctx, msg.Header)
+
+	if em.js != nil {
+		// 以 event ID 作為 Nats-Msg-Id，Stripe 重送的相同事件會在去重視窗內被丟棄
+		// 不同租戶的 Stripe 帳號不會產生相同的 event ID
+		if _, err = em.js.PublishMsg(ctx, msg, jetstream.WithMsgID(event.ID)); err != nil {
+			return fmt.Errorf("failed to publish event to JetStream: %w", err)
+		}
+		return nil
+	}
+
+	return em.natsConn.PublishMsg(msg)
 }
 
 func (em *EventManager) SubscribeToEvents(wp *WorkerPool) error {
-	_, err := em.natsConn.Subscribe("stripe.event.>", func(msg *nats.Msg) {
+	if em.js != nil {
+		return em.consumeJetStream(wp)
+	}
+
+	sub, err := em.natsConn.Subscribe(eventSubjectPrefix+".>", func(msg *nats.Msg) {
 		var event stripe.Event
 		if err := json.Unmarshal(msg.Data, &event); err != nil {
 			em.logger.Error("Failed to unmarshal event", zap.Error(err))
 			return
 		}
 
-		wp.Submit(context.Background(), &event)
+		ctx := extractTraceContext(tenantContext(context.Background(), msg.Header), msg.Header)
+		if err := wp.Submit(ctx, &event); err != nil {
+			em.logger.Error("Failed to submit event", zap.Error(err), zap.String("event_id", event.ID))
+		}
 	})
+	if err != nil {
+		return err
+	}
+
+	em.mu.Lock()
+	em.subscription = sub
+	em.mu.Unlock()
+	return nil
+}
+
+// Subscribed 回傳是否正在接收事件；core NATS 斷線期間訂閱仍有效，重新連線後會自動恢復
+func (em *EventManager) Subscribed() bool {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if em.consumeCtx != nil {
+		select {
+		case <-em.consumeCtx.Closed():
+			return false
+		default:
+			return true
+		}
+	}
+	return em.subscription != nil && em.subscription.IsValid()
+}
 
-	return err
+// Stop 停止接收新的事件，並等待已收到的訊息交給 worker pool 後才返回，呼叫端應在 WorkerPool.Shutdown 之前呼叫
+// 已送出但尚未 ack 的 JetStream 訊息會在 AckWait 後重新投遞
+func (em *EventManager) Stop() {
+	em.mu.Lock()
+	consumeCtx, subscription := em.consumeCtx, em.subscription
+	em.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), subscriptionDrainTimeout)
+	defer cancel()
+
+	if consumeCtx != nil {
+		consumeCtx.Drain()
+		select {
+		case <-consumeCtx.Closed():
+		case <-ctx.Done():
+			em.logger.Warn("Timed out draining jetstream consumer", zap.Duration("timeout", subscriptionDrainTimeout))
+		}
+	}
+	if subscription != nil && subscription.IsValid() {
+		closed := subscription.StatusChanged(nats.SubscriptionClosed)
+		if err := subscription.Drain(); err != nil {
+			em.logger.Warn("Failed to drain event subscription", zap.Error(err))
+			return
+		}
+		select {
+		case <-closed:
+		case <-ctx.Done():
+			em.logger.Warn("Timed out draining event subscription", zap.Duration("timeout", subscriptionDrainTimeout))
+		}
+	}
 }
 
 func (sp *StripePayment) registerEventHandlers() {
@@ -193,6 +308,15 @@ func (sp *StripePayment) registerEventHandlers() {
 		// Review
 		stripe.EventTypeReviewClosed: sp.handleReviewEvent,
 		stripe.EventTypeReviewOpened: sp.handleReviewEvent,
+
+		// Connect
+		stripe.EventTypeAccountUpdated:                 sp.handleAccountEvent,
+		stripe.EventTypeAccountApplicationDeauthorized: sp.handleAccountEvent,
+		stripe.EventTypeTransferCreated:                sp.handleTransferEvent,
+		stripe.EventTypeTransferReversed:               sp.handleTransferEvent,
+		stripe.EventTypeTransferUpdated:                sp.handleTransferEvent,
+		stripe.EventTypeApplicationFeeCreated:          sp.handleApplicationFeeEvent,
+		stripe.EventTypeApplicationFeeRefunded:         sp.handleApplicationFeeEvent,
 	}
 
 	// 使用 map 來註冊所有的事件處理器
@@ -2,10 +2,9 @@ package event
 
 import (
 	"context"
-	"github.com/jackc/pgx/v5/pgtype"
 	"time"
 
-	"github.com/stripe/stripe-go/v79"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	"goflare.io/payment/driver"
@@ -19,6 +18,12 @@ type Repository interface {
 	Create(ctx context.Context, customer *models.Event) error
 	GetByID(ctx context.Context, id string) (*models.Event, error)
 	MarkAsProcessed(ctx context.Context, id string) error
+	ListForReplay(ctx context.Context, filter ReplayFilter) ([]*models.Event, error)
+	IncrementAttempts(ctx context.Context, id, lastError string) (int, error)
+	ScheduleRetry(ctx context.Context, id string, nextAttemptAt time.Time) error
+	MarkAsFailed(ctx context.Context, id string) error
+	ClaimForRetry(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*models.Event, error)
+	ListFailed(ctx context.Context, limit, offset int) ([]*models.Event, error)
 }
 
 type repository struct {
@@ -33,31 +38,133 @@ func NewRepository(conn driver.PostgresPool, logger *zap.Logger) (Repository, er
 	}, nil
 }
 
+// Create 寫入事件紀錄，Stripe 重送相同事件時保留既有紀錄
 func (r *repository) Create(ctx context.Context, event *models.Event) error {
 	return sqlc.New(r.conn).CreateEvent(ctx, sqlc.CreateEventParams{
-		ID:        event.ID,
-		Type:      sqlc.EventType(event.Type),
-		Processed: event.Processed,
-		CreatedAt: pgtype.Timestamptz{Time: event.CreatedAt, Valid: true},
-		UpdatedAt: pgtype.Timestamptz{Time: event.UpdatedAt, Valid: true},
+		ID:              event.ID,
+		Type:            sqlc.EventType(event.Type),
+		Processed:       event.Processed,
+		Payload:         event.Payload,
+		ApiVersion:      nullableString(event.APIVersion),
+		StripeCreatedAt: nullableTimestamptz(event.StripeCreatedAt),
+		ObjectID:        nullableString(event.ObjectID),
+		CreatedAt:       pgtype.Timestamptz{Time: event.CreatedAt, Valid: true},
+		UpdatedAt:       pgtype.Timestamptz{Time: event.UpdatedAt, Valid: true},
 	})
 }
 
 func (r *repository) GetByID(ctx context.Context, id string) (*models.Event, error) {
-	sqlcEvent, err := sqlc.New(r.conn).GetEventByID(ctx, id)
+	sqlcEvent, err := sqlc.New(r.conn).GetEventByID(ctx, sqlc.GetEventByIDParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &models.Event{
-		ID:        sqlcEvent.ID,
-		Type:      stripe.EventType(sqlcEvent.Type),
-		Processed: sqlcEvent.Processed,
-	}, nil
+	return models.NewEvent().ConvertFromSQLCEvent(sqlcEvent), nil
 }
 
 func (r *repository) MarkAsProcessed(ctx context.Context, id string) error {
 	return sqlc.New(r.conn).MarkEventAsProcessed(ctx, sqlc.MarkEventAsProcessedParams{
 		ID:        id,
 		UpdatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		TenantID:  driver.TenantID(ctx),
+	})
+}
+
+// ListForReplay 依 Stripe 建立時間排序列出保留 payload 的事件
+func (r *repository) ListForReplay(ctx context.Context, filter ReplayFilter) ([]*models.Event, error) {
+	params := sqlc.ListEventsForReplayParams{
+		ObjectID:    nullableString(filter.ObjectID),
+		CreatedFrom: nullableTimestamptz(filter.From),
+		CreatedTo:   nullableTimestamptz(filter.To),
+		Limit:       int64(filter.Limit),
+		TenantID:    driver.TenantID(ctx),
+	}
+	if filter.Type != "" {
+		params.Type = sqlc.NullEventType{EventType: sqlc.EventType(filter.Type), Valid: true}
+	}
+
+	sqlcEvents, err := sqlc.New(r.conn).ListEventsForReplay(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertEvents(sqlcEvents), nil
+}
+
+// IncrementAttempts 累加處理次數並記錄最後一次的錯誤，回傳累加後的次數
+func (r *repository) IncrementAttempts(ctx context.Context, id, lastError string) (int, error) {
+	attempts, err := sqlc.New(r.conn).IncrementEventAttempts(ctx, sqlc.IncrementEventAttemptsParams{
+		ID:        id,
+		LastError: nullableString(lastError),
+		UpdatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		TenantID:  driver.TenantID(ctx),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(attempts), nil
+}
+
+func (r *repository) ScheduleRetry(ctx context.Context, id string, nextAttemptAt time.Time) error {
+	return sqlc.New(r.conn).ScheduleEventRetry(ctx, sqlc.ScheduleEventRetryParams{
+		ID:            id,
+		NextAttemptAt: pgtype.Timestamptz{Time: nextAttemptAt, Valid: true},
+		UpdatedAt:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		TenantID:      driver.TenantID(ctx),
+	})
+}
+
+func (r *repository) MarkAsFailed(ctx context.Context, id string) error {
+	return sqlc.New(r.conn).MarkEventAsFailed(ctx, sqlc.MarkEventAsFailedParams{
+		ID:        id,
+		UpdatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		TenantID:  driver.TenantID(ctx),
+	})
+}
+
+// ClaimForRetry 取出到期的事件並將 next_attempt_at 延後到 leaseUntil
+// 以 FOR UPDATE SKIP LOCKED 避免多個實例取得同一筆事件，處理中斷時事件會在租約到期後再次被取出
+func (r *repository) ClaimForRetry(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*models.Event, error) {
+	sqlcEvents, err := sqlc.New(r.conn).ClaimEventsForRetry(ctx, sqlc.ClaimEventsForRetryParams{
+		LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		Now:        pgtype.Timestamptz{Time: now, Valid: true},
+		Limit:      int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertEvents(sqlcEvents), nil
+}
+
+func (r *repository) ListFailed(ctx context.Context, limit, offset int) ([]*models.Event, error) {
+	sqlcEvents, err := sqlc.New(r.conn).ListFailedEvents(ctx, sqlc.ListFailedEventsParams{
+		Limit:    int64(limit),
+		Offset:   int64(offset),
+		TenantID: driver.TenantID(ctx),
 	})
+	if err != nil {
+		return nil, err
+	}
+	return convertEvents(sqlcEvents), nil
+}
+
+func convertEvents(sqlcEvents []*sqlc.Event) []*models.Event {
+	events := make([]*models.Event, 0, len(sqlcEvents))
+	for _, sqlcEvent := range sqlcEvents {
+		events = append(events, models.NewEvent().ConvertFromSQLCEvent(sqlcEvent))
+	}
+	return events
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullableTimestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
 }
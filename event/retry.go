@@ -0,0 +1,48 @@
+package event
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 8
+	defaultBaseDelay   = 30 * time.Second
+	defaultMaxDelay    = time.Hour
+)
+
+// RetryPolicy 決定事件處理失敗後的重試次數與間隔，未設定的欄位使用預設值
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	return p
+}
+
+// Backoff 回傳第 attempts 次失敗後的等待時間
+// 以 BaseDelay 為底指數成長並以 MaxDelay 為上限，再取其一半加上隨機抖動，避免大量事件同時重試
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	p = p.withDefaults()
+
+	delay := p.MaxDelay
+	if shift := max(attempts-1, 0); shift < 32 {
+		if d := p.BaseDelay << shift; d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
@@ -2,13 +2,37 @@ package event
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stripe/stripe-go/v79"
+
 	"goflare.io/payment/models"
 )
 
+// defaultReplayLimit 未指定 Limit 時單次最多重新處理的事件數
+const defaultReplayLimit = 1000
+
+// ReplayFilter 篩選要重新處理的事件，未設定的欄位不做篩選
+// From 包含、To 不包含，皆以 Stripe 的事件建立時間比較
+type ReplayFilter struct {
+	Type     stripe.EventType
+	ObjectID string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
 type Service interface {
 	Create(ctx context.Context, event *models.Event) error
 	IsEventProcessed(ctx context.Context, eventID string) (bool, error)
 	MarkEventAsProcessed(ctx context.Context, eventID string) error
+	ListForReplay(ctx context.Context, filter ReplayFilter) ([]*models.Event, error)
+	GetByID(ctx context.Context, eventID string) (*models.Event, error)
+	RecordFailure(ctx context.Context, eventID string, processErr error, policy RetryPolicy) (bool, error)
+	ClaimDueForRetry(ctx context.Context, limit int, lease time.Duration) ([]*models.Event, error)
+	ListFailed(ctx context.Context, limit, offset int) ([]*models.Event, error)
 }
 
 type service struct {
@@ -23,8 +47,12 @@ func (s *service) Create(ctx context.Context, event *models.Event) error {
 	return s.repo.Create(ctx, event)
 }
 
+// IsEventProcessed 回傳事件是否已處理完成，尚未保存的事件視為未處理
 func (s *service) IsEventProcessed(ctx context.Context, eventID string) (bool, error) {
 	event, err := s.repo.GetByID(ctx, eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
@@ -34,3 +62,42 @@ func (s *service) IsEventProcessed(ctx context.Context, eventID string) (bool, e
 func (s *service) MarkEventAsProcessed(ctx context.Context, eventID string) error {
 	return s.repo.MarkAsProcessed(ctx, eventID)
 }
+
+func (s *service) ListForReplay(ctx context.Context, filter ReplayFilter) ([]*models.Event, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultReplayLimit
+	}
+	return s.repo.ListForReplay(ctx, filter)
+}
+
+func (s *service) GetByID(ctx context.Context, eventID string) (*models.Event, error) {
+	return s.repo.GetByID(ctx, eventID)
+}
+
+// RecordFailure 記錄一次處理失敗，未達重試上限時依 policy 排定下次處理時間，否則標記為永久失敗
+// 回傳值表示事件是否已永久失敗
+func (s *service) RecordFailure(ctx context.Context, eventID string, processErr error, policy RetryPolicy) (bool, error) {
+	policy = policy.withDefaults()
+
+	attempts, err := s.repo.IncrementAttempts(ctx, eventID, processErr.Error())
+	if err != nil {
+		return false, err
+	}
+
+	if attempts >= policy.MaxAttempts {
+		return true, s.repo.MarkAsFailed(ctx, eventID)
+	}
+
+	return false, s.repo.ScheduleRetry(ctx, eventID, time.Now().Add(policy.Backoff(attempts)))
+}
+
+// ClaimDueForRetry 取出已到重試時間的事件，lease 期間內其他排程器不會再取得同一筆事件
+func (s *service) ClaimDueForRetry(ctx context.Context, limit int, lease time.Duration) ([]*models.Event, error) {
+	now := time.Now()
+	return s.repo.ClaimForRetry(ctx, now, now.Add(lease), limit)
+}
+
+// ListFailed 列出已達重試上限而永久失敗的事件，依最後更新時間由新到舊排序
+func (s *service) ListFailed(ctx context.Context, limit, offset int) ([]*models.Event, error) {
+	return s.repo.ListFailed(ctx, limit, offset)
+}
@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/models"
+)
+
+const (
+	// APIKeyMetadataKey 為 authorization 以外另一個可以帶入 API key 的 metadata，對應 HTTP 端的 X-API-Key header
+	APIKeyMetadataKey = "x-api-key"
+
+	authorizationMetadataKey = "authorization"
+	bearerScheme             = "Bearer "
+
+	// requestMethod 為 api_key_requests.method 中 gRPC 請求的值，與 HTTP 方法區分
+	requestMethod = "GRPC"
+)
+
+var errMissingAPIKey = domainerr.New(domainerr.CodeUnauthenticated, "missing api key")
+
+// methodScopes 為每個 RPC 需要的權限，與 HTTP 端對應路由的權限相同；不在表中的 RPC 一律拒絕
+var methodScopes = map[string]models.APIKeyScope{
+	"CreateCustomer": models.ScopeCustomersWrite,
+	"GetCustomer":    models.ScopeCustomersRead,
+	"UpdateCustomer": models.ScopeCustomersWrite,
+
+	"CreateProduct": models.ScopeProductsWrite,
+	"GetProduct":    models.ScopeProductsRead,
+	"UpdateProduct": models.ScopeProductsWrite,
+	"ListProducts":  models.ScopeProductsRead,
+
+	"CreatePrice": models.ScopePricesWrite,
+	"GetPrice":    models.ScopePricesRead,
+	"UpdatePrice": models.ScopePricesWrite,
+	"ListPrices":  models.ScopePricesRead,
+
+	"CreateSubscription": models.ScopeSubscriptionsWrite,
+	"GetSubscription":    models.ScopeSubscriptionsRead,
+	"UpdateSubscription": models.ScopeSubscriptionsWrite,
+	"CancelSubscription": models.ScopeSubscriptionsWrite,
+	"ListSubscriptions":  models.ScopeSubscriptionsRead,
+
+	"CreatePaymentIntent":  models.ScopePaymentIntentsWrite,
+	"GetPaymentIntent":     models.ScopePaymentIntentsRead,
+	"ConfirmPaymentIntent": models.ScopePaymentIntentsWrite,
+	"CancelPaymentIntent":  models.ScopePaymentIntentsWrite,
+
+	"CreateRefund": models.ScopeRefundsWrite,
+	"GetRefund":    models.ScopeRefundsRead,
+
+	"GetInvoice":   models.ScopeInvoicesRead,
+	"ListInvoices": models.ScopeInvoicesRead,
+	"PayInvoice":   models.ScopeInvoicesWrite,
+
+	// 付款方式屬於客戶，與 HTTP 端的 /payment/method 相同使用 customers 的權限
+	"CreatePaymentMethod": models.ScopeCustomersWrite,
+	"GetPaymentMethod":    models.ScopeCustomersRead,
+	"UpdatePaymentMethod": models.ScopeCustomersWrite,
+	"DeletePaymentMethod": models.ScopeCustomersWrite,
+	"ListPaymentMethods":  models.ScopeCustomersRead,
+}
+
+type apiKeyContextKey struct{}
+
+// apiKeyFromContext 回傳 RPC 認證使用的 API key，未認證時為 nil
+func apiKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*models.APIKey)
+	return key
+}
+
+// authInterceptor 對應 HTTP 端的 Auth，以 authorization: Bearer 或 x-api-key metadata 帶入的 key 認證，並檢查 RPC 需要的權限
+// health 探測與以簽章驗證的 HandleWebhook 不需要 API key
+func (s *Server) authInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if isHealthMethod(info.FullMethod) || isWebhookMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	raw := requestAPIKey(ctx)
+	if raw == "" {
+		return nil, s.toStatus(errMissingAPIKey)
+	}
+
+	key, err := s.apiKeys.Authenticate(ctx, raw)
+	if err != nil {
+		if domainerr.From(err).Code != domainerr.CodeUnauthenticated {
+			s.logger.Error("failed to authenticate api key", zap.Error(err))
+		}
+		return nil, s.toStatus(err)
+	}
+
+	// 與 HTTP 端相同，認證成功後的請求不論結果都記錄，包含權限不足而拒絕的請求
+	resp, err := s.authorize(context.WithValue(ctx, apiKeyContextKey{}, key), req, info, handler, key)
+	s.record(context.WithoutCancel(ctx), key, info.FullMethod, err)
+	return resp, err
+}
+
+// authorize 檢查 key 是否有 RPC 需要的權限，有權限時才呼叫 handler
+func (s *Server) authorize(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler, key *models.APIKey) (any, error) {
+	method := methodName(info.FullMethod)
+	scope, ok := methodScopes[method]
+	if !ok {
+		return nil, s.toStatus(domainerr.New(domainerr.CodePermissionDenied, "API key cannot call "+method))
+	}
+	if !key.Allows(scope) {
+		return nil, s.toStatus(domainerr.New(domainerr.CodePermissionDenied, "API key does not have the "+string(scope)+" scope"))
+	}
+
+	return handler(ctx, req)
+}
+
+// record 保存請求使用的 key，Method 固定為 GRPC、Path 為完整方法名稱、Status 為 gRPC 狀態碼；寫入失敗只記錄 log
+func (s *Server) record(ctx context.Context, key *models.APIKey, fullMethod string, err error) {
+	code := status.Code(err)
+	recordErr := s.apiKeys.RecordRequest(ctx, &models.APIKeyRequest{
+		APIKeyID:  key.ID,
+		Method:    requestMethod,
+		Path:      fullMethod,
+		Status:    int(code),
+		CreatedAt: time.Now(),
+	})
+	if recordErr != nil {
+		s.logger.Warn("failed to record api key usage",
+			zap.Error(recordErr),
+			zap.String("api_key", key.Prefix),
+			zap.String("method", fullMethod),
+			zap.String("code", code.String()),
+		)
+	}
+}
+
+func requestAPIKey(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, authorizationMetadataKey); len(values) > 0 {
+		if auth := values[0]; len(auth) > len(bearerScheme) && strings.EqualFold(auth[:len(bearerScheme)], bearerScheme) {
+			return strings.TrimSpace(auth[len(bearerScheme):])
+		}
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, APIKeyMetadataKey); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// methodName 回傳完整方法名稱（/package.Service/Method）中的 Method
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+func isHealthMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+func isWebhookMethod(fullMethod string) bool {
+	return methodName(fullMethod) == "HandleWebhook"
+}
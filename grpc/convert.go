@@ -0,0 +1,175 @@
+package grpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"goflare.io/payment/models"
+	pb "goflare.io/payment/proto/pb"
+)
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func toTimestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return toTimestamp(*t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// paginate 在記憶體中套用 limit/offset，limit 為 0 時回傳 offset 之後的全部資料
+func paginate[T any](items []T, limit, offset int32) []T {
+	if offset < 0 || int(offset) >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && int(limit) < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func toProtoCustomer(customer *models.Customer) *pb.Customer {
+	return &pb.Customer{
+		Id:        customer.ID,
+		Email:     customer.Email,
+		Name:      customer.Name,
+		Balance:   customer.Balance,
+		CreatedAt: toTimestamp(customer.CreatedAt),
+		UpdatedAt: toTimestamp(customer.UpdatedAt),
+	}
+}
+
+func toProtoProduct(product *models.Product) *pb.Product {
+	prices := make([]*pb.Price, 0, len(product.Prices))
+	for _, price := range product.Prices {
+		prices = append(prices, toProtoPrice(price))
+	}
+
+	return &pb.Product{
+		Id:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Active:      product.Active,
+		Metadata:    product.Metadata,
+		Prices:      prices,
+		CreatedAt:   toTimestamp(product.CreatedAt),
+		UpdatedAt:   toTimestamp(product.UpdatedAt),
+	}
+}
+
+func toProtoPrice(price *models.Price) *pb.Price {
+	return &pb.Price{
+		Id:                     price.ID,
+		ProductId:              price.ProductID,
+		Type:                   string(price.Type),
+		Currency:               string(price.Currency),
+		UnitAmount:             price.UnitAmount.Amount,
+		RecurringInterval:      string(price.RecurringInterval),
+		RecurringIntervalCount: price.RecurringIntervalCount,
+		TrialPeriodDays:        price.TrialPeriodDays,
+		Active:                 price.Active,
+		CreatedAt:              toTimestamp(price.CreatedAt),
+		UpdatedAt:              toTimestamp(price.UpdatedAt),
+	}
+}
+
+func toProtoSubscription(subscription *models.Subscription) *pb.Subscription {
+	return &pb.Subscription{
+		Id:                 subscription.ID,
+		CustomerId:         subscription.CustomerID,
+		PriceId:            subscription.PriceID,
+		Status:             string(subscription.Status),
+		CurrentPeriodStart: toTimestamp(subscription.CurrentPeriodStart),
+		CurrentPeriodEnd:   toTimestamp(subscription.CurrentPeriodEnd),
+		CanceledAt:         toTimestampPtr(subscription.CanceledAt),
+		CancelAtPeriodEnd:  subscription.CancelAtPeriodEnd,
+		TrialStart:         toTimestampPtr(subscription.TrialStart),
+		TrialEnd:           toTimestampPtr(subscription.TrialEnd),
+		CreatedAt:          toTimestamp(subscription.CreatedAt),
+		UpdatedAt:          toTimestamp(subscription.UpdatedAt),
+	}
+}
+
+func toProtoPaymentIntent(paymentIntent *models.PaymentIntent) *pb.PaymentIntent {
+	return &pb.PaymentIntent{
+		Id:                   paymentIntent.ID,
+		CustomerId:           paymentIntent.CustomerID,
+		Amount:               paymentIntent.Amount.Amount,
+		Currency:             string(paymentIntent.Amount.Currency),
+		Status:               string(paymentIntent.Status),
+		PaymentMethodId:      paymentIntent.PaymentMethodID,
+		SetupFutureUsage:     string(paymentIntent.SetupFutureUsage),
+		ClientSecret:         paymentIntent.ClientSecret,
+		CaptureMethod:        string(paymentIntent.CaptureMethod),
+		CreatedAt:            toTimestamp(paymentIntent.CreatedAt),
+		UpdatedAt:            toTimestamp(paymentIntent.UpdatedAt),
+		OnBehalfOf:           paymentIntent.OnBehalfOf,
+		TransferDestination:  paymentIntent.TransferDestination,
+		ApplicationFeeAmount: paymentIntent.ApplicationFeeAmount,
+	}
+}
+
+func toProtoRefund(refund *models.Refund) *pb.Refund {
+	return &pb.Refund{
+		Id:        refund.ID,
+		ChargeId:  refund.ChargeID,
+		Amount:    refund.Amount.Amount,
+		Status:    string(refund.Status),
+		Reason:    string(refund.Reason),
+		CreatedAt: toTimestamp(refund.CreatedAt),
+		UpdatedAt: toTimestamp(refund.UpdatedAt),
+	}
+}
+
+func toProtoInvoice(invoice *models.Invoice) *pb.Invoice {
+	var subscriptionID string
+	if invoice.SubscriptionID != nil {
+		subscriptionID = *invoice.SubscriptionID
+	}
+
+	return &pb.Invoice{
+		Id:              invoice.ID,
+		CustomerId:      invoice.CustomerID,
+		SubscriptionId:  subscriptionID,
+		Status:          string(invoice.Status),
+		Currency:        string(invoice.Currency),
+		AmountDue:       invoice.AmountDue.Amount,
+		AmountPaid:      invoice.AmountPaid.Amount,
+		AmountRemaining: invoice.AmountRemaining.Amount,
+		DueDate:         toTimestamp(invoice.DueDate),
+		PaidAt:          toTimestamp(invoice.PaidAt),
+		CreatedAt:       toTimestamp(invoice.CreatedAt),
+		UpdatedAt:       toTimestamp(invoice.UpdatedAt),
+	}
+}
+
+func toProtoPaymentMethod(paymentMethod *models.PaymentMethod) *pb.PaymentMethod {
+	return &pb.PaymentMethod{
+		Id:                  paymentMethod.ID,
+		CustomerId:          paymentMethod.CustomerID,
+		Type:                string(paymentMethod.Type),
+		CardLast4:           paymentMethod.CardLast4,
+		CardBrand:           string(paymentMethod.CardBrand),
+		CardExpMonth:        paymentMethod.CardExpMonth,
+		CardExpYear:         paymentMethod.CardExpYear,
+		BankAccountLast4:    paymentMethod.BankAccountLast4,
+		BankAccountBankName: paymentMethod.BankAccountBankName,
+		IsDefault:           paymentMethod.IsDefault,
+		CreatedAt:           toTimestamp(paymentMethod.CreatedAt),
+		UpdatedAt:           toTimestamp(paymentMethod.UpdatedAt),
+	}
+}
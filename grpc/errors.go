@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"goflare.io/payment"
+	"goflare.io/payment/domainerr"
+)
+
+// ErrorDomain 為 gRPC ErrorInfo 的 Domain
+const ErrorDomain = "payment.goflare.io"
+
+// toStatus 將領域錯誤轉換為 gRPC status，分類與 HTTP 的 problem 回應相同
+// internal 與 upstream_unavailable 的原始錯誤會寫入 log，但不會回傳給呼叫端
+func (s *Server) toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var signatureErr *payment.WebhookSignatureError
+	if errors.As(err, &signatureErr) {
+		err = domainerr.Wrap(err, domainerr.CodeValidation, "invalid webhook signature")
+	}
+
+	domainErr := domainerr.From(err)
+	if domainErr.Code.HTTPStatus() >= http.StatusInternalServerError && domainErr.Err != nil {
+		s.logger.Error("gRPC request failed", zap.String("code", string(domainErr.Code)), zap.Error(domainErr.Err))
+	}
+	return newStatus(domainErr)
+}
+
+// newStatus 建立帶有錯誤細節的 gRPC status
+// ErrorInfo 的 Reason 為大寫的 domainerr.Code，Metadata 帶有 decline_code、stripe_code、param 與 request_id；錯誤指出有問題的欄位時另外附上 BadRequest
+func newStatus(domainErr *domainerr.Error) error {
+	message := domainErr.Message
+	if message == "" {
+		message = "internal error"
+	}
+
+	st := status.New(domainErr.Code.GRPCCode(), message)
+
+	metadata := map[string]string{}
+	for key, value := range map[string]string{
+		"decline_code": domainErr.DeclineCode,
+		"stripe_code":  domainErr.StripeCode,
+		"param":        domainErr.Param,
+		"request_id":   domainErr.RequestID,
+	} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   strings.ToUpper(string(domainErr.Code)),
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	}}
+	if domainErr.Param != "" {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       domainErr.Param,
+				Description: message,
+			}},
+		})
+	}
+
+	withDetails, detailErr := st.WithDetails(details...)
+	if detailErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pb "goflare.io/payment/proto/pb"
+)
+
+// healthCheckInterval 為以 readiness 更新 gRPC health 狀態的間隔
+const healthCheckInterval = 5 * time.Second
+
+// watchHealth 定期以 readiness 的結果更新整體與 PaymentService 的 serving 狀態，直到 Stop
+func (s *Server) watchHealth() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.updateHealth()
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) updateHealth() {
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if report := s.checker.Readiness(context.Background()); !report.Ready {
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range []string{"", pb.PaymentService_ServiceDesc.ServiceName} {
+		s.health.SetServingStatus(service, servingStatus)
+	}
+	s.logger.Debug("updated gRPC health status", zap.String("status", servingStatus.String()))
+}
+
+// newHealthServer 建立 gRPC health protocol 的服務，readiness 檢查完成前回報 NOT_SERVING
+func newHealthServer() *grpchealth.Server {
+	server := grpchealth.NewServer()
+	for _, service := range []string{"", pb.PaymentService_ServiceDesc.ServiceName} {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return server
+}
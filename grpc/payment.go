@@ -0,0 +1,591 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/models"
+	pb "goflare.io/payment/proto/pb"
+)
+
+func requireID(field, value string) error {
+	if value == "" {
+		return invalidField(field, field+" is required")
+	}
+	return nil
+}
+
+// invalidField 回傳指出有問題欄位的 InvalidArgument，與 HTTP 端的 validation 錯誤相同帶有 param
+func invalidField(field, message string) error {
+	return newStatus(&domainerr.Error{Code: domainerr.CodeValidation, Message: message, Param: field})
+}
+
+// listParams 將列表請求共用的 limit、cursor 與建立時間範圍轉為 models.ListParams
+func listParams(limit int32, cursor string, createdFrom, createdTo *timestamppb.Timestamp) (models.ListParams, error) {
+	if limit < 0 || limit > models.MaxListLimit {
+		return models.ListParams{}, invalidField("limit", fmt.Sprintf("limit must be between 0 and %d", models.MaxListLimit))
+	}
+	decoded, err := models.DecodeCursor(cursor)
+	if err != nil {
+		return models.ListParams{}, invalidField("cursor", "invalid cursor")
+	}
+
+	return models.ListParams{
+		Limit:       int(limit),
+		Cursor:      decoded,
+		CreatedFrom: fromTimestamp(createdFrom),
+		CreatedTo:   fromTimestamp(createdTo),
+	}, nil
+}
+
+// idempotencyKeyMetadata 為呼叫端傳入冪等鍵的 metadata，與 HTTP 的 Idempotency-Key header 相同
+const idempotencyKeyMetadata = "idempotency-key"
+
+// idempotencyKey 取得呼叫端提供的冪等鍵，逾時後以相同的 key 重試時 Stripe 會回傳第一次的結果
+func idempotencyKey(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, idempotencyKeyMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// CreateCustomer creates a customer in Stripe
+func (s *Server) CreateCustomer(ctx context.Context, req *pb.CreateCustomerRequest) (*pb.Customer, error) {
+	if err := requireID("email", req.GetEmail()); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.payment.CreateCustomer(ctx, idempotencyKey(ctx), req.GetEmail(), req.GetName())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoCustomer(customer), nil
+}
+
+// GetCustomer retrieves a customer from the local database
+func (s *Server) GetCustomer(ctx context.Context, req *pb.GetCustomerRequest) (*pb.Customer, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.payment.GetCustomer(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoCustomer(customer), nil
+}
+
+// UpdateCustomer updates a customer's balance in Stripe
+func (s *Server) UpdateCustomer(ctx context.Context, req *pb.UpdateCustomerRequest) (*pb.Customer, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.UpdateCustomerBalance(ctx, idempotencyKey(ctx), &models.Customer{
+		ID:      req.GetId(),
+		Balance: req.GetBalance(),
+	}); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	customer, err := s.payment.GetCustomer(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	customer.Balance = req.GetBalance()
+
+	return toProtoCustomer(customer), nil
+}
+
+// CreateProduct creates a product in Stripe
+func (s *Server) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	if err := requireID("name", req.GetName()); err != nil {
+		return nil, err
+	}
+
+	product := models.Product{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Active:      req.GetActive(),
+		Metadata:    req.GetMetadata(),
+	}
+	created, err := s.payment.CreateProduct(ctx, idempotencyKey(ctx), product)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoProduct(created), nil
+}
+
+// GetProduct retrieves a product and its prices from the local database
+func (s *Server) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	var (
+		product *models.Product
+		err     error
+	)
+	if req.GetIncludeInactivePrices() {
+		product, err = s.payment.GetProductWithAllPrices(ctx, req.GetId())
+	} else {
+		product, err = s.payment.GetProductWithActivePrices(ctx, req.GetId())
+	}
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoProduct(product), nil
+}
+
+// UpdateProduct updates a product in Stripe
+func (s *Server) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	product := &models.Product{
+		ID:          req.GetId(),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Active:      req.GetActive(),
+		Metadata:    req.GetMetadata(),
+	}
+	if err := s.payment.UpdateProduct(ctx, idempotencyKey(ctx), product); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoProduct(product), nil
+}
+
+// ListProducts lists products from the local database
+func (s *Server) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	params, err := listParams(req.GetLimit(), req.GetCursor(), req.GetCreatedFrom(), req.GetCreatedTo())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetActiveOnly() {
+		params.Status = "active"
+	}
+
+	page, err := s.payment.ListProducts(ctx, params)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &pb.ListProductsResponse{
+		Products:   make([]*pb.Product, 0, len(page.Data)),
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}
+	for _, product := range page.Data {
+		resp.Products = append(resp.Products, toProtoProduct(product))
+	}
+
+	return resp, nil
+}
+
+// CreatePrice creates a price in Stripe
+func (s *Server) CreatePrice(ctx context.Context, req *pb.CreatePriceRequest) (*pb.Price, error) {
+	if err := requireID("product_id", req.GetProductId()); err != nil {
+		return nil, err
+	}
+	if req.GetUnitAmount() <= 0 {
+		return nil, invalidField("unit_amount", "unit_amount must be positive")
+	}
+
+	price := models.Price{
+		ProductID:              req.GetProductId(),
+		Type:                   stripe.PriceType(req.GetType()),
+		Currency:               stripe.Currency(req.GetCurrency()),
+		UnitAmount:             models.NewMoney(req.GetUnitAmount(), stripe.Currency(req.GetCurrency())),
+		RecurringInterval:      stripe.PriceRecurringInterval(req.GetRecurringInterval()),
+		RecurringIntervalCount: req.GetRecurringIntervalCount(),
+		TrialPeriodDays:        req.GetTrialPeriodDays(),
+		Active:                 true,
+	}
+	created, err := s.payment.CreatePrice(ctx, idempotencyKey(ctx), price)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoPrice(created), nil
+}
+
+// UpdatePrice deactivates a price in Stripe. Stripe prices cannot be re-activated through this service.
+func (s *Server) UpdatePrice(ctx context.Context, req *pb.UpdatePriceRequest) (*pb.Price, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+	if req.GetActive() {
+		return nil, invalidField("active", "prices can only be deactivated")
+	}
+
+	if err := s.payment.DeletePrice(ctx, idempotencyKey(ctx), req.GetId()); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return &pb.Price{Id: req.GetId(), Active: false}, nil
+}
+
+// ListPrices lists the prices of a product from the local database
+func (s *Server) ListPrices(ctx context.Context, req *pb.ListPricesRequest) (*pb.ListPricesResponse, error) {
+	if err := requireID("product_id", req.GetProductId()); err != nil {
+		return nil, err
+	}
+
+	var (
+		product *models.Product
+		err     error
+	)
+	if req.GetActiveOnly() {
+		product, err = s.payment.GetProductWithActivePrices(ctx, req.GetProductId())
+	} else {
+		product, err = s.payment.GetProductWithAllPrices(ctx, req.GetProductId())
+	}
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &pb.ListPricesResponse{Prices: make([]*pb.Price, 0, len(product.Prices))}
+	for _, price := range product.Prices {
+		resp.Prices = append(resp.Prices, toProtoPrice(price))
+	}
+	resp.Prices = paginate(resp.Prices, req.GetLimit(), req.GetOffset())
+
+	return resp, nil
+}
+
+// CreateSubscription creates a subscription in Stripe
+func (s *Server) CreateSubscription(ctx context.Context, req *pb.CreateSubscriptionRequest) (*pb.Subscription, error) {
+	if err := requireID("customer_id", req.GetCustomerId()); err != nil {
+		return nil, err
+	}
+	if err := requireID("price_id", req.GetPriceId()); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.payment.CreateSubscription(ctx, idempotencyKey(ctx), req.GetCustomerId(), req.GetPriceId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoSubscription(subscription), nil
+}
+
+// GetSubscription retrieves a subscription from the local database
+func (s *Server) GetSubscription(ctx context.Context, req *pb.GetSubscriptionRequest) (*pb.Subscription, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.payment.GetSubscription(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoSubscription(subscription), nil
+}
+
+// UpdateSubscription updates a subscription in Stripe
+func (s *Server) UpdateSubscription(ctx context.Context, req *pb.UpdateSubscriptionRequest) (*pb.Subscription, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.UpdateSubscription(ctx, idempotencyKey(ctx), &models.Subscription{
+		ID:                req.GetId(),
+		CancelAtPeriodEnd: req.GetCancelAtPeriodEnd(),
+	}); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	subscription, err := s.payment.GetSubscription(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	subscription.CancelAtPeriodEnd = req.GetCancelAtPeriodEnd()
+
+	return toProtoSubscription(subscription), nil
+}
+
+// CancelSubscription cancels a subscription in Stripe
+func (s *Server) CancelSubscription(ctx context.Context, req *pb.CancelSubscriptionRequest) (*pb.Subscription, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.CancelSubscription(ctx, idempotencyKey(ctx), req.GetId(), req.GetCancelAtPeriodEnd()); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	subscription, err := s.payment.GetSubscription(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+	subscription.CancelAtPeriodEnd = req.GetCancelAtPeriodEnd()
+
+	return toProtoSubscription(subscription), nil
+}
+
+// ListSubscriptions lists subscriptions from the local database
+func (s *Server) ListSubscriptions(ctx context.Context, req *pb.ListSubscriptionsRequest) (*pb.ListSubscriptionsResponse, error) {
+	params, err := listParams(req.GetLimit(), req.GetCursor(), req.GetCreatedFrom(), req.GetCreatedTo())
+	if err != nil {
+		return nil, err
+	}
+	params.CustomerID = req.GetCustomerId()
+	params.Status = req.GetStatus()
+
+	page, err := s.payment.ListSubscriptions(ctx, params)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &pb.ListSubscriptionsResponse{
+		Subscriptions: make([]*pb.Subscription, 0, len(page.Data)),
+		NextCursor:    page.NextCursor,
+		HasMore:       page.HasMore,
+	}
+	for _, subscription := range page.Data {
+		resp.Subscriptions = append(resp.Subscriptions, toProtoSubscription(subscription))
+	}
+
+	return resp, nil
+}
+
+// CreatePaymentIntent creates a payment intent in Stripe
+func (s *Server) CreatePaymentIntent(ctx context.Context, req *pb.CreatePaymentIntentRequest) (*pb.PaymentIntent, error) {
+	if err := requireID("customer_id", req.GetCustomerId()); err != nil {
+		return nil, err
+	}
+	if req.GetAmount() <= 0 {
+		return nil, invalidField("amount", "amount must be positive")
+	}
+	if err := requireID("currency", req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	paymentIntent, err := s.payment.CreatePaymentIntent(
+		ctx,
+		idempotencyKey(ctx),
+		req.GetCustomerId(),
+		req.GetPaymentMethodId(),
+		models.NewMoney(req.GetAmount(), stripe.Currency(req.GetCurrency())),
+		models.PaymentIntentConnect{
+			OnBehalfOf:           req.GetOnBehalfOf(),
+			TransferDestination:  req.GetTransferDestination(),
+			ApplicationFeeAmount: req.GetApplicationFeeAmount(),
+		},
+	)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoPaymentIntent(paymentIntent), nil
+}
+
+// GetPaymentIntent retrieves a payment intent from the local database
+func (s *Server) GetPaymentIntent(ctx context.Context, req *pb.GetPaymentIntentRequest) (*pb.PaymentIntent, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	paymentIntent, err := s.payment.GetPaymentIntent(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoPaymentIntent(paymentIntent), nil
+}
+
+// ConfirmPaymentIntent confirms a payment intent in Stripe
+func (s *Server) ConfirmPaymentIntent(ctx context.Context, req *pb.ConfirmPaymentIntentRequest) (*pb.PaymentIntent, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := requireID("payment_method_id", req.GetPaymentMethodId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.ConfirmPaymentIntent(ctx, idempotencyKey(ctx), req.GetId(), req.GetPaymentMethodId()); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return s.GetPaymentIntent(ctx, &pb.GetPaymentIntentRequest{Id: req.GetId()})
+}
+
+// CancelPaymentIntent cancels a payment intent in Stripe
+func (s *Server) CancelPaymentIntent(ctx context.Context, req *pb.CancelPaymentIntentRequest) (*pb.PaymentIntent, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.CancelPaymentIntent(ctx, idempotencyKey(ctx), req.GetId()); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return s.GetPaymentIntent(ctx, &pb.GetPaymentIntentRequest{Id: req.GetId()})
+}
+
+// CreateRefund creates a refund in Stripe
+func (s *Server) CreateRefund(ctx context.Context, req *pb.CreateRefundRequest) (*pb.Refund, error) {
+	if err := requireID("payment_intent_id", req.GetPaymentIntentId()); err != nil {
+		return nil, err
+	}
+	if req.GetAmount() <= 0 {
+		return nil, invalidField("amount", "amount must be positive")
+	}
+
+	refund, err := s.payment.CreateRefund(ctx, idempotencyKey(ctx), req.GetPaymentIntentId(), req.GetReason(), req.GetAmount())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoRefund(refund), nil
+}
+
+// GetRefund retrieves a refund from the local database
+func (s *Server) GetRefund(ctx context.Context, req *pb.GetRefundRequest) (*pb.Refund, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	refund, err := s.payment.GetRefund(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoRefund(refund), nil
+}
+
+// GetInvoice retrieves an invoice from the local database
+func (s *Server) GetInvoice(ctx context.Context, req *pb.GetInvoiceRequest) (*pb.Invoice, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.payment.GetInvoice(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoInvoice(invoice), nil
+}
+
+// ListInvoices lists invoices from the local database
+func (s *Server) ListInvoices(ctx context.Context, req *pb.ListInvoicesRequest) (*pb.ListInvoicesResponse, error) {
+	params, err := listParams(req.GetLimit(), req.GetCursor(), req.GetCreatedFrom(), req.GetCreatedTo())
+	if err != nil {
+		return nil, err
+	}
+	params.CustomerID = req.GetCustomerId()
+	params.Status = req.GetStatus()
+	params.Currency = stripe.Currency(strings.ToLower(req.GetCurrency()))
+
+	page, err := s.payment.ListInvoices(ctx, params)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &pb.ListInvoicesResponse{
+		Invoices:   make([]*pb.Invoice, 0, len(page.Data)),
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}
+	for _, invoice := range page.Data {
+		resp.Invoices = append(resp.Invoices, toProtoInvoice(invoice))
+	}
+
+	return resp, nil
+}
+
+// PayInvoice pays an invoice in Stripe
+func (s *Server) PayInvoice(ctx context.Context, req *pb.PayInvoiceRequest) (*pb.Invoice, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.PayInvoice(ctx, idempotencyKey(ctx), req.GetId()); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return s.GetInvoice(ctx, &pb.GetInvoiceRequest{Id: req.GetId()})
+}
+
+// GetPaymentMethod retrieves a payment method from the local database
+func (s *Server) GetPaymentMethod(ctx context.Context, req *pb.GetPaymentMethodRequest) (*pb.PaymentMethod, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	paymentMethod, err := s.payment.GetPaymentMethod(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return toProtoPaymentMethod(paymentMethod), nil
+}
+
+// DeletePaymentMethod detaches a payment method in Stripe and removes it from the local database
+func (s *Server) DeletePaymentMethod(ctx context.Context, req *pb.DeletePaymentMethodRequest) (*emptypb.Empty, error) {
+	if err := requireID("id", req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.DeletePaymentMethod(ctx, idempotencyKey(ctx), req.GetId()); err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListPaymentMethods lists the payment methods of a customer from the local database
+func (s *Server) ListPaymentMethods(ctx context.Context, req *pb.ListPaymentMethodsRequest) (*pb.ListPaymentMethodsResponse, error) {
+	if err := requireID("customer_id", req.GetCustomerId()); err != nil {
+		return nil, err
+	}
+
+	params, err := listParams(req.GetLimit(), req.GetCursor(), req.GetCreatedFrom(), req.GetCreatedTo())
+	if err != nil {
+		return nil, err
+	}
+	params.CustomerID = req.GetCustomerId()
+
+	page, err := s.payment.ListPaymentMethods(ctx, params)
+	if err != nil {
+		return nil, s.toStatus(err)
+	}
+
+	resp := &pb.ListPaymentMethodsResponse{
+		PaymentMethods: make([]*pb.PaymentMethod, 0, len(page.Data)),
+		NextCursor:     page.NextCursor,
+		HasMore:        page.HasMore,
+	}
+	for _, paymentMethod := range page.Data {
+		resp.PaymentMethods = append(resp.PaymentMethods, toProtoPaymentMethod(paymentMethod))
+	}
+
+	return resp, nil
+}
+
+// HandleWebhook verifies and dispatches a Stripe webhook event
+func (s *Server) HandleWebhook(ctx context.Context, req *pb.HandleWebhookRequest) (*emptypb.Empty, error) {
+	if err := s.payment.HandleStripeWebhook(ctx, req.GetPayload(), req.GetSignature()); err != nil {
+		s.logger.Error("Failed to handle webhook", zap.Error(err))
+		return nil, s.toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/ratelimit"
+)
+
+// RetryAfterMetadataKey 為超出限制時回傳的 header，對應 HTTP 的 Retry-After，單位為秒
+const RetryAfterMetadataKey = "retry-after"
+
+// rateLimitGroups 將 RPC 名稱中的資源對應到限流群組，與 HTTP 端的權限資源同名
+// PaymentIntent 與 PaymentMethod 必須在 Payment 開頭的其他名稱之前比對
+var rateLimitGroups = []struct {
+	noun  string
+	group string
+}{
+	{"PaymentIntent", "payment_intents"},
+	{"PaymentMethod", "payment_methods"},
+	{"Customer", "customers"},
+	{"Product", "products"},
+	{"Price", "prices"},
+	{"Subscription", "subscriptions"},
+	{"Refund", "refunds"},
+	{"Invoice", "invoices"},
+	{"Webhook", "webhooks"},
+}
+
+// customerRateLimitedMethods 為建立付款的 RPC，另外限制同一個客戶的請求速率
+var customerRateLimitedMethods = map[string]bool{
+	"CreatePaymentIntent": true,
+	"CreateSubscription":  true,
+}
+
+// rateLimitInterceptor 對應 HTTP 端的 RateLimit，以 API key 區分 bucket；不需要 API key 的 HandleWebhook 以呼叫端的來源位址區分
+// 超出限制時回傳 ResourceExhausted，並以 retry-after header 與 RetryInfo 告知需要等待的時間
+func (s *Server) rateLimitInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	// 探測不計入限流，避免負載平衡器的 health check 被拒絕
+	if !s.limiter.Enabled() || isHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	method := methodName(info.FullMethod)
+	group := rateLimitGroup(method)
+
+	bucket, rule := ratelimit.PeerBucket(peerAddress(ctx), group), s.limiter.Rule(group, "")
+	if key := apiKeyFromContext(ctx); key != nil {
+		bucket, rule = ratelimit.APIKeyBucket(key.Prefix, group), s.limiter.Rule(group, key.Prefix)
+	}
+
+	result := s.limiter.Allow(ctx, bucket, rule)
+	if !result.Allowed {
+		return nil, s.rateLimited(ctx, result, "rate limit exceeded")
+	}
+
+	if customerRateLimitedMethods[method] {
+		if r, ok := req.(interface{ GetCustomerId() string }); ok && r.GetCustomerId() != "" {
+			result = s.limiter.Allow(ctx, ratelimit.CustomerBucket(ctx, r.GetCustomerId()), s.limiter.CustomerRule())
+			if !result.Allowed {
+				return nil, s.rateLimited(ctx, result, "too many payment attempts for this customer")
+			}
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) rateLimited(ctx context.Context, result ratelimit.Result, message string) error {
+	seconds := ratelimit.RetryAfterSeconds(result.RetryAfter)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RetryAfterMetadataKey, strconv.Itoa(seconds))); err != nil {
+		s.logger.Warn("failed to set retry-after header", zap.Error(err))
+	}
+
+	st, err := status.New(codes.ResourceExhausted, message).WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: strings.ToUpper(string(domainerr.CodeRateLimited)),
+			Domain: ErrorDomain,
+		},
+		&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(result.RetryAfter),
+		},
+	)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, message)
+	}
+	return st.Err()
+}
+
+// rateLimitGroup 回傳 RPC 所屬的限流群組，沒有對應的資源時為 other
+func rateLimitGroup(method string) string {
+	for _, g := range rateLimitGroups {
+		if strings.Contains(method, g.noun) {
+			return g.group
+		}
+	}
+	return "other"
+}
+
+// peerAddress 回傳呼叫端的 IP，不含每個連線不同的 port
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
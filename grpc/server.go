@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"goflare.io/payment"
+	"goflare.io/payment/apikey"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/health"
+	pb "goflare.io/payment/proto/pb"
+	"goflare.io/payment/ratelimit"
+)
+
+var _ pb.PaymentServiceServer = (*Server)(nil)
+
+// TenantMetadataKey 為指定請求所屬租戶的 metadata，對應 HTTP 端的 X-Tenant-ID header
+const TenantMetadataKey = "x-tenant-id"
+
+// Server 將 proto/payment.proto 定義的 PaymentService 轉接到 payment.Payment
+// payment.Payment 沒有對應方法的 RPC（GetPrice、CreatePaymentMethod、UpdatePaymentMethod）回傳 Unimplemented
+type Server struct {
+	pb.UnimplementedPaymentServiceServer
+
+	payment payment.Payment
+	apiKeys apikey.Service
+	limiter *ratelimit.Limiter
+	checker *health.Checker
+	health  *grpchealth.Server
+	logger  *zap.Logger
+	server  *grpc.Server
+
+	// done 在 Stop 時關閉，停止更新 health 狀態
+	done chan struct{}
+}
+
+func NewServer(payment payment.Payment, apiKeys apikey.Service, limiter *ratelimit.Limiter, checker *health.Checker, logger *zap.Logger) *Server {
+	s := &Server{
+		payment: payment,
+		apiKeys: apiKeys,
+		limiter: limiter,
+		checker: checker,
+		health:  newHealthServer(),
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+
+	// stats handler 在 interceptor 之前執行，從 metadata 的 traceparent 接續呼叫端的 trace
+	s.server = grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()), grpc.ChainUnaryInterceptor(s.recoverInterceptor, s.authInterceptor, s.tenantInterceptor, s.rateLimitInterceptor))
+	pb.RegisterPaymentServiceServer(s.server, s)
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	return s
+}
+
+// Start listens on the provided address and serves gRPC requests until Stop is called.
+func (s *Server) Start(address string) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	return s.Serve(lis)
+}
+
+// Serve serves gRPC requests on an existing listener, e.g. a bufconn listener.
+func (s *Server) Serve(lis net.Listener) error {
+	go s.watchHealth()
+
+	s.logger.Info("gRPC server started", zap.String("address", lis.Addr().String()))
+	return s.server.Serve(lis)
+}
+
+// Stop reports NOT_SERVING to health checks, then waits for in-flight RPCs to finish before stopping the server.
+func (s *Server) Stop() {
+	close(s.done)
+	s.health.Shutdown()
+	s.server.GracefulStop()
+}
+
+// recoverInterceptor 對應 HTTP 端的 middleware.Recover，避免單一請求的 panic 終止整個服務
+func (s *Server) recoverInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			s.logger.Error("panic in gRPC handler", zap.String("method", info.FullMethod), zap.Any("panic", p))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// tenantInterceptor 對應 HTTP 端的 Tenancy，決定租戶並放進 ctx，必須放在 authInterceptor 之後
+//   - 屬於租戶的 API key：key 的租戶，x-tenant-id 指定其他租戶時回傳 PermissionDenied
+//   - 平台 API key、HandleWebhook 與 health 探測：x-tenant-id 指定的租戶，未指定時為預設租戶；webhook 以該租戶的簽章密鑰驗證
+func (s *Server) tenantInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var requested string
+	if values := metadata.ValueFromIncomingContext(ctx, TenantMetadataKey); len(values) > 0 {
+		requested = strings.TrimSpace(values[0])
+	}
+
+	tenantID := requested
+	if key := apiKeyFromContext(ctx); key != nil && key.TenantID != "" {
+		if requested != "" && requested != key.TenantID {
+			return nil, s.toStatus(domainerr.New(domainerr.CodePermissionDenied, "API key cannot access tenant "+requested))
+		}
+		tenantID = key.TenantID
+	}
+	if tenantID == "" {
+		tenantID = driver.DefaultTenantID
+	}
+
+	if !s.payment.HasTenant(tenantID) {
+		return nil, invalidField(TenantMetadataKey, "unknown tenant "+tenantID)
+	}
+
+	return handler(driver.WithTenant(ctx, tenantID), req)
+}
@@ -0,0 +1,374 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"goflare.io/payment"
+	"goflare.io/payment/apikey"
+	"goflare.io/payment/config"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	pb "goflare.io/payment/proto/pb"
+	"goflare.io/payment/ratelimit"
+)
+
+const (
+	platformKey = "pk_platform.secret"
+	tenantKey   = "pk_brand_a.secret"
+	readOnlyKey = "pk_readonly.secret"
+)
+
+// fakePayment 只實作測試用到的方法，其他方法呼叫時會 panic
+type fakePayment struct {
+	payment.Payment
+
+	customers map[string]*models.Customer
+	// tenants 記錄每次呼叫時 ctx 中的租戶
+	tenants []string
+}
+
+func (f *fakePayment) HasTenant(tenantID string) bool {
+	return tenantID == driver.DefaultTenantID || tenantID == "brand-a" || tenantID == "brand-b"
+}
+
+func (f *fakePayment) CreateCustomer(ctx context.Context, _, email, name string) (*models.Customer, error) {
+	f.recordTenant(ctx)
+	customer := &models.Customer{ID: "cus_123", Email: email, Name: name, CreatedAt: time.Unix(1725152400, 0)}
+	f.customers[customer.ID] = customer
+	return customer, nil
+}
+
+func (f *fakePayment) GetCustomer(ctx context.Context, customerID string) (*models.Customer, error) {
+	f.recordTenant(ctx)
+	customer, ok := f.customers[customerID]
+	if !ok {
+		return nil, domainerr.New(domainerr.CodeNotFound, "customer not found")
+	}
+	return customer, nil
+}
+
+func (f *fakePayment) recordTenant(ctx context.Context) {
+	tenantID, _ := driver.TenantFromContext(ctx)
+	f.tenants = append(f.tenants, tenantID)
+}
+
+// fakeAPIKeys 依固定的 key 認證，並保存 RecordRequest 寫入的請求紀錄
+type fakeAPIKeys struct {
+	apikey.Service
+
+	mu       sync.Mutex
+	requests []*models.APIKeyRequest
+}
+
+func (*fakeAPIKeys) Authenticate(_ context.Context, key string) (*models.APIKey, error) {
+	switch key {
+	case platformKey:
+		return &models.APIKey{ID: 1, Prefix: "pk_platform", Scopes: []models.APIKeyScope{models.ScopeCustomersRead, models.ScopeCustomersWrite, models.ScopeProductsRead, models.ScopeRefundsWrite}}, nil
+	case tenantKey:
+		return &models.APIKey{ID: 2, Prefix: "pk_brand_a", TenantID: "brand-a", Scopes: []models.APIKeyScope{models.ScopeCustomersRead, models.ScopeCustomersWrite}}, nil
+	case readOnlyKey:
+		return &models.APIKey{ID: 3, Prefix: "pk_readonly", Scopes: []models.APIKeyScope{models.ScopeCustomersRead}}, nil
+	}
+	return nil, domainerr.New(domainerr.CodeUnauthenticated, "invalid api key")
+}
+
+func (f *fakeAPIKeys) RecordRequest(_ context.Context, request *models.APIKeyRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, request)
+	return nil
+}
+
+// newTestClient 以 bufconn 啟動 Server，回傳連到它的 client
+func newTestClient(t *testing.T, fake *fakePayment, apiKeys *fakeAPIKeys) pb.PaymentServiceClient {
+	t.Helper()
+
+	limiter := ratelimit.NewLimiter(nil, &config.Config{}, zap.NewNop())
+	s := NewServer(fake, apiKeys, limiter, nil, zap.NewNop())
+
+	lis := bufconn.Listen(1 << 20)
+	// 不經過 Serve，測試不需要以 readiness 更新 health 狀態
+	go func() {
+		_ = s.server.Serve(lis)
+	}()
+	t.Cleanup(s.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return pb.NewPaymentServiceClient(conn)
+}
+
+func withKey(key string, pairs ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), append([]string{"authorization", "Bearer " + key}, pairs...)...)
+}
+
+func TestCustomerRoundTrip(t *testing.T) {
+	fake := &fakePayment{customers: map[string]*models.Customer{}}
+	client := newTestClient(t, fake, &fakeAPIKeys{})
+
+	created, err := client.CreateCustomer(withKey(platformKey), &pb.CreateCustomerRequest{Email: "jane@example.com", Name: "Jane"})
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	if created.GetId() != "cus_123" || created.GetEmail() != "jane@example.com" || created.GetName() != "Jane" {
+		t.Fatalf("unexpected customer %v", created)
+	}
+	if created.GetCreatedAt().AsTime().Unix() != 1725152400 {
+		t.Fatalf("created_at = %v", created.GetCreatedAt().AsTime())
+	}
+
+	got, err := client.GetCustomer(withKey(platformKey), &pb.GetCustomerRequest{Id: created.GetId()})
+	if err != nil {
+		t.Fatalf("GetCustomer: %v", err)
+	}
+	if !proto.Equal(got, created) {
+		t.Fatalf("GetCustomer = %v, want %v", got, created)
+	}
+
+	for i, tenantID := range fake.tenants {
+		if tenantID != driver.DefaultTenantID {
+			t.Fatalf("call %d ran in tenant %q, want %q", i, tenantID, driver.DefaultTenantID)
+		}
+	}
+}
+
+func TestErrorsMapToStatusCodes(t *testing.T) {
+	client := newTestClient(t, &fakePayment{customers: map[string]*models.Customer{}}, &fakeAPIKeys{})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		call func(ctx context.Context) error
+		code codes.Code
+		// param 為 InvalidArgument 指出的欄位，會出現在 BadRequest 的 field violation 中
+		param string
+	}{
+		{
+			name: "missing api key",
+			ctx:  context.Background(),
+			call: func(ctx context.Context) error {
+				_, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: "cus_123"})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "invalid api key",
+			ctx:  withKey("pk_unknown.secret"),
+			call: func(ctx context.Context) error {
+				_, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: "cus_123"})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "missing scope",
+			ctx:  withKey(readOnlyKey),
+			call: func(ctx context.Context) error {
+				_, err := client.CreateCustomer(ctx, &pb.CreateCustomerRequest{Email: "jane@example.com"})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "missing id",
+			ctx:  withKey(platformKey),
+			call: func(ctx context.Context) error {
+				_, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			param: "id",
+		},
+		{
+			name: "missing email",
+			ctx:  withKey(platformKey),
+			call: func(ctx context.Context) error {
+				_, err := client.CreateCustomer(ctx, &pb.CreateCustomerRequest{})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			param: "email",
+		},
+		{
+			name: "limit out of range",
+			ctx:  withKey(platformKey),
+			call: func(ctx context.Context) error {
+				_, err := client.ListProducts(ctx, &pb.ListProductsRequest{Limit: models.MaxListLimit + 1})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			param: "limit",
+		},
+		{
+			name: "invalid cursor",
+			ctx:  withKey(platformKey),
+			call: func(ctx context.Context) error {
+				_, err := client.ListProducts(ctx, &pb.ListProductsRequest{Cursor: "not-a-cursor"})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			param: "cursor",
+		},
+		{
+			name: "refund amount not positive",
+			ctx:  withKey(platformKey),
+			call: func(ctx context.Context) error {
+				_, err := client.CreateRefund(ctx, &pb.CreateRefundRequest{PaymentIntentId: "pi_123"})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			param: "amount",
+		},
+		{
+			name: "not found",
+			ctx:  withKey(platformKey),
+			call: func(ctx context.Context) error {
+				_, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: "cus_missing"})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "unknown tenant",
+			ctx:  withKey(platformKey, TenantMetadataKey, "brand-z"),
+			call: func(ctx context.Context) error {
+				_, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: "cus_123"})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			param: TenantMetadataKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.ctx)
+			if got := status.Code(err); got != tt.code {
+				t.Fatalf("code = %s, want %s (%v)", got, tt.code, err)
+			}
+			if tt.param != "" && violatedField(err) != tt.param {
+				t.Fatalf("field violation = %q, want %q", violatedField(err), tt.param)
+			}
+		})
+	}
+}
+
+// violatedField 回傳 status 的 BadRequest 細節中第一個有問題的欄位
+func violatedField(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok && len(badRequest.GetFieldViolations()) > 0 {
+			return badRequest.GetFieldViolations()[0].GetField()
+		}
+	}
+	return ""
+}
+
+func TestTenantBoundKey(t *testing.T) {
+	fake := &fakePayment{customers: map[string]*models.Customer{}}
+	client := newTestClient(t, fake, &fakeAPIKeys{})
+
+	if _, err := client.CreateCustomer(withKey(tenantKey), &pb.CreateCustomerRequest{Email: "jane@example.com"}); err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	if len(fake.tenants) != 1 || fake.tenants[0] != "brand-a" {
+		t.Fatalf("tenants = %v, want [brand-a]", fake.tenants)
+	}
+
+	// 屬於租戶的 key 不能以 x-tenant-id 切換到其他租戶
+	_, err := client.GetCustomer(withKey(tenantKey, TenantMetadataKey, "brand-b"), &pb.GetCustomerRequest{Id: "cus_123"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("code = %s, want PermissionDenied (%v)", status.Code(err), err)
+	}
+
+	// 平台 key 以 x-tenant-id 選擇租戶
+	if _, err = client.GetCustomer(withKey(platformKey, TenantMetadataKey, "brand-b"), &pb.GetCustomerRequest{Id: "cus_123"}); err != nil {
+		t.Fatalf("GetCustomer: %v", err)
+	}
+	if last := fake.tenants[len(fake.tenants)-1]; last != "brand-b" {
+		t.Fatalf("tenant = %q, want brand-b", last)
+	}
+}
+
+// TestRequestsAreRecorded 確認與 HTTP 端相同，認證成功的 RPC 不論成功或被拒絕都會記錄使用的 key
+func TestRequestsAreRecorded(t *testing.T) {
+	apiKeys := &fakeAPIKeys{}
+	client := newTestClient(t, &fakePayment{customers: map[string]*models.Customer{}}, apiKeys)
+
+	if _, err := client.CreateCustomer(withKey(platformKey), &pb.CreateCustomerRequest{Email: "jane@example.com"}); err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	if _, err := client.CreateCustomer(withKey(readOnlyKey), &pb.CreateCustomerRequest{Email: "jane@example.com"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("code = %s, want PermissionDenied (%v)", status.Code(err), err)
+	}
+	// 無效的 key 沒有可以記錄的 key
+	if _, err := client.GetCustomer(withKey("pk_unknown.secret"), &pb.GetCustomerRequest{Id: "cus_123"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("code = %s, want Unauthenticated (%v)", status.Code(err), err)
+	}
+
+	apiKeys.mu.Lock()
+	defer apiKeys.mu.Unlock()
+	want := []models.APIKeyRequest{
+		{APIKeyID: 1, Method: "GRPC", Path: "/payment.PaymentService/CreateCustomer", Status: int(codes.OK)},
+		{APIKeyID: 3, Method: "GRPC", Path: "/payment.PaymentService/CreateCustomer", Status: int(codes.PermissionDenied)},
+	}
+	if len(apiKeys.requests) != len(want) {
+		t.Fatalf("recorded %d requests, want %d", len(apiKeys.requests), len(want))
+	}
+	for i, request := range apiKeys.requests {
+		if request.CreatedAt.IsZero() {
+			t.Fatalf("request %d has no created_at", i)
+		}
+		request.CreatedAt = time.Time{}
+		if *request != want[i] {
+			t.Fatalf("request %d = %+v, want %+v", i, *request, want[i])
+		}
+	}
+}
+
+// TestOldUint64IDIsIgnored 確認舊版 client 以欄位 1 送出的 uint64 ID 不會被解析成字串 ID
+func TestOldUint64IDIsIgnored(t *testing.T) {
+	old := protowire.AppendTag(nil, 1, protowire.VarintType)
+	old = protowire.AppendVarint(old, 42)
+
+	var req pb.GetCustomerRequest
+	if err := proto.Unmarshal(old, &req); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if req.GetId() != "" {
+		t.Fatalf("id = %q, want empty", req.GetId())
+	}
+
+	client := newTestClient(t, &fakePayment{customers: map[string]*models.Customer{}}, &fakeAPIKeys{})
+	_, err := client.GetCustomer(withKey(platformKey), &req)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("code = %s, want InvalidArgument (%v)", status.Code(err), err)
+	}
+}
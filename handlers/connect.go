@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment"
+	"goflare.io/payment/models"
+)
+
+type ConnectHandler interface {
+	CreateConnectedAccount(c echo.Context) error
+	GetConnectedAccount(c echo.Context) error
+	CreateAccountLink(c echo.Context) error
+	CreateTransfer(c echo.Context) error
+	GetTransfer(c echo.Context) error
+	ReverseTransfer(c echo.Context) error
+	GetApplicationFee(c echo.Context) error
+}
+
+type connectHandler struct {
+	Payment payment.Payment
+}
+
+func NewConnectHandler(Payment payment.Payment) ConnectHandler {
+	return &connectHandler{
+		Payment: Payment,
+	}
+}
+
+// CreateConnectedAccount handles POST /connect/account
+func (ch *connectHandler) CreateConnectedAccount(c echo.Context) error {
+	var req models.ConnectedAccountParams
+	if err := c.Bind(&req); err != nil {
+		return badRequest(c, "Invalid request payload")
+	}
+
+	account, err := ch.Payment.CreateConnectedAccount(c.Request().Context(), idempotencyKey(c), req)
+	if err != nil {
+		return RespondError(c, err, "Failed to create connected account")
+	}
+
+	return c.JSON(http.StatusCreated, account)
+}
+
+// GetConnectedAccount handles GET /connect/account/:id
+func (ch *connectHandler) GetConnectedAccount(c echo.Context) error {
+	id := c.Param("id")
+
+	account, err := ch.Payment.GetConnectedAccount(c.Request().Context(), id)
+	if err != nil {
+		return RespondError(c, err, "Failed to get connected account")
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// CreateAccountLink handles POST /connect/account/:id/link，回傳商家完成 onboarding 的網址
+func (ch *connectHandler) CreateAccountLink(c echo.Context) error {
+	var req struct {
+		RefreshURL string `json:"refresh_url"`
+		ReturnURL  string `json:"return_url"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return badRequest(c, "Invalid request payload")
+	}
+	if req.RefreshURL == "" || req.ReturnURL == "" {
+		return badRequest(c, "refresh_url and return_url are required")
+	}
+
+	link, err := ch.Payment.CreateAccountLink(c.Request().Context(), idempotencyKey(c), c.Param("id"), req.RefreshURL, req.ReturnURL)
+	if err != nil {
+		return RespondError(c, err, "Failed to create account link")
+	}
+
+	return c.JSON(http.StatusCreated, link)
+}
+
+// CreateTransfer handles POST /transfer
+func (ch *connectHandler) CreateTransfer(c echo.Context) error {
+	var req struct {
+		DestinationAccountID string          `json:"destination_account_id"`
+		Amount               int64           `json:"amount"`
+		Currency             stripe.Currency `json:"currency"`
+		SourceTransactionID  string          `json:"source_transaction_id,omitempty"`
+		TransferGroup        string          `json:"transfer_group,omitempty"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return badRequest(c, "Invalid request payload")
+	}
+
+	transfer, err := ch.Payment.CreateTransfer(c.Request().Context(), idempotencyKey(c), req.DestinationAccountID, models.NewMoney(req.Amount, req.Currency), req.SourceTransactionID, req.TransferGroup)
+	if err != nil {
+		return RespondError(c, err, "Failed to create transfer")
+	}
+
+	return c.JSON(http.StatusCreated, transfer)
+}
+
+// GetTransfer handles GET /transfer/:id
+func (ch *connectHandler) GetTransfer(c echo.Context) error {
+	id := c.Param("id")
+
+	transfer, err := ch.Payment.GetTransfer(c.Request().Context(), id)
+	if err != nil {
+		return RespondError(c, err, "Failed to get transfer")
+	}
+
+	return c.JSON(http.StatusOK, transfer)
+}
+
+// ReverseTransfer handles POST /transfer/:id/reversal，amount 省略時取回全部金額
+func (ch *connectHandler) ReverseTransfer(c echo.Context) error {
+	var req struct {
+		Amount int64 `json:"amount,omitempty"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return badRequest(c, "Invalid request payload")
+	}
+
+	reversal, err := ch.Payment.ReverseTransfer(c.Request().Context(), idempotencyKey(c), c.Param("id"), req.Amount)
+	if err != nil {
+		return RespondError(c, err, "Failed to reverse transfer")
+	}
+
+	return c.JSON(http.StatusCreated, reversal)
+}
+
+// GetApplicationFee handles GET /connect/application_fee/:id
+func (ch *connectHandler) GetApplicationFee(c echo.Context) error {
+	id := c.Param("id")
+
+	fee, err := ch.Payment.GetApplicationFee(c.Request().Context(), id)
+	if err != nil {
+		return RespondError(c, err, "Failed to get application fee")
+	}
+
+	return c.JSON(http.StatusOK, fee)
+}
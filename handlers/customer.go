@@ -32,14 +32,15 @@ func NewCustomerHandler(
 func (ch *customerHandler) CreateCustomer(c echo.Context) error {
 	var customer models.Customer
 	if err := c.Bind(&customer); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
-	if err := ch.Payment.CreateCustomer(c.Request().Context(), customer.Email, customer.Name); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create customer"})
+	created, err := ch.Payment.CreateCustomer(c.Request().Context(), idempotencyKey(c), customer.Email, customer.Name)
+	if err != nil {
+		return RespondError(c, err, "Failed to create customer")
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, created)
 }
 
 // GetCustomer handles GET /customers/:id
@@ -48,7 +49,7 @@ func (ch *customerHandler) GetCustomer(c echo.Context) error {
 
 	customer, err := ch.Payment.GetCustomer(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Customer not found"})
+		return RespondError(c, err, "Failed to get customer")
 	}
 
 	return c.JSON(http.StatusOK, customer)
@@ -60,12 +61,12 @@ func (ch *customerHandler) UpdateCustomer(c echo.Context) error {
 
 	var customer models.Customer
 	if err := c.Bind(&customer); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 	customer.ID = id
 
-	if err := ch.Payment.UpdateCustomerBalance(&customer); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update customer"})
+	if err := ch.Payment.UpdateCustomerBalance(c.Request().Context(), idempotencyKey(c), &customer); err != nil {
+		return RespondError(c, err, "Failed to update customer")
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -75,8 +76,8 @@ func (ch *customerHandler) UpdateCustomer(c echo.Context) error {
 func (ch *customerHandler) DeleteCustomer(c echo.Context) error {
 	id := c.Param("id")
 
-	if err := ch.Payment.DeleteCustomer(id); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete customer"})
+	if err := ch.Payment.DeleteCustomer(c.Request().Context(), idempotencyKey(c), id); err != nil {
+		return RespondError(c, err, "Failed to delete customer")
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -93,7 +94,7 @@ func (ch *customerHandler) DeleteCustomer(c echo.Context) error {
 //
 //	customers, err := ch.Payment.List(c.Request().Context(), limit, offset)
 //	if err != nil {
-//		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list customers"})
+//		return RespondError(c, err, "Failed to list customers")
 //	}
 //
 //	return c.JSON(http.StatusOK, customers)
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"goflare.io/payment/domainerr"
+)
+
+// MIMEApplicationProblemJSON 為錯誤回應的 Content-Type
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem 為 RFC 9457 格式的錯誤回應，另外帶有 domainerr 的分類
+// Code 為錯誤分類，DeclineCode 與 StripeCode 為 Stripe 回傳的拒絕原因與錯誤代碼，呼叫端可以據此提示持卡人更換卡片或聯絡發卡行
+type Problem struct {
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Status      int            `json:"status"`
+	Detail      string         `json:"detail,omitempty"`
+	Instance    string         `json:"instance,omitempty"`
+	Code        domainerr.Code `json:"code"`
+	Param       string         `json:"param,omitempty"`
+	DeclineCode string         `json:"decline_code,omitempty"`
+	StripeCode  string         `json:"stripe_code,omitempty"`
+	RequestID   string         `json:"request_id,omitempty"`
+}
+
+// RespondError 依 domainerr 的分類回傳 problem 回應
+// message 為錯誤本身沒有可以回傳的說明時（例如 internal）使用的說明，例如 "Failed to create refund"
+// internal 與 upstream_unavailable 的原始錯誤會寫入 log，但不會回傳給呼叫端
+func RespondError(c echo.Context, err error, message string) error {
+	domainErr := domainerr.From(err)
+	if domainErr == nil {
+		domainErr = domainerr.New(domainerr.CodeInternal, "")
+	}
+
+	status := domainErr.Code.HTTPStatus()
+	if status >= http.StatusInternalServerError && domainErr.Err != nil {
+		c.Logger().Error(domainErr.Err)
+	}
+
+	detail := domainErr.Message
+	if detail == "" {
+		detail = message
+	}
+
+	problem := Problem{
+		Type:        "about:blank",
+		Title:       http.StatusText(status),
+		Status:      status,
+		Detail:      detail,
+		Instance:    c.Request().URL.Path,
+		Code:        domainErr.Code,
+		Param:       domainErr.Param,
+		DeclineCode: domainErr.DeclineCode,
+		StripeCode:  domainErr.StripeCode,
+		RequestID:   domainErr.RequestID,
+	}
+	if problem.Title == "" {
+		problem.Title = string(domainErr.Code)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(status, problem)
+}
+
+// badRequest 回傳 validation 錯誤，用於請求本身格式錯誤
+func badRequest(c echo.Context, message string) error {
+	return RespondError(c, domainerr.New(domainerr.CodeValidation, message), "")
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"goflare.io/payment"
+)
+
+const defaultEventListLimit = 100
+
+type EventHandler interface {
+	ListDeadLetters(c echo.Context) error
+	ReplayDeadLetter(c echo.Context) error
+	WorkerStats(c echo.Context) error
+	ListFailedEvents(c echo.Context) error
+}
+
+type eventHandler struct {
+	Payment payment.Payment
+	Logger  *zap.Logger
+}
+
+func NewEventHandler(payment payment.Payment, logger *zap.Logger) EventHandler {
+	return &eventHandler{
+		Payment: payment,
+		Logger:  logger,
+	}
+}
+
+// ListDeadLetters handles GET /event/dead-letter
+func (eh *eventHandler) ListDeadLetters(c echo.Context) error {
+	limit := defaultEventListLimit
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return badRequest(c, "Invalid limit")
+		}
+		limit = parsed
+	}
+
+	deadLetters, err := eh.Payment.ListDeadLetterEvents(c.Request().Context(), limit)
+	if err != nil {
+		eh.Logger.Error("Failed to list dead-letter events", zap.Error(err))
+		return RespondError(c, err, "Failed to list dead-letter events")
+	}
+
+	return c.JSON(http.StatusOK, deadLetters)
+}
+
+// ReplayDeadLetter handles POST /event/dead-letter/:sequence/replay
+func (eh *eventHandler) ReplayDeadLetter(c echo.Context) error {
+	sequence, err := strconv.ParseUint(c.Param("sequence"), 10, 64)
+	if err != nil {
+		return badRequest(c, "Invalid sequence")
+	}
+
+	if err = eh.Payment.ReplayDeadLetterEvent(c.Request().Context(), sequence); err != nil {
+		eh.Logger.Error("Failed to replay dead-letter event", zap.Error(err), zap.Uint64("sequence", sequence))
+		return RespondError(c, err, "Failed to replay dead-letter event")
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// WorkerStats handles GET /event/workers
+func (eh *eventHandler) WorkerStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, eh.Payment.WorkerPoolStats())
+}
+
+// ListFailedEvents handles GET /event/failed
+func (eh *eventHandler) ListFailedEvents(c echo.Context) error {
+	limit, offset := defaultEventListLimit, 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return badRequest(c, "Invalid limit")
+		}
+		limit = parsed
+	}
+	if raw := c.QueryParam("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return badRequest(c, "Invalid offset")
+		}
+		offset = parsed
+	}
+
+	events, err := eh.Payment.ListFailedEvents(c.Request().Context(), limit, offset)
+	if err != nil {
+		eh.Logger.Error("Failed to list failed events", zap.Error(err))
+		return RespondError(c, err, "Failed to list failed events")
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
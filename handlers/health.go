@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"goflare.io/payment/health"
+)
+
+type HealthHandler interface {
+	Liveness(c echo.Context) error
+	Readiness(c echo.Context) error
+}
+
+type healthHandler struct {
+	Checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) HealthHandler {
+	return &healthHandler{
+		Checker: checker,
+	}
+}
+
+// Liveness handles GET /healthz
+func (hh *healthHandler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, hh.Checker.Liveness())
+}
+
+// Readiness handles GET /readyz
+func (hh *healthHandler) Readiness(c echo.Context) error {
+	report := hh.Checker.Readiness(c.Request().Context())
+	if !report.Ready {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+// IdempotencyKeyHeader 為呼叫端傳入冪等鍵的 header，會一併轉送給 Stripe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKey 取得請求的冪等鍵，未提供時回傳空字串
+func idempotencyKey(c echo.Context) string {
+	return c.Request().Header.Get(IdempotencyKeyHeader)
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 
 	"goflare.io/payment"
+	"goflare.io/payment/models"
 )
 
 type InvoiceHandler interface {
@@ -37,20 +39,26 @@ func (ih *invoiceHandler) GetInvoice(c echo.Context) error {
 	invoice, err := ih.Payment.GetInvoice(c.Request().Context(), id)
 	if err != nil {
 		ih.logger.Error("Failed to get invoice", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Invoice not found"})
+		return RespondError(c, err, "Failed to get invoice")
 	}
 
 	return c.JSON(http.StatusOK, invoice)
 }
 
-// ListInvoices handles GET /invoices
+// ListInvoices handles GET /invoice
 func (ih *invoiceHandler) ListInvoices(c echo.Context) error {
-	customerID := c.QueryParam("customer_id")
+	params, err := listParams(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
 
-	invoices, err := ih.Payment.ListInvoices(c.Request().Context(), customerID)
+	invoices, err := ih.Payment.ListInvoices(c.Request().Context(), params)
 	if err != nil {
-		ih.logger.Error("Failed to list invoices", zap.Error(err), zap.String("customerID", customerID))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list invoices"})
+		if errors.Is(err, models.ErrInvalidListFilter) {
+			return badRequest(c, "Invalid status or currency filter")
+		}
+		ih.logger.Error("Failed to list invoices", zap.Error(err), zap.String("customerID", params.CustomerID))
+		return RespondError(c, err, "Failed to list invoices")
 	}
 
 	return c.JSON(http.StatusOK, invoices)
@@ -60,9 +68,9 @@ func (ih *invoiceHandler) ListInvoices(c echo.Context) error {
 func (ih *invoiceHandler) PayInvoice(c echo.Context) error {
 	id := c.Param("id")
 
-	if err := ih.Payment.PayInvoice(id); err != nil {
+	if err := ih.Payment.PayInvoice(c.Request().Context(), idempotencyKey(c), id); err != nil {
 		ih.logger.Error("Failed to pay invoice", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to pay invoice"})
+		return RespondError(c, err, "Failed to pay invoice")
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -76,13 +84,13 @@ func (ih *invoiceHandler) PayInvoice(c echo.Context) error {
 //	}
 //	if err := c.Bind(&req); err != nil {
 //		ih.logger.Error("Invalid request payload", zap.Error(err))
-//		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+//		return badRequest(c, "Invalid request payload")
 //	}
 //
 //	draftInvoice, err := ih.Payment.CreateDraftInvoice(c.Request().Context(), req.CustomerID)
 //	if err != nil {
 //		ih.logger.Error("Failed to create draft invoice", zap.Error(err), zap.Uint64("customerID", req.CustomerID))
-//		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create draft invoice"})
+//		return RespondError(c, err, "Failed to create draft invoice")
 //	}
 //
 //	return c.JSON(http.StatusCreated, draftInvoice)
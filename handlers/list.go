@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/models"
+)
+
+// listParams 從 query string 解析列表的分頁與篩選條件
+// limit、cursor、customer_id、status、currency，以及 RFC 3339 格式的 created_from（包含）與 created_to（不包含）
+func listParams(c echo.Context) (models.ListParams, error) {
+	params := models.ListParams{
+		CustomerID: c.QueryParam("customer_id"),
+		Status:     c.QueryParam("status"),
+		Currency:   stripe.Currency(strings.ToLower(c.QueryParam("currency"))),
+	}
+
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > models.MaxListLimit {
+			return params, fmt.Errorf("limit must be between 1 and %d", models.MaxListLimit)
+		}
+		params.Limit = limit
+	}
+
+	cursor, err := models.DecodeCursor(c.QueryParam("cursor"))
+	if err != nil {
+		return params, errors.New("invalid cursor")
+	}
+	params.Cursor = cursor
+
+	if params.CreatedFrom, err = parseListTime(c, "created_from"); err != nil {
+		return params, err
+	}
+	if params.CreatedTo, err = parseListTime(c, "created_to"); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+func parseListTime(c echo.Context, name string) (time.Time, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
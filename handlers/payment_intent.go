@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stripe/stripe-go/v79"
 
 	"goflare.io/payment"
+	"goflare.io/payment/models"
 )
 
 type PaymentIntentHandler interface {
@@ -15,7 +17,6 @@ type PaymentIntentHandler interface {
 	ConfirmPaymentIntent(c echo.Context) error
 	CancelPaymentIntent(c echo.Context) error
 	ListPaymentIntents(c echo.Context) error
-	ListPaymentIntentsByCustomer(c echo.Context) error
 }
 
 type paymentIntentHandler struct {
@@ -32,19 +33,21 @@ func NewPaymentIntentHandler(Payment payment.Payment) PaymentIntentHandler {
 func (ph *paymentIntentHandler) CreatePaymentIntent(c echo.Context) error {
 	var req struct {
 		CustomerID      string          `json:"customer_id"`
-		Amount          uint64          `json:"amount"`
+		Amount          int64           `json:"amount"`
 		Currency        stripe.Currency `json:"currency"`
 		PaymentMethodID string          `json:"payment_method_id,omitempty"`
+		models.PaymentIntentConnect
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
-	if err := ph.Payment.CreatePaymentIntent(req.CustomerID, req.PaymentMethodID, req.Amount, req.Currency); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create payment intent"})
+	paymentIntent, err := ph.Payment.CreatePaymentIntent(c.Request().Context(), idempotencyKey(c), req.CustomerID, req.PaymentMethodID, models.NewMoney(req.Amount, req.Currency), req.PaymentIntentConnect)
+	if err != nil {
+		return RespondError(c, err, "Failed to create payment intent")
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, paymentIntent)
 }
 
 // GetPaymentIntent handles GET /payment_intents/:id
@@ -53,7 +56,7 @@ func (ph *paymentIntentHandler) GetPaymentIntent(c echo.Context) error {
 
 	paymentIntent, err := ph.Payment.GetPaymentIntent(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Payment intent not found"})
+		return RespondError(c, err, "Failed to get payment intent")
 	}
 
 	return c.JSON(http.StatusOK, paymentIntent)
@@ -67,11 +70,11 @@ func (ph *paymentIntentHandler) ConfirmPaymentIntent(c echo.Context) error {
 		PaymentIntentID string `json:"payment_intent_id"`
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
-	if err := ph.Payment.ConfirmPaymentIntent(req.PaymentIntentID, req.PaymentMethodID); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to confirm payment intent"})
+	if err := ph.Payment.ConfirmPaymentIntent(c.Request().Context(), idempotencyKey(c), req.PaymentIntentID, req.PaymentMethodID); err != nil {
+		return RespondError(c, err, "Failed to confirm payment intent")
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -81,52 +84,26 @@ func (ph *paymentIntentHandler) ConfirmPaymentIntent(c echo.Context) error {
 func (ph *paymentIntentHandler) CancelPaymentIntent(c echo.Context) error {
 	id := c.Param("id")
 
-	if err := ph.Payment.CancelPaymentIntent(id); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to cancel payment intent"})
+	if err := ph.Payment.CancelPaymentIntent(c.Request().Context(), idempotencyKey(c), id); err != nil {
+		return RespondError(c, err, "Failed to cancel payment intent")
 	}
 
 	return c.NoContent(http.StatusOK)
 }
 
-// ListPaymentIntents handles GET /payment_intents
+// ListPaymentIntents handles GET /payment/intent，可以 customer_id 篩選
 func (ph *paymentIntentHandler) ListPaymentIntents(c echo.Context) error {
-	var req struct {
-		Limit  uint64 `json:"limit"`
-		Offset uint64 `json:"offset"`
-	}
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
-	}
-
-	if req.Limit == 0 {
-		req.Limit = 10 // 默認限制
-	}
-
-	paymentIntents, err := ph.Payment.ListPaymentIntent(c.Request().Context(), req.Limit, req.Offset)
+	params, err := listParams(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list payment intents"})
-	}
-
-	return c.JSON(http.StatusOK, paymentIntents)
-}
-
-func (ph *paymentIntentHandler) ListPaymentIntentsByCustomer(c echo.Context) error {
-	var req struct {
-		CustomerID string `json:"customer_id"`
-		Limit      uint64 `json:"limit"`
-		Offset     uint64 `json:"offset"`
-	}
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
-	}
-
-	if req.Limit == 0 {
-		req.Limit = 10 // 默認限制
+		return badRequest(c, err.Error())
 	}
 
-	paymentIntents, err := ph.Payment.ListPaymentIntentByCustomerID(c.Request().Context(), req.CustomerID, req.Limit, req.Offset)
+	paymentIntents, err := ph.Payment.ListPaymentIntents(c.Request().Context(), params)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list payment intents"})
+		if errors.Is(err, models.ErrInvalidListFilter) {
+			return badRequest(c, "Invalid status or currency filter")
+		}
+		return RespondError(c, err, "Failed to list payment intents")
 	}
 
 	return c.JSON(http.StatusOK, paymentIntents)
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"goflare.io/payment"
+	"goflare.io/payment/models"
+)
+
+type PaymentMethodHandler interface {
+	ListPaymentMethods(c echo.Context) error
+}
+
+type paymentMethodHandler struct {
+	Payment payment.Payment
+}
+
+func NewPaymentMethodHandler(Payment payment.Payment) PaymentMethodHandler {
+	return &paymentMethodHandler{
+		Payment: Payment,
+	}
+}
+
+// ListPaymentMethods handles GET /payment/method，可以 customer_id 篩選
+func (ph *paymentMethodHandler) ListPaymentMethods(c echo.Context) error {
+	params, err := listParams(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	paymentMethods, err := ph.Payment.ListPaymentMethods(c.Request().Context(), params)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidListFilter) {
+			return badRequest(c, "Invalid status or currency filter")
+		}
+		return RespondError(c, err, "Failed to list payment methods")
+	}
+
+	return c.JSON(http.StatusOK, paymentMethods)
+}
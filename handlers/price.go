@@ -33,28 +33,34 @@ func (ph *priceHandler) CreatePrice(c echo.Context) error {
 
 	var req models.Price
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
+	}
+
+	// unit_amount 未帶幣別時沿用 currency
+	if req.UnitAmount.Currency == "" {
+		req.UnitAmount.Currency = req.Currency
 	}
 
 	if err := validateCreatePriceRequest(req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return badRequest(c, err.Error())
 	}
 
-	if err := ph.Payment.CreatePrice(req); err != nil {
+	price, err := ph.Payment.CreatePrice(c.Request().Context(), idempotencyKey(c), req)
+	if err != nil {
 		ph.Logger.Error("Failed to create price", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create price"})
+		return RespondError(c, err, "Failed to create price")
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, price)
 }
 
 func (ph *priceHandler) DeletePrice(c echo.Context) error {
 
 	id := c.Param("id")
 
-	if err := ph.Payment.DeletePrice(id); err != nil {
+	if err := ph.Payment.DeletePrice(c.Request().Context(), idempotencyKey(c), id); err != nil {
 		ph.Logger.Error("Failed to delete price", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete price"})
+		return RespondError(c, err, "Failed to delete price")
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -64,9 +70,15 @@ func validateCreatePriceRequest(req models.Price) error {
 	if len(req.ProductID) == 0 {
 		return errors.New("product_id is required")
 	}
-	if req.UnitAmount <= 0 {
+	if req.Currency == "" {
+		return errors.New("currency is required")
+	}
+	if req.UnitAmount.Amount <= 0 {
 		return errors.New("unit_amount must be greater than 0")
 	}
+	if req.UnitAmount.Currency != req.Currency {
+		return errors.New("unit_amount currency must match currency")
+	}
 	if req.Type == stripe.PriceTypeRecurring {
 		if req.RecurringInterval == "" {
 			return errors.New("recurring_interval is required for recurring prices")
@@ -35,20 +35,21 @@ func (ph *productHandler) CreateProduct(c echo.Context) error {
 
 	var req models.Product
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
 	// 驗證請求數據
 	if err := validateCreateProductRequest(req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return badRequest(c, err.Error())
 	}
 
-	if err := ph.Payment.CreateProduct(req); err != nil {
+	product, err := ph.Payment.CreateProduct(c.Request().Context(), idempotencyKey(c), req)
+	if err != nil {
 		ph.Logger.Error("Failed to create product", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create product"})
+		return RespondError(c, err, "Failed to create product")
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, product)
 }
 
 func (ph *productHandler) GetProduct(c echo.Context) error {
@@ -59,7 +60,7 @@ func (ph *productHandler) GetProduct(c echo.Context) error {
 	product, err := ph.Payment.GetProductWithAllPrices(ctx, id)
 	if err != nil {
 		ph.Logger.Error("Failed to get product", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Product not found"})
+		return RespondError(c, err, "Failed to get product")
 	}
 
 	return c.JSON(http.StatusOK, product)
@@ -79,7 +80,7 @@ func (ph *productHandler) UpdateProduct(c echo.Context) error {
 
 	if err := c.Bind(&productReq); err != nil {
 		ph.Logger.Error("Failed to bind product request", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
 	product := &models.Product{
@@ -90,9 +91,9 @@ func (ph *productHandler) UpdateProduct(c echo.Context) error {
 		Metadata:    productReq.Metadata,
 	}
 
-	if err := ph.Payment.UpdateProduct(product); err != nil {
+	if err := ph.Payment.UpdateProduct(c.Request().Context(), idempotencyKey(c), product); err != nil {
 		ph.Logger.Error("Failed to update product", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update product"})
+		return RespondError(c, err, "Failed to update product")
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -102,21 +103,28 @@ func (ph *productHandler) DeleteProduct(c echo.Context) error {
 
 	id := c.Param("id")
 
-	if err := ph.Payment.DeleteProduct(id); err != nil {
+	if err := ph.Payment.DeleteProduct(c.Request().Context(), idempotencyKey(c), id); err != nil {
 		ph.Logger.Error("Failed to delete product", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete product"})
+		return RespondError(c, err, "Failed to delete product")
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+// ListProducts handles GET /product
 func (ph *productHandler) ListProducts(c echo.Context) error {
-	ctx := c.Request().Context()
+	params, err := listParams(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
 
-	products, err := ph.Payment.ListProducts(ctx)
+	products, err := ph.Payment.ListProducts(c.Request().Context(), params)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidListFilter) {
+			return badRequest(c, "Invalid status filter")
+		}
 		ph.Logger.Error("Failed to list products", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list products"})
+		return RespondError(c, err, "Failed to list products")
 	}
 
 	return c.JSON(http.StatusOK, products)
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 
 	"goflare.io/payment"
+	"goflare.io/payment/models"
 )
 
 type RefundHandler interface {
@@ -26,22 +28,23 @@ func NewRefundHandler(
 	}
 }
 
-// CreateRefund handles POST /refunds
+// CreateRefund handles POST /refund
 func (rh *refundHandler) CreateRefund(c echo.Context) error {
 	var req struct {
 		PaymentIntentID string `json:"payment_intent_id"`
-		Amount          uint64 `json:"amount"`
+		Amount          int64  `json:"amount"`
 		Reason          string `json:"reason"`
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
-	if err := rh.Payment.CreateRefund(req.PaymentIntentID, req.Reason, req.Amount); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create refund"})
+	refund, err := rh.Payment.CreateRefund(c.Request().Context(), idempotencyKey(c), req.PaymentIntentID, req.Reason, req.Amount)
+	if err != nil {
+		return RespondError(c, err, "Failed to create refund")
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, refund)
 }
 
 // GetRefund handles GET /refunds/:id
@@ -50,19 +53,26 @@ func (rh *refundHandler) GetRefund(c echo.Context) error {
 
 	refund, err := rh.Payment.GetRefund(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Refund not found"})
+		return RespondError(c, err, "Failed to get refund")
 	}
 
 	return c.JSON(http.StatusOK, refund)
 }
 
-// ListRefunds handles GET /refunds
+// ListRefunds handles GET /refund，可以 charge_id 篩選
 func (rh *refundHandler) ListRefunds(c echo.Context) error {
-	paymentIntentID := c.Param("id")
+	params, err := listParams(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	params.ChargeID = c.QueryParam("charge_id")
 
-	refunds, err := rh.Payment.ListRefunds(c.Request().Context(), paymentIntentID)
+	refunds, err := rh.Payment.ListRefunds(c.Request().Context(), params)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list refunds"})
+		if errors.Is(err, models.ErrInvalidListFilter) {
+			return badRequest(c, "Invalid status or currency filter")
+		}
+		return RespondError(c, err, "Failed to list refunds")
 	}
 
 	return c.JSON(http.StatusOK, refunds)
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -29,21 +30,22 @@ func NewSubscriptionHandler(
 	}
 }
 
-// CreateSubscription handles POST /subscriptions
+// CreateSubscription handles POST /subscription
 func (sh *subscriptionHandler) CreateSubscription(c echo.Context) error {
 	var req struct {
 		CustomerID string `json:"customer_id"`
 		PriceID    string `json:"price_id"`
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
-	if err := sh.Payment.CreateSubscription(req.CustomerID, req.PriceID); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create subscription"})
+	subscription, err := sh.Payment.CreateSubscription(c.Request().Context(), idempotencyKey(c), req.CustomerID, req.PriceID)
+	if err != nil {
+		return RespondError(c, err, "Failed to create subscription")
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return c.JSON(http.StatusCreated, subscription)
 }
 
 // GetSubscription handles GET /subscriptions/:id
@@ -52,7 +54,7 @@ func (sh *subscriptionHandler) GetSubscription(c echo.Context) error {
 
 	subscription, err := sh.Payment.GetSubscription(c.Request().Context(), id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Subscription not found"})
+		return RespondError(c, err, "Failed to get subscription")
 	}
 
 	return c.JSON(http.StatusOK, subscription)
@@ -64,12 +66,12 @@ func (sh *subscriptionHandler) UpdateSubscription(c echo.Context) error {
 
 	var subscription models.Subscription
 	if err := c.Bind(&subscription); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 	subscription.ID = id
 
-	if err := sh.Payment.UpdateSubscription(&subscription); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update subscription"})
+	if err := sh.Payment.UpdateSubscription(c.Request().Context(), idempotencyKey(c), &subscription); err != nil {
+		return RespondError(c, err, "Failed to update subscription")
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -82,23 +84,29 @@ func (sh *subscriptionHandler) CancelSubscription(c echo.Context) error {
 		CancelAtPeriodEnd bool `json:"cancel_at_period_end"`
 	}
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		return badRequest(c, "Invalid request payload")
 	}
 
-	if err := sh.Payment.CancelSubscription(id, req.CancelAtPeriodEnd); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to cancel subscription"})
+	if err := sh.Payment.CancelSubscription(c.Request().Context(), idempotencyKey(c), id, req.CancelAtPeriodEnd); err != nil {
+		return RespondError(c, err, "Failed to cancel subscription")
 	}
 
 	return c.NoContent(http.StatusOK)
 }
 
-// ListSubscriptions handles GET /subscriptions
+// ListSubscriptions handles GET /subscription
 func (sh *subscriptionHandler) ListSubscriptions(c echo.Context) error {
-	customerID := c.QueryParam("customer_id")
+	params, err := listParams(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
 
-	subscriptions, err := sh.Payment.ListSubscriptions(c.Request().Context(), customerID)
+	subscriptions, err := sh.Payment.ListSubscriptions(c.Request().Context(), params)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list subscriptions"})
+		if errors.Is(err, models.ErrInvalidListFilter) {
+			return badRequest(c, "Invalid status filter")
+		}
+		return RespondError(c, err, "Failed to list subscriptions")
 	}
 
 	return c.JSON(http.StatusOK, subscriptions)
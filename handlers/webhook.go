@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"io"
 	"net/http"
 
@@ -32,15 +33,22 @@ func (wh *webhookHandler) HandleWebhook(c echo.Context) error {
 	payload, err := io.ReadAll(c.Request().Body)
 	if err != nil {
 		wh.Logger.Error("Failed to read webhook payload", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read request body"})
+		return badRequest(c, "Failed to read request body")
 	}
 
 	signature := c.Request().Header.Get("Stripe-Signature")
 
 	err = wh.Payment.HandleStripeWebhook(c.Request().Context(), payload, signature)
 	if err != nil {
+		// 簽章錯誤回覆 400，Stripe 不會重試；其他錯誤回覆 500 讓 Stripe 稍後重送
+		var signatureErr *payment.WebhookSignatureError
+		if errors.As(err, &signatureErr) {
+			wh.Logger.Warn("Rejected webhook with invalid signature", zap.Error(err))
+			return badRequest(c, "Invalid webhook signature")
+		}
+
 		wh.Logger.Error("Failed to handle webhook", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to process webhook"})
+		return RespondError(c, err, "Failed to process webhook")
 	}
 
 	return c.NoContent(http.StatusOK)
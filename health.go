@@ -0,0 +1,54 @@
+package payment
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// degradedRetryInterval 為降級模式下重試啟動失敗的元件的間隔
+const degradedRetryInterval = 5 * time.Second
+
+// EventPipelineStatus 為事件管線的連線狀態，供 readiness 檢查
+// NATS 為連線狀態（CONNECTED、RECONNECTING 等），Subscribed 為是否正在接收事件
+type EventPipelineStatus struct {
+	NATS       string `json:"nats"`
+	Connected  bool   `json:"connected"`
+	Subscribed bool   `json:"subscribed"`
+}
+
+// EventPipelineStatus 回傳 NATS 連線與事件訂閱的狀態
+func (sp *StripePayment) EventPipelineStatus() EventPipelineStatus {
+	if sp.natsConn == nil || sp.eventManager == nil {
+		return EventPipelineStatus{NATS: "NOT_CONFIGURED"}
+	}
+	return EventPipelineStatus{
+		NATS:       sp.natsConn.Status().String(),
+		Connected:  sp.natsConn.IsConnected(),
+		Subscribed: sp.eventManager.Subscribed(),
+	}
+}
+
+// retryInBackground 在降級模式下於背景重試啟動失敗的元件，直到成功或 Close
+func (sp *StripePayment) retryInBackground(name string, err error, start func() error) {
+	sp.logger.Warn("failed to start, retrying in background", zap.String("component", name), zap.Error(err))
+
+	go func() {
+		ticker := time.NewTicker(degradedRetryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sp.done:
+				return
+			case <-ticker.C:
+				if err := start(); err != nil {
+					sp.logger.Debug("still failing to start", zap.String("component", name), zap.Error(err))
+					continue
+				}
+				sp.logger.Info("started after retry", zap.String("component", name))
+				return
+			}
+		}
+	}()
+}
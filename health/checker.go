@@ -0,0 +1,245 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"goflare.io/payment"
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/migrate"
+	"goflare.io/payment/migrations"
+)
+
+const (
+	defaultWorkerSaturation = 0.9
+	defaultTimeout          = 2 * time.Second
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Check 為單一依賴的檢查結果，Latency 為檢查花費的毫秒數
+type Check struct {
+	Status  Status         `json:"status"`
+	Latency int64          `json:"latency_ms,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Detail  map[string]any `json:"detail,omitempty"`
+}
+
+// Report 為 readiness 的檢查結果；任一硬性依賴為 down 時 Ready 為 false
+// Redis 為軟性依賴，冪等與限流在 Redis 無法連線時放行請求，因此只會讓 Status 成為 degraded
+type Report struct {
+	Status Status           `json:"status"`
+	Ready  bool             `json:"ready"`
+	Checks map[string]Check `json:"checks"`
+}
+
+// Checker 檢查 Postgres、Redis、NATS 與 worker pool 是否可以處理請求，供 /readyz 與 gRPC health 使用
+type Checker struct {
+	pool     driver.PostgresPool
+	rdb      *redis.Client
+	payment  payment.Payment
+	settings atomic.Pointer[settings]
+	logger   *zap.Logger
+
+	// migrator 在 Postgres 可以連線後確認 schema 版本，schemaVerified 為已確認版本相符且角色受 row level security 限制
+	migrator       *migrate.Migrator
+	schemaVerified atomic.Bool
+}
+
+// settings 為套用預設值後的 health 設定，設定檔更新時整組替換
+type settings struct {
+	saturation float64
+	timeout    time.Duration
+}
+
+func NewChecker(pool driver.PostgresPool, rdb *redis.Client, payment payment.Payment, cfg *config.Config, logger *zap.Logger) (*Checker, error) {
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := &Checker{
+		pool:     pool,
+		rdb:      rdb,
+		payment:  payment,
+		logger:   logger,
+		migrator: migrator,
+	}
+	hc.setConfig(cfg.Health)
+	cfg.OnReload(func(next *config.Config) {
+		hc.setConfig(next.Health)
+	})
+
+	return hc, nil
+}
+
+func (hc *Checker) setConfig(cfg config.HealthConfig) {
+	s := &settings{saturation: cfg.WorkerSaturation, timeout: cfg.Timeout}
+	if s.saturation <= 0 || s.saturation > 1 {
+		s.saturation = defaultWorkerSaturation
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultTimeout
+	}
+	hc.settings.Store(s)
+}
+
+// Liveness 只代表程序仍在執行，不檢查任何依賴，依賴中斷時不應重啟服務
+func (hc *Checker) Liveness() Report {
+	return Report{Status: StatusOK, Ready: true, Checks: map[string]Check{}}
+}
+
+// Readiness 同時檢查所有依賴，每項檢查各自受 Timeout 限制
+func (hc *Checker) Readiness(ctx context.Context) Report {
+	checks := map[string]func(context.Context) Check{
+		"postgres": hc.checkPostgres,
+		"redis":    hc.checkRedis,
+		"nats":     hc.checkNATS,
+		"workers":  hc.checkWorkers,
+	}
+
+	timeout := hc.settings.Load().timeout
+	report := Report{Status: StatusOK, Ready: true, Checks: make(map[string]Check, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			result := check(checkCtx)
+
+			mu.Lock()
+			report.Checks[name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for name, check := range report.Checks {
+		switch check.Status {
+		case StatusDown:
+			// Redis 無法連線時服務仍可運作，只降級
+			if name == "redis" {
+				if report.Status == StatusOK {
+					report.Status = StatusDegraded
+				}
+				continue
+			}
+			report.Status = StatusDown
+			report.Ready = false
+		case StatusDegraded:
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+
+	if !report.Ready {
+		hc.logger.Warn("readiness check failed", zap.Any("checks", report.Checks))
+	}
+
+	return report
+}
+
+// checkPostgres 在第一次連線成功時確認 schema 版本，版本不符時維持 down
+// 降級模式下 Postgres 無法連線時啟動不會檢查 schema 版本，需要在連上後補上
+func (hc *Checker) checkPostgres(ctx context.Context) Check {
+	start := time.Now()
+	err := hc.pool.Ping(ctx)
+	if err == nil && !hc.schemaVerified.Load() {
+		if err = hc.migrator.CheckVersion(ctx); err == nil {
+			err = driver.CheckRowLevelSecurity(ctx, hc.pool)
+		}
+		if err == nil {
+			hc.schemaVerified.Store(true)
+		}
+	}
+	return pingCheck(start, err)
+}
+
+func (hc *Checker) checkRedis(ctx context.Context) Check {
+	start := time.Now()
+	err := hc.rdb.Ping(ctx).Err()
+	return pingCheck(start, err)
+}
+
+// checkNATS 檢查 NATS 連線與事件訂閱，斷線時 NATS client 會持續重新連線，連上後自動恢復
+func (hc *Checker) checkNATS(_ context.Context) Check {
+	status := hc.payment.EventPipelineStatus()
+	check := Check{
+		Status: StatusOK,
+		Detail: map[string]any{
+			"state":      status.NATS,
+			"subscribed": status.Subscribed,
+		},
+	}
+
+	switch {
+	case !status.Connected:
+		check.Status = StatusDown
+		check.Error = "not connected to nats"
+	case !status.Subscribed:
+		check.Status = StatusDown
+		check.Error = "not subscribed to events"
+	}
+	return check
+}
+
+// checkWorkers 以佇列最滿的 shard 判斷 worker pool 是否飽和，飽和時事件會阻塞 NATS 的訂閱
+func (hc *Checker) checkWorkers(_ context.Context) Check {
+	stats := hc.payment.WorkerPoolStats()
+
+	var (
+		busy        int
+		utilization float64
+	)
+	for _, shard := range stats {
+		if shard.Busy {
+			busy++
+		}
+		if shard.Capacity > 0 {
+			utilization = max(utilization, float64(shard.Depth)/float64(shard.Capacity))
+		}
+	}
+
+	check := Check{
+		Status: StatusOK,
+		Detail: map[string]any{
+			"shards":            len(stats),
+			"busy":              busy,
+			"queue_utilization": utilization,
+		},
+	}
+	if utilization >= hc.settings.Load().saturation {
+		check.Status = StatusDown
+		check.Error = "worker pool is saturated"
+	}
+	return check
+}
+
+func pingCheck(start time.Time, err error) Check {
+	check := Check{Status: StatusOK, Latency: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Status = StatusDown
+		check.Error = err.Error()
+	}
+	return check
+}
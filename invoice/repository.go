@@ -13,6 +13,7 @@ import (
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -21,14 +22,16 @@ type Repository interface {
 	Create(ctx context.Context, tx pgx.Tx, invoice *models.Invoice) error
 	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Invoice, error)
 	Update(ctx context.Context, tx pgx.Tx, invoice *models.Invoice) error
-	List(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) ([]*models.Invoice, error)
+	List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Invoice], error)
 	Delete(ctx context.Context, tx pgx.Tx, id string) error
 	CreateInvoiceItem(ctx context.Context, tx pgx.Tx, item *models.InvoiceItem) error
 	GetInvoiceItemByID(ctx context.Context, tx pgx.Tx, id string) (*models.InvoiceItem, error)
 	UpdateInvoiceItem(ctx context.Context, tx pgx.Tx, item *models.InvoiceItem) error
 	DeleteInvoiceItem(ctx context.Context, tx pgx.Tx, id string) error
 	ListInvoiceItems(ctx context.Context, tx pgx.Tx, invoiceID string) ([]*models.InvoiceItem, error)
-	Upsert(ctx context.Context, tx pgx.Tx, invoice *models.PartialInvoice) error
+	Upsert(ctx context.Context, tx pgx.Tx, invoice *models.PartialInvoice) (bool, error)
+	UpsertBatch(ctx context.Context, tx pgx.Tx, invoices []*models.PartialInvoice) error
+	ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.Invoice, error)
 }
 
 type repository struct {
@@ -90,9 +93,9 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, invoice *models.Invo
 		SubscriptionID:  invoice.SubscriptionID,
 		Status:          sqlc.InvoiceStatus(invoice.Status),
 		Currency:        sqlc.Currency(invoice.Currency),
-		AmountDue:       invoice.AmountDue,
-		AmountPaid:      invoice.AmountPaid,
-		AmountRemaining: invoice.AmountRemaining,
+		AmountDue:       invoice.AmountDue.Amount,
+		AmountPaid:      invoice.AmountPaid.Amount,
+		AmountRemaining: invoice.AmountRemaining.Amount,
 		DueDate:         pgtype.Timestamptz{Time: invoice.DueDate, Valid: true},
 		PaidAt:          pgtype.Timestamptz{Time: invoice.PaidAt, Valid: !invoice.PaidAt.IsZero()},
 	}); err != nil {
@@ -100,7 +103,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, invoice *models.Invo
 	}
 
 	// 將新創建的發票加入緩存
-	cacheKey := fmt.Sprintf("invoice:%s", invoice.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice:%s", invoice.ID))
 	if err := r.cache.Set(ctx, cacheKey, invoice); err != nil {
 		r.logger.Warn("Failed to cache new invoice", zap.Error(err), zap.String("id", invoice.ID))
 	}
@@ -109,11 +112,12 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, invoice *models.Invo
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Invoice, error) {
-	cacheKey := fmt.Sprintf("invoice:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice:%s", id))
 
 	// 嘗試從緩存中獲取
 	var cachedInvoice models.Invoice
 	found, err := r.cache.Get(ctx, cacheKey, &cachedInvoice)
+	metrics.ObserveCache("invoice", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get invoice from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
@@ -121,7 +125,10 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	}
 
 	// 如果緩存中沒有，從數據庫獲取
-	sqlcInvoice, err := sqlc.New(r.conn).WithTx(tx).GetInvoice(ctx, id)
+	sqlcInvoice, err := sqlc.New(r.conn).WithTx(tx).GetInvoice(ctx, sqlc.GetInvoiceParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get invoice: %w", err)
 	}
@@ -147,16 +154,17 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, invoice *models.Invo
 	err := sqlc.New(r.conn).WithTx(tx).UpdateInvoice(ctx, sqlc.UpdateInvoiceParams{
 		ID:              invoice.ID,
 		Status:          sqlc.InvoiceStatus(invoice.Status),
-		AmountPaid:      invoice.AmountPaid,
-		AmountRemaining: invoice.AmountRemaining,
+		AmountPaid:      invoice.AmountPaid.Amount,
+		AmountRemaining: invoice.AmountRemaining.Amount,
 		PaidAt:          pgtype.Timestamptz{Time: invoice.PaidAt, Valid: !invoice.PaidAt.IsZero()},
+		TenantID:        driver.TenantID(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update invoice: %w", err)
 	}
 
 	// 更新緩存
-	cacheKey := fmt.Sprintf("invoice:%s", invoice.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice:%s", invoice.ID))
 	if err = r.cache.Set(ctx, cacheKey, invoice); err != nil {
 		r.logger.Warn("Failed to update invoice in cache", zap.Error(err), zap.String("id", invoice.ID))
 	}
@@ -164,47 +172,63 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, invoice *models.Invo
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) ([]*models.Invoice, error) {
-	sqlcInvoices, err := sqlc.New(r.conn).WithTx(tx).ListInvoicesByCustomerID(ctx, sqlc.ListInvoicesByCustomerIDParams{
-		CustomerID: customerID,
-		Limit:      int64(limit),
-		Offset:     int64(offset),
-	})
+// List 依 (created_at, id) 遞減以 keyset 分頁列出發票，多查詢一筆判斷是否還有下一頁
+func (r *repository) List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Invoice], error) {
+	limit := params.PageSize()
+	arg := sqlc.ListInvoicesParams{
+		CreatedFrom: pgtype.Timestamptz{Time: params.CreatedFrom, Valid: !params.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: params.CreatedTo, Valid: !params.CreatedTo.IsZero()},
+		Limit:       int64(limit + 1),
+		TenantID:    driver.TenantID(ctx),
+	}
+	if params.CustomerID != "" {
+		arg.CustomerID = &params.CustomerID
+	}
+	if params.Status != "" {
+		status := sqlc.InvoiceStatus(params.Status)
+		if !status.Valid() {
+			return nil, fmt.Errorf("%w: invoice status %q", models.ErrInvalidListFilter, params.Status)
+		}
+		arg.Status = sqlc.NullInvoiceStatus{InvoiceStatus: status, Valid: true}
+	}
+	if params.Currency != "" {
+		currency := sqlc.Currency(params.Currency)
+		if !currency.Valid() {
+			return nil, fmt.Errorf("%w: currency %q", models.ErrInvalidListFilter, params.Currency)
+		}
+		arg.Currency = sqlc.NullCurrency{Currency: currency, Valid: true}
+	}
+	if params.Cursor != nil {
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: params.Cursor.CreatedAt, Valid: true}
+		arg.CursorID = &params.Cursor.ID
+	}
+
+	sqlcInvoices, err := sqlc.New(r.conn).WithTx(tx).ListInvoices(ctx, arg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list invoices: %w", err)
 	}
 
 	invoices := make([]*models.Invoice, 0, len(sqlcInvoices))
 	for _, sqlcInvoice := range sqlcInvoices {
-		invoiceObj, release, err := r.getFromPool(ctx, reflect.TypeOf(&models.Invoice{}))
-		if err != nil {
-			return nil, err
-		}
-
-		invoice := invoiceObj.(*models.Invoice)
-		*invoice = *models.NewInvoice().ConvertFromSQLCInvoice(sqlcInvoice)
-		invoices = append(invoices, invoice)
-
-		// 更新每個發票的緩存
-		cacheKey := fmt.Sprintf("invoice:%s", invoice.ID)
-		if err = r.cache.Set(ctx, cacheKey, invoice); err != nil {
-			r.logger.Warn("Failed to cache invoice during list", zap.Error(err), zap.String("id", invoice.ID))
-		}
-
-		release()
+		invoices = append(invoices, models.NewInvoice().ConvertFromSQLCInvoice(sqlcInvoice))
 	}
 
-	return invoices, nil
+	return models.NewPage(invoices, limit, func(item *models.Invoice) models.Cursor {
+		return models.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+	}), nil
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	err := sqlc.New(r.conn).WithTx(tx).DeleteInvoice(ctx, id)
+	err := sqlc.New(r.conn).WithTx(tx).DeleteInvoice(ctx, sqlc.DeleteInvoiceParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete invoice: %w", err)
 	}
 
 	// 從緩存中刪除
-	cacheKey := fmt.Sprintf("invoice:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice:%s", id))
 	if err = r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete invoice from cache", zap.Error(err), zap.String("id", id))
 	}
@@ -215,7 +239,8 @@ func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
 func (r *repository) CreateInvoiceItem(ctx context.Context, tx pgx.Tx, item *models.InvoiceItem) error {
 	err := sqlc.New(r.conn).WithTx(tx).CreateInvoiceItem(ctx, sqlc.CreateInvoiceItemParams{
 		InvoiceID:   item.InvoiceID,
-		Amount:      item.Amount,
+		Amount:      item.Amount.Amount,
+		Currency:    sqlc.Currency(item.Amount.Currency),
 		Description: &item.Description,
 	})
 	if err != nil {
@@ -223,7 +248,7 @@ func (r *repository) CreateInvoiceItem(ctx context.Context, tx pgx.Tx, item *mod
 	}
 
 	// 將新創建的發票項目加入緩存
-	cacheKey := fmt.Sprintf("invoice_item:%s", item.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice_item:%s", item.ID))
 	if err = r.cache.Set(ctx, cacheKey, item); err != nil {
 		r.logger.Warn("Failed to cache new invoice item", zap.Error(err), zap.String("id", item.ID))
 	}
@@ -232,10 +257,11 @@ func (r *repository) CreateInvoiceItem(ctx context.Context, tx pgx.Tx, item *mod
 }
 
 func (r *repository) GetInvoiceItemByID(ctx context.Context, tx pgx.Tx, id string) (*models.InvoiceItem, error) {
-	cacheKey := fmt.Sprintf("invoice_item:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice_item:%s", id))
 	// 嘗試從緩存中獲取
 	var cachedItem models.InvoiceItem
 	found, err := r.cache.Get(ctx, cacheKey, &cachedItem)
+	metrics.ObserveCache("invoice_item", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get invoice item from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
@@ -243,7 +269,10 @@ func (r *repository) GetInvoiceItemByID(ctx context.Context, tx pgx.Tx, id strin
 	}
 
 	// 如果緩存中沒有，從數據庫獲取
-	sqlcItem, err := sqlc.New(r.conn).WithTx(tx).GetInvoiceItem(ctx, id)
+	sqlcItem, err := sqlc.New(r.conn).WithTx(tx).GetInvoiceItem(ctx, sqlc.GetInvoiceItemParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get invoice item: %w", err)
 	}
@@ -268,18 +297,22 @@ func (r *repository) GetInvoiceItemByID(ctx context.Context, tx pgx.Tx, id strin
 func (r *repository) UpdateInvoiceItem(ctx context.Context, tx pgx.Tx, item *models.InvoiceItem) error {
 	return sqlc.New(r.conn).WithTx(tx).UpdateInvoiceItem(ctx, sqlc.UpdateInvoiceItemParams{
 		ID:          item.ID,
-		Amount:      item.Amount,
+		Amount:      item.Amount.Amount,
 		Description: &item.Description,
+		TenantID:    driver.TenantID(ctx),
 	})
 }
 
 func (r *repository) DeleteInvoiceItem(ctx context.Context, tx pgx.Tx, id string) error {
 
-	if err := sqlc.New(r.conn).WithTx(tx).DeleteInvoiceItem(ctx, id); err != nil {
+	if err := sqlc.New(r.conn).WithTx(tx).DeleteInvoiceItem(ctx, sqlc.DeleteInvoiceItemParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	}); err != nil {
 		return fmt.Errorf("failed to delete invoice item: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("invoice_item:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice_item:%s", id))
 	if err := r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete invoice from cache", zap.Error(err), zap.String("id", id))
 	}
@@ -288,7 +321,10 @@ func (r *repository) DeleteInvoiceItem(ctx context.Context, tx pgx.Tx, id string
 }
 
 func (r *repository) ListInvoiceItems(ctx context.Context, tx pgx.Tx, invoiceID string) ([]*models.InvoiceItem, error) {
-	sqlcItems, err := sqlc.New(r.conn).WithTx(tx).ListInvoiceItems(ctx, invoiceID)
+	sqlcItems, err := sqlc.New(r.conn).WithTx(tx).ListInvoiceItems(ctx, sqlc.ListInvoiceItemsParams{
+		InvoiceID: invoiceID,
+		TenantID:  driver.TenantID(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list invoice items: %w", err)
 	}
@@ -298,7 +334,7 @@ func (r *repository) ListInvoiceItems(ctx context.Context, tx pgx.Tx, invoiceID
 		item := models.NewInvoiceItem().ConvertFromSQLCInvoiceItem(sqlcItem)
 		items = append(items, item)
 
-		cacheKey := fmt.Sprintf("invoice_item:%s", item.ID)
+		cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice_item:%s", item.ID))
 		if err = r.cache.Set(ctx, cacheKey, item); err != nil {
 			r.logger.Warn("Failed to cache invoice item during list", zap.Error(err), zap.String("id", item.ID))
 		}
@@ -307,11 +343,49 @@ func (r *repository) ListInvoiceItems(ctx context.Context, tx pgx.Tx, invoiceID
 	return items, nil
 }
 
-func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, invoice *models.PartialInvoice) error {
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, invoice *models.PartialInvoice) (bool, error) {
+	tag, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, invoice))
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert invoice: %w", err)
+	}
+
+	// 清除緩存，避免 GetByID 讀到 webhook 或對帳更新前的資料
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice:%s", invoice.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete invoice from cache", zap.Error(err), zap.String("id", invoice.ID))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, invoices []*models.PartialInvoice) error {
+	if len(invoices) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, invoice := range invoices {
+		batch.Queue(upsertQuery, upsertArgs(ctx, invoice))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert invoices: %w", err)
+	}
+
+	for _, invoice := range invoices {
+		cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("invoice:%s", invoice.ID))
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warn("Failed to delete invoice from cache", zap.Error(err), zap.String("id", invoice.ID))
+		}
+	}
+
+	return nil
+}
 
-	query := `
-    INSERT INTO invoices (id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at)
-    VALUES (@id, @customer_id, @subscription_id, @status, @currency, @amount_due, @amount_paid, @amount_remaining, @due_date, @paid_at, COALESCE(@created_at, NOW()), @updated_at)
+const upsertQuery = `
+    INSERT INTO invoices (id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at, last_event_at, deleted_at)
+    VALUES (@id, @customer_id, @subscription_id, @status, @currency, @amount_due, @amount_paid, @amount_remaining, @due_date, @paid_at, COALESCE(@created_at, NOW()), @updated_at, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, invoices.customer_id),
         subscription_id = COALESCE(@subscription_id, invoices.subscription_id),
@@ -322,12 +396,19 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, invoice *models.Part
         amount_remaining = COALESCE(@amount_remaining, invoices.amount_remaining),
         due_date = COALESCE(@due_date, invoices.due_date),
         paid_at = COALESCE(@paid_at, invoices.paid_at),
+        last_event_at = COALESCE(@last_event_at, invoices.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at
     WHERE invoices.id = @id
+      AND invoices.tenant_id = @tenant_id
+      AND invoices.deleted_at IS NULL
+      AND (invoices.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR invoices.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, invoice *models.PartialInvoice) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":               invoice.ID,
 		"customer_id":      invoice.CustomerID,
 		"subscription_id":  invoice.SubscriptionID,
@@ -340,11 +421,26 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, invoice *models.Part
 		"paid_at":          invoice.PaidAt,
 		"created_at":       invoice.CreatedAt,
 		"updated_at":       now,
+		"last_event_at":    invoice.LastEventAt,
+		"deleted_at":       invoice.DeletedAt,
+		"tenant_id":        driver.TenantID(ctx),
+	}
+}
+
+// ListByIDs 直接從資料庫讀取指定 ID 的發票，不經過緩存，供對帳比對使用
+func (r *repository) ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.Invoice, error) {
+	sqlcInvoices, err := sqlc.New(r.conn).WithTx(tx).ListInvoicesByIDs(ctx, sqlc.ListInvoicesByIDsParams{
+		Ids:      ids,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices by ids: %w", err)
 	}
 
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert invoice: %w", err)
+	invoices := make([]*models.Invoice, 0, len(sqlcInvoices))
+	for _, sqlcInvoice := range sqlcInvoices {
+		invoices = append(invoices, models.NewInvoice().ConvertFromSQLCInvoice(sqlcInvoice))
 	}
 
-	return nil
+	return invoices, nil
 }
@@ -17,14 +17,16 @@ type Service interface {
 	Create(ctx context.Context, invoice *models.Invoice) error
 	GetByID(ctx context.Context, id string) (*models.Invoice, error)
 	Update(ctx context.Context, invoice *models.Invoice) error
-	List(ctx context.Context, customerID string, limit, offset uint64) ([]*models.Invoice, error)
+	List(ctx context.Context, params models.ListParams) (*models.Page[*models.Invoice], error)
 	Delete(ctx context.Context, id string) error
-	PayInvoice(ctx context.Context, id string, amount float64) error
+	PayInvoice(ctx context.Context, id string, amount models.Money) error
 	CreateInvoiceItem(ctx context.Context, item *models.InvoiceItem) error
 	UpdateInvoiceItem(ctx context.Context, item *models.InvoiceItem) error
 	DeleteInvoiceItem(ctx context.Context, id string) error
 	ListInvoiceItems(ctx context.Context, invoiceID string) ([]*models.InvoiceItem, error)
-	Upsert(ctx context.Context, invoice *models.PartialInvoice) error
+	Upsert(ctx context.Context, invoice *models.PartialInvoice) (bool, error)
+	UpsertBatch(ctx context.Context, invoices []*models.PartialInvoice) error
+	ListByIDs(ctx context.Context, ids []string) ([]*models.Invoice, error)
 }
 
 type service struct {
@@ -99,17 +101,17 @@ func (s *service) Update(ctx context.Context, invoice *models.Invoice) error {
 	})
 }
 
-func (s *service) List(ctx context.Context, customerID string, limit, offset uint64) ([]*models.Invoice, error) {
-	var invoices []*models.Invoice
+func (s *service) List(ctx context.Context, params models.ListParams) (*models.Page[*models.Invoice], error) {
+	var page *models.Page[*models.Invoice]
 	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		var err error
-		invoices, err = s.repo.List(ctx, tx, customerID, limit, offset)
+		page, err = s.repo.List(ctx, tx, params)
 		if err != nil {
 			return fmt.Errorf("failed to list invoices: %w", err)
 		}
 		return nil
 	})
-	return invoices, err
+	return page, err
 }
 
 func (s *service) Delete(ctx context.Context, id string) error {
@@ -121,7 +123,7 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	})
 }
 
-func (s *service) PayInvoice(ctx context.Context, id string, amount float64) error {
+func (s *service) PayInvoice(ctx context.Context, id string, amount models.Money) error {
 	return s.transactionManager.ExecuteSerializableTransaction(ctx, func(tx pgx.Tx) error {
 		invoice, err := s.repo.GetByID(ctx, tx, id)
 		if err != nil {
@@ -132,15 +134,21 @@ func (s *service) PayInvoice(ctx context.Context, id string, amount float64) err
 			return fmt.Errorf("invoice is already paid")
 		}
 
-		if amount > invoice.AmountRemaining {
+		remaining, err := invoice.AmountRemaining.Sub(amount)
+		if err != nil {
+			return fmt.Errorf("failed to apply payment: %w", err)
+		}
+		if remaining.Amount < 0 {
 			return fmt.Errorf("payment amount exceeds remaining amount")
 		}
 
-		invoice.AmountPaid += amount
-		invoice.AmountRemaining -= amount
+		if invoice.AmountPaid, err = invoice.AmountPaid.Add(amount); err != nil {
+			return fmt.Errorf("failed to apply payment: %w", err)
+		}
+		invoice.AmountRemaining = remaining
 		invoice.PaidAt = time.Now()
 
-		if invoice.AmountRemaining == 0 {
+		if invoice.AmountRemaining.IsZero() {
 			invoice.Status = stripe.InvoiceStatusPaid
 		} else {
 			invoice.Status = stripe.InvoiceStatusUncollectible
@@ -166,13 +174,20 @@ func (s *service) CreateInvoiceItem(ctx context.Context, item *models.InvoiceIte
 			return fmt.Errorf("cannot add item to a paid invoice")
 		}
 
+		// 未指定幣別時沿用發票的幣別
+		if item.Amount.Currency == "" {
+			item.Amount.Currency = invoice.Currency
+		}
+
+		// 更新發票總額
+		if err = adjustInvoiceAmounts(invoice, item.Amount); err != nil {
+			return fmt.Errorf("failed to add invoice item amount: %w", err)
+		}
+
 		if err = s.repo.CreateInvoiceItem(ctx, tx, item); err != nil {
 			return fmt.Errorf("failed to create invoice item: %w", err)
 		}
 
-		// 更新發票總額
-		invoice.AmountDue += item.Amount
-		invoice.AmountRemaining += item.Amount
 		if err := s.repo.Update(ctx, tx, invoice); err != nil {
 			return fmt.Errorf("failed to update invoice after adding item: %w", err)
 		}
@@ -200,7 +215,13 @@ func (s *service) UpdateInvoiceItem(ctx context.Context, item *models.InvoiceIte
 		}
 
 		// 計算金額差異
-		amountDifference := item.Amount - originalItem.Amount
+		if item.Amount.Currency == "" {
+			item.Amount.Currency = originalItem.Amount.Currency
+		}
+		amountDifference, err := item.Amount.Sub(originalItem.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to calculate invoice item amount difference: %w", err)
+		}
 
 		// 更新發票項目
 		if err = s.repo.UpdateInvoiceItem(ctx, tx, item); err != nil {
@@ -208,8 +229,9 @@ func (s *service) UpdateInvoiceItem(ctx context.Context, item *models.InvoiceIte
 		}
 
 		// 更新發票總額
-		invoice.AmountDue += amountDifference
-		invoice.AmountRemaining += amountDifference
+		if err = adjustInvoiceAmounts(invoice, amountDifference); err != nil {
+			return fmt.Errorf("failed to update invoice item amount: %w", err)
+		}
 		if err = s.repo.Update(ctx, tx, invoice); err != nil {
 			return fmt.Errorf("failed to update invoice after updating item: %w", err)
 		}
@@ -242,8 +264,9 @@ func (s *service) DeleteInvoiceItem(ctx context.Context, id string) error {
 		}
 
 		// 更新發票總額
-		invoice.AmountDue -= item.Amount
-		invoice.AmountRemaining -= item.Amount
+		if err = adjustInvoiceAmounts(invoice, models.NewMoney(-item.Amount.Amount, item.Amount.Currency)); err != nil {
+			return fmt.Errorf("failed to remove invoice item amount: %w", err)
+		}
 		if err = s.repo.Update(ctx, tx, invoice); err != nil {
 			return fmt.Errorf("failed to update invoice after deleting item: %w", err)
 		}
@@ -265,8 +288,44 @@ func (s *service) ListInvoiceItems(ctx context.Context, invoiceID string) ([]*mo
 	return items, err
 }
 
-func (s *service) Upsert(ctx context.Context, invoice *models.PartialInvoice) error {
+func (s *service) Upsert(ctx context.Context, invoice *models.PartialInvoice) (bool, error) {
+	var applied bool
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		applied, err = s.repo.Upsert(ctx, tx, invoice)
+		return err
+	})
+	return applied, err
+}
+
+func (s *service) UpsertBatch(ctx context.Context, invoices []*models.PartialInvoice) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		return s.repo.Upsert(ctx, tx, invoice)
+		return s.repo.UpsertBatch(ctx, tx, invoices)
+	})
+}
+
+func (s *service) ListByIDs(ctx context.Context, ids []string) ([]*models.Invoice, error) {
+	var invoices []*models.Invoice
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		invoices, err = s.repo.ListByIDs(ctx, tx, ids)
+		return err
 	})
+	return invoices, err
+}
+
+// adjustInvoiceAmounts 將發票項目的金額變動同時套用到應付與未付金額
+func adjustInvoiceAmounts(invoice *models.Invoice, delta models.Money) error {
+	amountDue, err := invoice.AmountDue.Add(delta)
+	if err != nil {
+		return err
+	}
+	amountRemaining, err := invoice.AmountRemaining.Add(delta)
+	if err != nil {
+		return err
+	}
+
+	invoice.AmountDue = amountDue
+	invoice.AmountRemaining = amountRemaining
+	return nil
 }
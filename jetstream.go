@@ -0,0 +1,427 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+)
+
+const (
+	eventSubjectPrefix      = "stripe.event"
+	deadLetterSubjectPrefix = "stripe.dlq"
+
+	defaultStream           = "STRIPE_EVENTS"
+	defaultConsumer         = "payment-worker"
+	defaultDeadLetterStream = "STRIPE_EVENTS_DLQ"
+	defaultMaxDeliver       = 5
+	defaultAckWait          = 30 * time.Second
+
+	jetStreamSetupTimeout   = 10 * time.Second
+	jetStreamPublishTimeout = 5 * time.Second
+
+	// deadLetterFetchWait 為列出 dead-letter 時等待訊息的上限，stream 中符合條件的訊息少於 limit 時不會等到逾時
+	deadLetterFetchWait = 2 * time.Second
+)
+
+// dead-letter 訊息的 header，記錄原始事件與失敗原因
+const (
+	headerEventID         = "Stripe-Event-Id"
+	headerEventType       = "Stripe-Event-Type"
+	headerOriginalSubject = "Original-Subject"
+	headerFailureReason   = "Failure-Reason"
+	headerDeliveries      = "Deliveries"
+)
+
+var defaultBackoff = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+// ErrJetStreamDisabled 表示未啟用 JetStream，因此沒有 dead-letter stream 可供操作
+var ErrJetStreamDisabled = domainerr.New(domainerr.CodeNotImplemented, "jetstream is not enabled")
+
+// DeadLetter 代表一筆超過重試次數而移至 dead-letter stream 的事件
+type DeadLetter struct {
+	Sequence   uint64           `json:"sequence"`
+	TenantID   string           `json:"tenant_id"`
+	EventID    string           `json:"event_id"`
+	EventType  stripe.EventType `json:"event_type"`
+	Subject    string           `json:"subject"`
+	Reason     string           `json:"reason"`
+	Deliveries uint64           `json:"deliveries"`
+	FailedAt   time.Time        `json:"failed_at"`
+	Payload    json.RawMessage  `json:"payload"`
+}
+
+func withJetStreamDefaults(cfg config.JetStreamConfig) config.JetStreamConfig {
+	if cfg.Stream == "" {
+		cfg.Stream = defaultStream
+	}
+	if cfg.Consumer == "" {
+		cfg.Consumer = defaultConsumer
+	}
+	if cfg.DeadLetterStream == "" {
+		cfg.DeadLetterStream = defaultDeadLetterStream
+	}
+	if cfg.MaxDeliver <= 0 {
+		cfg.MaxDeliver = defaultMaxDeliver
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = defaultAckWait
+	}
+	if len(cfg.Backoff) == 0 {
+		cfg.Backoff = defaultBackoff
+	}
+	return cfg
+}
+
+// setupJetStream 建立事件 stream、dead-letter stream 與 durable consumer
+func (em *EventManager) setupJetStream(ctx context.Context) (jetstream.Consumer, error) {
+	if _, err := em.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     em.jsConfig.Stream,
+		Subjects: []string{eventSubjectPrefix + ".>"},
+		Storage:  jetstream.FileStorage,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create stream %s: %w", em.jsConfig.Stream, err)
+	}
+
+	if _, err := em.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     em.jsConfig.DeadLetterStream,
+		Subjects: []string{deadLetterSubjectPrefix + ".>"},
+		Storage:  jetstream.FileStorage,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter stream %s: %w", em.jsConfig.DeadLetterStream, err)
+	}
+
+	// MaxDeliver 由我們自行判斷並移至 dead-letter，因此 consumer 本身不限制投遞次數
+	consumer, err := em.js.CreateOrUpdateConsumer(ctx, em.jsConfig.Stream, jetstream.ConsumerConfig{
+		Durable:       em.jsConfig.Consumer,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       em.jsConfig.AckWait,
+		MaxDeliver:    -1,
+		FilterSubject: eventSubjectPrefix + ".>",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %s: %w", em.jsConfig.Consumer, err)
+	}
+
+	return consumer, nil
+}
+
+func (em *EventManager) consumeJetStream(wp *WorkerPool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), jetStreamSetupTimeout)
+	defer cancel()
+
+	consumer, err := em.setupJetStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		em.handleJetStreamMsg(wp, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume stream %s: %w", em.jsConfig.Stream, err)
+	}
+
+	em.mu.Lock()
+	em.consumeCtx = consumeCtx
+	em.mu.Unlock()
+	return nil
+}
+
+// handleJetStreamMsg 只有在 ProcessEvent 成功後才 ack，失敗時依 backoff 延遲重送
+func (em *EventManager) handleJetStreamMsg(wp *WorkerPool, msg jetstream.Msg) {
+	var event stripe.Event
+	if err := json.Unmarshal(msg.Data(), &event); err != nil {
+		// 無法解析的訊息重試也不會成功，直接移至 dead-letter
+		em.logger.Error("Failed to unmarshal event", zap.Error(err))
+		em.deadLetter(msg, nil, err)
+		return
+	}
+
+	// 等待 shard 佇列與處理期間持續回報 InProgress，排隊時間超過 AckWait 也不會被重複投遞
+	stopProgress := em.keepInProgress(msg, event.ID)
+
+	// 佇列已滿時在此阻塞，未 ack 的訊息達到 MaxAckPending 後 JetStream 會暫停投遞
+	ctx := extractTraceContext(tenantContext(context.Background(), msg.Headers()), msg.Headers())
+	if err := wp.SubmitWithCallback(ctx, &event, func(err error) {
+		stopProgress()
+		if err == nil {
+			if ackErr := msg.Ack(); ackErr != nil {
+				em.logger.Error("Failed to ack event", zap.Error(ackErr), zap.String("event_id", event.ID))
+			}
+			return
+		}
+
+		em.retryOrDeadLetter(msg, &event, err)
+	}); err != nil {
+		stopProgress()
+		em.logger.Error("Failed to submit event", zap.Error(err), zap.String("event_id", event.ID))
+		if nakErr := msg.Nak(); nakErr != nil {
+			em.logger.Error("Failed to nak event", zap.Error(nakErr), zap.String("event_id", event.ID))
+		}
+	}
+}
+
+// keepInProgress 每半個 AckWait 呼叫一次 msg.InProgress 重設 ack 計時，直到回傳的函式被呼叫
+func (em *EventManager) keepInProgress(msg jetstream.Msg, eventID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(em.jsConfig.AckWait / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := msg.InProgress(); err != nil {
+					em.logger.Warn("Failed to extend ack deadline", zap.Error(err), zap.String("event_id", eventID))
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+func (em *EventManager) retryOrDeadLetter(msg jetstream.Msg, event *stripe.Event, processErr error) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		em.logger.Error("Failed to read message metadata", zap.Error(err), zap.String("event_id", event.ID))
+		if nakErr := msg.Nak(); nakErr != nil {
+			em.logger.Error("Failed to nak event", zap.Error(nakErr), zap.String("event_id", event.ID))
+		}
+		return
+	}
+
+	if meta.NumDelivered >= uint64(em.jsConfig.MaxDeliver) {
+		em.deadLetter(msg, event, processErr)
+		return
+	}
+
+	delay := em.backoff(meta.NumDelivered)
+	em.logger.Warn("Event processing failed, scheduling redelivery",
+		zap.String("event_id", event.ID),
+		zap.Uint64("attempt", meta.NumDelivered),
+		zap.Duration("delay", delay),
+		zap.Error(processErr))
+
+	if err = msg.NakWithDelay(delay); err != nil {
+		em.logger.Error("Failed to nak event", zap.Error(err), zap.String("event_id", event.ID))
+	}
+}
+
+// backoff 回傳第 attempt 次失敗後的延遲，超過設定長度時沿用最後一個值
+func (em *EventManager) backoff(attempt uint64) time.Duration {
+	idx := int(attempt) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(em.jsConfig.Backoff) {
+		idx = len(em.jsConfig.Backoff) - 1
+	}
+	return em.jsConfig.Backoff[idx]
+}
+
+// deadLetter 將訊息複製到 dead-letter stream 後終止原訊息的投遞
+func (em *EventManager) deadLetter(msg jetstream.Msg, event *stripe.Event, processErr error) {
+	header := nats.Header{}
+	header.Set(headerOriginalSubject, msg.Subject())
+	header.Set(headerFailureReason, processErr.Error())
+	if meta, err := msg.Metadata(); err == nil {
+		header.Set(headerDeliveries, strconv.FormatUint(meta.NumDelivered, 10))
+	}
+	tenantID := msg.Headers().Get(headerTenantID)
+	if tenantID == "" {
+		tenantID = driver.DefaultTenantID
+	}
+	header.Set(headerTenantID, tenantID)
+	// 保留原本的 trace，replay 之前仍可以從 dead-letter 找回事件最初的 webhook 請求
+	injectTraceContext(extractTraceContext(context.Background(), msg.Headers()), header)
+	if event != nil {
+		header.Set(headerEventID, event.ID)
+		header.Set(headerEventType, string(event.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jetStreamPublishTimeout)
+	defer cancel()
+
+	subject := deadLetterSubject(tenantID) + strings.TrimPrefix(msg.Subject(), eventSubjectPrefix)
+	if _, err := em.js.PublishMsg(ctx, &nats.Msg{
+		Subject: subject,
+		Header:  header,
+		Data:    msg.Data(),
+	}); err != nil {
+		// 發佈失敗時保留原訊息，讓它稍後再次投遞
+		em.logger.Error("Failed to publish event to dead-letter stream", zap.Error(err), zap.String("subject", msg.Subject()))
+		if nakErr := msg.Nak(); nakErr != nil {
+			em.logger.Error("Failed to nak event", zap.Error(nakErr))
+		}
+		return
+	}
+
+	if err := msg.Term(); err != nil {
+		em.logger.Error("Failed to terminate dead-lettered event", zap.Error(err))
+	}
+
+	em.logger.Error("Event moved to dead-letter stream",
+		zap.String("subject", msg.Subject()),
+		zap.String("event_id", header.Get(headerEventID)),
+		zap.Error(processErr))
+}
+
+// ListDeadLetters 依序列號由舊到新列出 dead-letter stream 中屬於 ctx 租戶的事件
+// 以只訂閱該租戶 subject 的 ordered consumer 讀取，不需要掃描其他租戶的訊息
+func (em *EventManager) ListDeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	if em.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+
+	tenantID, ok := driver.TenantFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: no tenant in context", ErrUnknownTenant)
+	}
+
+	consumer, err := em.js.OrderedConsumer(ctx, em.jsConfig.DeadLetterStream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{deadLetterSubject(tenantID) + ".>"},
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter consumer: %w", err)
+	}
+
+	// 剩餘的訊息數少於 limit 時只取到目前的最後一筆，不等待新的 dead-letter
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead-letter consumer info: %w", err)
+	}
+	if pending := int(info.NumPending); pending < limit {
+		limit = pending
+	}
+
+	deadLetters := make([]*DeadLetter, 0, limit)
+	if limit == 0 {
+		return deadLetters, nil
+	}
+
+	batch, err := consumer.Fetch(limit, jetstream.FetchMaxWait(deadLetterFetchWait))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dead-letter messages: %w", err)
+	}
+	for msg := range batch.Messages() {
+		meta, err := msg.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead-letter message metadata: %w", err)
+		}
+		deadLetters = append(deadLetters, toDeadLetter(&jetstream.RawStreamMsg{
+			Subject:  msg.Subject(),
+			Sequence: meta.Sequence.Stream,
+			Header:   msg.Headers(),
+			Data:     msg.Data(),
+			Time:     meta.Timestamp,
+		}))
+	}
+	if err = batch.Error(); err != nil {
+		return nil, fmt.Errorf("failed to fetch dead-letter messages: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// ReplayDeadLetter 將 dead-letter 事件重新發佈至原始 subject，成功後自 dead-letter stream 移除
+// 只能重新發佈屬於 ctx 租戶的事件，其他租戶的事件視為不存在
+func (em *EventManager) ReplayDeadLetter(ctx context.Context, sequence uint64) error {
+	if em.js == nil {
+		return ErrJetStreamDisabled
+	}
+
+	tenantID, ok := driver.TenantFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no tenant in context", ErrUnknownTenant)
+	}
+
+	stream, err := em.js.Stream(ctx, em.jsConfig.DeadLetterStream)
+	if err != nil {
+		return fmt.Errorf("failed to get dead-letter stream: %w", err)
+	}
+
+	raw, err := stream.GetMsg(ctx, sequence)
+	if err != nil {
+		return fmt.Errorf("failed to get dead-letter message %d: %w", sequence, err)
+	}
+
+	deadLetter := toDeadLetter(raw)
+	if deadLetter.TenantID != tenantID {
+		return fmt.Errorf("failed to get dead-letter message %d: %w", sequence, jetstream.ErrMsgNotFound)
+	}
+
+	header := nats.Header{}
+	header.Set(headerTenantID, deadLetter.TenantID)
+	// 重新處理的事件接在 replay 請求的 trace 之下
+	injectTraceContext(ctx, header)
+
+	// 使用不同於原始事件的 msg ID，避免在去重視窗內被丟棄，同時防止重複 replay
+	msgID := fmt.Sprintf("%s:replay:%d", deadLetter.EventID, sequence)
+	if _, err = em.js.PublishMsg(ctx, &nats.Msg{
+		Subject: deadLetter.Subject,
+		Header:  header,
+		Data:    raw.Data,
+	}, jetstream.WithMsgID(msgID)); err != nil {
+		return fmt.Errorf("failed to republish event: %w", err)
+	}
+
+	if err = stream.DeleteMsg(ctx, sequence); err != nil {
+		return fmt.Errorf("failed to delete dead-letter message %d: %w", sequence, err)
+	}
+
+	em.logger.Info("Replayed dead-letter event",
+		zap.Uint64("sequence", sequence),
+		zap.String("event_id", deadLetter.EventID))
+
+	return nil
+}
+
+// deadLetterSubject 回傳租戶的 dead-letter subject 前綴，例如 stripe.dlq.default；其後接原始事件的類型
+func deadLetterSubject(tenantID string) string {
+	return deadLetterSubjectPrefix + "." + tenantID
+}
+
+func toDeadLetter(raw *jetstream.RawStreamMsg) *DeadLetter {
+	tenantID := raw.Header.Get(headerTenantID)
+	if tenantID == "" {
+		tenantID = driver.DefaultTenantID
+	}
+
+	subject := raw.Header.Get(headerOriginalSubject)
+	if subject == "" {
+		subject = eventSubjectPrefix + strings.TrimPrefix(raw.Subject, deadLetterSubject(tenantID))
+	}
+
+	deliveries, _ := strconv.ParseUint(raw.Header.Get(headerDeliveries), 10, 64)
+
+	return &DeadLetter{
+		Sequence:   raw.Sequence,
+		TenantID:   tenantID,
+		EventID:    raw.Header.Get(headerEventID),
+		EventType:  stripe.EventType(raw.Header.Get(headerEventType)),
+		Subject:    subject,
+		Reason:     raw.Header.Get(headerFailureReason),
+		Deliveries: deliveries,
+		FailedAt:   raw.Time,
+		Payload:    raw.Data,
+	}
+}
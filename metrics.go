@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/metrics"
+)
+
+// meteredTransport 記錄每個 Stripe HTTP 請求的延遲與錯誤，endpoint 為不含物件 ID 的資源路徑
+type meteredTransport struct {
+	base http.RoundTripper
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	metrics.ObserveStripeRequest(req.Method, stripeResource(req.URL.Path), status, err, time.Since(start))
+	return resp, err
+}
+
+// observePaymentIntent 依支付意圖事件累計成功、失敗與取消的筆數與金額，其他事件不記錄
+// 事件處理失敗後重試成功時只會累計一次，但寫入資料庫成功後標記事件失敗而重試時會重複累計
+func observePaymentIntent(eventType stripe.EventType, paymentIntent *stripe.PaymentIntent) {
+	var status string
+	switch eventType {
+	case stripe.EventTypePaymentIntentSucceeded:
+		status = "succeeded"
+	case stripe.EventTypePaymentIntentPaymentFailed:
+		status = "failed"
+	case stripe.EventTypePaymentIntentCanceled:
+		status = "canceled"
+	default:
+		return
+	}
+
+	currency := string(paymentIntent.Currency)
+	metrics.PaymentIntents.WithLabelValues(status, currency).Inc()
+	metrics.PaymentIntentAmount.WithLabelValues(status, currency).Add(float64(paymentIntent.Amount))
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "payment"
+
+// Registry 為本服務所有指標的 registry，不使用 prometheus 的全域 registry，避免依賴套件註冊的指標混入
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler 回傳輸出 Registry 中所有指標的 HTTP handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+var (
+	// WebhooksReceived 為通過簽章驗證的 webhook 數量，依租戶與事件類型區分
+	WebhooksReceived = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_received_total",
+		Help:      "Stripe webhooks that passed signature verification, by event type.",
+	}, []string{"tenant", "event_type"})
+
+	// WebhookVerificationFailures 為簽章驗證失敗的 webhook 數量；簽章未通過前 payload 不可信，不以事件類型區分
+	WebhookVerificationFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_verification_failures_total",
+		Help:      "Stripe webhooks rejected because of an invalid signature.",
+	}, []string{"tenant"})
+
+	// EventProcessingDuration 為 ProcessEvent 處理一個事件的時間，依事件類型（即 handler）與結果區分
+	EventProcessingDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_processing_duration_seconds",
+		Help:      "Time spent processing a Stripe event, by event type and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type", "result"})
+
+	// EventProcessingErrors 為處理失敗的事件數量，依事件類型區分
+	EventProcessingErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_processing_errors_total",
+		Help:      "Stripe events whose handler returned an error, by event type.",
+	}, []string{"event_type"})
+
+	// WorkerQueueDepth 為每個 shard 佇列中等待處理的事件數量
+	WorkerQueueDepth = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_queue_depth",
+		Help:      "Events waiting in each worker pool shard.",
+	}, []string{"shard"})
+
+	// WorkersBusy 為正在處理事件的 worker 數量
+	WorkersBusy = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workers_busy",
+		Help:      "Worker pool shards currently processing an event.",
+	})
+
+	// StripeRequestDuration 為 Stripe API 每個 HTTP 請求的時間，stripe-go 重試時每次請求各自記錄
+	StripeRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stripe_request_duration_seconds",
+		Help:      "Latency of Stripe API requests, by method and endpoint.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "endpoint"})
+
+	// StripeRequestErrors 為失敗的 Stripe API 請求，code 為 HTTP 狀態碼，連線失敗或逾時為 error
+	StripeRequestErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stripe_request_errors_total",
+		Help:      "Stripe API requests that failed, by method, endpoint and status code.",
+	}, []string{"method", "endpoint", "code"})
+
+	// TransactionRetries 為 ExecuteTransactionWithRetry 重試交易的次數
+	TransactionRetries = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_transaction_retries_total",
+		Help:      "Database transactions retried after a failure.",
+	})
+
+	// TransactionRollbacks 為回滾的交易數量，reason 為 error 或 panic
+	TransactionRollbacks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_transaction_rollbacks_total",
+		Help:      "Database transactions rolled back, by reason.",
+	}, []string{"reason"})
+
+	// CacheRequests 為 ember 快取的讀取結果，命中率為 hit 佔全部的比例
+	CacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups, by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+
+	// PaymentIntents 為成功、失敗與取消的支付意圖數量，依幣別區分
+	PaymentIntents = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_intents_total",
+		Help:      "Payment intents that succeeded, failed or were canceled, by currency.",
+	}, []string{"status", "currency"})
+
+	// PaymentIntentAmount 為成功、失敗與取消的支付意圖金額，單位為幣別的最小單位（例如 usd 的 cent）
+	PaymentIntentAmount = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_intent_amount_total",
+		Help:      "Amount of payment intents that succeeded, failed or were canceled, in the currency's minor unit.",
+	}, []string{"status", "currency"})
+)
+
+// ObserveCache 記錄一次快取讀取的結果
+func ObserveCache(cache string, found bool, err error) {
+	result := "miss"
+	switch {
+	case err != nil:
+		result = "error"
+	case found:
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveStripeRequest 記錄一次 Stripe API 請求，err 不為 nil 時代表請求沒有收到回應
+func ObserveStripeRequest(method, endpoint string, status int, err error, elapsed time.Duration) {
+	StripeRequestDuration.WithLabelValues(method, endpoint).Observe(elapsed.Seconds())
+	switch {
+	case err != nil:
+		StripeRequestErrors.WithLabelValues(method, endpoint, "error").Inc()
+	case status >= http.StatusBadRequest:
+		StripeRequestErrors.WithLabelValues(method, endpoint, strconv.Itoa(status)).Inc()
+	}
+}
@@ -0,0 +1,273 @@
+// Package migrate 執行嵌入執行檔的資料庫 migration，並檢查資料庫的 schema 版本是否與執行檔相符
+// 版本記錄在與 golang-migrate 相同的 schema_migrations 資料表，以 migrate CLI 執行過的資料庫可以直接沿用
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"goflare.io/payment/driver"
+)
+
+const (
+	// versionTable 只有一列，記錄最後一個執行的版本；資料表為空時代表沒有執行過任何 migration
+	versionTable = "schema_migrations"
+
+	// lockID 為執行 migration 期間持有的 advisory lock，避免多個程序同時執行 migration
+	lockID int64 = 202409100001
+)
+
+var (
+	// ErrDirty 表示上一次以 migrate CLI 執行的 migration 中途失敗，需要人工修復 schema 後再更新 schema_migrations
+	ErrDirty = errors.New("database schema is dirty")
+
+	// ErrVersionMismatch 表示資料庫的 schema 版本與執行檔預期的版本不同
+	ErrVersionMismatch = errors.New("database schema version mismatch")
+)
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Migrator 依版本順序執行 migration
+// 每個 migration 與版本紀錄在同一個交易內執行，失敗時整個 migration 回滾，不會留下 dirty 的版本；因此 migration 不能使用 CREATE INDEX CONCURRENTLY 等無法在交易內執行的語法
+type Migrator struct {
+	pool       driver.PostgresPool
+	migrations []Migration
+}
+
+// New 讀取 fsys 中的 migration，通常為 migrations.FS
+func New(pool driver.PostgresPool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		pool:       pool,
+		migrations: migrations,
+	}, nil
+}
+
+// Latest 回傳執行檔預期的 schema 版本，也就是最後一個 migration 的版本
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 回傳資料庫目前的 schema 版本，沒有執行過任何 migration 時為 0
+func (m *Migrator) Version(ctx context.Context) (version uint64, dirty bool, err error) {
+	return readVersion(ctx, m.pool)
+}
+
+// Status 為資料庫目前的 schema 版本與每個 migration 是否已執行
+type Status struct {
+	Version uint64
+	Dirty   bool
+	Latest  uint64
+	Applied []Migration
+	Pending []Migration
+}
+
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Version: version, Dirty: dirty, Latest: m.Latest()}
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// CheckVersion 在啟動時確認資料庫的 schema 版本與執行檔預期的版本相同
+// 版本較舊時應先執行 paymentctl migrate up；版本較新代表資料庫已由較新的版本 migrate，舊的執行檔不應繼續寫入
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	latest := m.Latest()
+	switch {
+	case dirty:
+		return fmt.Errorf("%w at version %d", ErrDirty, version)
+	case version < latest:
+		return fmt.Errorf("%w: database is at version %d but %d is expected, run paymentctl migrate up", ErrVersionMismatch, version, latest)
+	case version > latest:
+		return fmt.Errorf("%w: database is at version %d which is newer than %d expected by this binary", ErrVersionMismatch, version, latest)
+	}
+	return nil
+}
+
+// Up 依序執行尚未執行的 migration，steps 大於 0 時最多執行 steps 個，回傳已執行的 migration
+// 其中一個失敗時停止，之前已執行的 migration 不會回滾
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+
+			if err = apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down 由目前的版本往回執行 steps 個 migration 的 down，steps 小於等於 0 時回滾全部，回傳已回滾的 migration
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if steps > 0 && len(reverted) == steps {
+				break
+			}
+
+			var previous uint64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err = apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s down failed: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// withLock 在持有 advisory lock 的單一連線上執行 fn，必要時建立 schema_migrations
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+	}()
+
+	if _, err = conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+versionTable+" (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		return fmt.Errorf("failed to create %s: %w", versionTable, err)
+	}
+
+	return fn(conn)
+}
+
+// current 回傳資料庫目前的版本，dirty 或不在執行檔中的版本都無法繼續執行 migration
+func (m *Migrator) current(ctx context.Context, conn *pgxpool.Conn) (uint64, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d, fix the schema manually and update %s before migrating", ErrDirty, version, versionTable)
+	}
+	if version == 0 {
+		return 0, nil
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return version, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: database is at version %d which this binary does not know", ErrVersionMismatch, version)
+}
+
+func readVersion(ctx context.Context, q queryRower) (uint64, bool, error) {
+	var exists bool
+	if err := q.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", versionTable).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := q.QueryRow(ctx, "SELECT version, dirty FROM "+versionTable+" LIMIT 1").Scan(&version, &dirty)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	// golang-migrate 在第一個 migration 就失敗時記錄為 -1
+	if version < 0 {
+		version = 0
+	}
+	return uint64(version), dirty, nil
+}
+
+// apply 在交易內執行 migration 並把版本更新為 version，version 為 0 時清空版本紀錄
+// 沒有參數的 Exec 使用 simple protocol，一次可以執行檔案中的多個語句
+func apply(ctx context.Context, conn *pgxpool.Conn, sql string, version uint64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, "TRUNCATE "+versionTable); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err = tx.Exec(ctx, "INSERT INTO "+versionTable+" (version, dirty) VALUES ($1, false)", int64(version)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
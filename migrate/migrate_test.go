@@ -0,0 +1,159 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"goflare.io/payment/migrate"
+	"goflare.io/payment/migrations"
+	"goflare.io/payment/pgtest"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	m, err := migrate.New(nil, migrations.FS)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if m.Latest() == 0 {
+		t.Fatal("no migrations embedded")
+	}
+}
+
+func TestLoadRejectsInvalidMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{"1_init.up.sql": {Data: []byte("SELECT 1")}},
+		},
+		{
+			name: "version used twice",
+			fsys: fstest.MapFS{
+				"1_init.up.sql":    {Data: []byte("SELECT 1")},
+				"1_init.down.sql":  {Data: []byte("SELECT 1")},
+				"1_other.up.sql":   {Data: []byte("SELECT 1")},
+				"1_other.down.sql": {Data: []byte("SELECT 1")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := migrate.New(nil, tt.fsys); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestLatestIsHighestVersion(t *testing.T) {
+	m, err := migrate.New(nil, fstest.MapFS{
+		"10_second.up.sql":   {Data: []byte("SELECT 1")},
+		"10_second.down.sql": {Data: []byte("SELECT 1")},
+		"9_first.up.sql":     {Data: []byte("SELECT 1")},
+		"9_first.down.sql":   {Data: []byte("SELECT 1")},
+		"README.md":          {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if m.Latest() != 10 {
+		t.Fatalf("Latest = %d, want 10", m.Latest())
+	}
+}
+
+// TestUpDown 在空的資料庫逐一執行每個 migration 的 up，再全部 down 回到空的 schema，最後再次 up 確認 down 沒有留下物件
+func TestUpDown(t *testing.T) {
+	pool := pgtest.Connect(t)
+	ctx := context.Background()
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	assertVersion(t, m, 0)
+	if err = m.CheckVersion(ctx); !errors.Is(err, migrate.ErrVersionMismatch) {
+		t.Fatalf("CheckVersion on empty database = %v, want ErrVersionMismatch", err)
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	total := len(status.Pending)
+
+	for _, migration := range status.Pending {
+		applied, err := m.Up(ctx, 1)
+		if err != nil {
+			t.Fatalf("Up: %v", err)
+		}
+		if len(applied) != 1 || applied[0].Version != migration.Version {
+			t.Fatalf("Up(1) applied %v, want %d", applied, migration.Version)
+		}
+		assertVersion(t, m, migration.Version)
+	}
+	if err = m.CheckVersion(ctx); err != nil {
+		t.Fatalf("CheckVersion after up: %v", err)
+	}
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != 0 {
+		t.Fatalf("Up at latest = %v, %v", applied, err)
+	}
+
+	reverted, err := m.Down(ctx, 0)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != total {
+		t.Fatalf("Down reverted %d migrations, want %d", len(reverted), total)
+	}
+	assertVersion(t, m, 0)
+
+	// down 之後只剩 schema_migrations，資料表、型別與函式都應已刪除
+	var leftovers []string
+	rows, err := pool.Query(ctx, `
+		SELECT c.relname FROM pg_class c
+		WHERE c.relnamespace = current_schema()::regnamespace AND c.relkind IN ('r', 'v', 'm', 'S') AND c.relname <> 'schema_migrations'
+		UNION ALL
+		SELECT t.typname FROM pg_type t
+		WHERE t.typnamespace = current_schema()::regnamespace AND t.typtype IN ('e', 'd', 'c')
+		  AND NOT EXISTS (SELECT 1 FROM pg_class c WHERE c.oid = t.typrelid)
+		UNION ALL
+		SELECT p.proname FROM pg_proc p WHERE p.pronamespace = current_schema()::regnamespace`)
+	if err != nil {
+		t.Fatalf("failed to list schema objects: %v", err)
+	}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			t.Fatalf("failed to scan schema object: %v", err)
+		}
+		leftovers = append(leftovers, name)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to list schema objects: %v", err)
+	}
+	if len(leftovers) > 0 {
+		t.Fatalf("objects left after down: %v", leftovers)
+	}
+
+	if applied, err := m.Up(ctx, 0); err != nil || len(applied) != total {
+		t.Fatalf("Up after down applied %d migrations (%v), want %d", len(applied), err, total)
+	}
+	assertVersion(t, m, m.Latest())
+}
+
+func assertVersion(t *testing.T, m *migrate.Migrator, want uint64) {
+	t.Helper()
+
+	version, dirty, err := m.Version(context.Background())
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if version != want || dirty {
+		t.Fatalf("version = %d (dirty %t), want %d", version, dirty, want)
+	}
+}
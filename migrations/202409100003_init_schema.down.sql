@@ -42,3 +42,5 @@ DROP TABLE IF EXISTS quotes CASCADE;
 DROP TABLE IF EXISTS payment_links CASCADE;
 DROP TABLE IF EXISTS tax_rates CASCADE;
 DROP TABLE IF EXISTS reviews CASCADE;
+
+-- users 可能由認證服務建立並持有其他資料，不隨 migration 刪除
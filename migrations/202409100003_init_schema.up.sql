@@ -1,3 +1,12 @@
+-- users 原本由認證服務在同一個資料庫建立，這裡只建立 customers 參照與查詢的欄位，讓 schema 可以在空的資料庫上建立
+-- 已存在時沿用原本的資料表
+CREATE TABLE IF NOT EXISTS users (
+    email VARCHAR(255) PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE customers (
                            id VARCHAR(255) PRIMARY KEY CHECK (id ~ '^[a-z]+_[a-zA-Z0-9]+$'),
                            user_email VARCHAR(255) NOT NULL REFERENCES users(email) UNIQUE,
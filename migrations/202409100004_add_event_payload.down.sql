@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_events_object_id;
+DROP INDEX IF EXISTS idx_events_stripe_created_at;
+DROP INDEX IF EXISTS idx_events_type_stripe_created_at;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS object_id,
+    DROP COLUMN IF EXISTS stripe_created_at,
+    DROP COLUMN IF EXISTS api_version,
+    DROP COLUMN IF EXISTS payload;
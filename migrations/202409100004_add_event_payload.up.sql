@@ -0,0 +1,9 @@
+ALTER TABLE events
+    ADD COLUMN payload JSONB,
+    ADD COLUMN api_version VARCHAR(50),
+    ADD COLUMN stripe_created_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN object_id VARCHAR(255);
+
+CREATE INDEX idx_events_type_stripe_created_at ON events(type, stripe_created_at);
+CREATE INDEX idx_events_stripe_created_at ON events(stripe_created_at);
+CREATE INDEX idx_events_object_id ON events(object_id);
@@ -0,0 +1,18 @@
+ALTER TABLE reviews DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE tax_rates DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE payment_links DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE quotes DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE checkout_sessions DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE promotion_codes DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE discounts DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE coupons DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE payment_methods DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE prices DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE products DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE disputes DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE refunds DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE charges DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS last_event_at;
@@ -0,0 +1,19 @@
+-- last_event_at 記錄最後一次寫入該列的 Stripe 事件建立時間，Upsert 會略過比它更舊的事件
+ALTER TABLE customers ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE subscriptions ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE invoices ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payment_intents ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE charges ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE refunds ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE disputes ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE prices ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payment_methods ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE coupons ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE discounts ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE promotion_codes ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE checkout_sessions ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE quotes ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payment_links ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tax_rates ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE reviews ADD COLUMN last_event_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_events_failed;
+DROP INDEX IF EXISTS idx_events_next_attempt_at;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS failed,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,8 @@
+ALTER TABLE events
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN failed BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_events_next_attempt_at ON events(next_attempt_at) WHERE processed = FALSE AND failed = FALSE;
+CREATE INDEX idx_events_failed ON events(failed) WHERE failed = TRUE;
@@ -0,0 +1,12 @@
+CREATE TABLE outbox_messages (
+    id VARCHAR(255) PRIMARY KEY,
+    subject VARCHAR(255) NOT NULL,
+    schema_version INT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages(created_at, id) WHERE published_at IS NULL;
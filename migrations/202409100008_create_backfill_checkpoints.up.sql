@@ -0,0 +1,7 @@
+CREATE TABLE backfill_checkpoints (
+    resource VARCHAR(64) PRIMARY KEY,
+    last_id VARCHAR(255),
+    imported BIGINT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
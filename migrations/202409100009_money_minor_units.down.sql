@@ -0,0 +1,19 @@
+ALTER TABLE refunds DROP COLUMN currency;
+ALTER TABLE invoice_items DROP COLUMN currency;
+
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE DECIMAL(10, 2) USING amount::DECIMAL(10, 2);
+
+ALTER TABLE payment_intents
+    ALTER COLUMN amount TYPE DECIMAL(10, 2) USING (amount / 100.0)::DECIMAL(10, 2);
+
+ALTER TABLE invoice_items
+    ALTER COLUMN amount TYPE DECIMAL(10, 2) USING (amount / 100.0)::DECIMAL(10, 2);
+
+ALTER TABLE invoices
+    ALTER COLUMN amount_due TYPE DECIMAL(10, 2) USING (amount_due / 100.0)::DECIMAL(10, 2),
+    ALTER COLUMN amount_paid TYPE DECIMAL(10, 2) USING (amount_paid / 100.0)::DECIMAL(10, 2),
+    ALTER COLUMN amount_remaining TYPE DECIMAL(10, 2) USING (amount_remaining / 100.0)::DECIMAL(10, 2);
+
+ALTER TABLE prices
+    ALTER COLUMN unit_amount TYPE DECIMAL(10, 2) USING (unit_amount / 100.0)::DECIMAL(10, 2);
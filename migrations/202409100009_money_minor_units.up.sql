@@ -0,0 +1,51 @@
+-- 金額一律改以最小貨幣單位（BIGINT）保存，與 Stripe API 及 models.Money 相同
+-- 價格、發票與支付意圖先前以 DECIMAL(10, 2) 保存 Stripe 金額除以 100 的結果，乘以 100 後四捨五入即可還原 Stripe 的原始金額
+-- 退款則直接寫入 Stripe 的最小貨幣單位，只需要轉換型別
+
+ALTER TABLE prices
+    ALTER COLUMN unit_amount TYPE BIGINT USING ROUND(unit_amount * 100)::BIGINT;
+
+ALTER TABLE invoices
+    ALTER COLUMN amount_due TYPE BIGINT USING ROUND(amount_due * 100)::BIGINT,
+    ALTER COLUMN amount_paid TYPE BIGINT USING ROUND(amount_paid * 100)::BIGINT,
+    ALTER COLUMN amount_remaining TYPE BIGINT USING ROUND(amount_remaining * 100)::BIGINT;
+
+ALTER TABLE invoice_items
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+ALTER TABLE payment_intents
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount)::BIGINT;
+
+-- 退款與發票項目沒有自己的幣別，分別沿用扣款與發票的幣別
+-- 對應不到時退回資料庫設定的 payment.default_currency（例如 ALTER DATABASE payment SET payment.default_currency = 'usd'），仍無法決定時在 SET NOT NULL 之前中止並列出筆數
+ALTER TABLE invoice_items ADD COLUMN currency currency;
+UPDATE invoice_items SET currency = COALESCE(
+    (SELECT invoices.currency FROM invoices WHERE invoices.id = invoice_items.invoice_id),
+    NULLIF(current_setting('payment.default_currency', true), '')::currency
+);
+
+ALTER TABLE refunds ADD COLUMN currency currency;
+UPDATE refunds SET currency = COALESCE(
+    (SELECT charges.currency FROM charges WHERE charges.id = refunds.charge_id),
+    NULLIF(current_setting('payment.default_currency', true), '')::currency
+);
+
+DO $$
+DECLARE
+    missing_items BIGINT;
+    missing_refunds BIGINT;
+BEGIN
+    SELECT count(*) INTO missing_items FROM invoice_items WHERE currency IS NULL;
+    SELECT count(*) INTO missing_refunds FROM refunds WHERE currency IS NULL;
+    IF missing_items > 0 OR missing_refunds > 0 THEN
+        RAISE EXCEPTION 'cannot determine currency for % invoice_items and % refunds', missing_items, missing_refunds
+            USING HINT = 'set payment.default_currency on the database, or fix the orphaned rows, then rerun the migration';
+    END IF;
+END
+$$;
+
+ALTER TABLE invoice_items ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE refunds ALTER COLUMN currency SET NOT NULL;
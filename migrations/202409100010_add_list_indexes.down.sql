@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_refunds_charge_id_created_at_id;
+DROP INDEX IF EXISTS idx_refunds_created_at_id;
+DROP INDEX IF EXISTS idx_payment_intents_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_payment_intents_created_at_id;
+DROP INDEX IF EXISTS idx_payment_methods_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_created_at_id;
+DROP INDEX IF EXISTS idx_products_created_at_id;
@@ -0,0 +1,11 @@
+-- 列表以 (created_at, id) 遞減做 keyset 分頁，依客戶或扣款篩選時使用對應的複合索引
+CREATE INDEX idx_products_created_at_id ON products(created_at DESC, id DESC);
+CREATE INDEX idx_subscriptions_created_at_id ON subscriptions(created_at DESC, id DESC);
+CREATE INDEX idx_subscriptions_customer_id_created_at_id ON subscriptions(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_invoices_created_at_id ON invoices(created_at DESC, id DESC);
+CREATE INDEX idx_invoices_customer_id_created_at_id ON invoices(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_methods_customer_id_created_at_id ON payment_methods(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_intents_created_at_id ON payment_intents(created_at DESC, id DESC);
+CREATE INDEX idx_payment_intents_customer_id_created_at_id ON payment_intents(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_refunds_created_at_id ON refunds(created_at DESC, id DESC);
+CREATE INDEX idx_refunds_charge_id_created_at_id ON refunds(charge_id, created_at DESC, id DESC);
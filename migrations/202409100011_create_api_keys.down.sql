@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_api_key_requests_api_key_id;
+DROP TABLE IF EXISTS api_key_requests;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,21 @@
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE api_key_requests (
+    id BIGSERIAL PRIMARY KEY,
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id),
+    method VARCHAR(16) NOT NULL,
+    path VARCHAR(2048) NOT NULL,
+    status INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_api_key_requests_api_key_id ON api_key_requests(api_key_id, created_at DESC);
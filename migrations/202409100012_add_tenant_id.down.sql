@@ -0,0 +1,141 @@
+SET app.all_tenants = 'on';
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_products_tenant_id_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_id_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_id_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_tenant_id_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_tenant_id_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_payment_methods_tenant_id_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_payment_intents_tenant_id_created_at_id;
+DROP INDEX IF EXISTS idx_payment_intents_tenant_id_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_refunds_tenant_id_created_at_id;
+DROP INDEX IF EXISTS idx_refunds_tenant_id_charge_id_created_at_id;
+CREATE INDEX idx_products_created_at_id ON products(created_at DESC, id DESC);
+CREATE INDEX idx_subscriptions_created_at_id ON subscriptions(created_at DESC, id DESC);
+CREATE INDEX idx_subscriptions_customer_id_created_at_id ON subscriptions(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_invoices_created_at_id ON invoices(created_at DESC, id DESC);
+CREATE INDEX idx_invoices_customer_id_created_at_id ON invoices(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_methods_customer_id_created_at_id ON payment_methods(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_intents_created_at_id ON payment_intents(created_at DESC, id DESC);
+CREATE INDEX idx_payment_intents_customer_id_created_at_id ON payment_intents(customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_refunds_created_at_id ON refunds(created_at DESC, id DESC);
+CREATE INDEX idx_refunds_charge_id_created_at_id ON refunds(charge_id, created_at DESC, id DESC);
+
+ALTER TABLE backfill_checkpoints DROP CONSTRAINT backfill_checkpoints_pkey;
+ALTER TABLE backfill_checkpoints ADD PRIMARY KEY (resource);
+ALTER TABLE promotion_codes DROP CONSTRAINT promotion_codes_tenant_id_code_key;
+ALTER TABLE promotion_codes ADD CONSTRAINT promotion_codes_code_key UNIQUE (code);
+ALTER TABLE customers DROP CONSTRAINT customers_tenant_id_user_email_key;
+ALTER TABLE customers ADD CONSTRAINT customers_user_email_key UNIQUE (user_email);
+
+DROP POLICY IF EXISTS tenant_isolation ON backfill_checkpoints;
+ALTER TABLE backfill_checkpoints NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE backfill_checkpoints DISABLE ROW LEVEL SECURITY;
+ALTER TABLE backfill_checkpoints DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON outbox_messages;
+ALTER TABLE outbox_messages NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE outbox_messages DISABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON reviews;
+ALTER TABLE reviews NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE reviews DISABLE ROW LEVEL SECURITY;
+ALTER TABLE reviews DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON tax_rates;
+ALTER TABLE tax_rates NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tax_rates DISABLE ROW LEVEL SECURITY;
+ALTER TABLE tax_rates DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON payment_links;
+ALTER TABLE payment_links NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE payment_links DISABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_links DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON quotes;
+ALTER TABLE quotes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE quotes DISABLE ROW LEVEL SECURITY;
+ALTER TABLE quotes DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON checkout_sessions;
+ALTER TABLE checkout_sessions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE checkout_sessions DISABLE ROW LEVEL SECURITY;
+ALTER TABLE checkout_sessions DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON promotion_codes;
+ALTER TABLE promotion_codes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE promotion_codes DISABLE ROW LEVEL SECURITY;
+ALTER TABLE promotion_codes DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON discounts;
+ALTER TABLE discounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE discounts DISABLE ROW LEVEL SECURITY;
+ALTER TABLE discounts DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON coupons;
+ALTER TABLE coupons NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE coupons DISABLE ROW LEVEL SECURITY;
+ALTER TABLE coupons DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON disputes;
+ALTER TABLE disputes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE disputes DISABLE ROW LEVEL SECURITY;
+ALTER TABLE disputes DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON events;
+ALTER TABLE events NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE events DISABLE ROW LEVEL SECURITY;
+ALTER TABLE events DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON refunds;
+ALTER TABLE refunds NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE refunds DISABLE ROW LEVEL SECURITY;
+ALTER TABLE refunds DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON charges;
+ALTER TABLE charges NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE charges DISABLE ROW LEVEL SECURITY;
+ALTER TABLE charges DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON payment_intents;
+ALTER TABLE payment_intents NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE payment_intents DISABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON payment_methods;
+ALTER TABLE payment_methods NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE payment_methods DISABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_methods DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON invoice_items;
+ALTER TABLE invoice_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE invoice_items DISABLE ROW LEVEL SECURITY;
+ALTER TABLE invoice_items DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON invoices;
+ALTER TABLE invoices NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE invoices DISABLE ROW LEVEL SECURITY;
+ALTER TABLE invoices DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON subscriptions;
+ALTER TABLE subscriptions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON prices;
+ALTER TABLE prices NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE prices DISABLE ROW LEVEL SECURITY;
+ALTER TABLE prices DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON products;
+ALTER TABLE products NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE products DISABLE ROW LEVEL SECURITY;
+ALTER TABLE products DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS tenant_isolation ON customers;
+ALTER TABLE customers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE customers DISABLE ROW LEVEL SECURITY;
+ALTER TABLE customers DROP COLUMN IF EXISTS tenant_id;
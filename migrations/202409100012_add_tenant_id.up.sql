@@ -0,0 +1,216 @@
+-- 每個資料表以 tenant_id 區分租戶（品牌），既有資料歸屬 default 租戶
+-- 新增的資料列以連線上的 app.tenant_id 為預設值，應用程式在取得連線時依請求的租戶設定
+-- row level security 讓每個查詢只看得到目前租戶的資料；app.all_tenants = 'on' 時可跨租戶讀寫，僅供 outbox relay 與重試排程等系統工作使用
+-- 資料表擁有者也受 FORCE ROW LEVEL SECURITY 限制，之後的 migration 需要修改資料時先 SET app.all_tenants = 'on'
+
+ALTER TABLE customers ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE customers ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE customers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE customers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON customers
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE products ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE products ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE products ENABLE ROW LEVEL SECURITY;
+ALTER TABLE products FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON products
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE prices ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE prices ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE prices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE prices FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON prices
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE subscriptions ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE subscriptions ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON subscriptions
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE invoices ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE invoices ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE invoices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invoices FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoices
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE invoice_items ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE invoice_items ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE invoice_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invoice_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON invoice_items
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE payment_methods ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE payment_methods ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE payment_methods ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_methods FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON payment_methods
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE payment_intents ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE payment_intents ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE payment_intents ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_intents FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON payment_intents
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE charges ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE charges ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE charges ENABLE ROW LEVEL SECURITY;
+ALTER TABLE charges FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON charges
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE refunds ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE refunds ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE refunds ENABLE ROW LEVEL SECURITY;
+ALTER TABLE refunds FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON refunds
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE events ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE events ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE events FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON events
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE disputes ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE disputes ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE disputes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE disputes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON disputes
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE coupons ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE coupons ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE coupons ENABLE ROW LEVEL SECURITY;
+ALTER TABLE coupons FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON coupons
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE discounts ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE discounts ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE discounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE discounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON discounts
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE promotion_codes ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE promotion_codes ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE promotion_codes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE promotion_codes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON promotion_codes
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE checkout_sessions ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE checkout_sessions ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE checkout_sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checkout_sessions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON checkout_sessions
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE quotes ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE quotes ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE quotes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE quotes FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON quotes
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE payment_links ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE payment_links ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE payment_links ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payment_links FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON payment_links
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE tax_rates ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE tax_rates ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE tax_rates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tax_rates FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON tax_rates
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE reviews ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE reviews ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE reviews ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reviews FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON reviews
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE outbox_messages ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE outbox_messages ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE outbox_messages ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox_messages FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON outbox_messages
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE backfill_checkpoints ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE backfill_checkpoints ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', true), '');
+ALTER TABLE backfill_checkpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE backfill_checkpoints FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON backfill_checkpoints
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+-- 不同 Stripe 帳號可能有相同 email 的客戶或相同的促銷碼
+ALTER TABLE customers DROP CONSTRAINT customers_user_email_key;
+ALTER TABLE customers ADD CONSTRAINT customers_tenant_id_user_email_key UNIQUE (tenant_id, user_email);
+ALTER TABLE promotion_codes DROP CONSTRAINT promotion_codes_code_key;
+ALTER TABLE promotion_codes ADD CONSTRAINT promotion_codes_tenant_id_code_key UNIQUE (tenant_id, code);
+
+-- 回填進度以租戶分開保存
+ALTER TABLE backfill_checkpoints DROP CONSTRAINT backfill_checkpoints_pkey;
+ALTER TABLE backfill_checkpoints ADD PRIMARY KEY (tenant_id, resource);
+
+-- 列表的 keyset 分頁索引改以 tenant_id 開頭
+DROP INDEX IF EXISTS idx_products_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_created_at_id;
+DROP INDEX IF EXISTS idx_subscriptions_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_payment_methods_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_payment_intents_created_at_id;
+DROP INDEX IF EXISTS idx_payment_intents_customer_id_created_at_id;
+DROP INDEX IF EXISTS idx_refunds_created_at_id;
+DROP INDEX IF EXISTS idx_refunds_charge_id_created_at_id;
+CREATE INDEX idx_products_tenant_id_created_at_id ON products(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_subscriptions_tenant_id_created_at_id ON subscriptions(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_subscriptions_tenant_id_customer_id_created_at_id ON subscriptions(tenant_id, customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_invoices_tenant_id_created_at_id ON invoices(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_invoices_tenant_id_customer_id_created_at_id ON invoices(tenant_id, customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_methods_tenant_id_customer_id_created_at_id ON payment_methods(tenant_id, customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_intents_tenant_id_created_at_id ON payment_intents(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_payment_intents_tenant_id_customer_id_created_at_id ON payment_intents(tenant_id, customer_id, created_at DESC, id DESC);
+CREATE INDEX idx_refunds_tenant_id_created_at_id ON refunds(tenant_id, created_at DESC, id DESC);
+CREATE INDEX idx_refunds_tenant_id_charge_id_created_at_id ON refunds(tenant_id, charge_id, created_at DESC, id DESC);
+
+-- API key 屬於單一租戶；tenant_id 為 NULL 的平台 key 以 X-Tenant-ID header 指定租戶
+-- 認證時還不知道租戶，因此 api_keys 不啟用 row level security
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64);
@@ -0,0 +1,7 @@
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS application_fee_amount;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS transfer_destination;
+ALTER TABLE payment_intents DROP COLUMN IF EXISTS on_behalf_of;
+
+DROP TABLE IF EXISTS application_fees;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS connected_accounts;
@@ -0,0 +1,76 @@
+-- Stripe Connect：平台的連結帳號、轉帳與平台手續費
+-- 轉帳與手續費可能在本服務之外建立，webhook 到達時連結帳號不一定已存在於本地，因此不建立外鍵
+
+CREATE TABLE connected_accounts (
+    id VARCHAR(255) PRIMARY KEY CHECK (id ~ '^[a-z]+_[a-zA-Z0-9]+$'),
+    type VARCHAR(32) NOT NULL,
+    email VARCHAR(255),
+    country CHAR(2),
+    charges_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    payouts_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    details_submitted BOOLEAN NOT NULL DEFAULT FALSE,
+    capabilities JSONB NOT NULL DEFAULT '{}',
+    requirements_due TEXT[] NOT NULL DEFAULT '{}',
+    disabled_reason VARCHAR(255),
+    deauthorized_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_event_at TIMESTAMP WITH TIME ZONE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')
+);
+
+CREATE TABLE transfers (
+    id VARCHAR(255) PRIMARY KEY CHECK (id ~ '^[a-z]+_[a-zA-Z0-9]+$'),
+    destination_account_id VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    amount_reversed BIGINT NOT NULL DEFAULT 0,
+    currency currency NOT NULL,
+    reversed BOOLEAN NOT NULL DEFAULT FALSE,
+    source_transaction_id VARCHAR(255),
+    transfer_group VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_event_at TIMESTAMP WITH TIME ZONE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')
+);
+
+CREATE TABLE application_fees (
+    id VARCHAR(255) PRIMARY KEY CHECK (id ~ '^[a-z]+_[a-zA-Z0-9]+$'),
+    account_id VARCHAR(255) NOT NULL,
+    charge_id VARCHAR(255),
+    originating_transaction_id VARCHAR(255),
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    amount_refunded BIGINT NOT NULL DEFAULT 0,
+    currency currency NOT NULL,
+    refunded BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_event_at TIMESTAMP WITH TIME ZONE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')
+);
+
+CREATE INDEX idx_transfers_tenant_id_destination_account_id ON transfers(tenant_id, destination_account_id, created_at DESC);
+CREATE INDEX idx_application_fees_tenant_id_account_id ON application_fees(tenant_id, account_id, created_at DESC);
+
+ALTER TABLE connected_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE connected_accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON connected_accounts
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE transfers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transfers FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON transfers
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE application_fees ENABLE ROW LEVEL SECURITY;
+ALTER TABLE application_fees FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON application_fees
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+-- destination charge 與 on_behalf_of 的設定，未使用 Connect 的支付意圖為 NULL
+ALTER TABLE payment_intents ADD COLUMN on_behalf_of VARCHAR(255);
+ALTER TABLE payment_intents ADD COLUMN transfer_destination VARCHAR(255);
+ALTER TABLE payment_intents ADD COLUMN application_fee_amount BIGINT;
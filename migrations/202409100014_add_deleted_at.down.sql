@@ -0,0 +1,6 @@
+-- 回滾後 tombstone 列會重新出現在查詢結果中；其他資料表仍以外鍵參照它們，因此不在此刪除
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE prices DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE payment_methods DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+-- Stripe 刪除物件後保留該列作為 tombstone，deleted_at 為刪除事件的建立時間
+-- Upsert 不再更新已刪除的列，較晚送達的舊事件不會讓物件復活；查詢時排除已刪除的列
+ALTER TABLE customers ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE prices ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE invoices ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE payment_methods ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+-- 移除外鍵期間寫入的客戶可能沒有對應的 users 列，以 NOT VALID 重建，只檢查之後寫入的列
+ALTER TABLE customers ADD CONSTRAINT customers_user_email_fkey FOREIGN KEY (user_email) REFERENCES users(email) NOT VALID;
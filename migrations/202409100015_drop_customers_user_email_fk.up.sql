@@ -0,0 +1,3 @@
+-- customers 由 Stripe 事件與 backfill 寫入，沒有任何流程建立 users 列，外鍵會讓這些寫入失敗
+-- user_email 只作為識別客戶的欄位，名稱改以 LEFT JOIN 讀取，users 沒有對應的列時為空字串
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_user_email_fkey;
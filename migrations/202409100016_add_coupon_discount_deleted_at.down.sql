@@ -0,0 +1,3 @@
+-- 回滾後 tombstone 列會重新出現在查詢結果中
+ALTER TABLE discounts DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE coupons DROP COLUMN IF EXISTS deleted_at;
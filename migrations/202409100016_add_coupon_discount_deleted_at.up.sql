@@ -0,0 +1,3 @@
+-- 優惠券與折扣與客戶相同改為保留 tombstone，刪除事件之後才送達的舊 updated 事件不會重新建立它們
+ALTER TABLE coupons ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE discounts ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
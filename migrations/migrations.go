@@ -0,0 +1,10 @@
+// Package migrations 將資料庫 migration 嵌入執行檔，由 migrate 套件執行
+// 檔名沿用 golang-migrate 的格式：<version>_<name>.up.sql 與 <version>_<name>.down.sql
+package migrations
+
+import "embed"
+
+// FS 為所有 migration 檔案
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/sqlc"
+)
+
+// APIKeyScope 為 API key 可以使用的權限，格式為「資源:read」或「資源:write」
+type APIKeyScope string
+
+const (
+	// ScopeAll 允許所有操作
+	ScopeAll APIKeyScope = "*"
+	// ScopeReadOnly 允許所有資源的讀取
+	ScopeReadOnly APIKeyScope = "read_only"
+
+	ScopeCustomersRead          APIKeyScope = "customers:read"
+	ScopeCustomersWrite         APIKeyScope = "customers:write"
+	ScopeProductsRead           APIKeyScope = "products:read"
+	ScopeProductsWrite          APIKeyScope = "products:write"
+	ScopePricesRead             APIKeyScope = "prices:read"
+	ScopePricesWrite            APIKeyScope = "prices:write"
+	ScopePaymentIntentsRead     APIKeyScope = "payment_intents:read"
+	ScopePaymentIntentsWrite    APIKeyScope = "payment_intents:write"
+	ScopeSubscriptionsRead      APIKeyScope = "subscriptions:read"
+	ScopeSubscriptionsWrite     APIKeyScope = "subscriptions:write"
+	ScopeInvoicesRead           APIKeyScope = "invoices:read"
+	ScopeInvoicesWrite          APIKeyScope = "invoices:write"
+	ScopeRefundsRead            APIKeyScope = "refunds:read"
+	ScopeRefundsWrite           APIKeyScope = "refunds:write"
+	ScopeEventsRead             APIKeyScope = "events:read"
+	ScopeEventsWrite            APIKeyScope = "events:write"
+	ScopeConnectedAccountsRead  APIKeyScope = "connected_accounts:read"
+	ScopeConnectedAccountsWrite APIKeyScope = "connected_accounts:write"
+	ScopeTransfersRead          APIKeyScope = "transfers:read"
+	ScopeTransfersWrite         APIKeyScope = "transfers:write"
+	ScopeMetricsRead            APIKeyScope = "metrics:read"
+)
+
+// ErrInvalidAPIKeyScope 代表發行 API key 時指定了不存在的權限
+var ErrInvalidAPIKeyScope = domainerr.New(domainerr.CodeValidation, "invalid api key scope")
+
+// apiKeyResources 為可以授權的資源，每個資源都有 read 與 write 兩種權限
+var apiKeyResources = map[string]bool{
+	"customers":          true,
+	"products":           true,
+	"prices":             true,
+	"payment_intents":    true,
+	"subscriptions":      true,
+	"invoices":           true,
+	"refunds":            true,
+	"events":             true,
+	"connected_accounts": true,
+	"transfers":          true,
+	"metrics":            true,
+}
+
+// ParseAPIKeyScopes 解析並檢查權限字串，空字串會被略過
+func ParseAPIKeyScopes(raw []string) ([]APIKeyScope, error) {
+	scopes := make([]APIKeyScope, 0, len(raw))
+	for _, r := range raw {
+		scope := APIKeyScope(strings.TrimSpace(r))
+		if scope == "" {
+			continue
+		}
+		if !scope.Valid() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAPIKeyScope, scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyScope)
+	}
+	return scopes, nil
+}
+
+// Valid 回傳權限是否為可授權的值
+func (s APIKeyScope) Valid() bool {
+	if s == ScopeAll || s == ScopeReadOnly {
+		return true
+	}
+	resource, access, ok := strings.Cut(string(s), ":")
+	return ok && apiKeyResources[resource] && (access == "read" || access == "write")
+}
+
+// Resource 回傳權限所屬的資源，例如 customers:write 的 customers；* 與 read_only 沒有資源
+func (s APIKeyScope) Resource() string {
+	resource, _, ok := strings.Cut(string(s), ":")
+	if !ok {
+		return ""
+	}
+	return resource
+}
+
+// APIKey 為呼叫 HTTP API 的憑證，資料庫只保存 key 的 SHA-256 雜湊
+// Prefix 為 key 開頭不含秘密的部分，用來查詢、撤銷與在紀錄中辨識 key
+// TenantID 為 key 所屬的租戶，空值代表平台 key，可以用 X-Tenant-ID header 指定租戶
+type APIKey struct {
+	ID         int64         `json:"id"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"`
+	Hash       string        `json:"-"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	TenantID   string        `json:"tenant_id,omitempty"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// Revoked 回傳 key 是否已被撤銷
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Allows 判斷 key 是否擁有指定的權限
+// * 允許所有操作，read_only 允許所有 :read，同一資源的 :write 也包含 :read
+func (k *APIKey) Allows(scope APIKeyScope) bool {
+	resource, access, _ := strings.Cut(string(scope), ":")
+	for _, granted := range k.Scopes {
+		switch {
+		case granted == ScopeAll, granted == scope:
+			return true
+		case access == "read" && granted == ScopeReadOnly:
+			return true
+		case access == "read" && granted == APIKeyScope(resource+":write"):
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRequest 為以 API key 認證的一次請求，記錄使用的 key 與回應狀態
+// HTTP 請求的 Status 為 HTTP 狀態碼；gRPC 請求的 Method 為 GRPC，Status 為 gRPC 狀態碼
+type APIKeyRequest struct {
+	APIKeyID  int64
+	Method    string
+	Path      string
+	Status    int
+	CreatedAt time.Time
+}
+
+func (k *APIKey) ConvertFromSQLCAPIKey(sqlcAPIKey any) *APIKey {
+
+	switch sk := sqlcAPIKey.(type) {
+	case *sqlc.ApiKey:
+		k.ID = sk.ID
+		k.Name = sk.Name
+		k.Prefix = sk.Prefix
+		k.Hash = sk.Hash
+		k.Scopes = make([]APIKeyScope, len(sk.Scopes))
+		for i, scope := range sk.Scopes {
+			k.Scopes[i] = APIKeyScope(scope)
+		}
+		if sk.TenantID != nil {
+			k.TenantID = *sk.TenantID
+		}
+		if sk.LastUsedAt.Valid {
+			lastUsedAt := sk.LastUsedAt.Time
+			k.LastUsedAt = &lastUsedAt
+		}
+		if sk.RevokedAt.Valid {
+			revokedAt := sk.RevokedAt.Time
+			k.RevokedAt = &revokedAt
+		}
+		k.CreatedAt = sk.CreatedAt.Time
+	default:
+		return nil
+	}
+
+	return k
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/sqlc"
+)
+
+// ApplicationFee 代表平台從 destination charge 或 direct charge 收取的手續費
+type ApplicationFee struct {
+	ID                       string          `json:"id"`
+	AccountID                string          `json:"account_id"`
+	ChargeID                 string          `json:"charge_id,omitempty"`
+	OriginatingTransactionID string          `json:"originating_transaction_id,omitempty"`
+	Amount                   Money           `json:"amount"`
+	AmountRefunded           Money           `json:"amount_refunded"`
+	Currency                 stripe.Currency `json:"currency"`
+	Refunded                 bool            `json:"refunded"`
+	CreatedAt                time.Time       `json:"created_at"`
+	UpdatedAt                time.Time       `json:"updated_at"`
+}
+
+type PartialApplicationFee struct {
+	ID                       string
+	AccountID                *string
+	ChargeID                 *string
+	OriginatingTransactionID *string
+	Amount                   *int64
+	AmountRefunded           *int64
+	Currency                 *stripe.Currency
+	Refunded                 *bool
+	CreatedAt                *time.Time
+	UpdatedAt                *time.Time
+	LastEventAt              *time.Time
+}
+
+func NewApplicationFee() *ApplicationFee {
+	return &ApplicationFee{}
+}
+
+func (f *ApplicationFee) ConvertFromSQLCApplicationFee(sqlcFee any) *ApplicationFee {
+
+	var (
+		id, accountID, chargeID, originatingTransactionID string
+		amount, amountRefunded                            int64
+		currency                                          stripe.Currency
+		refunded                                          bool
+		createdAt, updatedAt                              time.Time
+	)
+
+	switch sp := sqlcFee.(type) {
+	case *sqlc.ApplicationFee:
+		id = sp.ID
+		accountID = sp.AccountID
+		if sp.ChargeID != nil {
+			chargeID = *sp.ChargeID
+		}
+		if sp.OriginatingTransactionID != nil {
+			originatingTransactionID = *sp.OriginatingTransactionID
+		}
+		amount = sp.Amount
+		amountRefunded = sp.AmountRefunded
+		currency = stripe.Currency(sp.Currency)
+		refunded = sp.Refunded
+		createdAt = sp.CreatedAt.Time
+		updatedAt = sp.UpdatedAt.Time
+	default:
+		return nil
+	}
+
+	f.ID = id
+	f.AccountID = accountID
+	f.ChargeID = chargeID
+	f.OriginatingTransactionID = originatingTransactionID
+	f.Amount = NewMoney(amount, currency)
+	f.AmountRefunded = NewMoney(amountRefunded, currency)
+	f.Currency = currency
+	f.Refunded = refunded
+	f.CreatedAt = createdAt
+	f.UpdatedAt = updatedAt
+
+	return f
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"goflare.io/payment/sqlc"
+)
+
+// BackfillCheckpoint 記錄單一資源回填的進度
+// LastID 為最後一頁寫入完成的 Stripe 物件 ID，中斷後從它之後繼續；CompletedAt 不為 nil 時代表已回填完畢
+type BackfillCheckpoint struct {
+	Resource    string     `json:"resource"`
+	LastID      string     `json:"last_id,omitempty"`
+	Imported    int64      `json:"imported"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (c *BackfillCheckpoint) ConvertFromSQLCBackfillCheckpoint(sqlcCheckpoint any) *BackfillCheckpoint {
+
+	switch sc := sqlcCheckpoint.(type) {
+	case *sqlc.BackfillCheckpoint:
+		c.Resource = sc.Resource
+		if sc.LastID != nil {
+			c.LastID = *sc.LastID
+		}
+		c.Imported = sc.Imported
+		if sc.CompletedAt.Valid {
+			completedAt := sc.CompletedAt.Time
+			c.CompletedAt = &completedAt
+		}
+		c.UpdatedAt = sc.UpdatedAt.Time
+	default:
+		return nil
+	}
+
+	return c
+}
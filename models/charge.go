@@ -10,7 +10,7 @@ type Charge struct {
 	ID              string              `json:"id"`
 	CustomerID      string              `json:"customer_id"`
 	PaymentIntentID string              `json:"payment_intent_id"`
-	Amount          float64             `json:"amount"`
+	Amount          Money               `json:"amount"`
 	Currency        stripe.Currency     `json:"currency"`
 	Status          stripe.ChargeStatus `json:"status"`
 	Paid            bool                `json:"paid"`
@@ -25,7 +25,7 @@ type PartialCharge struct {
 	ID              string
 	CustomerID      *string
 	PaymentIntentID *string
-	Amount          *float64
+	Amount          *int64
 	Currency        *stripe.Currency
 	Status          *stripe.ChargeStatus
 	Paid            *bool
@@ -34,4 +34,5 @@ type PartialCharge struct {
 	FailureMessage  *string
 	CreatedAt       *time.Time
 	UpdatedAt       *time.Time
+	LastEventAt     *time.Time
 }
@@ -14,7 +14,7 @@ type CheckoutSession struct {
 	Mode            stripe.CheckoutSessionMode   `json:"mode"`
 	SuccessURL      string                       `json:"success_url"`
 	CancelURL       string                       `json:"cancel_url"`
-	AmountTotal     int64                        `json:"amount_total"`
+	AmountTotal     Money                        `json:"amount_total"`
 	Currency        stripe.Currency              `json:"currency"`
 	CreatedAt       time.Time                    `json:"created_at"`
 	UpdatedAt       time.Time                    `json:"updated_at"`
@@ -32,4 +32,5 @@ type PartialCheckoutSession struct {
 	Currency        *stripe.Currency              `json:"currency,omitempty"`
 	CreatedAt       *time.Time                    `json:"created_at,omitempty"`
 	UpdatedAt       *time.Time                    `json:"updated_at,omitempty"`
+	LastEventAt     *time.Time
 }
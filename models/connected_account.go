@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/sqlc"
+)
+
+// ConnectedAccount 代表平台底下的 Stripe Connect 連結帳號
+// Capabilities 為 capability 名稱對應的狀態，例如 card_payments: active、transfers: pending
+// RequirementsDue 為帳號目前必須補齊的資料，補齊前 ChargesEnabled 或 PayoutsEnabled 可能為 false
+type ConnectedAccount struct {
+	ID               string             `json:"id"`
+	Type             stripe.AccountType `json:"type"`
+	Email            string             `json:"email,omitempty"`
+	Country          string             `json:"country,omitempty"`
+	ChargesEnabled   bool               `json:"charges_enabled"`
+	PayoutsEnabled   bool               `json:"payouts_enabled"`
+	DetailsSubmitted bool               `json:"details_submitted"`
+	Capabilities     map[string]string  `json:"capabilities"`
+	RequirementsDue  []string           `json:"requirements_due"`
+	DisabledReason   string             `json:"disabled_reason,omitempty"`
+	DeauthorizedAt   *time.Time         `json:"deauthorized_at,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+type PartialConnectedAccount struct {
+	ID               string
+	Type             *stripe.AccountType
+	Email            *string
+	Country          *string
+	ChargesEnabled   *bool
+	PayoutsEnabled   *bool
+	DetailsSubmitted *bool
+	Capabilities     map[string]string
+	RequirementsDue  []string
+	DisabledReason   *string
+	CreatedAt        *time.Time
+	UpdatedAt        *time.Time
+	LastEventAt      *time.Time
+}
+
+// ConnectedAccountParams 為建立連結帳號的參數，Type 為空時建立 express 帳號
+type ConnectedAccountParams struct {
+	Type    stripe.AccountType `json:"type"`
+	Country string             `json:"country"`
+	Email   string             `json:"email"`
+}
+
+// AccountLink 為連結帳號的 onboarding 網址，只能使用一次並在 ExpiresAt 後失效
+type AccountLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewConnectedAccount() *ConnectedAccount {
+	return &ConnectedAccount{}
+}
+
+func (a *ConnectedAccount) ConvertFromSQLCConnectedAccount(sqlcAccount any) *ConnectedAccount {
+
+	var (
+		id, email, country, disabledReason               string
+		accountType                                      stripe.AccountType
+		chargesEnabled, payoutsEnabled, detailsSubmitted bool
+		capabilities                                     map[string]string
+		requirementsDue                                  []string
+		deauthorizedAt                                   *time.Time
+		createdAt, updatedAt                             time.Time
+	)
+
+	switch sp := sqlcAccount.(type) {
+	case *sqlc.ConnectedAccount:
+		id = sp.ID
+		accountType = stripe.AccountType(sp.Type)
+		if sp.Email != nil {
+			email = *sp.Email
+		}
+		if sp.Country != nil {
+			country = *sp.Country
+		}
+		chargesEnabled = sp.ChargesEnabled
+		payoutsEnabled = sp.PayoutsEnabled
+		detailsSubmitted = sp.DetailsSubmitted
+		// capabilities 只由本服務寫入，格式錯誤時視為沒有 capability
+		_ = json.Unmarshal(sp.Capabilities, &capabilities)
+		requirementsDue = sp.RequirementsDue
+		if sp.DisabledReason != nil {
+			disabledReason = *sp.DisabledReason
+		}
+		if sp.DeauthorizedAt.Valid {
+			deauthorizedAt = &sp.DeauthorizedAt.Time
+		}
+		createdAt = sp.CreatedAt.Time
+		updatedAt = sp.UpdatedAt.Time
+	default:
+		return nil
+	}
+
+	a.ID = id
+	a.Type = accountType
+	a.Email = email
+	a.Country = country
+	a.ChargesEnabled = chargesEnabled
+	a.PayoutsEnabled = payoutsEnabled
+	a.DetailsSubmitted = detailsSubmitted
+	a.Capabilities = capabilities
+	a.RequirementsDue = requirementsDue
+	a.DisabledReason = disabledReason
+	a.DeauthorizedAt = deauthorizedAt
+	a.CreatedAt = createdAt
+	a.UpdatedAt = updatedAt
+
+	return a
+}
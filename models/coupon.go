@@ -9,7 +9,7 @@ import (
 type Coupon struct {
 	ID               string                `json:"id"`
 	Name             string                `json:"name"`
-	AmountOff        int64                 `json:"amount_off,omitempty"`
+	AmountOff        *Money                `json:"amount_off,omitempty"`
 	PercentOff       float64               `json:"percent_off,omitempty"`
 	Currency         stripe.Currency       `json:"currency,omitempty"`
 	Duration         stripe.CouponDuration `json:"duration"`
@@ -36,4 +36,6 @@ type PartialCoupon struct {
 	CreatedAt        *time.Time
 	UpdatedAt        *time.Time
 	RedeemBy         *time.Time
+	LastEventAt      *time.Time
+	DeletedAt        *time.Time
 }
@@ -19,11 +19,13 @@ type Customer struct {
 }
 
 type PartialCustomer struct {
-	ID        string
-	Email     *string
-	Balance   *int64
-	CreatedAt *time.Time
-	UpdatedAt *time.Time
+	ID          string
+	Email       *string
+	Balance     *int64
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+	LastEventAt *time.Time
+	DeletedAt   *time.Time
 }
 
 func NewCustomer() *Customer {
@@ -40,6 +42,7 @@ func (c *Customer) ConvertFromSQLCCustomer(sqlcCustomer any) *Customer {
 	case *sqlc.Customer:
 		id = sp.ID
 		balance = sp.Balance
+		email = sp.UserEmail
 		createdAt = sp.CreatedAt.Time
 		updatedAt = sp.UpdatedAt.Time
 	case *sqlc.GetCustomerRow:
@@ -13,11 +13,13 @@ type Discount struct {
 }
 
 type PartialDiscount struct {
-	ID         string
-	CustomerID *string
-	CouponID   *string
-	Start      *time.Time
-	End        *time.Time
-	CreatedAt  *time.Time
-	UpdatedAt  *time.Time
+	ID          string
+	CustomerID  *string
+	CouponID    *string
+	Start       *time.Time
+	End         *time.Time
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+	LastEventAt *time.Time
+	DeletedAt   *time.Time
 }
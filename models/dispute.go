@@ -11,7 +11,7 @@ import (
 type Dispute struct {
 	ID            string               `json:"id"`
 	ChargeID      string               `json:"charge_id"`
-	Amount        int64                `json:"amount"`
+	Amount        Money                `json:"amount"`
 	Currency      stripe.Currency      `json:"currency"`
 	Status        stripe.DisputeStatus `json:"status"`
 	Reason        stripe.DisputeReason `json:"reason"`
@@ -30,6 +30,7 @@ type PartialDispute struct {
 	EvidenceDueBy *time.Time
 	CreatedAt     *time.Time
 	UpdatedAt     *time.Time
+	LastEventAt   *time.Time
 }
 
 func NewDispute() *Dispute {
@@ -40,6 +41,7 @@ func (d *Dispute) ConvertFromSQLCDispute(sqlcDispute any) *Dispute {
 
 	var (
 		id, chargeID                        string
+		amount                              int64
 		status                              stripe.DisputeStatus
 		reason                              stripe.DisputeReason
 		currency                            stripe.Currency
@@ -50,6 +52,7 @@ func (d *Dispute) ConvertFromSQLCDispute(sqlcDispute any) *Dispute {
 	case *sqlc.Dispute:
 		id = sp.ID
 		chargeID = sp.ChargeID
+		amount = sp.Amount
 		status = stripe.DisputeStatus(sp.Status)
 		reason = stripe.DisputeReason(sp.Reason)
 		currency = stripe.Currency(sp.Currency)
@@ -62,6 +65,7 @@ func (d *Dispute) ConvertFromSQLCDispute(sqlcDispute any) *Dispute {
 
 	d.ID = id
 	d.ChargeID = chargeID
+	d.Amount = NewMoney(amount, currency)
 	d.Status = status
 	d.Reason = reason
 	d.EvidenceDueBy = evidenceDueBy
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DomainEventType 為對外發佈的領域事件類型，同時作為 NATS subject
+type DomainEventType string
+
+const (
+	DomainEventPaymentIntentSucceeded DomainEventType = "payment.intent.succeeded"
+	DomainEventPaymentIntentFailed    DomainEventType = "payment.intent.failed"
+	DomainEventPaymentIntentCanceled  DomainEventType = "payment.intent.canceled"
+	DomainEventSubscriptionCreated    DomainEventType = "payment.subscription.created"
+	DomainEventSubscriptionUpdated    DomainEventType = "payment.subscription.updated"
+	DomainEventSubscriptionCanceled   DomainEventType = "payment.subscription.canceled"
+	DomainEventInvoicePaid            DomainEventType = "payment.invoice.paid"
+	DomainEventInvoicePaymentFailed   DomainEventType = "payment.invoice.payment_failed"
+	DomainEventInvoiceOverdue         DomainEventType = "payment.invoice.overdue"
+)
+
+// DomainEventSchemaVersion 目前發佈的 payload 版本，對應 outbox/schemas 下的 *.v1.json
+// 欄位只增不減，移除或改變欄位意義時才提高版本
+const DomainEventSchemaVersion = 1
+
+// DomainEvent 為領域事件的共同外層，Data 的結構由 Type 與 SchemaVersion 決定
+type DomainEvent struct {
+	ID            string          `json:"id"`
+	Type          DomainEventType `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	StripeEventID string          `json:"stripe_event_id"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          any             `json:"data"`
+}
+
+// NewDomainEvent 建立領域事件，ID 由 Stripe 事件 ID 與類型組成
+// 同一個 Stripe 事件重送或重新處理時得到相同 ID，outbox 與 JetStream 皆以此去重
+func NewDomainEvent(eventType DomainEventType, stripeEventID, aggregateID string, occurredAt time.Time, data any) *DomainEvent {
+	return &DomainEvent{
+		ID:            fmt.Sprintf("%s:%s", stripeEventID, eventType),
+		Type:          eventType,
+		SchemaVersion: DomainEventSchemaVersion,
+		StripeEventID: stripeEventID,
+		AggregateID:   aggregateID,
+		OccurredAt:    occurredAt,
+		Data:          data,
+	}
+}
+
+// PaymentIntentEventV1 為 payment.intent.* 的 payload，金額為最小貨幣單位
+type PaymentIntentEventV1 struct {
+	PaymentIntentID string `json:"payment_intent_id"`
+	CustomerID      string `json:"customer_id,omitempty"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
+	Status          string `json:"status"`
+	FailureCode     string `json:"failure_code,omitempty"`
+	FailureMessage  string `json:"failure_message,omitempty"`
+}
+
+// SubscriptionEventV1 為 payment.subscription.* 的 payload
+type SubscriptionEventV1 struct {
+	SubscriptionID     string     `json:"subscription_id"`
+	CustomerID         string     `json:"customer_id"`
+	PriceID            string     `json:"price_id,omitempty"`
+	Status             string     `json:"status"`
+	CurrentPeriodStart time.Time  `json:"current_period_start"`
+	CurrentPeriodEnd   time.Time  `json:"current_period_end"`
+	CancelAtPeriodEnd  bool       `json:"cancel_at_period_end"`
+	CanceledAt         *time.Time `json:"canceled_at,omitempty"`
+}
+
+// InvoiceEventV1 為 payment.invoice.* 的 payload，金額為最小貨幣單位
+type InvoiceEventV1 struct {
+	InvoiceID       string     `json:"invoice_id"`
+	CustomerID      string     `json:"customer_id"`
+	SubscriptionID  string     `json:"subscription_id,omitempty"`
+	Status          string     `json:"status"`
+	Currency        string     `json:"currency"`
+	AmountDue       int64      `json:"amount_due"`
+	AmountPaid      int64      `json:"amount_paid"`
+	AmountRemaining int64      `json:"amount_remaining"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+}
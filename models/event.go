@@ -1,15 +1,88 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/sqlc"
 )
 
 type Event struct {
-	ID        string           `json:"id"`
-	Type      stripe.EventType `json:"type"`
-	Processed bool             `json:"processed"`
-	CreatedAt time.Time        `json:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at"`
+	ID              string           `json:"id"`
+	Type            stripe.EventType `json:"type"`
+	Processed       bool             `json:"processed"`
+	Payload         json.RawMessage  `json:"payload,omitempty"`
+	APIVersion      string           `json:"api_version"`
+	StripeCreatedAt time.Time        `json:"stripe_created_at"`
+	ObjectID        string           `json:"object_id"`
+	Attempts        int              `json:"attempts"`
+	LastError       string           `json:"last_error,omitempty"`
+	NextAttemptAt   *time.Time       `json:"next_attempt_at,omitempty"`
+	Failed          bool             `json:"failed"`
+	TenantID        string           `json:"tenant_id"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+func NewEvent() *Event {
+	return &Event{}
+}
+
+// NewEventFromStripe 以 webhook 收到的原始內容建立事件紀錄，保留 payload 以便日後重新處理
+func NewEventFromStripe(stripeEvent *stripe.Event, payload []byte) *Event {
+	var objectID string
+	if stripeEvent.Data != nil {
+		if id, ok := stripeEvent.Data.Object["id"].(string); ok {
+			objectID = id
+		}
+	}
+
+	now := time.Now()
+	return &Event{
+		ID:              stripeEvent.ID,
+		Type:            stripeEvent.Type,
+		Processed:       false,
+		Payload:         payload,
+		APIVersion:      stripeEvent.APIVersion,
+		StripeCreatedAt: time.Unix(stripeEvent.Created, 0),
+		ObjectID:        objectID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func (e *Event) ConvertFromSQLCEvent(sqlcEvent any) *Event {
+
+	switch se := sqlcEvent.(type) {
+	case *sqlc.Event:
+		e.ID = se.ID
+		e.Type = stripe.EventType(se.Type)
+		e.Processed = se.Processed
+		e.Payload = se.Payload
+		if se.ApiVersion != nil {
+			e.APIVersion = *se.ApiVersion
+		}
+		e.StripeCreatedAt = se.StripeCreatedAt.Time
+		if se.ObjectID != nil {
+			e.ObjectID = *se.ObjectID
+		}
+		e.Attempts = int(se.Attempts)
+		if se.LastError != nil {
+			e.LastError = *se.LastError
+		}
+		if se.NextAttemptAt.Valid {
+			nextAttemptAt := se.NextAttemptAt.Time
+			e.NextAttemptAt = &nextAttemptAt
+		}
+		e.Failed = se.Failed
+		e.TenantID = se.TenantID
+		e.CreatedAt = se.CreatedAt.Time
+		e.UpdatedAt = se.UpdatedAt.Time
+	default:
+		return nil
+	}
+
+	return e
 }
@@ -16,9 +16,9 @@ type Invoice struct {
 	SubscriptionID  *string              `json:"subscription_id,omitempty"`
 	Status          stripe.InvoiceStatus `json:"status"`
 	Currency        stripe.Currency      `json:"currency"`
-	AmountDue       float64              `json:"amount_due"`
-	AmountPaid      float64              `json:"amount_paid"`
-	AmountRemaining float64              `json:"amount_remaining"`
+	AmountDue       Money                `json:"amount_due"`
+	AmountPaid      Money                `json:"amount_paid"`
+	AmountRemaining Money                `json:"amount_remaining"`
 	InvoiceItems    []*InvoiceItem       `json:"invoice_items"`
 	DueDate         time.Time            `json:"due_date"`
 	PaidAt          time.Time            `json:"paid_at"`
@@ -32,13 +32,15 @@ type PartialInvoice struct {
 	SubscriptionID  *string
 	Status          *stripe.InvoiceStatus
 	Currency        *stripe.Currency
-	AmountDue       *float64
-	AmountPaid      *float64
-	AmountRemaining *float64
+	AmountDue       *int64
+	AmountPaid      *int64
+	AmountRemaining *int64
 	DueDate         *time.Time
 	PaidAt          *time.Time
 	CreatedAt       *time.Time
 	UpdatedAt       *time.Time
+	LastEventAt     *time.Time
+	DeletedAt       *time.Time
 }
 
 func NewInvoice() *Invoice {
@@ -49,7 +51,7 @@ func (i *Invoice) ConvertFromSQLCInvoice(sqlcInvoice any) *Invoice {
 
 	var (
 		id, customerID, subscriptionID         string
-		amountDue, amountPaid, amountRemaining float64
+		amountDue, amountPaid, amountRemaining int64
 		status                                 stripe.InvoiceStatus
 		currency                               stripe.Currency
 		dueDate, paidAt, createdAt, updatedAt  time.Time
@@ -78,9 +80,9 @@ func (i *Invoice) ConvertFromSQLCInvoice(sqlcInvoice any) *Invoice {
 	i.ID = id
 	i.CustomerID = customerID
 	i.SubscriptionID = &subscriptionID
-	i.AmountDue = amountDue
-	i.AmountPaid = amountPaid
-	i.AmountRemaining = amountRemaining
+	i.AmountDue = NewMoney(amountDue, currency)
+	i.AmountPaid = NewMoney(amountPaid, currency)
+	i.AmountRemaining = NewMoney(amountRemaining, currency)
 	i.Currency = currency
 	i.Status = status
 	i.DueDate = dueDate
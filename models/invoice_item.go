@@ -1,14 +1,16 @@
 package models
 
 import (
+	"github.com/stripe/stripe-go/v79"
+
 	"goflare.io/payment/sqlc"
 )
 
 type InvoiceItem struct {
-	ID          string  `json:"id"`
-	InvoiceID   string  `json:"invoice_id"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
+	ID          string `json:"id"`
+	InvoiceID   string `json:"invoice_id"`
+	Amount      Money  `json:"amount"`
+	Description string `json:"description"`
 }
 
 func NewInvoiceItem() *InvoiceItem {
@@ -18,13 +20,15 @@ func NewInvoiceItem() *InvoiceItem {
 func (item *InvoiceItem) ConvertFromSQLCInvoiceItem(sqlcInvoiceItem any) *InvoiceItem {
 
 	var id, invoiceID, desc string
-	var amount float64
+	var amount int64
+	var currency stripe.Currency
 
 	switch sp := sqlcInvoiceItem.(type) {
 	case *sqlc.InvoiceItem:
 		id = sp.ID
 		invoiceID = sp.InvoiceID
 		amount = sp.Amount
+		currency = stripe.Currency(sp.Currency)
 		if sp.Description != nil {
 			desc = *sp.Description
 		}
@@ -34,7 +38,7 @@ func (item *InvoiceItem) ConvertFromSQLCInvoiceItem(sqlcInvoiceItem any) *Invoic
 
 	item.ID = id
 	item.InvoiceID = invoiceID
-	item.Amount = amount
+	item.Amount = NewMoney(amount, currency)
 	item.Description = desc
 
 	return item
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/domainerr"
+)
+
+const (
+	// DefaultListLimit 未指定 Limit 時每頁的筆數
+	DefaultListLimit = 20
+	// MaxListLimit 每頁筆數的上限，與 Stripe list API 相同
+	MaxListLimit = 100
+)
+
+// ErrInvalidCursor 代表分頁游標無法解析，通常是呼叫端自行組出或截斷了 next_cursor
+var ErrInvalidCursor = domainerr.New(domainerr.CodeValidation, "invalid cursor")
+
+// ErrInvalidListFilter 代表篩選條件不是該資源可用的值，例如不存在的狀態或幣別
+var ErrInvalidListFilter = domainerr.New(domainerr.CodeValidation, "invalid list filter")
+
+// ListParams 為列表查詢的分頁與篩選條件，未設定的欄位不做篩選
+// 各資源只套用資料表中存在的欄位，例如產品沒有幣別與客戶；產品的 Status 為 active 或 inactive，ChargeID 只用於退款
+// CreatedFrom 包含、CreatedTo 不包含
+type ListParams struct {
+	Limit       int
+	Cursor      *Cursor
+	CustomerID  string
+	ChargeID    string
+	Status      string
+	Currency    stripe.Currency
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// PageSize 回傳實際使用的每頁筆數，未指定時為 DefaultListLimit，最多 MaxListLimit
+func (p ListParams) PageSize() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultListLimit
+	case p.Limit > MaxListLimit:
+		return MaxListLimit
+	default:
+		return p.Limit
+	}
+}
+
+// Cursor 為 keyset 分頁的位置，記錄上一頁最後一筆的 (created_at, id)
+// 列表依 created_at、id 遞減排序，下一頁從比游標更舊的資料開始，分頁期間新增的資料不會造成重複或遺漏
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode 將游標編碼為不透明的字串，呼叫端只應原樣傳回
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor 解析 Encode 產生的游標，空字串代表第一頁並回傳 nil
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	unixMicro, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: time.UnixMicro(unixMicro).UTC(), ID: id}, nil
+}
+
+// Page 為列表回應的標準格式，HasMore 為 true 時以 NextCursor 取得下一頁
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+
+	next *Cursor
+}
+
+// Next 回傳下一頁的游標，沒有下一頁時為 nil，供服務內部逐頁讀取
+func (p *Page[T]) Next() *Cursor {
+	return p.next
+}
+
+// NewPage 以多查詢一筆的結果建立分頁：items 超過 limit 時截斷並以最後一筆產生下一頁的游標
+func NewPage[T any](items []T, limit int, cursorOf func(T) Cursor) *Page[T] {
+	page := &Page[T]{Data: items}
+	if len(items) > limit {
+		page.Data = items[:limit]
+		next := cursorOf(page.Data[limit-1])
+		page.HasMore = true
+		page.NextCursor = next.Encode()
+		page.next = &next
+	}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+	return page
+}
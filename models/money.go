@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/domainerr"
+)
+
+// ErrCurrencyMismatch 代表兩個不同幣別的金額無法直接運算
+var ErrCurrencyMismatch = domainerr.New(domainerr.CodeValidation, "currency mismatch")
+
+// zeroDecimalCurrencies 為 Stripe 以整數表示、沒有小數位的幣別
+// ISK、HUF 與 TWD 在 Stripe API 中仍以兩位小數表示，因此不在此列表中
+// https://docs.stripe.com/currencies#zero-decimal
+var zeroDecimalCurrencies = map[stripe.Currency]struct{}{
+	stripe.CurrencyBIF: {},
+	stripe.CurrencyCLP: {},
+	stripe.CurrencyDJF: {},
+	stripe.CurrencyGNF: {},
+	stripe.CurrencyJPY: {},
+	stripe.CurrencyKMF: {},
+	stripe.CurrencyKRW: {},
+	stripe.CurrencyMGA: {},
+	stripe.CurrencyPYG: {},
+	stripe.CurrencyRWF: {},
+	stripe.CurrencyUGX: {},
+	stripe.CurrencyVND: {},
+	stripe.CurrencyVUV: {},
+	stripe.CurrencyXAF: {},
+	stripe.CurrencyXOF: {},
+	stripe.CurrencyXPF: {},
+}
+
+// threeDecimalCurrencies 為 Stripe 以三位小數表示的幣別，stripe-go 沒有為這些幣別定義常數
+// https://docs.stripe.com/currencies#three-decimal
+var threeDecimalCurrencies = map[stripe.Currency]struct{}{
+	"bhd": {},
+	"jod": {},
+	"kwd": {},
+	"omr": {},
+	"tnd": {},
+}
+
+// CurrencyExponent 回傳幣別最小單位的小數位數，例如 USD 為 2、JPY 為 0、KWD 為 3
+func CurrencyExponent(currency stripe.Currency) int {
+	currency = stripe.Currency(strings.ToLower(string(currency)))
+	if _, ok := zeroDecimalCurrencies[currency]; ok {
+		return 0
+	}
+	if _, ok := threeDecimalCurrencies[currency]; ok {
+		return 3
+	}
+	return 2
+}
+
+// Money 以最小貨幣單位保存金額，與 Stripe API 相同，例如 USD 19.99 為 1999、JPY 500 為 500
+// Money represents an amount in the smallest currency unit, as used by the Stripe API
+type Money struct {
+	Amount   int64           `json:"amount"`
+	Currency stripe.Currency `json:"currency"`
+}
+
+func NewMoney(amount int64, currency stripe.Currency) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+// Major 以主要貨幣單位的十進位字串表示金額，例如 1999 USD 為 "19.99"
+func (m Money) Major() string {
+	exponent := CurrencyExponent(m.Currency)
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Major() + " " + strings.ToUpper(string(m.Currency))
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add 回傳兩個相同幣別金額的總和
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return NewMoney(m.Amount+other.Amount, m.Currency), nil
+}
+
+// Sub 回傳兩個相同幣別金額的差
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return NewMoney(m.Amount-other.Amount, m.Currency), nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if !strings.EqualFold(string(m.Currency), string(other.Currency)) {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
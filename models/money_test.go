@@ -0,0 +1,92 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/models"
+)
+
+func TestCurrencyExponent(t *testing.T) {
+	tests := []struct {
+		currency stripe.Currency
+		want     int
+	}{
+		{stripe.CurrencyJPY, 0},
+		{stripe.CurrencyUSD, 2},
+		{stripe.CurrencyTWD, 2},
+		{"kwd", 3},
+		{"KWD", 3},
+		{"JPY", 0},
+	}
+
+	for _, tt := range tests {
+		if got := models.CurrencyExponent(tt.currency); got != tt.want {
+			t.Errorf("CurrencyExponent(%s) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money models.Money
+		want  string
+	}{
+		{models.NewMoney(500, stripe.CurrencyJPY), "500 JPY"},
+		{models.NewMoney(-500, stripe.CurrencyJPY), "-500 JPY"},
+		{models.NewMoney(1999, stripe.CurrencyUSD), "19.99 USD"},
+		{models.NewMoney(5, stripe.CurrencyUSD), "0.05 USD"},
+		{models.NewMoney(0, stripe.CurrencyUSD), "0.00 USD"},
+		{models.NewMoney(-1999, stripe.CurrencyUSD), "-19.99 USD"},
+		{models.NewMoney(-5, stripe.CurrencyUSD), "-0.05 USD"},
+		{models.NewMoney(12345, "kwd"), "12.345 KWD"},
+		{models.NewMoney(7, "kwd"), "0.007 KWD"},
+		{models.NewMoney(-1000, "kwd"), "-1.000 KWD"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("Money{%d, %s}.String() = %q, want %q", tt.money.Amount, tt.money.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	usd := func(amount int64) models.Money { return models.NewMoney(amount, stripe.CurrencyUSD) }
+
+	tests := []struct {
+		name    string
+		op      func(a, b models.Money) (models.Money, error)
+		a, b    models.Money
+		want    models.Money
+		wantErr error
+	}{
+		{name: "add", op: models.Money.Add, a: usd(1999), b: usd(1), want: usd(2000)},
+		{name: "add negative", op: models.Money.Add, a: usd(1999), b: usd(-2000), want: usd(-1)},
+		{name: "sub", op: models.Money.Sub, a: usd(1999), b: usd(999), want: usd(1000)},
+		{name: "sub below zero", op: models.Money.Sub, a: usd(100), b: usd(250), want: usd(-150)},
+		{name: "currency case differs", op: models.Money.Add, a: usd(100), b: models.NewMoney(100, "USD"), want: usd(200)},
+		{name: "add mismatch", op: models.Money.Add, a: usd(100), b: models.NewMoney(100, stripe.CurrencyJPY), wantErr: models.ErrCurrencyMismatch},
+		{name: "sub mismatch", op: models.Money.Sub, a: usd(100), b: models.NewMoney(100, "kwd"), wantErr: models.ErrCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op(tt.a, tt.b)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
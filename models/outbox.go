@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"goflare.io/payment/sqlc"
+)
+
+// OutboxMessage 為等待發佈的領域事件，與業務資料在同一個交易內寫入
+type OutboxMessage struct {
+	ID            string          `json:"id"`
+	Subject       string          `json:"subject"`
+	SchemaVersion int             `json:"schema_version"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty"`
+	TenantID      string          `json:"tenant_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// NewOutboxMessage 將領域事件序列化為 outbox 訊息，subject 即事件類型
+func NewOutboxMessage(event *DomainEvent) (*OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxMessage{
+		ID:            event.ID,
+		Subject:       string(event.Type),
+		SchemaVersion: event.SchemaVersion,
+		Payload:       payload,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+func (m *OutboxMessage) ConvertFromSQLCOutboxMessage(sqlcMessage any) *OutboxMessage {
+
+	switch sm := sqlcMessage.(type) {
+	case *sqlc.OutboxMessage:
+		m.ID = sm.ID
+		m.Subject = sm.Subject
+		m.SchemaVersion = int(sm.SchemaVersion)
+		m.Payload = sm.Payload
+		m.Attempts = int(sm.Attempts)
+		if sm.LastError != nil {
+			m.LastError = *sm.LastError
+		}
+		if sm.PublishedAt.Valid {
+			publishedAt := sm.PublishedAt.Time
+			m.PublishedAt = &publishedAt
+		}
+		m.TenantID = sm.TenantID
+		m.CreatedAt = sm.CreatedAt.Time
+	default:
+		return nil
+	}
+
+	return m
+}
@@ -11,31 +11,45 @@ import (
 // PaymentIntent 代表一次支付意圖
 // PaymentIntent represents a payment intent
 type PaymentIntent struct {
-	ID               string
-	CustomerID       string
-	Amount           float64
-	Currency         stripe.Currency
-	Status           stripe.PaymentIntentStatus
-	PaymentMethodID  string
-	SetupFutureUsage stripe.PaymentIntentSetupFutureUsage
-	ClientSecret     string
-	CaptureMethod    stripe.PaymentIntentCaptureMethod
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	ID               string                               `json:"id"`
+	CustomerID       string                               `json:"customer_id"`
+	Amount           Money                                `json:"amount"`
+	Status           stripe.PaymentIntentStatus           `json:"status"`
+	PaymentMethodID  string                               `json:"payment_method_id,omitempty"`
+	SetupFutureUsage stripe.PaymentIntentSetupFutureUsage `json:"setup_future_usage,omitempty"`
+	ClientSecret     string                               `json:"client_secret"`
+	CaptureMethod    stripe.PaymentIntentCaptureMethod    `json:"capture_method"`
+	CreatedAt        time.Time                            `json:"created_at"`
+	UpdatedAt        time.Time                            `json:"updated_at"`
+	PaymentIntentConnect
+}
+
+// PaymentIntentConnect 為 Stripe Connect 的 destination charge 設定，未使用 Connect 時為零值
+//   - OnBehalfOf：以連結帳號作為交易商家，影響結算幣別與帳單上的商家名稱
+//   - TransferDestination：付款成功後自動轉帳給連結帳號
+//   - ApplicationFeeAmount：平台從轉帳中保留的手續費，只能與 TransferDestination 一起使用
+type PaymentIntentConnect struct {
+	OnBehalfOf           string `json:"on_behalf_of,omitempty"`
+	TransferDestination  string `json:"transfer_destination,omitempty"`
+	ApplicationFeeAmount int64  `json:"application_fee_amount,omitempty"`
 }
 
 type PartialPaymentIntent struct {
-	ID               string
-	CustomerID       *string
-	Amount           *float64
-	Currency         *stripe.Currency
-	Status           *stripe.PaymentIntentStatus
-	PaymentMethodID  *string
-	SetupFutureUsage *stripe.PaymentIntentSetupFutureUsage
-	ClientSecret     *string
-	CaptureMethod    *stripe.PaymentIntentCaptureMethod
-	CreatedAt        *time.Time
-	UpdatedAt        *time.Time
+	ID                   string
+	CustomerID           *string
+	Amount               *int64
+	Currency             *stripe.Currency
+	Status               *stripe.PaymentIntentStatus
+	PaymentMethodID      *string
+	SetupFutureUsage     *stripe.PaymentIntentSetupFutureUsage
+	ClientSecret         *string
+	CaptureMethod        *stripe.PaymentIntentCaptureMethod
+	OnBehalfOf           *string
+	TransferDestination  *string
+	ApplicationFeeAmount *int64
+	CreatedAt            *time.Time
+	UpdatedAt            *time.Time
+	LastEventAt          *time.Time
 }
 
 func NewPaymentIntent() *PaymentIntent {
@@ -46,12 +60,13 @@ func (pi *PaymentIntent) ConvertFromSQLCPaymentIntent(sqlcPaymentIntent any) *Pa
 
 	var (
 		id, customerID, clientSecret, paymentMethodID string
-		amount                                        float64
+		amount                                        int64
 		captureMethod                                 stripe.PaymentIntentCaptureMethod
 		setupFutureUsage                              stripe.PaymentIntentSetupFutureUsage
 		currency                                      stripe.Currency
 		status                                        stripe.PaymentIntentStatus
 		createdAt, updatedAt                          time.Time
+		connect                                       PaymentIntentConnect
 	)
 
 	switch sp := sqlcPaymentIntent.(type) {
@@ -71,21 +86,70 @@ func (pi *PaymentIntent) ConvertFromSQLCPaymentIntent(sqlcPaymentIntent any) *Pa
 		clientSecret = sp.ClientSecret
 		createdAt = sp.CreatedAt.Time
 		updatedAt = sp.UpdatedAt.Time
+		connect = newPaymentIntentConnect(sp.OnBehalfOf, sp.TransferDestination, sp.ApplicationFeeAmount)
+	case *sqlc.GetPaymentIntentRow:
+		id = sp.ID
+		customerID = sp.CustomerID
+		amount = sp.Amount
+		currency = stripe.Currency(sp.Currency)
+		status = stripe.PaymentIntentStatus(sp.Status)
+		if sp.PaymentMethodID != nil {
+			paymentMethodID = *sp.PaymentMethodID
+		}
+		if sp.SetupFutureUsage.Valid {
+			setupFutureUsage = stripe.PaymentIntentSetupFutureUsage(sp.SetupFutureUsage.PaymentIntentSetupFutureUsage)
+		}
+		captureMethod = stripe.PaymentIntentCaptureMethod(sp.CaptureMethod)
+		clientSecret = sp.ClientSecret
+		createdAt = sp.CreatedAt.Time
+		updatedAt = sp.UpdatedAt.Time
+		connect = newPaymentIntentConnect(sp.OnBehalfOf, sp.TransferDestination, sp.ApplicationFeeAmount)
+	case *sqlc.ListPaymentIntentsRow:
+		id = sp.ID
+		customerID = sp.CustomerID
+		amount = sp.Amount
+		currency = stripe.Currency(sp.Currency)
+		status = stripe.PaymentIntentStatus(sp.Status)
+		if sp.PaymentMethodID != nil {
+			paymentMethodID = *sp.PaymentMethodID
+		}
+		if sp.SetupFutureUsage.Valid {
+			setupFutureUsage = stripe.PaymentIntentSetupFutureUsage(sp.SetupFutureUsage.PaymentIntentSetupFutureUsage)
+		}
+		captureMethod = stripe.PaymentIntentCaptureMethod(sp.CaptureMethod)
+		clientSecret = sp.ClientSecret
+		createdAt = sp.CreatedAt.Time
+		updatedAt = sp.UpdatedAt.Time
+		connect = newPaymentIntentConnect(sp.OnBehalfOf, sp.TransferDestination, sp.ApplicationFeeAmount)
 	default:
 		return nil
 	}
 
 	pi.ID = id
 	pi.CustomerID = customerID
-	pi.Amount = amount
-	pi.Currency = currency
+	pi.Amount = NewMoney(amount, currency)
 	pi.Status = status
 	pi.PaymentMethodID = paymentMethodID
 	pi.SetupFutureUsage = setupFutureUsage
 	pi.ClientSecret = clientSecret
 	pi.CaptureMethod = captureMethod
+	pi.PaymentIntentConnect = connect
 	pi.CreatedAt = createdAt
 	pi.UpdatedAt = updatedAt
 
 	return pi
 }
+
+func newPaymentIntentConnect(onBehalfOf, transferDestination *string, applicationFeeAmount *int64) PaymentIntentConnect {
+	var connect PaymentIntentConnect
+	if onBehalfOf != nil {
+		connect.OnBehalfOf = *onBehalfOf
+	}
+	if transferDestination != nil {
+		connect.TransferDestination = *transferDestination
+	}
+	if applicationFeeAmount != nil {
+		connect.ApplicationFeeAmount = *applicationFeeAmount
+	}
+	return connect
+}
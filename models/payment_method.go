@@ -37,6 +37,8 @@ type PartialPaymentMethod struct {
 	BankAccountBankName *string
 	CreatedAt           *time.Time
 	UpdatedAt           *time.Time
+	LastEventAt         *time.Time
+	DeletedAt           *time.Time
 }
 
 func NewPaymentMethod() *PaymentMethod {
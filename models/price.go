@@ -15,7 +15,7 @@ type Price struct {
 	ProductID              string                        `json:"product_id"`
 	Type                   stripe.PriceType              `json:"type"`
 	Currency               stripe.Currency               `json:"currency"`
-	UnitAmount             float64                       `json:"unit_amount"`
+	UnitAmount             Money                         `json:"unit_amount"`
 	RecurringInterval      stripe.PriceRecurringInterval `json:"recurring_interval,omitempty"`
 	RecurringIntervalCount int32                         `json:"recurring_interval_count,omitempty"`
 	TrialPeriodDays        int32                         `json:"trial_period_days,omitempty"`
@@ -28,13 +28,15 @@ type PartialPrice struct {
 	ProductID              *string
 	Active                 *bool
 	Currency               *stripe.Currency
-	UnitAmount             *float64
+	UnitAmount             *int64
 	Type                   *stripe.PriceType
 	RecurringInterval      *stripe.PriceRecurringInterval
 	RecurringIntervalCount *int32
 	TrialPeriodDays        *int32
 	CreatedAt              *time.Time
 	UpdatedAt              *time.Time
+	LastEventAt            *time.Time
+	DeletedAt              *time.Time
 }
 
 func NewPrice() *Price {
@@ -46,7 +48,7 @@ func (p *Price) ConvertFromSQLCPrice(sqlcPrice any) *Price {
 	var (
 		id, productID                           string
 		recurringIntervalCount, trialPeriodDays int32
-		unitAmount                              float64
+		unitAmount                              int64
 		active                                  bool
 		currency                                stripe.Currency
 		priceType                               stripe.PriceType
@@ -78,7 +80,7 @@ func (p *Price) ConvertFromSQLCPrice(sqlcPrice any) *Price {
 	p.Active = active
 	p.RecurringIntervalCount = recurringIntervalCount
 	p.TrialPeriodDays = trialPeriodDays
-	p.UnitAmount = unitAmount
+	p.UnitAmount = NewMoney(unitAmount, currency)
 	p.Active = active
 	p.Currency = currency
 	p.Type = priceType
@@ -29,6 +29,8 @@ type PartialProduct struct {
 	Metadata    *map[string]string
 	CreatedAt   *time.Time
 	UpdatedAt   *time.Time
+	LastEventAt *time.Time
+	DeletedAt   *time.Time
 }
 
 func NewProduct() *Product {
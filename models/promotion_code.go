@@ -26,4 +26,5 @@ type PartialPromotionCode struct {
 	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
 	CreatedAt      *time.Time `json:"created_at,omitempty"`
 	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+	LastEventAt    *time.Time
 }
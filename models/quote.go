@@ -10,7 +10,7 @@ type Quote struct {
 	ID          string             `json:"id"`
 	CustomerID  string             `json:"customer_id"`
 	Status      stripe.QuoteStatus `json:"status"`
-	AmountTotal int64              `json:"amount_total"`
+	AmountTotal Money              `json:"amount_total"`
 	Currency    stripe.Currency    `json:"currency"`
 	ValidUntil  *time.Time         `json:"valid_until,omitempty"`
 	AcceptedAt  *time.Time         `json:"accepted_at,omitempty"`
@@ -30,4 +30,5 @@ type PartialQuote struct {
 	CanceledAt  *time.Time          `json:"canceled_at,omitempty"`
 	CreatedAt   *time.Time          `json:"created_at,omitempty"`
 	UpdatedAt   *time.Time          `json:"updated_at,omitempty"`
+	LastEventAt *time.Time
 }
@@ -11,7 +11,7 @@ import (
 type Refund struct {
 	ID        string              `json:"id"`
 	ChargeID  string              `json:"charge_id"`
-	Amount    float64             `json:"amount"`
+	Amount    Money               `json:"amount"`
 	Status    stripe.RefundStatus `json:"status"`
 	Reason    stripe.RefundReason `json:"reason"`
 	CreatedAt time.Time           `json:"created_at"`
@@ -19,13 +19,15 @@ type Refund struct {
 }
 
 type PartialRefund struct {
-	ID        string
-	ChargeID  *string
-	Amount    *float64
-	Status    *stripe.RefundStatus
-	Reason    *stripe.RefundReason
-	CreatedAt *time.Time
-	UpdatedAt *time.Time
+	ID          string
+	ChargeID    *string
+	Amount      *int64
+	Currency    *stripe.Currency
+	Status      *stripe.RefundStatus
+	Reason      *stripe.RefundReason
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+	LastEventAt *time.Time
 }
 
 func NewRefund() *Refund {
@@ -35,7 +37,8 @@ func NewRefund() *Refund {
 func (r *Refund) ConvertFromSQLCRefund(sqlcRefund any) *Refund {
 
 	var (
-		amount               float64
+		amount               int64
+		currency             stripe.Currency
 		id, chargeID         string
 		reason               stripe.RefundReason
 		status               stripe.RefundStatus
@@ -47,6 +50,7 @@ func (r *Refund) ConvertFromSQLCRefund(sqlcRefund any) *Refund {
 		id = sp.ID
 		chargeID = sp.ChargeID
 		amount = sp.Amount
+		currency = stripe.Currency(sp.Currency)
 		if sp.Reason.Valid {
 			reason = stripe.RefundReason(sp.Reason.RefundReason)
 		}
@@ -59,7 +63,7 @@ func (r *Refund) ConvertFromSQLCRefund(sqlcRefund any) *Refund {
 
 	r.ID = id
 	r.ChargeID = chargeID
-	r.Amount = amount
+	r.Amount = NewMoney(amount, currency)
 	r.Reason = reason
 	r.Status = status
 	r.CreatedAt = createdAt
@@ -28,4 +28,5 @@ type PartialReview struct {
 	ClosedAt        *time.Time                 `json:"closed_at,omitempty"`
 	CreatedAt       *time.Time                 `json:"created_at,omitempty"`
 	UpdatedAt       *time.Time                 `json:"updated_at,omitempty"`
+	LastEventAt     *time.Time
 }
@@ -38,6 +38,7 @@ type PartialSubscription struct {
 	TrialEnd           *time.Time
 	CreatedAt          *time.Time
 	UpdatedAt          *time.Time
+	LastEventAt        *time.Time
 }
 
 func NewSubscription() *Subscription {
@@ -24,4 +24,5 @@ type PartialTaxRate struct {
 	Active       *bool      `json:"active,omitempty"`
 	CreatedAt    *time.Time `json:"created_at,omitempty"`
 	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	LastEventAt  *time.Time
 }
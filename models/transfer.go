@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+
+	"goflare.io/payment/sqlc"
+)
+
+// Transfer 代表平台轉給連結帳號的款項
+// destination charge 由 Stripe 在付款成功後自動建立轉帳，也可以用 CreateTransfer 另外轉帳（separate charges and transfers）
+type Transfer struct {
+	ID                   string          `json:"id"`
+	DestinationAccountID string          `json:"destination_account_id"`
+	Amount               Money           `json:"amount"`
+	AmountReversed       Money           `json:"amount_reversed"`
+	Currency             stripe.Currency `json:"currency"`
+	Reversed             bool            `json:"reversed"`
+	SourceTransactionID  string          `json:"source_transaction_id,omitempty"`
+	TransferGroup        string          `json:"transfer_group,omitempty"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+}
+
+type PartialTransfer struct {
+	ID                   string
+	DestinationAccountID *string
+	Amount               *int64
+	AmountReversed       *int64
+	Currency             *stripe.Currency
+	Reversed             *bool
+	SourceTransactionID  *string
+	TransferGroup        *string
+	CreatedAt            *time.Time
+	UpdatedAt            *time.Time
+	LastEventAt          *time.Time
+}
+
+// TransferReversal 代表從連結帳號取回的轉帳金額，累計金額記錄在 Transfer.AmountReversed，不另外保存
+type TransferReversal struct {
+	ID         string    `json:"id"`
+	TransferID string    `json:"transfer_id"`
+	Amount     Money     `json:"amount"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewTransfer() *Transfer {
+	return &Transfer{}
+}
+
+func (t *Transfer) ConvertFromSQLCTransfer(sqlcTransfer any) *Transfer {
+
+	var (
+		id, destinationAccountID, sourceTransactionID, transferGroup string
+		amount, amountReversed                                       int64
+		currency                                                     stripe.Currency
+		reversed                                                     bool
+		createdAt, updatedAt                                         time.Time
+	)
+
+	switch sp := sqlcTransfer.(type) {
+	case *sqlc.Transfer:
+		id = sp.ID
+		destinationAccountID = sp.DestinationAccountID
+		amount = sp.Amount
+		amountReversed = sp.AmountReversed
+		currency = stripe.Currency(sp.Currency)
+		reversed = sp.Reversed
+		if sp.SourceTransactionID != nil {
+			sourceTransactionID = *sp.SourceTransactionID
+		}
+		if sp.TransferGroup != nil {
+			transferGroup = *sp.TransferGroup
+		}
+		createdAt = sp.CreatedAt.Time
+		updatedAt = sp.UpdatedAt.Time
+	default:
+		return nil
+	}
+
+	t.ID = id
+	t.DestinationAccountID = destinationAccountID
+	t.Amount = NewMoney(amount, currency)
+	t.AmountReversed = NewMoney(amountReversed, currency)
+	t.Currency = currency
+	t.Reversed = reversed
+	t.SourceTransactionID = sourceTransactionID
+	t.TransferGroup = transferGroup
+	t.CreatedAt = createdAt
+	t.UpdatedAt = updatedAt
+
+	return t
+}
@@ -0,0 +1,160 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/outbox"
+)
+
+const (
+	domainEventSubjectPrefix = "payment"
+
+	defaultOutboxInterval        = time.Second
+	defaultOutboxBatchSize       = 100
+	defaultOutboxStream          = "PAYMENT_DOMAIN_EVENTS"
+	defaultOutboxDuplicateWindow = 10 * time.Minute
+
+	headerSchemaVersion = "Schema-Version"
+)
+
+// OutboxRelay 定期將 outbox 中尚未發佈的領域事件送到 NATS
+// 啟用 JetStream 時以 outbox 訊息 ID 作為 Nats-Msg-Id，relay 重複發佈的訊息會被 stream 丟棄
+type OutboxRelay struct {
+	outbox   outbox.Service
+	natsConn *nats.Conn
+	js       jetstream.JetStream
+	cfg      config.OutboxConfig
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOutboxRelay 建立 relay，js 為 nil 時使用 core NATS 發佈
+func NewOutboxRelay(service outbox.Service, natsConn *nats.Conn, js jetstream.JetStream, cfg config.OutboxConfig, logger *zap.Logger) *OutboxRelay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultOutboxInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOutboxBatchSize
+	}
+	if cfg.Stream == "" {
+		cfg.Stream = defaultOutboxStream
+	}
+	if cfg.DuplicateWindow <= 0 {
+		cfg.DuplicateWindow = defaultOutboxDuplicateWindow
+	}
+
+	return &OutboxRelay{
+		outbox:   service,
+		natsConn: natsConn,
+		js:       js,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Start 建立領域事件的 stream（若啟用 JetStream）並開始定期發佈
+func (r *OutboxRelay) Start() error {
+	if r.js != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), jetStreamSetupTimeout)
+		defer cancel()
+
+		if _, err := r.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:       r.cfg.Stream,
+			Subjects:   []string{domainEventSubjectPrefix + ".>"},
+			Storage:    jetstream.FileStorage,
+			Duplicates: r.cfg.DuplicateWindow,
+		}); err != nil {
+			return fmt.Errorf("failed to create stream %s: %w", r.cfg.Stream, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.runOnce(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop 停止 relay 並等待目前這一批發佈完成
+func (r *OutboxRelay) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// runOnce 持續發佈直到 outbox 清空或某一批沒有全部發佈成功
+// relay 不屬於單一租戶，一次取出所有租戶的訊息，訊息所屬的租戶放在 Tenant-Id header
+func (r *OutboxRelay) runOnce(ctx context.Context) {
+	ctx = driver.WithAllTenants(ctx)
+	for ctx.Err() == nil {
+		published, err := r.outbox.PublishPending(ctx, r.cfg.BatchSize, r.publish)
+		if err != nil {
+			r.logger.Error("Failed to publish outbox messages", zap.Error(err))
+			return
+		}
+		if published < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, message *models.OutboxMessage) (err error) {
+	ctx, span := startPublishSpan(ctx, message.Subject)
+	defer func() { endSpan(span, err) }()
+
+	msg := &nats.Msg{
+		Subject: message.Subject,
+		Data:    message.Payload,
+		Header:  nats.Header{},
+	}
+	msg.Header.Set(jetstream.MsgIDHeader, message.ID)
+	msg.Header.Set(headerSchemaVersion, strconv.Itoa(message.SchemaVersion))
+	msg.Header.Set(headerTenantID, message.TenantID)
+	injectTraceContext(ctx, msg.Header)
+
+	if r.js != nil {
+		publishCtx, cancel := context.WithTimeout(ctx, jetStreamPublishTimeout)
+		defer cancel()
+		_, err = r.js.PublishMsg(publishCtx, msg)
+	} else if err = r.natsConn.PublishMsg(msg); err == nil {
+		err = r.natsConn.FlushWithContext(ctx)
+	}
+
+	if err != nil {
+		r.logger.Warn("Failed to publish domain event",
+			zap.Error(err),
+			zap.String("id", message.ID),
+			zap.Int("attempts", message.Attempts+1))
+	}
+	return err
+}
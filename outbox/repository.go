@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/sqlc"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tx pgx.Tx, message *models.OutboxMessage) error
+	ClaimPending(ctx context.Context, tx pgx.Tx, limit int) ([]*models.OutboxMessage, error)
+	MarkPublished(ctx context.Context, tx pgx.Tx, id string) error
+	RecordFailure(ctx context.Context, tx pgx.Tx, id, lastError string) error
+}
+
+type repository struct {
+	conn driver.PostgresPool
+}
+
+func NewRepository(conn driver.PostgresPool) Repository {
+	return &repository{conn: conn}
+}
+
+// Create 寫入 outbox 訊息，相同 ID 已存在時略過，重新處理同一個 Stripe 事件不會重複發佈
+func (r *repository) Create(ctx context.Context, tx pgx.Tx, message *models.OutboxMessage) error {
+	return sqlc.New(r.conn).WithTx(tx).CreateOutboxMessage(ctx, sqlc.CreateOutboxMessageParams{
+		ID:            message.ID,
+		Subject:       message.Subject,
+		SchemaVersion: int32(message.SchemaVersion),
+		Payload:       message.Payload,
+		CreatedAt:     pgtype.Timestamptz{Time: message.CreatedAt, Valid: true},
+	})
+}
+
+// ClaimPending 依建立順序鎖定尚未發佈的訊息，其他 relay 會略過已鎖定的列
+func (r *repository) ClaimPending(ctx context.Context, tx pgx.Tx, limit int) ([]*models.OutboxMessage, error) {
+	sqlcMessages, err := sqlc.New(r.conn).WithTx(tx).ClaimPendingOutboxMessages(ctx, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*models.OutboxMessage, 0, len(sqlcMessages))
+	for _, sqlcMessage := range sqlcMessages {
+		messages = append(messages, new(models.OutboxMessage).ConvertFromSQLCOutboxMessage(sqlcMessage))
+	}
+	return messages, nil
+}
+
+func (r *repository) MarkPublished(ctx context.Context, tx pgx.Tx, id string) error {
+	return sqlc.New(r.conn).WithTx(tx).MarkOutboxMessagePublished(ctx, sqlc.MarkOutboxMessagePublishedParams{
+		ID:          id,
+		PublishedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+}
+
+func (r *repository) RecordFailure(ctx context.Context, tx pgx.Tx, id, lastError string) error {
+	return sqlc.New(r.conn).WithTx(tx).RecordOutboxMessageFailure(ctx, sqlc.RecordOutboxMessageFailureParams{
+		ID:        id,
+		LastError: &lastError,
+	})
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+// PublishFunc 發佈單筆 outbox 訊息，回傳 nil 代表訊息已送達 broker
+type PublishFunc func(ctx context.Context, message *models.OutboxMessage) error
+
+type Service interface {
+	Add(ctx context.Context, events ...*models.DomainEvent) error
+	PublishPending(ctx context.Context, limit int, publish PublishFunc) (int, error)
+}
+
+type service struct {
+	repo               Repository
+	transactionManager *driver.TransactionManager
+}
+
+func NewService(repo Repository, tm *driver.TransactionManager) Service {
+	return &service{
+		repo:               repo,
+		transactionManager: tm,
+	}
+}
+
+// Add 將領域事件寫入 outbox
+// 在 TransactionManager.WithinTransaction 內呼叫時會加入同一個交易，與業務資料一起提交
+func (s *service) Add(ctx context.Context, events ...*models.DomainEvent) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		for _, event := range events {
+			message, err := models.NewOutboxMessage(event)
+			if err != nil {
+				return fmt.Errorf("failed to encode domain event %s: %w", event.ID, err)
+			}
+			if err = s.repo.Create(ctx, tx, message); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PublishPending 依序發佈一批尚未發佈的訊息，回傳成功發佈的數量
+// 遇到發佈失敗即停止這一批並記錄錯誤，避免後面的訊息先於失敗的訊息送出
+// 發佈成功但標記前中斷的訊息會被再次發佈，消費端應以訊息 ID 去重
+func (s *service) PublishPending(ctx context.Context, limit int, publish PublishFunc) (int, error) {
+	var published int
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		messages, err := s.repo.ClaimPending(ctx, tx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if publishErr := publish(ctx, message); publishErr != nil {
+				if err = s.repo.RecordFailure(ctx, tx, message.ID, publishErr.Error()); err != nil {
+					return err
+				}
+				return nil
+			}
+			if err = s.repo.MarkPublished(ctx, tx, message.ID); err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
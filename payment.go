@@ -3,55 +3,71 @@ package payment
 import (
 	"context"
 
-	"github.com/stripe/stripe-go/v79"
-
 	"goflare.io/payment/models"
 )
 
+// Payment 定義支付服務的操作
+// 會呼叫 Stripe 的寫入操作接受 idempotencyKey 並轉送給 Stripe，呼叫端逾時後以同一個 key 重試不會重複扣款或退款；空字串表示不指定
+// ctx 會帶入每個 Stripe 請求，HTTP 請求取消或 gRPC deadline 到期時立即中止對 Stripe 的呼叫
+// List 系列方法從本地資料庫以 keyset 游標分頁讀取，篩選條件見 models.ListParams
+// 所有方法都以 ctx 中的租戶（driver.WithTenant）讀寫資料並選擇 Stripe 帳號，ctx 沒有租戶時回傳 ErrUnknownTenant
 type Payment interface {
-	CreateCustomer(ctx context.Context, email, name string) error // Interacts with Stripe
+	CreateCustomer(ctx context.Context, idempotencyKey, email, name string) (*models.Customer, error) // Interacts with Stripe
 	GetCustomer(ctx context.Context, customerID string) (*models.Customer, error)
-	UpdateCustomerBalance(customer *models.Customer) error // Interacts with Stripe
-	DeleteCustomer(customerID string) error                // Interacts with Stripe
+	UpdateCustomerBalance(ctx context.Context, idempotencyKey string, customer *models.Customer) error // Interacts with Stripe
+	DeleteCustomer(ctx context.Context, idempotencyKey, customerID string) error                       // Interacts with Stripe
 
-	CreateProduct(req models.Product) error // Interacts with Stripe
+	CreateProduct(ctx context.Context, idempotencyKey string, req models.Product) (*models.Product, error) // Interacts with Stripe
 	GetProductWithActivePrices(ctx context.Context, productID string) (*models.Product, error)
 	GetProductWithAllPrices(ctx context.Context, productID string) (*models.Product, error)
-	UpdateProduct(product *models.Product) error // Interacts with Stripe
-	DeleteProduct(productID string) error        // Interacts with Stripe
-	ListProducts(ctx context.Context) ([]*models.Product, error)
+	UpdateProduct(ctx context.Context, idempotencyKey string, product *models.Product) error // Interacts with Stripe
+	DeleteProduct(ctx context.Context, idempotencyKey, productID string) error               // Interacts with Stripe
+	ListProducts(ctx context.Context, params models.ListParams) (*models.Page[*models.Product], error)
 
-	CreatePrice(price models.Price) error // Interacts with Stripe
-	DeletePrice(priceID string) error     // Interacts with Stripe
+	CreatePrice(ctx context.Context, idempotencyKey string, price models.Price) (*models.Price, error) // Interacts with Stripe
+	DeletePrice(ctx context.Context, idempotencyKey, priceID string) error                             // Interacts with Stripe
 
-	CreateSubscription(customerID, priceID string) error // Interacts with Stripe
+	CreateSubscription(ctx context.Context, idempotencyKey, customerID, priceID string) (*models.Subscription, error) // Interacts with Stripe
 	GetSubscription(ctx context.Context, subscriptionID string) (*models.Subscription, error)
-	UpdateSubscription(subscription *models.Subscription) error             // Interacts with Stripe
-	CancelSubscription(subscriptionID string, cancelAtPeriodEnd bool) error // Interacts with Stripe
-	ListSubscriptions(ctx context.Context, customerID string) ([]*models.Subscription, error)
+	UpdateSubscription(ctx context.Context, idempotencyKey string, subscription *models.Subscription) error      // Interacts with Stripe
+	CancelSubscription(ctx context.Context, idempotencyKey, subscriptionID string, cancelAtPeriodEnd bool) error // Interacts with Stripe
+	ListSubscriptions(ctx context.Context, params models.ListParams) (*models.Page[*models.Subscription], error)
 
-	CreateInvoice(customerID, subscriptionID string) error // Interacts with Stripe
+	CreateInvoice(ctx context.Context, idempotencyKey, customerID, subscriptionID string) error // Interacts with Stripe
 	GetInvoice(ctx context.Context, invoiceID string) (*models.Invoice, error)
-	PayInvoice(invoiceID string) error // Interacts with Stripe
-	ListInvoices(ctx context.Context, customerID string) ([]*models.Invoice, error)
+	PayInvoice(ctx context.Context, idempotencyKey, invoiceID string) error // Interacts with Stripe
+	ListInvoices(ctx context.Context, params models.ListParams) (*models.Page[*models.Invoice], error)
 
 	GetPaymentMethod(ctx context.Context, paymentMethodID string) (*models.PaymentMethod, error)
-	DeletePaymentMethod(ctx context.Context, paymentMethodID string) error // Interacts with Stripe
-	ListPaymentMethods(ctx context.Context, customerID string) ([]*models.PaymentMethod, error)
+	DeletePaymentMethod(ctx context.Context, idempotencyKey, paymentMethodID string) error // Interacts with Stripe
+	ListPaymentMethods(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentMethod], error)
 
-	CreatePaymentIntent(customerID, paymentMethodStripeID string, amount uint64, currency stripe.Currency) error // Interacts with Stripe
+	CreatePaymentIntent(ctx context.Context, idempotencyKey, customerID, paymentMethodStripeID string, amount models.Money, connect models.PaymentIntentConnect) (*models.PaymentIntent, error) // Interacts with Stripe
 	GetPaymentIntent(ctx context.Context, paymentIntentID string) (*models.PaymentIntent, error)
-	ConfirmPaymentIntent(paymentIntentID, paymentMethodID string) error // Interacts with Stripe
-	CancelPaymentIntent(paymentIntentID string) error
-	ListPaymentIntent(ctx context.Context, limit, offset uint64) ([]*models.PaymentIntent, error) // Interacts with Stripe
-	ListPaymentIntentByCustomerID(ctx context.Context, customerID string, limit, offset uint64) ([]*models.PaymentIntent, error)
+	ConfirmPaymentIntent(ctx context.Context, idempotencyKey, paymentIntentID, paymentMethodID string) error // Interacts with Stripe
+	CancelPaymentIntent(ctx context.Context, idempotencyKey, paymentIntentID string) error
+	ListPaymentIntents(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentIntent], error)
 
-	CreateRefund(paymentIntentID, reason string, amount uint64) error // Interacts with Stripe
+	CreateRefund(ctx context.Context, idempotencyKey, paymentIntentID, reason string, amount int64) (*models.Refund, error) // Interacts with Stripe
 	GetRefund(ctx context.Context, refundID string) (*models.Refund, error)
-	UpdateRefund(refundID string, reason string) error // Interacts with Stripe
-	ListRefunds(ctx context.Context, chargeID string) ([]*models.Refund, error)
+	UpdateRefund(ctx context.Context, idempotencyKey, refundID, reason string) error // Interacts with Stripe
+	ListRefunds(ctx context.Context, params models.ListParams) (*models.Page[*models.Refund], error)
+
+	CreateConnectedAccount(ctx context.Context, idempotencyKey string, req models.ConnectedAccountParams) (*models.ConnectedAccount, error) // Interacts with Stripe
+	GetConnectedAccount(ctx context.Context, accountID string) (*models.ConnectedAccount, error)
+	CreateAccountLink(ctx context.Context, idempotencyKey, accountID, refreshURL, returnURL string) (*models.AccountLink, error)                                               // Interacts with Stripe
+	CreateTransfer(ctx context.Context, idempotencyKey, destinationAccountID string, amount models.Money, sourceTransactionID, transferGroup string) (*models.Transfer, error) // Interacts with Stripe
+	GetTransfer(ctx context.Context, transferID string) (*models.Transfer, error)
+	ReverseTransfer(ctx context.Context, idempotencyKey, transferID string, amount int64) (*models.TransferReversal, error) // Interacts with Stripe
+	GetApplicationFee(ctx context.Context, feeID string) (*models.ApplicationFee, error)
 
 	HandleStripeWebhook(ctx context.Context, payload []byte, signature string) error // Interacts with Stripe
+	ListDeadLetterEvents(ctx context.Context, limit int) ([]*DeadLetter, error)
+	ReplayDeadLetterEvent(ctx context.Context, sequence uint64) error
+	WorkerPoolStats() []ShardStats
+	EventPipelineStatus() EventPipelineStatus
+	HasTenant(tenantID string) bool
+	ListFailedEvents(ctx context.Context, limit, offset int) ([]*models.Event, error)
 
 	Close()
 }
@@ -7,12 +7,14 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	"goflare.io/ember"
 	"goflare.io/ember/config"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -21,9 +23,9 @@ type Repository interface {
 	Create(ctx context.Context, tx pgx.Tx, paymentIntent *models.PaymentIntent) error
 	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.PaymentIntent, error)
 	Update(ctx context.Context, tx pgx.Tx, paymentIntent *models.PaymentIntent) error
-	List(ctx context.Context, tx pgx.Tx, limit, offset uint64) ([]*models.PaymentIntent, error)
-	ListByCustomer(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) ([]*models.PaymentIntent, error)
-	Upsert(ctx context.Context, tx pgx.Tx, paymentIntent *models.PartialPaymentIntent) error
+	List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.PaymentIntent], error)
+	Upsert(ctx context.Context, tx pgx.Tx, paymentIntent *models.PartialPaymentIntent) (bool, error)
+	ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.PaymentIntent, error)
 }
 
 type repository struct {
@@ -83,8 +85,8 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, paymentIntent *model
 	if err := sqlc.New(r.conn).WithTx(tx).CreatePaymentIntent(ctx, sqlc.CreatePaymentIntentParams{
 		ID:               paymentIntent.ID,
 		CustomerID:       paymentIntent.CustomerID,
-		Amount:           paymentIntent.Amount,
-		Currency:         sqlc.Currency(paymentIntent.Currency),
+		Amount:           paymentIntent.Amount.Amount,
+		Currency:         sqlc.Currency(paymentIntent.Amount.Currency),
 		Status:           sqlc.PaymentIntentStatus(paymentIntent.Status),
 		PaymentMethodID:  &paymentIntent.PaymentMethodID,
 		SetupFutureUsage: sqlc.NullPaymentIntentSetupFutureUsage{PaymentIntentSetupFutureUsage: sqlc.PaymentIntentSetupFutureUsage(paymentIntent.SetupFutureUsage), Valid: paymentIntent.SetupFutureUsage != ""},
@@ -94,7 +96,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, paymentIntent *model
 		return fmt.Errorf("failed to create payment intent: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("payment_intent:%s", paymentIntent.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_intent:%s", paymentIntent.ID))
 	if err := r.cache.Set(ctx, cacheKey, paymentIntent, config.NewConfig().DefaultExpiration); err != nil {
 		r.logger.Warn("Failed to cache payment intent", zap.Error(err), zap.String("id", paymentIntent.ID))
 	}
@@ -103,7 +105,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, paymentIntent *model
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.PaymentIntent, error) {
-	cacheKey := fmt.Sprintf("payment_intent:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_intent:%s", id))
 
 	paymentIntent, release, err := r.getFromPool(ctx)
 	if err != nil {
@@ -112,13 +114,17 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	defer release()
 
 	found, err := r.cache.Get(ctx, cacheKey, paymentIntent)
+	metrics.ObserveCache("payment_intent", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get payment intent from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
 		return paymentIntent, nil
 	}
 
-	sqlcPaymentIntent, err := sqlc.New(r.conn).WithTx(tx).GetPaymentIntent(ctx, id)
+	sqlcPaymentIntent, err := sqlc.New(r.conn).WithTx(tx).GetPaymentIntent(ctx, sqlc.GetPaymentIntentParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payment intent: %w", err)
 	}
@@ -140,12 +146,13 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, paymentIntent *model
 		PaymentMethodID:  &paymentIntent.PaymentMethodID,
 		SetupFutureUsage: sqlc.NullPaymentIntentSetupFutureUsage{PaymentIntentSetupFutureUsage: sqlc.PaymentIntentSetupFutureUsage(paymentIntent.SetupFutureUsage), Valid: paymentIntent.SetupFutureUsage != ""},
 		ClientSecret:     paymentIntent.ClientSecret,
+		TenantID:         driver.TenantID(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update payment intent: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("payment_intent:%s", paymentIntent.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_intent:%s", paymentIntent.ID))
 	if err = r.cache.Set(ctx, cacheKey, paymentIntent, config.NewConfig().DefaultExpiration); err != nil {
 		r.logger.Warn("Failed to update payment intent in cache", zap.Error(err), zap.String("id", paymentIntent.ID))
 	}
@@ -153,101 +160,56 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, paymentIntent *model
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, tx pgx.Tx, limit, offset uint64) ([]*models.PaymentIntent, error) {
-	cacheKey := fmt.Sprintf("payment_intents:limit:%d:offset:%d", limit, offset)
-	var cachedPaymentIntents []*models.PaymentIntent
-	found, err := r.cache.Get(ctx, cacheKey, &cachedPaymentIntents)
-	if err != nil {
-		r.logger.Warn("Failed to get payment intents from cache", zap.Error(err))
-	} else if found {
-		return cachedPaymentIntents, nil
+// List 依 (created_at, id) 遞減以 keyset 分頁列出支付意圖，多查詢一筆判斷是否還有下一頁
+func (r *repository) List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.PaymentIntent], error) {
+	limit := params.PageSize()
+	arg := sqlc.ListPaymentIntentsParams{
+		CreatedFrom: pgtype.Timestamptz{Time: params.CreatedFrom, Valid: !params.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: params.CreatedTo, Valid: !params.CreatedTo.IsZero()},
+		Limit:       int64(limit + 1),
+		TenantID:    driver.TenantID(ctx),
 	}
-
-	sqlcPaymentIntents, err := sqlc.New(r.conn).WithTx(tx).ListPaymentIntents(ctx, sqlc.ListPaymentIntentsParams{
-		Limit:  int64(limit),
-		Offset: int64(offset),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list payment intents: %w", err)
+	if params.CustomerID != "" {
+		arg.CustomerID = &params.CustomerID
 	}
-
-	paymentIntents := make([]*models.PaymentIntent, 0, len(sqlcPaymentIntents))
-	for _, sqlcPaymentIntent := range sqlcPaymentIntents {
-		pi, release, err := r.getFromPool(ctx)
-		if err != nil {
-			r.logger.Error("Failed to get payment intent from pool", zap.Error(err))
-			continue
+	if params.Status != "" {
+		status := sqlc.PaymentIntentStatus(params.Status)
+		if !status.Valid() {
+			return nil, fmt.Errorf("%w: payment intent status %q", models.ErrInvalidListFilter, params.Status)
 		}
-
-		*pi = *models.NewPaymentIntent().ConvertFromSQLCPaymentIntent(sqlcPaymentIntent)
-		paymentIntents = append(paymentIntents, pi)
-
-		// 更新單個 PaymentIntent 的緩存
-		singleCacheKey := fmt.Sprintf("payment_intent:%s", pi.ID)
-		if err = r.cache.Set(ctx, singleCacheKey, pi, config.NewConfig().DefaultExpiration); err != nil {
-			r.logger.Warn("Failed to cache single payment intent", zap.Error(err), zap.String("id", pi.ID))
+		arg.Status = sqlc.NullPaymentIntentStatus{PaymentIntentStatus: status, Valid: true}
+	}
+	if params.Currency != "" {
+		currency := sqlc.Currency(params.Currency)
+		if !currency.Valid() {
+			return nil, fmt.Errorf("%w: currency %q", models.ErrInvalidListFilter, params.Currency)
 		}
-
-		release()
+		arg.Currency = sqlc.NullCurrency{Currency: currency, Valid: true}
 	}
-
-	if err = r.cache.Set(ctx, cacheKey, paymentIntents); err != nil {
-		r.logger.Warn("Failed to cache payment intents list", zap.Error(err))
+	if params.Cursor != nil {
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: params.Cursor.CreatedAt, Valid: true}
+		arg.CursorID = &params.Cursor.ID
 	}
 
-	return paymentIntents, nil
-}
-
-func (r *repository) ListByCustomer(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) ([]*models.PaymentIntent, error) {
-	cacheKey := fmt.Sprintf("payment_intents:customer:%s:limit:%d:offset:%d", customerID, limit, offset)
-	var cachedPaymentIntents []*models.PaymentIntent
-	found, err := r.cache.Get(ctx, cacheKey, &cachedPaymentIntents)
-	if err != nil {
-		r.logger.Warn("Failed to get payment intents from cache", zap.Error(err), zap.String("customerID", customerID))
-	} else if found {
-		return cachedPaymentIntents, nil
-	}
-
-	sqlcPaymentIntents, err := sqlc.New(r.conn).WithTx(tx).ListPaymentIntentsByCustomer(ctx, sqlc.ListPaymentIntentsByCustomerParams{
-		CustomerID: customerID,
-		Limit:      int64(limit),
-		Offset:     int64(offset),
-	})
+	sqlcPaymentIntents, err := sqlc.New(r.conn).WithTx(tx).ListPaymentIntents(ctx, arg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payment intents: %w", err)
 	}
 
 	paymentIntents := make([]*models.PaymentIntent, 0, len(sqlcPaymentIntents))
 	for _, sqlcPaymentIntent := range sqlcPaymentIntents {
-		pi, release, err := r.getFromPool(ctx)
-		if err != nil {
-			r.logger.Error("Failed to get payment intent from pool", zap.Error(err))
-			continue
-		}
-
-		*pi = *models.NewPaymentIntent().ConvertFromSQLCPaymentIntent(sqlcPaymentIntent)
-		paymentIntents = append(paymentIntents, pi)
-
-		// 更新單個 PaymentIntent 的緩存
-		singleCacheKey := fmt.Sprintf("payment_intent:%s", pi.ID)
-		if err = r.cache.Set(ctx, singleCacheKey, pi, config.NewConfig().DefaultExpiration); err != nil {
-			r.logger.Warn("Failed to cache single payment intent", zap.Error(err), zap.String("id", pi.ID))
-		}
-
-		release()
-	}
-
-	if err = r.cache.Set(ctx, cacheKey, paymentIntents, 5*time.Minute); err != nil {
-		r.logger.Warn("Failed to cache payment intents list", zap.Error(err), zap.String("customerID", customerID))
+		paymentIntents = append(paymentIntents, models.NewPaymentIntent().ConvertFromSQLCPaymentIntent(sqlcPaymentIntent))
 	}
 
-	return paymentIntents, nil
+	return models.NewPage(paymentIntents, limit, func(item *models.PaymentIntent) models.Cursor {
+		return models.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+	}), nil
 }
 
-func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentIntent *models.PartialPaymentIntent) error {
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentIntent *models.PartialPaymentIntent) (bool, error) {
 	const query = `
-    INSERT INTO payment_intents (id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at)
-    VALUES (@id, @customer_id, @amount, @currency, @status, @payment_method_id, @setup_future_usage, @client_secret,@capture_method, COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO payment_intents (id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, on_behalf_of, transfer_destination, application_fee_amount, created_at, updated_at, last_event_at)
+    VALUES (@id, @customer_id, @amount, @currency, @status, @payment_method_id, @setup_future_usage, @client_secret,@capture_method, @on_behalf_of, @transfer_destination, @application_fee_amount, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, payment_intents.customer_id),
         amount = COALESCE(@amount, payment_intents.amount),
@@ -257,28 +219,64 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentIntent *model
         setup_future_usage = COALESCE(@setup_future_usage, payment_intents.setup_future_usage),
         client_secret = COALESCE(@client_secret, payment_intents.client_secret),
         capture_method = COALESCE(@capture_method, payment_intents.capture_method),
+        on_behalf_of = COALESCE(@on_behalf_of, payment_intents.on_behalf_of),
+        transfer_destination = COALESCE(@transfer_destination, payment_intents.transfer_destination),
+        application_fee_amount = COALESCE(@application_fee_amount, payment_intents.application_fee_amount),
+        last_event_at = COALESCE(@last_event_at, payment_intents.last_event_at),
         updated_at = @updated_at
     WHERE payment_intents.id = @id
+      AND payment_intents.tenant_id = @tenant_id
+      AND (payment_intents.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR payment_intents.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
 	args := pgx.NamedArgs{
-		"id":                 paymentIntent.ID,
-		"customer_id":        paymentIntent.CustomerID,
-		"amount":             paymentIntent.Amount,
-		"currency":           paymentIntent.Currency,
-		"status":             paymentIntent.Status,
-		"payment_method_id":  paymentIntent.PaymentMethodID,
-		"setup_future_usage": paymentIntent.SetupFutureUsage,
-		"client_secret":      paymentIntent.ClientSecret,
-		"capture_method":     paymentIntent.CaptureMethod,
-		"created_at":         paymentIntent.CreatedAt,
-		"updated_at":         now,
+		"id":                     paymentIntent.ID,
+		"customer_id":            paymentIntent.CustomerID,
+		"amount":                 paymentIntent.Amount,
+		"currency":               paymentIntent.Currency,
+		"status":                 paymentIntent.Status,
+		"payment_method_id":      paymentIntent.PaymentMethodID,
+		"setup_future_usage":     paymentIntent.SetupFutureUsage,
+		"client_secret":          paymentIntent.ClientSecret,
+		"capture_method":         paymentIntent.CaptureMethod,
+		"on_behalf_of":           paymentIntent.OnBehalfOf,
+		"transfer_destination":   paymentIntent.TransferDestination,
+		"application_fee_amount": paymentIntent.ApplicationFeeAmount,
+		"created_at":             paymentIntent.CreatedAt,
+		"updated_at":             now,
+		"last_event_at":          paymentIntent.LastEventAt,
+		"tenant_id":              driver.TenantID(ctx),
+	}
+
+	tag, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert payment intent: %w", err)
 	}
 
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert payment intent: %w", err)
+	// 清除緩存，避免 GetByID 讀到 webhook 或對帳更新前的資料
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_intent:%s", paymentIntent.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete payment intent from cache", zap.Error(err), zap.String("id", paymentIntent.ID))
 	}
 
-	return nil
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListByIDs 直接從資料庫讀取指定 ID 的支付意圖，不經過緩存，供對帳比對使用
+func (r *repository) ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.PaymentIntent, error) {
+	sqlcPaymentIntents, err := sqlc.New(r.conn).WithTx(tx).ListPaymentIntentsByIDs(ctx, sqlc.ListPaymentIntentsByIDsParams{
+		Ids:      ids,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment intents by ids: %w", err)
+	}
+
+	paymentIntents := make([]*models.PaymentIntent, 0, len(sqlcPaymentIntents))
+	for _, sqlcPaymentIntent := range sqlcPaymentIntents {
+		paymentIntents = append(paymentIntents, models.NewPaymentIntent().ConvertFromSQLCPaymentIntent(sqlcPaymentIntent))
+	}
+
+	return paymentIntents, nil
 }
@@ -16,12 +16,12 @@ type Service interface {
 	Create(ctx context.Context, paymentIntent *models.PaymentIntent) error
 	GetByID(ctx context.Context, id string) (*models.PaymentIntent, error)
 	Update(ctx context.Context, paymentIntent *models.PaymentIntent) error
-	List(ctx context.Context, limit, offset uint64) ([]*models.PaymentIntent, error)
-	ListByCustomer(ctx context.Context, customerID string, limit, offset uint64) ([]*models.PaymentIntent, error)
+	List(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentIntent], error)
 	Confirm(ctx context.Context, id string, paymentMethodID string) error
 	Failed(ctx context.Context, id string, paymentMethodID string) error
 	Cancel(ctx context.Context, id string) error
-	Upsert(ctx context.Context, paymentIntent *models.PartialPaymentIntent) error
+	Upsert(ctx context.Context, paymentIntent *models.PartialPaymentIntent) (bool, error)
+	ListByIDs(ctx context.Context, ids []string) ([]*models.PaymentIntent, error)
 }
 
 type service struct {
@@ -72,24 +72,14 @@ func (s *service) Update(ctx context.Context, paymentIntent *models.PaymentInten
 	})
 }
 
-func (s *service) List(ctx context.Context, limit, offset uint64) ([]*models.PaymentIntent, error) {
-	var paymentIntents []*models.PaymentIntent
+func (s *service) List(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentIntent], error) {
+	var page *models.Page[*models.PaymentIntent]
 	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		var err error
-		paymentIntents, err = s.repo.List(ctx, tx, limit, offset)
+		page, err = s.repo.List(ctx, tx, params)
 		return err
 	})
-	return paymentIntents, err
-}
-
-func (s *service) ListByCustomer(ctx context.Context, customerID string, limit, offset uint64) ([]*models.PaymentIntent, error) {
-	var paymentIntents []*models.PaymentIntent
-	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		var err error
-		paymentIntents, err = s.repo.ListByCustomer(ctx, tx, customerID, limit, offset)
-		return err
-	})
-	return paymentIntents, err
+	return page, err
 }
 
 func (s *service) Confirm(ctx context.Context, id, paymentMethodID string) error {
@@ -148,8 +138,22 @@ func (s *service) Cancel(ctx context.Context, id string) error {
 	})
 }
 
-func (s *service) Upsert(ctx context.Context, paymentIntent *models.PartialPaymentIntent) error {
-	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		return s.repo.Upsert(ctx, tx, paymentIntent)
+func (s *service) Upsert(ctx context.Context, paymentIntent *models.PartialPaymentIntent) (bool, error) {
+	var applied bool
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		applied, err = s.repo.Upsert(ctx, tx, paymentIntent)
+		return err
 	})
+	return applied, err
+}
+
+func (s *service) ListByIDs(ctx context.Context, ids []string) ([]*models.PaymentIntent, error) {
+	var paymentIntents []*models.PaymentIntent
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		paymentIntents, err = s.repo.ListByIDs(ctx, tx, ids)
+		return err
+	})
+	return paymentIntents, err
 }
@@ -27,26 +27,31 @@ func NewRepository(conn driver.PostgresPool) Repository {
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentLink *models.PartialPaymentLink) error {
 	const query = `
-    INSERT INTO payment_links (id, active, url, amount, currency, created_at, updated_at)
-    VALUES (@id, @active, @url, @amount, @currency, COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO payment_links (id, active, url, amount, currency, created_at, updated_at, last_event_at)
+    VALUES (@id, @active, @url, @amount, @currency, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         active = COALESCE(@active, payment_links.active),
         url = COALESCE(@url, payment_links.url),
         amount = COALESCE(@amount, payment_links.amount),
         currency = COALESCE(@currency, payment_links.currency),
+        last_event_at = COALESCE(@last_event_at, payment_links.last_event_at),
         updated_at = @updated_at
     WHERE payment_links.id = @id
+      AND payment_links.tenant_id = @tenant_id
+      AND (payment_links.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR payment_links.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
 	args := pgx.NamedArgs{
-		"id":         paymentLink.ID,
-		"active":     paymentLink.Active,
-		"url":        paymentLink.URL,
-		"amount":     paymentLink.Amount,
-		"currency":   paymentLink.Currency,
-		"created_at": paymentLink.CreatedAt,
-		"updated_at": now,
+		"id":            paymentLink.ID,
+		"active":        paymentLink.Active,
+		"url":           paymentLink.URL,
+		"amount":        paymentLink.Amount,
+		"currency":      paymentLink.Currency,
+		"created_at":    paymentLink.CreatedAt,
+		"updated_at":    now,
+		"last_event_at": paymentLink.LastEventAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
 
 	if _, err := tx.Exec(ctx, query, args); err != nil {
@@ -57,5 +62,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentLink *models.
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeletePaymentLink(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeletePaymentLink(ctx, sqlc.DeletePaymentLinkParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
@@ -8,11 +8,13 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -22,8 +24,9 @@ type Repository interface {
 	GetByID(ctx context.Context, tx pgx.Tx, id string) (*AutoReleasePaymentMethod, error)
 	Update(ctx context.Context, tx pgx.Tx, paymentMethod *models.PaymentMethod) error
 	Delete(ctx context.Context, tx pgx.Tx, id string) error
-	List(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) (*AutoReleasePaymentMethods, error)
+	List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.PaymentMethod], error)
 	Upsert(ctx context.Context, tx pgx.Tx, paymentMethod *models.PartialPaymentMethod) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, paymentMethods []*models.PartialPaymentMethod) error
 }
 
 type repository struct {
@@ -38,11 +41,6 @@ type AutoReleasePaymentMethod struct {
 	release func()
 }
 
-type AutoReleasePaymentMethods struct {
-	PaymentMethods []*models.PaymentMethod
-	release        func()
-}
-
 func NewRepository(conn driver.PostgresPool, logger *zap.Logger, cache *ember.MultiCache, poolManager ignite.Manager) (Repository, error) {
 	err := poolManager.RegisterPool(reflect.TypeOf(&models.PaymentMethod{}), ignite.Config[any]{
 		InitialSize: 10,
@@ -106,7 +104,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, paymentMethod *model
 	}
 
 	// 將新創建的支付方法加入緩存
-	cacheKey := fmt.Sprintf("payment_method:%s", paymentMethod.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_method:%s", paymentMethod.ID))
 	if err = r.cache.Set(ctx, cacheKey, paymentMethod); err != nil {
 		r.logger.Warn("Failed to cache new payment method", zap.Error(err), zap.String("id", paymentMethod.ID))
 	}
@@ -115,11 +113,12 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, paymentMethod *model
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*AutoReleasePaymentMethod, error) {
-	cacheKey := fmt.Sprintf("payment_method:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_method:%s", id))
 
 	// 嘗試從緩存中獲取
 	var cachedPM models.PaymentMethod
 	found, err := r.cache.Get(ctx, cacheKey, &cachedPM)
+	metrics.ObserveCache("payment_method", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get payment method from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
@@ -140,7 +139,10 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*AutoRe
 		return nil, err
 	}
 
-	sqlcPaymentMethod, err := sqlc.New(r.conn).WithTx(tx).GetPaymentMethod(ctx, id)
+	sqlcPaymentMethod, err := sqlc.New(r.conn).WithTx(tx).GetPaymentMethod(ctx, sqlc.GetPaymentMethodParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		release()
 		return nil, fmt.Errorf("failed to get payment method: %w", err)
@@ -161,7 +163,10 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*AutoRe
 
 func (r *repository) Update(ctx context.Context, tx pgx.Tx, paymentMethod *models.PaymentMethod) error {
 	// 首先獲取當前的支付方法，以獲取 updated_at 值
-	currentPM, err := sqlc.New(r.conn).WithTx(tx).GetPaymentMethod(ctx, paymentMethod.ID)
+	currentPM, err := sqlc.New(r.conn).WithTx(tx).GetPaymentMethod(ctx, sqlc.GetPaymentMethodParams{
+		ID:       paymentMethod.ID,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to get current payment method: %w", err)
 	}
@@ -178,6 +183,7 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, paymentMethod *model
 		BankAccountBankName: &paymentMethod.BankAccountBankName,
 		IsDefault:           paymentMethod.IsDefault,
 		UpdatedAt:           currentPM.UpdatedAt, // 使用當前的 updated_at 值
+		TenantID:            driver.TenantID(ctx),
 	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return fmt.Errorf("payment method not found: %w", err)
@@ -186,7 +192,7 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, paymentMethod *model
 	}
 
 	// 更新緩存
-	cacheKey := fmt.Sprintf("payment_method:%s", paymentMethod.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_method:%s", paymentMethod.ID))
 	if err := r.cache.Set(ctx, cacheKey, paymentMethod); err != nil {
 		r.logger.Warn("Failed to update payment method in cache", zap.Error(err), zap.String("id", paymentMethod.ID))
 	}
@@ -195,12 +201,15 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, paymentMethod *model
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	if err := sqlc.New(r.conn).WithTx(tx).DeletePaymentMethod(ctx, id); err != nil {
+	if err := sqlc.New(r.conn).WithTx(tx).DeletePaymentMethod(ctx, sqlc.DeletePaymentMethodParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	}); err != nil {
 		return fmt.Errorf("failed to delete payment method: %w", err)
 	}
 
 	// 從緩存中刪除
-	cacheKey := fmt.Sprintf("payment_method:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_method:%s", id))
 	if err := r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete payment method from cache", zap.Error(err), zap.String("id", id))
 	}
@@ -208,53 +217,72 @@ func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) (*AutoReleasePaymentMethods, error) {
+// List 依 (created_at, id) 遞減以 keyset 分頁列出支付方式，多查詢一筆判斷是否還有下一頁
+func (r *repository) List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.PaymentMethod], error) {
+	limit := params.PageSize()
+	arg := sqlc.ListPaymentMethodsParams{
+		CreatedFrom: pgtype.Timestamptz{Time: params.CreatedFrom, Valid: !params.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: params.CreatedTo, Valid: !params.CreatedTo.IsZero()},
+		Limit:       int64(limit + 1),
+		TenantID:    driver.TenantID(ctx),
+	}
+	if params.CustomerID != "" {
+		arg.CustomerID = &params.CustomerID
+	}
+	if params.Cursor != nil {
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: params.Cursor.CreatedAt, Valid: true}
+		arg.CursorID = &params.Cursor.ID
+	}
 
-	sqlcPaymentMethods, err := sqlc.New(r.conn).WithTx(tx).ListPaymentMethods(ctx, sqlc.ListPaymentMethodsParams{
-		CustomerID: customerID,
-		Limit:      int64(limit),
-		Offset:     int64(offset),
-	})
+	sqlcPaymentMethods, err := sqlc.New(r.conn).WithTx(tx).ListPaymentMethods(ctx, arg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list payment methods: %w", err)
 	}
 
 	paymentMethods := make([]*models.PaymentMethod, 0, len(sqlcPaymentMethods))
-	var releaseFunc []func()
-
-	for _, sqlcPM := range sqlcPaymentMethods {
-		pm, release, err := r.getFromPool(ctx)
-		if err != nil {
-			for _, rf := range releaseFunc {
-				rf()
-			}
-			return nil, fmt.Errorf("failed to get payment method from pool: %w", err)
-		}
-		*pm = *models.NewPaymentMethod().ConvertFromSQLCPaymentMethod(sqlcPM)
-		paymentMethods = append(paymentMethods, pm)
-		releaseFunc = append(releaseFunc, release)
-
-		// 更新每個 PaymentMethod 的緩存
-		cacheKey := fmt.Sprintf("payment_method:%s", pm.ID)
-		if err = r.cache.Set(ctx, cacheKey, pm); err != nil {
-			r.logger.Warn("Failed to cache payment method during list", zap.Error(err), zap.String("id", pm.ID))
-		}
+	for _, sqlcPaymentMethod := range sqlcPaymentMethods {
+		paymentMethods = append(paymentMethods, models.NewPaymentMethod().ConvertFromSQLCPaymentMethod(sqlcPaymentMethod))
 	}
 
-	return &AutoReleasePaymentMethods{
-		PaymentMethods: paymentMethods,
-		release: func() {
-			for _, rf := range releaseFunc {
-				rf()
-			}
-		},
-	}, nil
+	return models.NewPage(paymentMethods, limit, func(item *models.PaymentMethod) models.Cursor {
+		return models.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+	}), nil
 }
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentMethod *models.PartialPaymentMethod) error {
-	const query = `
-    INSERT INTO payment_methods (id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, created_at, updated_at)
-    VALUES (@id, @customer_id, @type, @card_last4, @card_brand, @card_exp_month, @card_exp_year, @bank_account_last4, @bank_account_bank_name, COALESCE(@created_at, NOW()), @updated_at)
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, paymentMethod)); err != nil {
+		return fmt.Errorf("failed to upsert payment method: %w", err)
+	}
+
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("payment_method:%s", paymentMethod.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete payment method from cache", zap.Error(err), zap.String("id", paymentMethod.ID))
+	}
+
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, paymentMethods []*models.PartialPaymentMethod) error {
+	if len(paymentMethods) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, paymentMethod := range paymentMethods {
+		batch.Queue(upsertQuery, upsertArgs(ctx, paymentMethod))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert payment methods: %w", err)
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO payment_methods (id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, created_at, updated_at, last_event_at, deleted_at)
+    VALUES (@id, @customer_id, @type, @card_last4, @card_brand, @card_exp_month, @card_exp_year, @bank_account_last4, @bank_account_bank_name, COALESCE(@created_at, NOW()), @updated_at, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, payment_methods.customer_id),
         type = COALESCE(@type, payment_methods.type),
@@ -264,12 +292,19 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentMethod *model
         card_exp_year = COALESCE(@card_exp_year, payment_methods.card_exp_year),
         bank_account_last4 = COALESCE(@bank_account_last4, payment_methods.bank_account_last4),
         bank_account_bank_name = COALESCE(@bank_account_bank_name, payment_methods.bank_account_bank_name),
+        last_event_at = COALESCE(@last_event_at, payment_methods.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at
     WHERE payment_methods.id = @id
+      AND payment_methods.tenant_id = @tenant_id
+      AND payment_methods.deleted_at IS NULL
+      AND (payment_methods.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR payment_methods.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, paymentMethod *models.PartialPaymentMethod) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":                     paymentMethod.ID,
 		"customer_id":            paymentMethod.CustomerID,
 		"type":                   paymentMethod.Type,
@@ -281,11 +316,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, paymentMethod *model
 		"bank_account_bank_name": paymentMethod.BankAccountBankName,
 		"created_at":             paymentMethod.CreatedAt,
 		"updated_at":             now,
+		"last_event_at":          paymentMethod.LastEventAt,
+		"deleted_at":             paymentMethod.DeletedAt,
+		"tenant_id":              driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert payment method: %w", err)
-	}
-
-	return nil
 }
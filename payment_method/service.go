@@ -17,9 +17,10 @@ type Service interface {
 	GetByID(ctx context.Context, id string) (*models.PaymentMethod, error)
 	Update(ctx context.Context, paymentMethod *models.PaymentMethod) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, customerID string, limit, offset uint64) ([]*models.PaymentMethod, error)
+	List(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentMethod], error)
 	SetDefault(ctx context.Context, customerID, paymentMethodID string) error
 	Upsert(ctx context.Context, paymentMethod *models.PartialPaymentMethod) error
+	UpsertBatch(ctx context.Context, paymentMethods []*models.PartialPaymentMethod) error
 }
 
 type service struct {
@@ -38,13 +39,12 @@ func NewService(repo Repository, tm *driver.TransactionManager, logger *zap.Logg
 
 func (s *service) Create(ctx context.Context, paymentMethod *models.PaymentMethod) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		existingMethods, err := s.repo.List(ctx, tx, paymentMethod.CustomerID, 1, 0)
+		existingMethods, err := s.repo.List(ctx, tx, models.ListParams{CustomerID: paymentMethod.CustomerID, Limit: 1})
 		if err != nil {
 			return fmt.Errorf("failed to check existing payment methods: %w", err)
 		}
-		defer existingMethods.release()
 
-		if len(existingMethods.PaymentMethods) == 0 {
+		if len(existingMethods.Data) == 0 {
 			paymentMethod.IsDefault = true
 		}
 
@@ -102,39 +102,41 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	})
 }
 
-func (s *service) List(ctx context.Context, customerID string, limit, offset uint64) ([]*models.PaymentMethod, error) {
-	var result []*models.PaymentMethod
+func (s *service) List(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentMethod], error) {
+	var page *models.Page[*models.PaymentMethod]
 	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		autoReleasePaymentMethods, err := s.repo.List(ctx, tx, customerID, limit, offset)
-		if err != nil {
-			return err
-		}
-		defer autoReleasePaymentMethods.release()
-		result = autoReleasePaymentMethods.PaymentMethods
-		return nil
+		var err error
+		page, err = s.repo.List(ctx, tx, params)
+		return err
 	})
-	return result, err
+	return page, err
 }
 
 func (s *service) SetDefault(ctx context.Context, customerID, paymentMethodID string) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		paymentMethods, err := s.repo.List(ctx, tx, customerID, 1000, 0)
-		if err != nil {
-			return fmt.Errorf("failed to list payment methods: %w", err)
-		}
-		defer paymentMethods.release()
-
 		var targetMethod *models.PaymentMethod
-		for _, pm := range paymentMethods.PaymentMethods {
-			if pm.ID == paymentMethodID {
-				targetMethod = pm
+		params := models.ListParams{CustomerID: customerID, Limit: models.MaxListLimit}
+		for {
+			paymentMethods, err := s.repo.List(ctx, tx, params)
+			if err != nil {
+				return fmt.Errorf("failed to list payment methods: %w", err)
 			}
-			if pm.IsDefault {
-				pm.IsDefault = false
-				if err := s.repo.Update(ctx, tx, pm); err != nil {
-					return fmt.Errorf("failed to unset default payment method: %w", err)
+
+			for _, pm := range paymentMethods.Data {
+				if pm.ID == paymentMethodID {
+					targetMethod = pm
+				}
+				if pm.IsDefault {
+					pm.IsDefault = false
+					if err := s.repo.Update(ctx, tx, pm); err != nil {
+						return fmt.Errorf("failed to unset default payment method: %w", err)
+					}
 				}
 			}
+
+			if params.Cursor = paymentMethods.Next(); params.Cursor == nil {
+				break
+			}
 		}
 
 		if targetMethod == nil {
@@ -142,7 +144,7 @@ func (s *service) SetDefault(ctx context.Context, customerID, paymentMethodID st
 		}
 
 		targetMethod.IsDefault = true
-		if err = s.repo.Update(ctx, tx, targetMethod); err != nil {
+		if err := s.repo.Update(ctx, tx, targetMethod); err != nil {
 			return fmt.Errorf("failed to set default payment method: %w", err)
 		}
 
@@ -155,3 +157,9 @@ func (s *service) Upsert(ctx context.Context, paymentMethod *models.PartialPayme
 		return s.repo.Upsert(ctx, tx, paymentMethod)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, paymentMethods []*models.PartialPaymentMethod) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, paymentMethods)
+	})
+}
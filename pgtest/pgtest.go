@@ -0,0 +1,90 @@
+// Package pgtest 提供需要 Postgres 的測試使用的資料庫
+// 以 PAYMENT_TEST_POSTGRES_URL 指定連線，未設定時略過測試；每個測試使用獨立的 schema，結束時刪除
+package pgtest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/migrate"
+	"goflare.io/payment/migrations"
+)
+
+// URLEnv 為測試使用的 Postgres 連線字串，可以是 URL 或 key=value 格式
+const URLEnv = "PAYMENT_TEST_POSTGRES_URL"
+
+// Connect 建立空的 schema 並回傳只使用該 schema 的連線池，與服務相同依 ctx 的租戶設定連線
+func Connect(t testing.TB) driver.PostgresPool {
+	t.Helper()
+
+	dsn := os.Getenv(URLEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", URLEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	admin, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", URLEnv, err)
+	}
+
+	schema := fmt.Sprintf("pgtest_%d", time.Now().UnixNano())
+	if _, err = admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		_ = admin.Close(ctx)
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+		_ = admin.Close(ctx)
+	})
+
+	db, err := driver.ConnectSQL(withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("failed to connect to schema %s: %v", schema, err)
+	}
+	pool := db.Pool
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// Migrate 與 Connect 相同，並執行全部的 migration
+func Migrate(t testing.TB) driver.PostgresPool {
+	t.Helper()
+
+	pool := Connect(t)
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err = migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	return pool
+}
+
+// withSearchPath 在連線字串加上 search_path，連線後建立與查詢的物件都在 schema 中
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn) + " search_path=" + schema
+}
@@ -12,6 +12,7 @@ import (
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -24,6 +25,7 @@ type Repository interface {
 	List(ctx context.Context, tx pgx.Tx, productID string) ([]*models.Price, error)
 	ListActive(ctx context.Context, tx pgx.Tx, productID string) ([]*models.Price, error)
 	Upsert(ctx context.Context, tx pgx.Tx, price *models.PartialPrice) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, prices []*models.PartialPrice) error
 }
 
 type repository struct {
@@ -84,7 +86,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, price *models.Price)
 		ProductID:              price.ProductID,
 		Type:                   sqlc.PriceType(price.Type),
 		Currency:               sqlc.Currency(price.Currency),
-		UnitAmount:             price.UnitAmount,
+		UnitAmount:             price.UnitAmount.Amount,
 		RecurringInterval:      sqlc.NullPriceRecurringInterval{PriceRecurringInterval: sqlc.PriceRecurringInterval(price.RecurringInterval), Valid: price.RecurringInterval != ""},
 		RecurringIntervalCount: price.RecurringIntervalCount,
 		TrialPeriodDays:        price.TrialPeriodDays,
@@ -93,11 +95,11 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, price *models.Price)
 	}
 
 	// 將新創建的價格加入緩存
-	cacheKey := fmt.Sprintf("price:%s", price.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", price.ID))
 	if err := r.cache.Set(ctx, cacheKey, price); err != nil {
 		r.logger.Warn("Failed to cache new price", zap.Error(err), zap.String("id", price.ID))
 	}
-	cacheKey = fmt.Sprintf("prices:product:%s", price.ProductID)
+	cacheKey = driver.TenantCacheKey(ctx, fmt.Sprintf("prices:product:%s", price.ProductID))
 	var cachedPrices []*models.Price
 	if _, err := r.cache.Get(ctx, cacheKey, &cachedPrices); err == nil {
 		cachedPrices = append(cachedPrices, price)
@@ -110,7 +112,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, price *models.Price)
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Price, error) {
-	cacheKey := fmt.Sprintf("price:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", id))
 
 	price, release, err := r.getFromPool(ctx)
 	if err != nil {
@@ -119,13 +121,17 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	defer release()
 
 	found, err := r.cache.Get(ctx, cacheKey, price)
+	metrics.ObserveCache("price", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get price from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
 		return price, nil
 	}
 
-	sqlcPrice, err := sqlc.New(r.conn).WithTx(tx).GetPrice(ctx, id)
+	sqlcPrice, err := sqlc.New(r.conn).WithTx(tx).GetPrice(ctx, sqlc.GetPriceParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		r.logger.Error("error getting price", zap.Error(err))
 		return nil, err
@@ -147,21 +153,22 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, price *models.Price)
 		ProductID:              price.ProductID,
 		Type:                   sqlc.PriceType(price.Type),
 		Currency:               sqlc.Currency(price.Currency),
-		UnitAmount:             price.UnitAmount,
+		UnitAmount:             price.UnitAmount.Amount,
 		RecurringInterval:      sqlc.NullPriceRecurringInterval{PriceRecurringInterval: sqlc.PriceRecurringInterval(price.RecurringInterval), Valid: price.RecurringInterval != ""},
 		RecurringIntervalCount: price.RecurringIntervalCount,
 		TrialPeriodDays:        price.TrialPeriodDays,
 		Active:                 price.Active,
+		TenantID:               driver.TenantID(ctx),
 	}); err != nil {
 		return fmt.Errorf("failed to update price: %w", err)
 	}
 
 	// 更新緩存中的價格信息
-	cacheKey := fmt.Sprintf("price:%s", price.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", price.ID))
 	if err := r.cache.Set(ctx, cacheKey, price); err != nil {
 		r.logger.Warn("Failed to update price in cache", zap.Error(err), zap.String("id", price.ID))
 	}
-	cacheKey = fmt.Sprintf("prices:product:%s", price.ProductID)
+	cacheKey = driver.TenantCacheKey(ctx, fmt.Sprintf("prices:product:%s", price.ProductID))
 	if err := r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete price from cache", zap.Error(err), zap.String("productID", price.ProductID))
 	}
@@ -170,17 +177,20 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, price *models.Price)
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	productID, err := sqlc.New(r.conn).WithTx(tx).DeletePrice(ctx, id)
+	productID, err := sqlc.New(r.conn).WithTx(tx).DeletePrice(ctx, sqlc.DeletePriceParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete price: %w", err)
 	}
 
 	// 從緩存中刪除價格
-	cacheKey := fmt.Sprintf("price:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", id))
 	if err = r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete price from cache", zap.Error(err), zap.String("id", id))
 	}
-	cacheKey = fmt.Sprintf("prices:product:%s", productID)
+	cacheKey = driver.TenantCacheKey(ctx, fmt.Sprintf("prices:product:%s", productID))
 	if err = r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete price from cache", zap.Error(err), zap.String("id", id))
 	}
@@ -189,16 +199,20 @@ func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
 }
 
 func (r *repository) List(ctx context.Context, tx pgx.Tx, productID string) ([]*models.Price, error) {
-	cacheKey := fmt.Sprintf("prices:product:%s", productID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("prices:product:%s", productID))
 	var cachedPrices []*models.Price
 	found, err := r.cache.Get(ctx, cacheKey, &cachedPrices)
+	metrics.ObserveCache("price", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get prices from cache", zap.Error(err), zap.String("productID", productID))
 	} else if found {
 		return cachedPrices, nil
 	}
 
-	sqlcPrices, err := sqlc.New(r.conn).WithTx(tx).ListPrices(ctx, productID)
+	sqlcPrices, err := sqlc.New(r.conn).WithTx(tx).ListPrices(ctx, sqlc.ListPricesParams{
+		ProductID: productID,
+		TenantID:  driver.TenantID(ctx),
+	})
 	if err != nil {
 		r.logger.Error("error listing prices", zap.Error(err))
 		return nil, err
@@ -216,7 +230,7 @@ func (r *repository) List(ctx context.Context, tx pgx.Tx, productID string) ([]*
 		prices = append(prices, price)
 
 		// 更新單個價格的緩存
-		singleCacheKey := fmt.Sprintf("price:%s", price.ID)
+		singleCacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", price.ID))
 		if err = r.cache.Set(ctx, singleCacheKey, price); err != nil {
 			r.logger.Warn("Failed to cache single price", zap.Error(err), zap.String("id", price.ID))
 		}
@@ -233,16 +247,20 @@ func (r *repository) List(ctx context.Context, tx pgx.Tx, productID string) ([]*
 }
 
 func (r *repository) ListActive(ctx context.Context, tx pgx.Tx, productID string) ([]*models.Price, error) {
-	cacheKey := fmt.Sprintf("prices:product:%s", productID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("prices:product:%s", productID))
 	var cachedPrices []*models.Price
 	found, err := r.cache.Get(ctx, cacheKey, &cachedPrices)
+	metrics.ObserveCache("price", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get prices from cache", zap.Error(err), zap.String("productID", productID))
 	} else if found {
 		return cachedPrices, nil
 	}
 
-	sqlcPrices, err := sqlc.New(r.conn).WithTx(tx).ListActivePrices(ctx, productID)
+	sqlcPrices, err := sqlc.New(r.conn).WithTx(tx).ListActivePrices(ctx, sqlc.ListActivePricesParams{
+		ProductID: productID,
+		TenantID:  driver.TenantID(ctx),
+	})
 	if err != nil {
 		r.logger.Error("error listing prices", zap.Error(err))
 		return nil, err
@@ -260,7 +278,7 @@ func (r *repository) ListActive(ctx context.Context, tx pgx.Tx, productID string
 		prices = append(prices, price)
 
 		// 更新單個價格的緩存
-		singleCacheKey := fmt.Sprintf("price:%s", price.ID)
+		singleCacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", price.ID))
 		if err := r.cache.Set(ctx, singleCacheKey, price); err != nil {
 			r.logger.Warn("Failed to cache single price", zap.Error(err), zap.String("id", price.ID))
 		}
@@ -277,9 +295,46 @@ func (r *repository) ListActive(ctx context.Context, tx pgx.Tx, productID string
 }
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, price *models.PartialPrice) error {
-	const query = `
-    INSERT INTO prices (id, product_id, active, currency, unit_amount, type, recurring_interval, recurring_interval_count, trial_period_days, created_at, updated_at)
-    VALUES (@id, @product_id, @active, @currency, @unit_amount, @type, @recurring_interval, COALESCE(@recurring_interval_count, 1), @trial_period_days, COALESCE(@created_at, NOW()), @updated_at)
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, price)); err != nil {
+		return fmt.Errorf("failed to upsert price: %w", err)
+	}
+
+	// 價格同時快取在單筆與產品的價格列表中，兩者都要清除
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("price:%s", price.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete price from cache", zap.Error(err), zap.String("id", price.ID))
+	}
+	if price.ProductID != nil {
+		cacheKey = driver.TenantCacheKey(ctx, fmt.Sprintf("prices:product:%s", *price.ProductID))
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warn("Failed to delete price from cache", zap.Error(err), zap.String("productID", *price.ProductID))
+		}
+	}
+
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, prices []*models.PartialPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, price := range prices {
+		batch.Queue(upsertQuery, upsertArgs(ctx, price))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert prices: %w", err)
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO prices (id, product_id, active, currency, unit_amount, type, recurring_interval, recurring_interval_count, trial_period_days, created_at, updated_at, last_event_at, deleted_at)
+    VALUES (@id, @product_id, @active, @currency, @unit_amount, @type, @recurring_interval, COALESCE(@recurring_interval_count, 1), @trial_period_days, COALESCE(@created_at, NOW()), @updated_at, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         product_id = COALESCE(@product_id, prices.product_id),
         active = COALESCE(@active, prices.active),
@@ -289,10 +344,17 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, price *models.Partia
         recurring_interval = COALESCE(@recurring_interval, prices.recurring_interval),
         recurring_interval_count = COALESCE(@recurring_interval_count, prices.recurring_interval_count, 1),
         trial_period_days = COALESCE(@trial_period_days, prices.trial_period_days),
+        last_event_at = COALESCE(@last_event_at, prices.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at
     WHERE prices.id = @id
+      AND prices.tenant_id = @tenant_id
+      AND prices.deleted_at IS NULL
+      AND (prices.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR prices.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, price *models.PartialPrice) pgx.NamedArgs {
 	now := time.Now()
 	ric := 1
 	var tpd int32
@@ -302,7 +364,7 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, price *models.Partia
 	if price.TrialPeriodDays != nil {
 		tpd = *price.TrialPeriodDays
 	}
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":                       price.ID,
 		"product_id":               price.ProductID,
 		"active":                   price.Active,
@@ -314,11 +376,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, price *models.Partia
 		"trial_period_days":        tpd,
 		"created_at":               now,
 		"updated_at":               now,
+		"last_event_at":            price.LastEventAt,
+		"deleted_at":               price.DeletedAt,
+		"tenant_id":                driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert price: %w", err)
-	}
-
-	return nil
 }
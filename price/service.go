@@ -19,6 +19,7 @@ type Service interface {
 	List(ctx context.Context, productID string) ([]*models.Price, error)
 	ListActive(ctx context.Context, productID string) ([]*models.Price, error)
 	Upsert(ctx context.Context, price *models.PartialPrice) error
+	UpsertBatch(ctx context.Context, prices []*models.PartialPrice) error
 }
 
 type service struct {
@@ -68,7 +69,7 @@ func (s *service) Update(ctx context.Context, price *models.Price) error {
 		if price.Currency != "" {
 			existingPrice.Currency = price.Currency
 		}
-		if price.UnitAmount != 0 {
+		if !price.UnitAmount.IsZero() {
 			existingPrice.UnitAmount = price.UnitAmount
 		}
 		if price.RecurringInterval != "" {
@@ -120,3 +121,9 @@ func (s *service) Upsert(ctx context.Context, price *models.PartialPrice) error
 		return s.repo.Upsert(ctx, tx, price)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, prices []*models.PartialPrice) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, prices)
+	})
+}
@@ -8,11 +8,13 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -22,8 +24,9 @@ type Repository interface {
 	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Product, error)
 	Update(ctx context.Context, tx pgx.Tx, product *models.Product) error
 	Delete(ctx context.Context, tx pgx.Tx, id string) error
-	List(ctx context.Context, tx pgx.Tx, limit, offset uint64) ([]*models.Product, error)
+	List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Product], error)
 	Upsert(ctx context.Context, tx pgx.Tx, product *models.PartialProduct) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, products []*models.PartialProduct) error
 }
 
 type repository struct {
@@ -103,7 +106,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, product *models.Prod
 	product.ID = sqlcProduct.ID
 	product.CreatedAt = sqlcProduct.CreatedAt.Time
 	product.UpdatedAt = sqlcProduct.UpdatedAt.Time
-	cacheKey := fmt.Sprintf("product:%s", product.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("product:%s", product.ID))
 	if err = r.cache.Set(ctx, cacheKey, product); err != nil {
 		r.logger.Warn("Failed to cache new product", zap.Error(err), zap.String("id", product.ID))
 	}
@@ -112,7 +115,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, product *models.Prod
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Product, error) {
-	cacheKey := fmt.Sprintf("product:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("product:%s", id))
 	product, release, err := r.getFromPool(ctx)
 	if err != nil {
 		return nil, err
@@ -120,13 +123,17 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	defer release()
 
 	found, err := r.cache.Get(ctx, cacheKey, product)
+	metrics.ObserveCache("product", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get product from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
 		return product, nil
 	}
 
-	sqlcProduct, err := sqlc.New(r.conn).WithTx(tx).GetProduct(ctx, id)
+	sqlcProduct, err := sqlc.New(r.conn).WithTx(tx).GetProduct(ctx, sqlc.GetProductParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		r.logger.Error("error getting product", zap.Error(err))
 		return nil, err
@@ -158,6 +165,7 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, product *models.Prod
 		Description: &product.Description,
 		Active:      product.Active,
 		Metadata:    metadata,
+		TenantID:    driver.TenantID(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
@@ -166,7 +174,7 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, product *models.Prod
 	// 更新緩存中的產品信息
 	product.CreatedAt = sqlcProduct.CreatedAt.Time
 	product.UpdatedAt = sqlcProduct.UpdatedAt.Time
-	cacheKey := fmt.Sprintf("product:%s", product.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("product:%s", product.ID))
 	if err = r.cache.Set(ctx, cacheKey, product); err != nil {
 		r.logger.Warn("Failed to update product in cache", zap.Error(err), zap.String("id", product.ID))
 	}
@@ -175,13 +183,16 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, product *models.Prod
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	err := sqlc.New(r.conn).WithTx(tx).DeleteProduct(ctx, id)
+	err := sqlc.New(r.conn).WithTx(tx).DeleteProduct(ctx, sqlc.DeleteProductParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
 	// 從緩存中刪除產品
-	cacheKey := fmt.Sprintf("product:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("product:%s", id))
 	if err = r.cache.Delete(ctx, cacheKey); err != nil {
 		r.logger.Warn("Failed to delete product from cache", zap.Error(err), zap.String("id", id))
 	}
@@ -189,81 +200,105 @@ func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, tx pgx.Tx, limit, offset uint64) ([]*models.Product, error) {
-	cacheKey := fmt.Sprintf("products:limit:%d:offset:%d", limit, offset)
-	var cachedProducts []*models.Product
-	found, err := r.cache.Get(ctx, cacheKey, &cachedProducts)
-	if err != nil {
-		r.logger.Warn("Failed to get products from cache", zap.Error(err))
-	} else if found {
-		return cachedProducts, nil
+// List 依 (created_at, id) 遞減以 keyset 分頁列出產品，多查詢一筆判斷是否還有下一頁
+func (r *repository) List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Product], error) {
+	limit := params.PageSize()
+	arg := sqlc.ListProductsParams{
+		CreatedFrom: pgtype.Timestamptz{Time: params.CreatedFrom, Valid: !params.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: params.CreatedTo, Valid: !params.CreatedTo.IsZero()},
+		Limit:       int64(limit + 1),
+		TenantID:    driver.TenantID(ctx),
+	}
+	switch params.Status {
+	case "":
+	case "active", "inactive":
+		active := params.Status == "active"
+		arg.Active = &active
+	default:
+		return nil, fmt.Errorf("%w: product status %q", models.ErrInvalidListFilter, params.Status)
+	}
+	if params.Cursor != nil {
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: params.Cursor.CreatedAt, Valid: true}
+		arg.CursorID = &params.Cursor.ID
 	}
 
-	sqlcProducts, err := sqlc.New(r.conn).WithTx(tx).ListProducts(ctx, sqlc.ListProductsParams{
-		Limit:  int64(limit),
-		Offset: int64(offset),
-	})
+	sqlcProducts, err := sqlc.New(r.conn).WithTx(tx).ListProducts(ctx, arg)
 	if err != nil {
-		r.logger.Error("error listing products", zap.Error(err))
-		return nil, err
+		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
 
 	products := make([]*models.Product, 0, len(sqlcProducts))
 	for _, sqlcProduct := range sqlcProducts {
-		product, release, err := r.getFromPool(ctx)
-		if err != nil {
-			r.logger.Error("Failed to get product from pool", zap.Error(err))
-			continue
-		}
+		products = append(products, models.NewProduct().ConvertFromSQLCProduct(sqlcProduct))
+	}
 
-		*product = *models.NewProduct().ConvertFromSQLCProduct(sqlcProduct)
-		products = append(products, product)
+	return models.NewPage(products, limit, func(item *models.Product) models.Cursor {
+		return models.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+	}), nil
+}
 
-		// 更新單個產品的緩存
-		singleCacheKey := fmt.Sprintf("product:%s", product.ID)
-		if err = r.cache.Set(ctx, singleCacheKey, product); err != nil {
-			r.logger.Warn("Failed to cache single product", zap.Error(err), zap.String("id", product.ID))
-		}
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, product *models.PartialProduct) error {
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, product)); err != nil {
+		return fmt.Errorf("failed to upsert product: %w", err)
+	}
 
-		release()
+	// 清除緩存，刪除事件寫入 tombstone 後 GetByID 不應再讀到快取中的產品
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("product:%s", product.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete product from cache", zap.Error(err), zap.String("id", product.ID))
 	}
 
-	// 緩存整個列表結果
-	if err = r.cache.Set(ctx, cacheKey, products); err != nil {
-		r.logger.Warn("Failed to cache products list", zap.Error(err))
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, products []*models.PartialProduct) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, product := range products {
+		batch.Queue(upsertQuery, upsertArgs(ctx, product))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert products: %w", err)
 	}
 
-	return products, nil
+	return nil
 }
 
-func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, product *models.PartialProduct) error {
-	const query = `
-    INSERT INTO products (id, name, description, active, metadata, created_at, updated_at)
-    VALUES (@id, @name, @description, @active, @metadata, COALESCE(@created_at, NOW()), @updated_at)
+const upsertQuery = `
+    INSERT INTO products (id, name, description, active, metadata, created_at, updated_at, last_event_at, deleted_at)
+    VALUES (@id, @name, @description, @active, @metadata, COALESCE(@created_at, NOW()), @updated_at, @last_event_at, @deleted_at)
     ON CONFLICT (id) DO UPDATE SET
         name = COALESCE(@name, products.name),
         description = COALESCE(@description, products.description),
         active = COALESCE(@active, products.active),
         metadata = COALESCE(@metadata, products.metadata),
+        last_event_at = COALESCE(@last_event_at, products.last_event_at),
+        deleted_at = @deleted_at,
         updated_at = @updated_at
     WHERE products.id = @id
+      AND products.tenant_id = @tenant_id
+      AND products.deleted_at IS NULL
+      AND (products.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR products.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, product *models.PartialProduct) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
-		"id":          product.ID,
-		"name":        product.Name,
-		"description": product.Description,
-		"active":      product.Active,
-		"metadata":    product.Metadata,
-		"created_at":  now,
-		"updated_at":  now,
+	return pgx.NamedArgs{
+		"id":            product.ID,
+		"name":          product.Name,
+		"description":   product.Description,
+		"active":        product.Active,
+		"metadata":      product.Metadata,
+		"created_at":    now,
+		"updated_at":    now,
+		"last_event_at": product.LastEventAt,
+		"deleted_at":    product.DeletedAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
-
-	_, err := tx.Exec(ctx, query, args)
-	if err != nil {
-		return fmt.Errorf("failed to upsert product: %w", err)
-	}
-
-	return nil
 }
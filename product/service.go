@@ -16,8 +16,9 @@ type Service interface {
 	GetByID(ctx context.Context, id string) (*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset uint64) ([]*models.Product, error)
+	List(ctx context.Context, params models.ListParams) (*models.Page[*models.Product], error)
 	Upsert(ctx context.Context, product *models.PartialProduct) error
+	UpsertBatch(ctx context.Context, products []*models.PartialProduct) error
 }
 
 type service struct {
@@ -87,14 +88,14 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	})
 }
 
-func (s *service) List(ctx context.Context, limit, offset uint64) ([]*models.Product, error) {
-	var products []*models.Product
+func (s *service) List(ctx context.Context, params models.ListParams) (*models.Page[*models.Product], error) {
+	var page *models.Page[*models.Product]
 	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		var err error
-		products, err = s.repo.List(ctx, tx, limit, offset)
+		page, err = s.repo.List(ctx, tx, params)
 		return err
 	})
-	return products, err
+	return page, err
 }
 
 func (s *service) Upsert(ctx context.Context, product *models.PartialProduct) error {
@@ -102,3 +103,9 @@ func (s *service) Upsert(ctx context.Context, product *models.PartialProduct) er
 		return s.repo.Upsert(ctx, tx, product)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, products []*models.PartialProduct) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, products)
+	})
+}
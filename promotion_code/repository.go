@@ -14,6 +14,7 @@ import (
 
 type Repository interface {
 	Upsert(ctx context.Context, tx pgx.Tx, promotionCode *models.PartialPromotionCode) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, promotionCodes []*models.PartialPromotionCode) error
 	Delete(ctx context.Context, tx pgx.Tx, id string) error
 }
 
@@ -26,9 +27,34 @@ func NewRepository(conn driver.PostgresPool) Repository {
 }
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, promotionCode *models.PartialPromotionCode) error {
-	const query = `
-    INSERT INTO promotion_codes (id, code, coupon_id, customer_id, active, max_redemptions, times_redeemed, expires_at, created_at, updated_at)
-    VALUES (@id, @code, @coupon_id, @customer_id, @active, @max_redemptions, @times_redeemed, @expires_at, COALESCE(@created_at, NOW()), @updated_at)
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, promotionCode)); err != nil {
+		return fmt.Errorf("failed to upsert promotion code: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, promotionCodes []*models.PartialPromotionCode) error {
+	if len(promotionCodes) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, promotionCode := range promotionCodes {
+		batch.Queue(upsertQuery, upsertArgs(ctx, promotionCode))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert promotion codes: %w", err)
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO promotion_codes (id, code, coupon_id, customer_id, active, max_redemptions, times_redeemed, expires_at, created_at, updated_at, last_event_at)
+    VALUES (@id, @code, @coupon_id, @customer_id, @active, @max_redemptions, @times_redeemed, @expires_at, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         code = COALESCE(@code, promotion_codes.code),
         coupon_id = COALESCE(@coupon_id, promotion_codes.coupon_id),
@@ -37,12 +63,17 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, promotionCode *model
         max_redemptions = COALESCE(@max_redemptions, promotion_codes.max_redemptions),
         times_redeemed = COALESCE(@times_redeemed, promotion_codes.times_redeemed),
         expires_at = COALESCE(@expires_at, promotion_codes.expires_at),
+        last_event_at = COALESCE(@last_event_at, promotion_codes.last_event_at),
         updated_at = @updated_at
     WHERE promotion_codes.id = @id
+      AND promotion_codes.tenant_id = @tenant_id
+      AND (promotion_codes.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR promotion_codes.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, promotionCode *models.PartialPromotionCode) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":              promotionCode.ID,
 		"code":            promotionCode.Code,
 		"coupon_id":       promotionCode.CouponID,
@@ -53,15 +84,14 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, promotionCode *model
 		"expires_at":      promotionCode.ExpiresAt,
 		"created_at":      promotionCode.CreatedAt,
 		"updated_at":      now,
+		"last_event_at":   promotionCode.LastEventAt,
+		"tenant_id":       driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert promotion code: %w", err)
-	}
-
-	return nil
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeletePromotionCodes(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeletePromotionCodes(ctx, sqlc.DeletePromotionCodesParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
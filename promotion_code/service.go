@@ -11,6 +11,7 @@ import (
 
 type Service interface {
 	Upsert(ctx context.Context, coupon *models.PartialPromotionCode) error
+	UpsertBatch(ctx context.Context, promotionCodes []*models.PartialPromotionCode) error
 	Delete(ctx context.Context, id string) error
 }
 
@@ -32,6 +33,12 @@ func (s *service) Upsert(ctx context.Context, pc *models.PartialPromotionCode) e
 	})
 }
 
+func (s *service) UpsertBatch(ctx context.Context, promotionCodes []*models.PartialPromotionCode) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, promotionCodes)
+	})
+}
+
 func (s *service) Delete(ctx context.Context, id string) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		return s.repo.Delete(ctx, tx, id)
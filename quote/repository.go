@@ -27,8 +27,8 @@ func NewRepository(conn driver.PostgresPool) Repository {
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, quote *models.PartialQuote) error {
 	const query = `
-    INSERT INTO quotes (id, customer_id, status, amount_total, currency, valid_until, accepted_at, canceled_at, created_at, updated_at)
-    VALUES (@id, @customer_id, @status, @amount_total, @currency, @valid_until, @accepted_at, @canceled_at, COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO quotes (id, customer_id, status, amount_total, currency, valid_until, accepted_at, canceled_at, created_at, updated_at, last_event_at)
+    VALUES (@id, @customer_id, @status, @amount_total, @currency, @valid_until, @accepted_at, @canceled_at, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, quotes.customer_id),
         status = COALESCE(@status, quotes.status),
@@ -37,22 +37,27 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, quote *models.Partia
         valid_until = COALESCE(@valid_until, quotes.valid_until),
         accepted_at = COALESCE(@accepted_at, quotes.accepted_at),
         canceled_at = COALESCE(@canceled_at, quotes.canceled_at),
+        last_event_at = COALESCE(@last_event_at, quotes.last_event_at),
         updated_at = @updated_at
     WHERE quotes.id = @id
+      AND quotes.tenant_id = @tenant_id
+      AND (quotes.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR quotes.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
 	args := pgx.NamedArgs{
-		"id":           quote.ID,
-		"customer_id":  quote.CustomerID,
-		"status":       quote.Status,
-		"amount_total": quote.AmountTotal,
-		"currency":     quote.Currency,
-		"valid_until":  quote.ValidUntil,
-		"accepted_at":  quote.AcceptedAt,
-		"canceled_at":  quote.CanceledAt,
-		"created_at":   quote.CreatedAt,
-		"updated_at":   now,
+		"id":            quote.ID,
+		"customer_id":   quote.CustomerID,
+		"status":        quote.Status,
+		"amount_total":  quote.AmountTotal,
+		"currency":      quote.Currency,
+		"valid_until":   quote.ValidUntil,
+		"accepted_at":   quote.AcceptedAt,
+		"canceled_at":   quote.CanceledAt,
+		"created_at":    quote.CreatedAt,
+		"updated_at":    now,
+		"last_event_at": quote.LastEventAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
 
 	if _, err := tx.Exec(ctx, query, args); err != nil {
@@ -63,5 +68,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, quote *models.Partia
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteQuote(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeleteQuote(ctx, sqlc.DeleteQuoteParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+)
+
+const keyPrefix = "ratelimit:"
+
+// tokenBucket 在 Redis 內完成補充與扣除，多個實例同時請求同一個 bucket 時不會超出限制
+// 時間取自 Redis 的 TIME，不受各實例時鐘誤差影響；bucket 在補滿後過期，閒置的 key 不會留在 Redis
+// 回傳是否允許、剩餘的 token 與下一個 token 補充前需要等待的毫秒數
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// Result 為一次請求的限流結果，Limit 為 0 代表沒有限制
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter 以 Redis 中的 token bucket 限制請求速率，HTTP 與 gRPC 共用
+// 設定檔中的 rate_limit 變更時立即套用，已存在的 bucket 以新的速率繼續補充
+type Limiter struct {
+	redis  *redis.Client
+	config atomic.Pointer[config.RateLimitConfig]
+	logger *zap.Logger
+}
+
+func NewLimiter(rdb *redis.Client, cfg *config.Config, logger *zap.Logger) *Limiter {
+	l := &Limiter{
+		redis:  rdb,
+		logger: logger,
+	}
+	l.config.Store(&cfg.RateLimit)
+
+	cfg.OnReload(func(next *config.Config) {
+		l.config.Store(&next.RateLimit)
+		logger.Info("rate limit config reloaded", zap.Bool("enabled", next.RateLimit.Enabled))
+	})
+
+	return l
+}
+
+// Enabled 回傳設定是否啟用限流
+func (l *Limiter) Enabled() bool {
+	return l.config.Load().Enabled
+}
+
+// Rule 回傳 API key 在路由群組的限制，依序使用 api_keys 中 key 前綴的設定、groups 中群組的設定與 default
+func (l *Limiter) Rule(group, apiKey string) config.RateLimitRule {
+	cfg := l.config.Load()
+	if rule, ok := cfg.APIKeys[apiKey]; ok && apiKey != "" {
+		return rule
+	}
+	if rule, ok := cfg.Groups[group]; ok {
+		return rule
+	}
+	return cfg.Default
+}
+
+// CustomerRule 回傳建立付款時對同一個客戶的限制
+func (l *Limiter) CustomerRule() config.RateLimitRule {
+	return l.config.Load().Customer
+}
+
+// Allow 從 key 的 bucket 取出一個 token
+// Redis 無法使用時允許請求並記錄 log，限流失效不應讓整個服務無法使用
+func (l *Limiter) Allow(ctx context.Context, key string, rule config.RateLimitRule) Result {
+	if !l.Enabled() || rule.Rate <= 0 {
+		return Result{Allowed: true}
+	}
+
+	burst := rule.Burst
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rule.Rate)))
+	}
+	result := Result{Allowed: true, Limit: burst, Remaining: burst}
+
+	values, err := tokenBucket.Run(ctx, l.redis, []string{keyPrefix + key}, rule.Rate, burst).Int64Slice()
+	if err != nil || len(values) != 3 {
+		l.logger.Warn("failed to check rate limit, allowing request", zap.Error(err), zap.String("key", key))
+		return result
+	}
+
+	result.Allowed = values[0] == 1
+	result.Remaining = int(values[1])
+	result.RetryAfter = time.Duration(values[2]) * time.Millisecond
+	return result
+}
+
+// APIKeyBucket 回傳 API key 在路由群組的 bucket；key 的前綴不會重複，平台 key 在所有租戶共用同一個 bucket
+func APIKeyBucket(prefix, group string) string {
+	return "key:" + prefix + ":" + group
+}
+
+// PeerBucket 回傳沒有 API key 的呼叫端（gRPC 的 HandleWebhook）以來源位址區分的 bucket
+func PeerBucket(address, group string) string {
+	return "peer:" + address + ":" + group
+}
+
+// CustomerBucket 回傳客戶的 bucket，以租戶區分，不論使用哪個 API key 都會扣除同一個 bucket
+func CustomerBucket(ctx context.Context, customerID string) string {
+	return driver.TenantCacheKey(ctx, "customer:"+customerID)
+}
+
+// RetryAfterSeconds 將等待時間無條件進位為 Retry-After header 使用的秒數，至少為 1
+func RetryAfterSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
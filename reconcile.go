@@ -0,0 +1,471 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+// reconcilePageSize 為每次向 Stripe 取得以及比對本地資料的筆數，Stripe list API 的上限為 100
+const reconcilePageSize = 100
+
+// ReconcileResource 為可對帳的資源類型
+type ReconcileResource string
+
+const (
+	ReconcileCustomers      ReconcileResource = "customers"
+	ReconcileSubscriptions  ReconcileResource = "subscriptions"
+	ReconcileInvoices       ReconcileResource = "invoices"
+	ReconcilePaymentIntents ReconcileResource = "payment_intents"
+)
+
+// ReconcileResources 依外鍵相依順序列出所有可對帳的資源，修復時客戶需先於訂閱與發票寫入
+var ReconcileResources = []ReconcileResource{
+	ReconcileCustomers,
+	ReconcileSubscriptions,
+	ReconcileInvoices,
+	ReconcilePaymentIntents,
+}
+
+// DriftKind 為本地資料與 Stripe 不一致的類型
+type DriftKind string
+
+const (
+	// DriftMissing Stripe 有此物件但本地沒有，通常是漏收 created 事件
+	DriftMissing DriftKind = "missing"
+	// DriftMismatch 本地資料與 Stripe 的欄位不同，通常是漏收 updated 事件
+	DriftMismatch DriftKind = "mismatch"
+)
+
+// ReconcileOptions 設定一次對帳的範圍
+// Resources 為空時比對所有資源，CreatedFrom 為零值時不限制 Stripe 物件的建立時間
+type ReconcileOptions struct {
+	Resources   []ReconcileResource
+	CreatedFrom time.Time
+	Repair      bool
+}
+
+// FieldDrift 為單一欄位的差異，時間欄位以 RFC 3339 表示，金額為最小貨幣單位
+type FieldDrift struct {
+	Field  string `json:"field"`
+	Local  any    `json:"local"`
+	Stripe any    `json:"stripe"`
+}
+
+// Drift 為單一物件的差異，Repair 時記錄修復結果
+type Drift struct {
+	Resource    ReconcileResource `json:"resource"`
+	ID          string            `json:"id"`
+	Kind        DriftKind         `json:"kind"`
+	Fields      []FieldDrift      `json:"fields,omitempty"`
+	Repaired    bool              `json:"repaired"`
+	RepairError string            `json:"repair_error,omitempty"`
+}
+
+// ReconcileReport 為一次對帳的結果
+type ReconcileReport struct {
+	StartedAt  time.Time                 `json:"started_at"`
+	FinishedAt time.Time                 `json:"finished_at"`
+	Checked    map[ReconcileResource]int `json:"checked"`
+	Drifts     []Drift                   `json:"drifts"`
+}
+
+// Reconcile 逐頁讀取 Stripe 的資源並與本地資料比對，回傳差異報告
+// 只比對本地有保存且 webhook 會寫入的欄位；Repair 時以 Stripe 的資料透過既有的 Upsert 修正
+// 本地已套用比該頁更新的 webhook 事件時，Upsert 不會覆蓋，該筆差異在下一次對帳時消失
+// 單一資源發生錯誤時回傳目前為止的報告與錯誤
+// 比對 ctx 所屬租戶的 Stripe 帳號與該租戶的本地資料
+func (sp *StripePayment) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := opts.Resources
+	if len(resources) == 0 {
+		resources = ReconcileResources
+	}
+	for _, resource := range resources {
+		if !slices.Contains(ReconcileResources, resource) {
+			return nil, fmt.Errorf("unknown reconcile resource: %s", resource)
+		}
+	}
+
+	report := &ReconcileReport{
+		StartedAt: time.Now(),
+		Checked:   make(map[ReconcileResource]int),
+		Drifts:    []Drift{},
+	}
+
+	var listParams stripe.ListParams
+	listParams.Context = ctx
+	listParams.Limit = stripe.Int64(reconcilePageSize)
+
+	var created *stripe.RangeQueryParams
+	if !opts.CreatedFrom.IsZero() {
+		created = &stripe.RangeQueryParams{GreaterThanOrEqual: opts.CreatedFrom.Unix()}
+	}
+
+	// 依 ReconcileResources 的順序執行，與呼叫端傳入的順序無關
+	for _, resource := range ReconcileResources {
+		if !slices.Contains(resources, resource) {
+			continue
+		}
+
+		var err error
+		switch resource {
+		case ReconcileCustomers:
+			iter := sc.Customers.List(&stripe.CustomerListParams{ListParams: listParams, CreatedRange: created})
+			err = reconcileResource(ctx, sp.customerReconciler(), iter.Iter, iter.Customer, opts.Repair, report)
+		case ReconcileSubscriptions:
+			iter := sc.Subscriptions.List(&stripe.SubscriptionListParams{ListParams: listParams, CreatedRange: created, Status: stripe.String("all")})
+			err = reconcileResource(ctx, sp.subscriptionReconciler(), iter.Iter, iter.Subscription, opts.Repair, report)
+		case ReconcileInvoices:
+			iter := sc.Invoices.List(&stripe.InvoiceListParams{ListParams: listParams, CreatedRange: created})
+			err = reconcileResource(ctx, sp.invoiceReconciler(), iter.Iter, iter.Invoice, opts.Repair, report)
+		case ReconcilePaymentIntents:
+			iter := sc.PaymentIntents.List(&stripe.PaymentIntentListParams{ListParams: listParams, CreatedRange: created})
+			err = reconcileResource(ctx, sp.paymentIntentReconciler(), iter.Iter, iter.PaymentIntent, opts.Repair, report)
+		}
+		if err != nil {
+			report.FinishedAt = time.Now()
+			return report, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// reconciler 描述一種資源如何讀取本地資料、比對欄位與修復，S 為 Stripe 物件，L 為本地 model
+type reconciler[S, L any] struct {
+	resource ReconcileResource
+	stripeID func(S) string
+	localID  func(L) string
+	lookup   func(ctx context.Context, ids []string) ([]L, error)
+	diff     func(S, L) []FieldDrift
+	repair   func(ctx context.Context, item S, observedAt *time.Time) error
+}
+
+// reconcileResource 將 Stripe iterator 切成頁，每頁以一次查詢讀取本地資料後比對
+// observedAt 為向 Stripe 取得該頁之前的時間，修復時作為 last_event_at：
+// 之後建立的 webhook 事件仍可更新，較舊且延遲送達的事件則無法覆蓋修復結果
+func reconcileResource[S, L any](ctx context.Context, r reconciler[S, L], iter *stripe.Iter, current func() S, repair bool, report *ReconcileReport) error {
+	page := make([]S, 0, reconcilePageSize)
+	observedAt := time.Now()
+	for iter.Next() {
+		page = append(page, current())
+		if len(page) == reconcilePageSize {
+			if err := r.reconcilePage(ctx, page, observedAt, repair, report); err != nil {
+				return err
+			}
+			page = page[:0]
+			observedAt = time.Now()
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to list %s from stripe: %w", r.resource, err)
+	}
+
+	return r.reconcilePage(ctx, page, observedAt, repair, report)
+}
+
+func (r reconciler[S, L]) reconcilePage(ctx context.Context, items []S, observedAt time.Time, repair bool, report *ReconcileReport) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = r.stripeID(item)
+	}
+
+	locals, err := r.lookup(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load local %s: %w", r.resource, err)
+	}
+	localByID := make(map[string]L, len(locals))
+	for _, local := range locals {
+		localByID[r.localID(local)] = local
+	}
+
+	for _, item := range items {
+		report.Checked[r.resource]++
+
+		drift := Drift{Resource: r.resource, ID: r.stripeID(item)}
+		if local, ok := localByID[drift.ID]; !ok {
+			drift.Kind = DriftMissing
+		} else if fields := r.diff(item, local); len(fields) > 0 {
+			drift.Kind = DriftMismatch
+			drift.Fields = fields
+		} else {
+			continue
+		}
+
+		if repair {
+			if err = r.repair(ctx, item, &observedAt); err != nil {
+				drift.RepairError = err.Error()
+			} else {
+				drift.Repaired = true
+			}
+		}
+		report.Drifts = append(report.Drifts, drift)
+	}
+
+	return nil
+}
+
+func (sp *StripePayment) customerReconciler() reconciler[*stripe.Customer, *models.Customer] {
+	return reconciler[*stripe.Customer, *models.Customer]{
+		resource: ReconcileCustomers,
+		stripeID: func(c *stripe.Customer) string { return c.ID },
+		localID:  func(c *models.Customer) string { return c.ID },
+		lookup:   sp.customer.ListByIDs,
+		diff: func(remote *stripe.Customer, local *models.Customer) []FieldDrift {
+			var d fieldDiff
+			d.compare("balance", local.Balance, remote.Balance)
+			return d
+		},
+		repair: func(ctx context.Context, c *stripe.Customer, observedAt *time.Time) error {
+			return sp.customer.Upsert(ctx, partialCustomerFromStripe(c, observedAt))
+		},
+	}
+}
+
+func (sp *StripePayment) subscriptionReconciler() reconciler[*stripe.Subscription, *models.Subscription] {
+	return reconciler[*stripe.Subscription, *models.Subscription]{
+		resource: ReconcileSubscriptions,
+		stripeID: func(s *stripe.Subscription) string { return s.ID },
+		localID:  func(s *models.Subscription) string { return s.ID },
+		lookup:   sp.subscription.ListByIDs,
+		diff: func(remote *stripe.Subscription, local *models.Subscription) []FieldDrift {
+			partial := partialSubscriptionFromStripe(remote, nil)
+
+			var d fieldDiff
+			if partial.CustomerID != nil {
+				d.compare("customer_id", local.CustomerID, *partial.CustomerID)
+			}
+			if partial.PriceID != nil {
+				d.compare("price_id", local.PriceID, *partial.PriceID)
+			}
+			if partial.Status != nil {
+				d.compare("status", string(local.Status), string(*partial.Status))
+			}
+			if partial.CurrentPeriodStart != nil {
+				d.compareTime("current_period_start", &local.CurrentPeriodStart, partial.CurrentPeriodStart)
+			}
+			if partial.CurrentPeriodEnd != nil {
+				d.compareTime("current_period_end", &local.CurrentPeriodEnd, partial.CurrentPeriodEnd)
+			}
+			d.compare("cancel_at_period_end", local.CancelAtPeriodEnd, *partial.CancelAtPeriodEnd)
+			if partial.CanceledAt != nil {
+				d.compareTime("canceled_at", local.CanceledAt, partial.CanceledAt)
+			}
+			return d
+		},
+		repair: func(ctx context.Context, s *stripe.Subscription, observedAt *time.Time) error {
+			_, err := sp.subscription.Upsert(ctx, partialSubscriptionFromStripe(s, observedAt))
+			return err
+		},
+	}
+}
+
+func (sp *StripePayment) invoiceReconciler() reconciler[*stripe.Invoice, *models.Invoice] {
+	return reconciler[*stripe.Invoice, *models.Invoice]{
+		resource: ReconcileInvoices,
+		stripeID: func(i *stripe.Invoice) string { return i.ID },
+		localID:  func(i *models.Invoice) string { return i.ID },
+		lookup:   sp.invoice.ListByIDs,
+		diff: func(remote *stripe.Invoice, local *models.Invoice) []FieldDrift {
+			partial := partialInvoiceFromStripe(remote, nil)
+
+			var d fieldDiff
+			if partial.CustomerID != nil {
+				d.compare("customer_id", local.CustomerID, *partial.CustomerID)
+			}
+			if partial.Status != nil {
+				d.compare("status", string(local.Status), string(*partial.Status))
+			}
+			if partial.Currency != nil {
+				d.compare("currency", string(local.Currency), string(*partial.Currency))
+			}
+			d.compare("amount_due", local.AmountDue.Amount, *partial.AmountDue)
+			d.compare("amount_paid", local.AmountPaid.Amount, *partial.AmountPaid)
+			d.compare("amount_remaining", local.AmountRemaining.Amount, *partial.AmountRemaining)
+			if partial.DueDate != nil {
+				d.compareTime("due_date", &local.DueDate, partial.DueDate)
+			}
+			return d
+		},
+		repair: func(ctx context.Context, i *stripe.Invoice, observedAt *time.Time) error {
+			_, err := sp.invoice.Upsert(ctx, partialInvoiceFromStripe(i, observedAt))
+			return err
+		},
+	}
+}
+
+func (sp *StripePayment) paymentIntentReconciler() reconciler[*stripe.PaymentIntent, *models.PaymentIntent] {
+	return reconciler[*stripe.PaymentIntent, *models.PaymentIntent]{
+		resource: ReconcilePaymentIntents,
+		stripeID: func(pi *stripe.PaymentIntent) string { return pi.ID },
+		localID:  func(pi *models.PaymentIntent) string { return pi.ID },
+		lookup:   sp.paymentIntent.ListByIDs,
+		diff: func(remote *stripe.PaymentIntent, local *models.PaymentIntent) []FieldDrift {
+			partial := partialPaymentIntentFromStripe(remote, nil)
+
+			var d fieldDiff
+			if partial.CustomerID != nil {
+				d.compare("customer_id", local.CustomerID, *partial.CustomerID)
+			}
+			if partial.Amount != nil {
+				d.compare("amount", local.Amount.Amount, *partial.Amount)
+			}
+			if partial.Currency != nil {
+				d.compare("currency", string(local.Amount.Currency), string(*partial.Currency))
+			}
+			if partial.Status != nil {
+				d.compare("status", string(local.Status), string(*partial.Status))
+			}
+			if partial.PaymentMethodID != nil {
+				d.compare("payment_method_id", local.PaymentMethodID, *partial.PaymentMethodID)
+			}
+			if partial.CaptureMethod != nil {
+				d.compare("capture_method", string(local.CaptureMethod), string(*partial.CaptureMethod))
+			}
+			return d
+		},
+		repair: func(ctx context.Context, pi *stripe.PaymentIntent, observedAt *time.Time) error {
+			_, err := sp.paymentIntent.Upsert(ctx, partialPaymentIntentFromStripe(pi, observedAt))
+			return err
+		},
+	}
+}
+
+// fieldDiff 累積不一致的欄位，值須為可比較的型別
+type fieldDiff []FieldDrift
+
+func (d *fieldDiff) compare(field string, local, remote any) {
+	if local != remote {
+		*d = append(*d, FieldDrift{Field: field, Local: local, Stripe: remote})
+	}
+}
+
+// compareTime 以秒為單位比較時間，nil 或零值視為未設定
+func (d *fieldDiff) compareTime(field string, local, remote *time.Time) {
+	d.compare(field, formatReconcileTime(local), formatReconcileTime(remote))
+}
+
+func formatReconcileTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// ReconcileScheduler 定期執行對帳並記錄差異
+// 多個實例同時啟用會重複呼叫 Stripe API，建議只在單一實例設定 interval
+type ReconcileScheduler struct {
+	payment  *StripePayment
+	interval time.Duration
+	options  func() ReconcileOptions
+	logger   *zap.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewReconcileScheduler(sp *StripePayment, cfg config.ReconcileConfig, logger *zap.Logger) *ReconcileScheduler {
+	resources := make([]ReconcileResource, len(cfg.Resources))
+	for i, resource := range cfg.Resources {
+		resources[i] = ReconcileResource(resource)
+	}
+
+	return &ReconcileScheduler{
+		payment:  sp,
+		interval: cfg.Interval,
+		options: func() ReconcileOptions {
+			opts := ReconcileOptions{Resources: resources, Repair: cfg.Repair}
+			if cfg.Lookback > 0 {
+				opts.CreatedFrom = time.Now().Add(-cfg.Lookback)
+			}
+			return opts
+		},
+		logger: logger,
+	}
+}
+
+func (rs *ReconcileScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	rs.done = make(chan struct{})
+
+	go func() {
+		defer close(rs.done)
+
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rs.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止排程器，進行中的對帳會因 ctx 取消而中止
+func (rs *ReconcileScheduler) Stop() {
+	if rs.cancel == nil {
+		return
+	}
+	rs.cancel()
+	<-rs.done
+}
+
+// runOnce 依序對每個租戶的 Stripe 帳號對帳，單一租戶失敗不影響其他租戶
+func (rs *ReconcileScheduler) runOnce(ctx context.Context) {
+	for _, tenantID := range rs.payment.TenantIDs() {
+		if ctx.Err() != nil {
+			return
+		}
+		rs.reconcileTenant(driver.WithTenant(ctx, tenantID), tenantID)
+	}
+}
+
+func (rs *ReconcileScheduler) reconcileTenant(ctx context.Context, tenantID string) {
+	report, err := rs.payment.Reconcile(ctx, rs.options())
+	if err != nil {
+		rs.logger.Error("Reconciliation failed", zap.Error(err), zap.String("tenant", tenantID))
+		if report == nil {
+			return
+		}
+	}
+
+	for _, drift := range report.Drifts {
+		rs.logger.Warn("Reconciliation drift",
+			zap.String("tenant", tenantID),
+			zap.String("resource", string(drift.Resource)),
+			zap.String("id", drift.ID),
+			zap.String("kind", string(drift.Kind)),
+			zap.Any("fields", drift.Fields),
+			zap.Bool("repaired", drift.Repaired),
+			zap.String("repair_error", drift.RepairError))
+	}
+
+	rs.logger.Info("Reconciliation finished",
+		zap.String("tenant", tenantID),
+		zap.Any("checked", report.Checked),
+		zap.Int("drifts", len(report.Drifts)),
+		zap.Duration("duration", report.FinishedAt.Sub(report.StartedAt)))
+}
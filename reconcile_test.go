@@ -0,0 +1,386 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/customer"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/invoice"
+	"goflare.io/payment/models"
+	"goflare.io/payment/payment_intent"
+	"goflare.io/payment/subscription"
+)
+
+// mockStripe 以 httptest 模擬 Stripe 的 list API，依 limit 與 starting_after 分頁
+type mockStripe struct {
+	mu       sync.Mutex
+	lists    map[string][]map[string]any
+	requests []url.Values
+	// status 不為零時所有請求都回傳該狀態碼
+	status int
+}
+
+func newMockStripe(t *testing.T, lists map[string][]map[string]any) (*mockStripe, *httptest.Server) {
+	t.Helper()
+
+	m := &mockStripe{lists: lists}
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+	return m, server
+}
+
+func (m *mockStripe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, r.URL.Query())
+	w.Header().Set("Content-Type", "application/json")
+
+	if m.status != 0 {
+		w.WriteHeader(m.status)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]any{"type": "invalid_request_error", "message": "Invalid API Key provided"},
+		})
+		return
+	}
+
+	items := m.lists[r.URL.Path]
+	start := 0
+	if after := r.URL.Query().Get("starting_after"); after != "" {
+		for i, item := range items {
+			if item["id"] == after {
+				start = i + 1
+			}
+		}
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	end := min(start+limit, len(items))
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"object":   "list",
+		"url":      r.URL.Path,
+		"has_more": end < len(items),
+		"data":     append([]map[string]any{}, items[start:end]...),
+	})
+}
+
+func (m *mockStripe) requestCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+type fakeCustomers struct {
+	customer.Service
+
+	local   map[string]*models.Customer
+	lookups int
+	upserts []*models.PartialCustomer
+	err     error
+}
+
+func (f *fakeCustomers) ListByIDs(_ context.Context, ids []string) ([]*models.Customer, error) {
+	f.lookups++
+	var customers []*models.Customer
+	for _, id := range ids {
+		if c, ok := f.local[id]; ok {
+			customers = append(customers, c)
+		}
+	}
+	return customers, nil
+}
+
+func (f *fakeCustomers) Upsert(_ context.Context, c *models.PartialCustomer) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.upserts = append(f.upserts, c)
+	return nil
+}
+
+type fakeSubscriptions struct {
+	subscription.Service
+
+	local   map[string]*models.Subscription
+	upserts []*models.PartialSubscription
+}
+
+func (f *fakeSubscriptions) ListByIDs(_ context.Context, ids []string) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for _, id := range ids {
+		if s, ok := f.local[id]; ok {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (f *fakeSubscriptions) Upsert(_ context.Context, s *models.PartialSubscription) (bool, error) {
+	f.upserts = append(f.upserts, s)
+	return true, nil
+}
+
+type fakeInvoices struct {
+	invoice.Service
+}
+
+func (fakeInvoices) ListByIDs(context.Context, []string) ([]*models.Invoice, error) {
+	return nil, nil
+}
+
+type fakePaymentIntents struct {
+	payment_intent.Service
+}
+
+func (fakePaymentIntents) ListByIDs(context.Context, []string) ([]*models.PaymentIntent, error) {
+	return nil, nil
+}
+
+// newReconcileTestPayment 建立只有預設租戶的 StripePayment，Stripe 請求送到 apiBase
+func newReconcileTestPayment(apiBase string, customers *fakeCustomers, subscriptions *fakeSubscriptions) *StripePayment {
+	cfg := &config.Config{Stripe: config.StripeConfig{SecretKey: "sk_test_123", APIBase: apiBase}}
+	return &StripePayment{
+		tenants:       newTenants(cfg, zap.NewNop()),
+		logger:        zap.NewNop(),
+		customer:      customers,
+		subscription:  subscriptions,
+		invoice:       fakeInvoices{},
+		paymentIntent: fakePaymentIntents{},
+	}
+}
+
+func stripeSubscription(id, status string, periodStart int64) map[string]any {
+	return map[string]any{
+		"id":                   id,
+		"object":               "subscription",
+		"customer":             "cus_A",
+		"status":               status,
+		"current_period_start": periodStart,
+		"current_period_end":   periodStart + 30*24*3600,
+		"cancel_at_period_end": false,
+		"items": map[string]any{
+			"object": "list",
+			"data": []map[string]any{{
+				"id":    "si_A",
+				"price": map[string]any{"id": "price_A", "object": "price"},
+			}},
+		},
+	}
+}
+
+func TestReconcileReportsDrift(t *testing.T) {
+	const periodStart = 1726000000
+
+	mock, server := newMockStripe(t, map[string][]map[string]any{
+		"/v1/customers": {
+			{"id": "cus_A", "object": "customer", "balance": 500},
+			{"id": "cus_B", "object": "customer", "balance": 0},
+		},
+		"/v1/subscriptions": {
+			stripeSubscription("sub_A", "canceled", periodStart),
+		},
+	})
+
+	customers := &fakeCustomers{local: map[string]*models.Customer{
+		"cus_A": {ID: "cus_A", Balance: 500},
+	}}
+	subscriptions := &fakeSubscriptions{local: map[string]*models.Subscription{
+		"sub_A": {
+			ID:                 "sub_A",
+			CustomerID:         "cus_A",
+			PriceID:            "price_A",
+			Status:             "active",
+			CurrentPeriodStart: time.Unix(periodStart, 0),
+			CurrentPeriodEnd:   time.Unix(periodStart+30*24*3600, 0),
+		},
+	}}
+	sp := newReconcileTestPayment(server.URL, customers, subscriptions)
+
+	report, err := sp.Reconcile(driver.WithTenant(context.Background(), driver.DefaultTenantID), ReconcileOptions{})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	if report.Checked[ReconcileCustomers] != 2 || report.Checked[ReconcileSubscriptions] != 1 {
+		t.Fatalf("checked = %v", report.Checked)
+	}
+	if len(report.Drifts) != 2 {
+		t.Fatalf("drifts = %+v, want 2", report.Drifts)
+	}
+
+	missing := report.Drifts[0]
+	if missing.Resource != ReconcileCustomers || missing.ID != "cus_B" || missing.Kind != DriftMissing || missing.Repaired {
+		t.Fatalf("unexpected drift %+v", missing)
+	}
+
+	mismatch := report.Drifts[1]
+	if mismatch.Resource != ReconcileSubscriptions || mismatch.ID != "sub_A" || mismatch.Kind != DriftMismatch {
+		t.Fatalf("unexpected drift %+v", mismatch)
+	}
+	if len(mismatch.Fields) != 1 || mismatch.Fields[0] != (FieldDrift{Field: "status", Local: "active", Stripe: "canceled"}) {
+		t.Fatalf("fields = %+v", mismatch.Fields)
+	}
+
+	if len(customers.upserts) != 0 || len(subscriptions.upserts) != 0 {
+		t.Fatal("reconcile without repair must not write")
+	}
+
+	// 訂閱需列出所有狀態，否則已取消的訂閱不會被比對
+	for _, query := range mock.requests {
+		if query.Get("limit") != strconv.Itoa(reconcilePageSize) {
+			t.Fatalf("limit = %q, want %d", query.Get("limit"), reconcilePageSize)
+		}
+	}
+	if mock.requests[1].Get("status") != "all" {
+		t.Fatalf("subscriptions listed with status %q, want all", mock.requests[1].Get("status"))
+	}
+}
+
+func TestReconcileRepair(t *testing.T) {
+	_, server := newMockStripe(t, map[string][]map[string]any{
+		"/v1/customers": {
+			{"id": "cus_A", "object": "customer", "balance": 0},
+			{"id": "cus_B", "object": "customer", "balance": 700},
+		},
+	})
+
+	customers := &fakeCustomers{local: map[string]*models.Customer{
+		"cus_A": {ID: "cus_A", Balance: 300},
+	}}
+	sp := newReconcileTestPayment(server.URL, customers, &fakeSubscriptions{})
+
+	before := time.Now()
+	report, err := sp.Reconcile(driver.WithTenant(context.Background(), driver.DefaultTenantID), ReconcileOptions{
+		Resources: []ReconcileResource{ReconcileCustomers},
+		Repair:    true,
+	})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	if len(report.Drifts) != 2 {
+		t.Fatalf("drifts = %+v, want 2", report.Drifts)
+	}
+	for _, drift := range report.Drifts {
+		if !drift.Repaired || drift.RepairError != "" {
+			t.Fatalf("drift not repaired: %+v", drift)
+		}
+	}
+
+	if len(customers.upserts) != 2 {
+		t.Fatalf("upserts = %d, want 2", len(customers.upserts))
+	}
+	// 歸零的餘額也要寫回，last_event_at 為取得該頁之前的時間
+	if balance := customers.upserts[0].Balance; balance == nil || *balance != 0 {
+		t.Fatalf("cus_A balance = %v, want 0", balance)
+	}
+	for _, upsert := range customers.upserts {
+		if upsert.LastEventAt == nil || upsert.LastEventAt.Before(before) || upsert.LastEventAt.After(time.Now()) {
+			t.Fatalf("%s: last_event_at = %v", upsert.ID, upsert.LastEventAt)
+		}
+	}
+}
+
+func TestReconcileRepairFailureIsReported(t *testing.T) {
+	_, server := newMockStripe(t, map[string][]map[string]any{
+		"/v1/customers": {{"id": "cus_A", "object": "customer", "balance": 100}},
+	})
+
+	customers := &fakeCustomers{local: map[string]*models.Customer{}, err: errors.New("connection reset")}
+	sp := newReconcileTestPayment(server.URL, customers, &fakeSubscriptions{})
+
+	report, err := sp.Reconcile(driver.WithTenant(context.Background(), driver.DefaultTenantID), ReconcileOptions{
+		Resources: []ReconcileResource{ReconcileCustomers},
+		Repair:    true,
+	})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(report.Drifts) != 1 || report.Drifts[0].Repaired || report.Drifts[0].RepairError != "connection reset" {
+		t.Fatalf("drifts = %+v", report.Drifts)
+	}
+}
+
+func TestReconcilePaging(t *testing.T) {
+	const total = reconcilePageSize + 50
+
+	remote := make([]map[string]any, total)
+	local := make(map[string]*models.Customer, total)
+	for i := range remote {
+		id := fmt.Sprintf("cus_%03d", i)
+		remote[i] = map[string]any{"id": id, "object": "customer", "balance": 0}
+		local[id] = &models.Customer{ID: id}
+	}
+
+	mock, server := newMockStripe(t, map[string][]map[string]any{"/v1/customers": remote})
+	customers := &fakeCustomers{local: local}
+	sp := newReconcileTestPayment(server.URL, customers, &fakeSubscriptions{})
+
+	report, err := sp.Reconcile(driver.WithTenant(context.Background(), driver.DefaultTenantID), ReconcileOptions{
+		Resources:   []ReconcileResource{ReconcileCustomers},
+		CreatedFrom: time.Unix(1726000000, 0),
+	})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	if report.Checked[ReconcileCustomers] != total || len(report.Drifts) != 0 {
+		t.Fatalf("checked = %v, drifts = %+v", report.Checked, report.Drifts)
+	}
+	if mock.requestCount() != 2 || customers.lookups != 2 {
+		t.Fatalf("stripe requests = %d, lookups = %d, want 2 pages", mock.requestCount(), customers.lookups)
+	}
+	if after := mock.requests[1].Get("starting_after"); after != fmt.Sprintf("cus_%03d", reconcilePageSize-1) {
+		t.Fatalf("second page starting_after = %q", after)
+	}
+	if gte := mock.requests[0].Get("created[gte]"); gte != "1726000000" {
+		t.Fatalf("created[gte] = %q", gte)
+	}
+}
+
+func TestReconcileStripeError(t *testing.T) {
+	mock, server := newMockStripe(t, nil)
+	mock.status = http.StatusUnauthorized
+	sp := newReconcileTestPayment(server.URL, &fakeCustomers{}, &fakeSubscriptions{})
+
+	report, err := sp.Reconcile(driver.WithTenant(context.Background(), driver.DefaultTenantID), ReconcileOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if report == nil || len(report.Checked) != 0 || report.FinishedAt.IsZero() {
+		t.Fatalf("report = %+v", report)
+	}
+	// 第一個資源失敗後不再繼續其他資源
+	if mock.requestCount() != 1 {
+		t.Fatalf("stripe requests = %d, want 1", mock.requestCount())
+	}
+}
+
+func TestReconcileRequiresTenant(t *testing.T) {
+	sp := newReconcileTestPayment("http://127.0.0.1:0", &fakeCustomers{}, &fakeSubscriptions{})
+
+	if _, err := sp.Reconcile(context.Background(), ReconcileOptions{}); !errors.Is(err, ErrUnknownTenant) {
+		t.Fatal("expected ErrUnknownTenant without a tenant in ctx")
+	}
+	if _, err := sp.Reconcile(driver.WithTenant(context.Background(), driver.DefaultTenantID), ReconcileOptions{
+		Resources: []ReconcileResource{"charges"},
+	}); err == nil {
+		t.Fatal("expected error for unknown resource")
+	}
+}
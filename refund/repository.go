@@ -7,11 +7,13 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -20,9 +22,9 @@ type Repository interface {
 	Create(ctx context.Context, tx pgx.Tx, refund *models.Refund) error
 	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Refund, error)
 	Update(ctx context.Context, tx pgx.Tx, refund *models.Refund) error
-	List(ctx context.Context, tx pgx.Tx, chargeID string, limit, offset uint64) ([]*models.Refund, error)
-	ListByChargeID(ctx context.Context, chargeID string) ([]*models.Refund, error)
+	List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Refund], error)
 	Upsert(ctx context.Context, tx pgx.Tx, refund *models.PartialRefund) error
+	UpsertBatch(ctx context.Context, tx pgx.Tx, refunds []*models.PartialRefund) error
 }
 
 type repository struct {
@@ -80,14 +82,15 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, refund *models.Refun
 	if err := sqlc.New(r.conn).WithTx(tx).CreateRefund(ctx, sqlc.CreateRefundParams{
 		ID:       refund.ID,
 		ChargeID: refund.ChargeID,
-		Amount:   refund.Amount,
+		Amount:   refund.Amount.Amount,
+		Currency: sqlc.Currency(refund.Amount.Currency),
 		Status:   sqlc.RefundStatus(refund.Status),
 		Reason:   sqlc.NullRefundReason{RefundReason: sqlc.RefundReason(refund.Reason), Valid: refund.Reason != ""},
 	}); err != nil {
 		return fmt.Errorf("failed to create refund: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("refund:%s", refund.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("refund:%s", refund.ID))
 	if err := r.cache.Set(ctx, cacheKey, refund); err != nil {
 		r.logger.Warn("Failed to cache refund", zap.Error(err), zap.String("id", refund.ID))
 	}
@@ -96,7 +99,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, refund *models.Refun
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Refund, error) {
-	cacheKey := fmt.Sprintf("refund:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("refund:%s", id))
 
 	refund, release, err := r.getFromPool(ctx)
 	if err != nil {
@@ -105,13 +108,17 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	defer release()
 
 	found, err := r.cache.Get(ctx, cacheKey, refund)
+	metrics.ObserveCache("refund", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get refund from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
 		return refund, nil
 	}
 
-	sqlcRefund, err := sqlc.New(r.conn).WithTx(tx).GetRefund(ctx, id)
+	sqlcRefund, err := sqlc.New(r.conn).WithTx(tx).GetRefund(ctx, sqlc.GetRefundParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get refund: %w", err)
 	}
@@ -127,14 +134,15 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 
 func (r *repository) Update(ctx context.Context, tx pgx.Tx, refund *models.Refund) error {
 	if err := sqlc.New(r.conn).WithTx(tx).UpdateRefund(ctx, sqlc.UpdateRefundParams{
-		ID:     refund.ID,
-		Status: sqlc.RefundStatus(refund.Status),
-		Reason: sqlc.NullRefundReason{RefundReason: sqlc.RefundReason(refund.Reason)},
+		ID:       refund.ID,
+		Status:   sqlc.RefundStatus(refund.Status),
+		Reason:   sqlc.NullRefundReason{RefundReason: sqlc.RefundReason(refund.Reason)},
+		TenantID: driver.TenantID(ctx),
 	}); err != nil {
 		return fmt.Errorf("failed to update refund: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("refund:%s", refund.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("refund:%s", refund.ID))
 	if err := r.cache.Set(ctx, cacheKey, refund); err != nil {
 		r.logger.Warn("Failed to update refund in cache", zap.Error(err), zap.String("id", refund.ID))
 	}
@@ -142,123 +150,107 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, refund *models.Refun
 	return nil
 }
 
-func (r *repository) List(ctx context.Context, tx pgx.Tx, chargeID string, limit, offset uint64) ([]*models.Refund, error) {
-	cacheKey := fmt.Sprintf("refunds:chargeID:%s:limit:%d:offset:%d", chargeID, limit, offset)
-	var cachedRefunds []*models.Refund
-	found, err := r.cache.Get(ctx, cacheKey, &cachedRefunds)
-	if err != nil {
-		r.logger.Warn("Failed to get refunds from cache", zap.Error(err), zap.String("chargeID", chargeID))
-	} else if found {
-		return cachedRefunds, nil
+// List 依 (created_at, id) 遞減以 keyset 分頁列出退款，多查詢一筆判斷是否還有下一頁
+func (r *repository) List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Refund], error) {
+	limit := params.PageSize()
+	arg := sqlc.ListRefundsParams{
+		CreatedFrom: pgtype.Timestamptz{Time: params.CreatedFrom, Valid: !params.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: params.CreatedTo, Valid: !params.CreatedTo.IsZero()},
+		Limit:       int64(limit + 1),
+		TenantID:    driver.TenantID(ctx),
+	}
+	if params.ChargeID != "" {
+		arg.ChargeID = &params.ChargeID
+	}
+	if params.Status != "" {
+		status := sqlc.RefundStatus(params.Status)
+		if !status.Valid() {
+			return nil, fmt.Errorf("%w: refund status %q", models.ErrInvalidListFilter, params.Status)
+		}
+		arg.Status = sqlc.NullRefundStatus{RefundStatus: status, Valid: true}
+	}
+	if params.Currency != "" {
+		currency := sqlc.Currency(params.Currency)
+		if !currency.Valid() {
+			return nil, fmt.Errorf("%w: currency %q", models.ErrInvalidListFilter, params.Currency)
+		}
+		arg.Currency = sqlc.NullCurrency{Currency: currency, Valid: true}
+	}
+	if params.Cursor != nil {
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: params.Cursor.CreatedAt, Valid: true}
+		arg.CursorID = &params.Cursor.ID
 	}
 
-	sqlcRefunds, err := sqlc.New(r.conn).WithTx(tx).ListRefunds(ctx, sqlc.ListRefundsParams{
-		ChargeID: chargeID,
-		Limit:    int64(limit),
-		Offset:   int64(offset),
-	})
+	sqlcRefunds, err := sqlc.New(r.conn).WithTx(tx).ListRefunds(ctx, arg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list refunds: %w", err)
 	}
 
 	refunds := make([]*models.Refund, 0, len(sqlcRefunds))
 	for _, sqlcRefund := range sqlcRefunds {
-		refund, release, err := r.getFromPool(ctx)
-		if err != nil {
-			r.logger.Error("Failed to get refund from pool", zap.Error(err))
-			continue
-		}
-
-		*refund = *models.NewRefund().ConvertFromSQLCRefund(sqlcRefund)
-		refunds = append(refunds, refund)
-
-		// Update cache for individual refund
-		individualCacheKey := fmt.Sprintf("refund:%s", refund.ID)
-		if err = r.cache.Set(ctx, individualCacheKey, refund); err != nil {
-			r.logger.Warn("Failed to cache individual refund", zap.Error(err), zap.String("id", refund.ID))
-		}
-
-		release()
-	}
-
-	// Cache the list of refunds
-	if err = r.cache.Set(ctx, cacheKey, refunds); err != nil {
-		r.logger.Warn("Failed to cache refunds list", zap.Error(err), zap.String("chargeID", chargeID))
+		refunds = append(refunds, models.NewRefund().ConvertFromSQLCRefund(sqlcRefund))
 	}
 
-	return refunds, nil
+	return models.NewPage(refunds, limit, func(item *models.Refund) models.Cursor {
+		return models.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+	}), nil
 }
 
-func (r *repository) ListByChargeID(ctx context.Context, chargeID string) ([]*models.Refund, error) {
-	cacheKey := fmt.Sprintf("refunds:chargeID:%s", chargeID)
-	var cachedRefunds []*models.Refund
-	found, err := r.cache.Get(ctx, cacheKey, &cachedRefunds)
-	if err != nil {
-		r.logger.Warn("Failed to get refunds from cache", zap.Error(err), zap.String("chargeID", chargeID))
-	} else if found {
-		return cachedRefunds, nil
-	}
-
-	sqlcRefunds, err := sqlc.New(r.conn).ListByChargeID(ctx, chargeID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list refunds by Stripe ID: %w", err)
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, refund *models.PartialRefund) error {
+	if _, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, refund)); err != nil {
+		return fmt.Errorf("failed to upsert refund: %w", err)
 	}
 
-	refunds := make([]*models.Refund, 0, len(sqlcRefunds))
-	for _, sqlcRefund := range sqlcRefunds {
-		refund, release, err := r.getFromPool(ctx)
-		if err != nil {
-			r.logger.Error("Failed to get refund from pool", zap.Error(err))
-			continue
-		}
-
-		*refund = *models.NewRefund().ConvertFromSQLCRefund(sqlcRefund)
-		refunds = append(refunds, refund)
+	return nil
+}
 
-		// Update cache for individual refund
-		individualCacheKey := fmt.Sprintf("refund:%s", refund.ID)
-		if err = r.cache.Set(ctx, individualCacheKey, refund); err != nil {
-			r.logger.Warn("Failed to cache individual refund", zap.Error(err), zap.String("id", refund.ID))
-		}
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, refunds []*models.PartialRefund) error {
+	if len(refunds) == 0 {
+		return nil
+	}
 
-		release()
+	batch := &pgx.Batch{}
+	for _, refund := range refunds {
+		batch.Queue(upsertQuery, upsertArgs(ctx, refund))
 	}
 
-	// Cache the list of refunds
-	if err = r.cache.Set(ctx, cacheKey, refunds); err != nil {
-		r.logger.Warn("Failed to cache refunds list", zap.Error(err), zap.String("chargeID", chargeID))
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert refunds: %w", err)
 	}
 
-	return refunds, nil
+	return nil
 }
 
-func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, refund *models.PartialRefund) error {
-	const query = `
-    INSERT INTO refunds (id, charge_id, amount, status, reason, created_at, updated_at)
-    VALUES (@id, @charge_id, @amount, @status, @reason, COALESCE(@created_at, NOW()), @updated_at)
+const upsertQuery = `
+    INSERT INTO refunds (id, charge_id, amount, currency, status, reason, created_at, updated_at, last_event_at)
+    VALUES (@id, @charge_id, @amount, @currency, @status, @reason, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         charge_id = COALESCE(@charge_id, refunds.charge_id),
         amount = COALESCE(@amount, refunds.amount),
+        currency = COALESCE(@currency, refunds.currency),
         status = COALESCE(@status, refunds.status),
         reason = COALESCE(@reason, refunds.reason),
+        last_event_at = COALESCE(@last_event_at, refunds.last_event_at),
         updated_at = @updated_at
     WHERE refunds.id = @id
+      AND refunds.tenant_id = @tenant_id
+      AND (refunds.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR refunds.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, refund *models.PartialRefund) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
-		"id":         refund.ID,
-		"charge_id":  refund.ChargeID,
-		"amount":     refund.Amount,
-		"status":     refund.Status,
-		"reason":     refund.Reason,
-		"created_at": refund.CreatedAt,
-		"updated_at": now,
+	return pgx.NamedArgs{
+		"id":            refund.ID,
+		"charge_id":     refund.ChargeID,
+		"amount":        refund.Amount,
+		"currency":      refund.Currency,
+		"status":        refund.Status,
+		"reason":        refund.Reason,
+		"created_at":    refund.CreatedAt,
+		"updated_at":    now,
+		"last_event_at": refund.LastEventAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
-
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert refund: %w", err)
-	}
-
-	return nil
 }
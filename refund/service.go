@@ -16,9 +16,9 @@ type Service interface {
 	Create(ctx context.Context, refund *models.Refund) error
 	GetByID(ctx context.Context, id string) (*models.Refund, error)
 	UpdateStatus(ctx context.Context, id string, status stripe.RefundStatus, reason stripe.RefundReason) error
-	List(ctx context.Context, chargeID string, limit, offset uint64) ([]*models.Refund, error)
-	ListByChargeID(ctx context.Context, chargeID string) ([]*models.Refund, error)
+	List(ctx context.Context, params models.ListParams) (*models.Page[*models.Refund], error)
 	Upsert(ctx context.Context, refund *models.PartialRefund) error
+	UpsertBatch(ctx context.Context, refunds []*models.PartialRefund) error
 }
 
 type service struct {
@@ -73,29 +73,16 @@ func (s *service) UpdateStatus(ctx context.Context, id string, status stripe.Ref
 	return nil
 }
 
-func (s *service) List(ctx context.Context, chargeID string, limit, offset uint64) ([]*models.Refund, error) {
-	var refunds []*models.Refund
+func (s *service) List(ctx context.Context, params models.ListParams) (*models.Page[*models.Refund], error) {
+	var page *models.Page[*models.Refund]
 	if err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		var err error
-		refunds, err = s.repo.List(ctx, tx, chargeID, limit, offset)
+		page, err = s.repo.List(ctx, tx, params)
 		return err
 	}); err != nil {
 		return nil, fmt.Errorf("failed to list refunds: %w", err)
 	}
-	return refunds, nil
-}
-
-func (s *service) ListByChargeID(ctx context.Context, chargeID string) ([]*models.Refund, error) {
-
-	var refunds []*models.Refund
-	if err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		var err error
-		refunds, err = s.repo.ListByChargeID(ctx, chargeID)
-		return err
-	}); err != nil {
-		return nil, fmt.Errorf("failed to list refunds: %w", err)
-	}
-	return refunds, nil
+	return page, nil
 }
 
 func (s *service) Upsert(ctx context.Context, refund *models.PartialRefund) error {
@@ -103,3 +90,9 @@ func (s *service) Upsert(ctx context.Context, refund *models.PartialRefund) erro
 		return s.repo.Upsert(ctx, tx, refund)
 	})
 }
+
+func (s *service) UpsertBatch(ctx context.Context, refunds []*models.PartialRefund) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.UpsertBatch(ctx, tx, refunds)
+	})
+}
@@ -0,0 +1,66 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/event"
+	"goflare.io/payment/models"
+)
+
+// ReplayResult 彙整一次 ReplayEvents 的處理結果
+type ReplayResult struct {
+	Matched  int
+	Replayed int
+	Failures []ReplayFailure
+}
+
+type ReplayFailure struct {
+	EventID string
+	Err     error
+}
+
+// ListReplayableEvents 列出符合條件且保留原始 payload 的事件
+func (sp *StripePayment) ListReplayableEvents(ctx context.Context, filter event.ReplayFilter) ([]*models.Event, error) {
+	return sp.event.ListForReplay(ctx, filter)
+}
+
+// ReplayEvents 依 Stripe 建立時間順序，將保存的原始事件重新交給 ProcessEvent 處理
+// 單筆事件失敗不會中斷其餘事件，失敗的事件會記錄在 ReplayResult.Failures
+func (sp *StripePayment) ReplayEvents(ctx context.Context, filter event.ReplayFilter) (*ReplayResult, error) {
+	events, err := sp.event.ListForReplay(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events for replay: %w", err)
+	}
+
+	result := &ReplayResult{Matched: len(events)}
+	for _, e := range events {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var stripeEvent stripe.Event
+		if err = json.Unmarshal(e.Payload, &stripeEvent); err != nil {
+			result.Failures = append(result.Failures, ReplayFailure{EventID: e.ID, Err: fmt.Errorf("failed to unmarshal payload: %w", err)})
+			continue
+		}
+
+		if err = sp.ProcessEvent(ctx, &stripeEvent); err != nil {
+			result.Failures = append(result.Failures, ReplayFailure{EventID: e.ID, Err: err})
+			continue
+		}
+
+		result.Replayed++
+	}
+
+	sp.logger.Info("Replayed events",
+		zap.Int("matched", result.Matched),
+		zap.Int("replayed", result.Replayed),
+		zap.Int("failed", len(result.Failures)))
+
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/event"
+)
+
+const (
+	defaultRetryInterval  = 15 * time.Second
+	defaultRetryBatchSize = 100
+
+	// retryLease 為取出事件後暫時延後的 next_attempt_at，需大於單筆事件的處理時間
+	retryLease = 5 * time.Minute
+)
+
+// RetryScheduler 定期取出到期的失敗事件，重新交給 WorkerPool 處理
+// 處理結果由 ProcessEvent 寫回 events 表，成功即標記為已處理，失敗則排定下一次重試
+type RetryScheduler struct {
+	events    event.Service
+	pool      *WorkerPool
+	interval  time.Duration
+	batchSize int
+	logger    *zap.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newRetryPolicy(cfg config.RetryConfig) event.RetryPolicy {
+	return event.RetryPolicy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}
+}
+
+func NewRetryScheduler(events event.Service, pool *WorkerPool, cfg config.RetryConfig, logger *zap.Logger) *RetryScheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultRetryInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultRetryBatchSize
+	}
+
+	return &RetryScheduler{
+		events:    events,
+		pool:      pool,
+		interval:  cfg.Interval,
+		batchSize: cfg.BatchSize,
+		logger:    logger,
+	}
+}
+
+func (rs *RetryScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	rs.done = make(chan struct{})
+
+	go func() {
+		defer close(rs.done)
+
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rs.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止排程器並等待目前這一批事件送入 WorkerPool
+func (rs *RetryScheduler) Stop() {
+	if rs.cancel == nil {
+		return
+	}
+	rs.cancel()
+	<-rs.done
+}
+
+// runOnce 取出所有租戶到期的事件，再以事件所屬的租戶處理
+func (rs *RetryScheduler) runOnce(ctx context.Context) {
+	events, err := rs.events.ClaimDueForRetry(driver.WithAllTenants(ctx), rs.batchSize, retryLease)
+	if err != nil {
+		rs.logger.Error("Failed to claim events for retry", zap.Error(err))
+		return
+	}
+
+	for _, e := range events {
+		var stripeEvent stripe.Event
+		if err = json.Unmarshal(e.Payload, &stripeEvent); err != nil {
+			rs.logger.Error("Failed to unmarshal event for retry", zap.Error(err), zap.String("event_id", e.ID))
+			continue
+		}
+
+		// 送入失敗的事件會在租約到期後再次被取出
+		if err = rs.pool.Submit(driver.WithTenant(ctx, e.TenantID), &stripeEvent); err != nil {
+			rs.logger.Warn("Stopped submitting events for retry", zap.Error(err))
+			return
+		}
+
+		rs.logger.Info("Retrying event",
+			zap.String("event_id", e.ID),
+			zap.String("tenant", e.TenantID),
+			zap.Int("attempt", e.Attempts+1))
+	}
+}
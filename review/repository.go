@@ -27,8 +27,8 @@ func NewRepository(conn driver.PostgresPool) Repository {
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, review *models.PartialReview) error {
 	const query = `
-    INSERT INTO reviews (id, payment_intent_id, reason, closed_reason, status, opened_at, closed_at, created_at, updated_at)
-    VALUES (@id, @payment_intent_id, @reason, @closed_reason, @status, @opened_at, @closed_at, COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO reviews (id, payment_intent_id, reason, closed_reason, status, opened_at, closed_at, created_at, updated_at, last_event_at)
+    VALUES (@id, @payment_intent_id, @reason, @closed_reason, @status, @opened_at, @closed_at, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         payment_intent_id = COALESCE(@payment_intent_id, reviews.payment_intent_id),
         reason = COALESCE(@reason, reviews.reason),
@@ -36,8 +36,11 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, review *models.Parti
         status = COALESCE(@status, reviews.status),
         opened_at = COALESCE(@opened_at, reviews.opened_at),
         closed_at = COALESCE(@closed_at, reviews.closed_at),
+        last_event_at = COALESCE(@last_event_at, reviews.last_event_at),
         updated_at = @updated_at
     WHERE reviews.id = @id
+      AND reviews.tenant_id = @tenant_id
+      AND (reviews.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR reviews.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
@@ -51,6 +54,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, review *models.Parti
 		"closed_at":         review.ClosedAt,
 		"created_at":        review.CreatedAt,
 		"updated_at":        now,
+		"last_event_at":     review.LastEventAt,
+		"tenant_id":         driver.TenantID(ctx),
 	}
 
 	if _, err := tx.Exec(ctx, query, args); err != nil {
@@ -61,5 +66,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, review *models.Parti
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteReviews(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeleteReviews(ctx, sqlc.DeleteReviewsParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
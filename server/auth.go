@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/apikey"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/handlers"
+	"goflare.io/payment/models"
+)
+
+const (
+	// APIKeyHeader 為 Authorization 以外另一個可以帶入 API key 的 header
+	APIKeyHeader = "X-API-Key"
+
+	bearerScheme = "Bearer "
+)
+
+var errMissingAPIKey = domainerr.New(domainerr.CodeUnauthenticated, "Missing API key")
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext 回傳請求認證使用的 API key，未認證時為 nil
+func APIKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*models.APIKey)
+	return key
+}
+
+// Auth 以 API key 認證 HTTP 請求，並依路由檢查 key 的權限
+type Auth struct {
+	apiKeys apikey.Service
+	logger  *zap.Logger
+}
+
+func NewAuth(apiKeys apikey.Service, logger *zap.Logger) *Auth {
+	return &Auth{
+		apiKeys: apiKeys,
+		logger:  logger,
+	}
+}
+
+// Middleware 驗證 Authorization: Bearer 或 X-API-Key header 帶入的 key
+//   - 缺少 key、key 無效或已撤銷：回傳 401
+//   - 認證成功：把 key 放進請求的 context，handler 完成後記錄使用的 key、路徑與回應狀態
+//
+// /webhook 與 /webhook/:tenant 由 Stripe 呼叫並以簽章驗證，/healthz 與 /readyz 供探測使用，都不需要 API key
+func (a *Auth) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isWebhookPath(c.Path()) || isHealthPath(c.Path()) {
+				return next(c)
+			}
+
+			req := c.Request()
+			raw := requestAPIKey(req)
+			if raw == "" {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, strings.TrimSpace(bearerScheme))
+				return handlers.RespondError(c, errMissingAPIKey, "")
+			}
+
+			key, err := a.apiKeys.Authenticate(req.Context(), raw)
+			if errors.Is(err, apikey.ErrInvalidAPIKey) {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, strings.TrimSpace(bearerScheme))
+				return handlers.RespondError(c, err, "")
+			}
+			if err != nil {
+				a.logger.Error("failed to authenticate api key", zap.Error(err))
+				return handlers.RespondError(c, err, "Failed to authenticate API key")
+			}
+
+			c.SetRequest(req.WithContext(context.WithValue(req.Context(), apiKeyContextKey{}, key)))
+
+			// 在 middleware 內交給 echo 的錯誤處理，才能記錄錯誤回應的狀態
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+
+			a.record(context.WithoutCancel(req.Context()), key, req, c.Response().Status)
+			return nil
+		}
+	}
+}
+
+// RequireScope 回傳檢查權限的路由 middleware，key 沒有該權限時回傳 403
+func (a *Auth) RequireScope(scope models.APIKeyScope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := APIKeyFromContext(c.Request().Context())
+			if key == nil {
+				return handlers.RespondError(c, errMissingAPIKey, "")
+			}
+			if !key.Allows(scope) {
+				return handlers.RespondError(c, domainerr.New(domainerr.CodePermissionDenied, "API key does not have the "+string(scope)+" scope"), "")
+			}
+			return next(c)
+		}
+	}
+}
+
+// record 保存請求使用的 key；寫入失敗只記錄 log，不影響已完成的請求
+func (a *Auth) record(ctx context.Context, key *models.APIKey, req *http.Request, status int) {
+	err := a.apiKeys.RecordRequest(ctx, &models.APIKeyRequest{
+		APIKeyID:  key.ID,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Status:    status,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		a.logger.Warn("failed to record api key usage",
+			zap.Error(err),
+			zap.String("api_key", key.Prefix),
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+		)
+	}
+}
+
+func requestAPIKey(req *http.Request) string {
+	if auth := req.Header.Get(echo.HeaderAuthorization); len(auth) > len(bearerScheme) &&
+		strings.EqualFold(auth[:len(bearerScheme)], bearerScheme) {
+		return strings.TrimSpace(auth[len(bearerScheme):])
+	}
+	return strings.TrimSpace(req.Header.Get(APIKeyHeader))
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/handlers"
+)
+
+const (
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyLockTTL = time.Minute
+
+	// maxIdempotencyKeyLength 與 Stripe 的上限相同，key 會原樣轉送給 Stripe
+	maxIdempotencyKeyLength = 255
+
+	idempotencyKeyPrefix = "idempotency:"
+
+	// IdempotentReplayedHeader 標示回應是重送先前保存的結果
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+var (
+	errIdempotencyInProgress = domainerr.New(domainerr.CodeConflict, "A request with this Idempotency-Key is in progress, retry later")
+	errIdempotencyMismatch   = domainerr.New(domainerr.CodeConflict, "Idempotency-Key was already used with a different request")
+)
+
+// idempotencyRecord 為 Redis 中保存的請求指紋與回應，Completed 為 false 代表請求仍在處理中
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency 以 Redis 保存帶有 Idempotency-Key 的寫入請求與回應
+// 同一個 key 重送相同的請求時回傳保存的回應，不再執行 handler；請求內容不同時拒絕
+type Idempotency struct {
+	redis   *redis.Client
+	ttl     atomic.Int64
+	lockTTL atomic.Int64
+	logger  *zap.Logger
+}
+
+func NewIdempotency(rdb *redis.Client, cfg *config.Config, logger *zap.Logger) *Idempotency {
+	i := &Idempotency{
+		redis:  rdb,
+		logger: logger,
+	}
+	i.setConfig(cfg.Idempotency)
+	cfg.OnReload(func(next *config.Config) {
+		i.setConfig(next.Idempotency)
+	})
+
+	return i
+}
+
+// setConfig 套用保存時間，只影響之後保存的 key
+func (i *Idempotency) setConfig(cfg config.IdempotencyConfig) {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	lockTTL := cfg.LockTTL
+	if lockTTL <= 0 {
+		lockTTL = defaultIdempotencyLockTTL
+	}
+
+	i.ttl.Store(int64(ttl))
+	i.lockTTL.Store(int64(lockTTL))
+}
+
+// Middleware 處理帶有 Idempotency-Key 的 POST、PUT、PATCH 與 DELETE 請求
+//   - 第一次收到 key 時先以 SETNX 佔用，handler 完成後保存回應；5xx、403 與 429 不保存，呼叫端可以同一個 key 重試
+//   - 相同 key 與相同請求：處理中回傳 409，已完成則重送保存的回應並加上 Idempotent-Replayed header
+//   - 相同 key 但 method、路徑或 body 不同：回傳 409
+//
+// Redis 無法使用時直接執行 handler，key 仍會轉送給 Stripe，重試不會重複扣款
+func (i *Idempotency) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(handlers.IdempotencyKeyHeader)
+			if key == "" || !isMutatingMethod(req.Method) {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return handlers.RespondError(c, domainerr.New(domainerr.CodeValidation, "Idempotency-Key must be at most 255 characters"), "")
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return handlers.RespondError(c, domainerr.Wrap(err, domainerr.CodeValidation, "Failed to read request body"), "")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := req.Context()
+			redisKey := idempotencyKeyPrefix + key
+			if apiKey := APIKeyFromContext(ctx); apiKey != nil {
+				// 不同 API key 各自擁有 key 的命名空間，不會重送其他呼叫端的回應
+				redisKey = idempotencyKeyPrefix + apiKey.Prefix + ":" + key
+			}
+			// 平台 key 可以操作多個租戶，同一個 key 在不同租戶的請求各自保存
+			redisKey = driver.TenantCacheKey(ctx, redisKey)
+			fingerprint := requestFingerprint(req, body)
+
+			pending, err := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+			if err != nil {
+				return err
+			}
+			acquired, err := i.redis.SetNX(ctx, redisKey, pending, time.Duration(i.lockTTL.Load())).Result()
+			if err != nil {
+				i.logger.Warn("failed to acquire idempotency key, continuing without replay protection",
+					zap.Error(err), zap.String("key", key))
+				return next(c)
+			}
+			if !acquired {
+				return i.replay(c, redisKey, fingerprint)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			// 在 middleware 內交給 echo 的錯誤處理，才能記錄錯誤回應
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+
+			i.store(context.WithoutCancel(ctx), redisKey, fingerprint, c.Response(), recorder.body.Bytes())
+			return nil
+		}
+	}
+}
+
+// replay 處理 key 已被佔用的請求
+func (i *Idempotency) replay(c echo.Context, redisKey, fingerprint string) error {
+	stored, err := i.redis.Get(c.Request().Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// 先前的請求剛好失敗或過期，讓呼叫端重試
+		return handlers.RespondError(c, errIdempotencyInProgress, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	var record idempotencyRecord
+	if err = json.Unmarshal(stored, &record); err != nil {
+		return err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return handlers.RespondError(c, errIdempotencyMismatch, "")
+	}
+	if !record.Completed {
+		return handlers.RespondError(c, errIdempotencyInProgress, "")
+	}
+
+	c.Response().Header().Set(IdempotentReplayedHeader, "true")
+	if len(record.Body) == 0 {
+		return c.NoContent(record.Status)
+	}
+	return c.Blob(record.Status, record.ContentType, record.Body)
+}
+
+// store 保存 handler 的回應；沒有寫出回應或不應保存的狀態碼時釋放 key，讓呼叫端重試
+func (i *Idempotency) store(ctx context.Context, redisKey, fingerprint string, resp *echo.Response, body []byte) {
+	if !resp.Committed || !storableStatus(resp.Status) {
+		if err := i.redis.Del(ctx, redisKey).Err(); err != nil {
+			i.logger.Warn("failed to release idempotency key", zap.Error(err), zap.String("key", redisKey))
+		}
+		return
+	}
+
+	record, err := json.Marshal(idempotencyRecord{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		Status:      resp.Status,
+		ContentType: resp.Header().Get(echo.HeaderContentType),
+		Body:        body,
+	})
+	if err == nil {
+		err = i.redis.Set(ctx, redisKey, record, time.Duration(i.ttl.Load())).Err()
+	}
+	if err != nil {
+		i.logger.Warn("failed to store idempotent response", zap.Error(err), zap.String("key", redisKey))
+	}
+}
+
+// storableStatus 回報回應是否應保存供重送
+// 403 與 429 由路由的權限檢查與限流產生，handler 沒有執行；取得權限或等待 Retry-After 後以同一個 key 重試時應重新執行
+func storableStatus(status int) bool {
+	switch {
+	case status >= http.StatusInternalServerError, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		return false
+	}
+	return true
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint 以 method、路徑與 body 判斷重送的是否為同一個請求
+func requestFingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder 在寫出回應的同時保留一份 body
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
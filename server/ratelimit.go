@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/handlers"
+	"goflare.io/payment/ratelimit"
+)
+
+const (
+	// RateLimitLimitHeader 與 RateLimitRemainingHeader 回報 bucket 的容量與剩餘的請求數
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+)
+
+// RateLimit 以 ratelimit.Limiter 限制 HTTP 請求，超出限制時回傳 429 與 Retry-After
+type RateLimit struct {
+	limiter *ratelimit.Limiter
+}
+
+func NewRateLimit(limiter *ratelimit.Limiter) *RateLimit {
+	return &RateLimit{limiter: limiter}
+}
+
+// Middleware 回傳限制 API key 在路由群組請求速率的路由 middleware，必須放在 Auth 之後
+// 以 Idempotency-Key 重送而回傳保存的回應時不會執行路由 middleware，也不會扣除 token
+func (r *RateLimit) Middleware(group string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := APIKeyFromContext(c.Request().Context())
+			if !r.limiter.Enabled() || key == nil {
+				return next(c)
+			}
+
+			rule := r.limiter.Rule(group, key.Prefix)
+			result := r.limiter.Allow(c.Request().Context(), ratelimit.APIKeyBucket(key.Prefix, group), rule)
+			if !result.Allowed {
+				return tooManyRequests(c, result, "Rate limit exceeded")
+			}
+			setRateLimitHeaders(c, result)
+			return next(c)
+		}
+	}
+}
+
+// Customer 回傳限制同一個客戶建立付款速率的路由 middleware，不論使用哪個 API key，用來減緩盜刷卡片的測試
+// 客戶取自 JSON 或表單 body 的 customer_id，讀取後還原 body 供 handler 使用；沒有 customer_id 時不限制
+func (r *RateLimit) Customer() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !r.limiter.Enabled() {
+				return next(c)
+			}
+
+			customerID, err := requestCustomerID(c)
+			if err != nil {
+				return handlers.RespondError(c, domainerr.Wrap(err, domainerr.CodeValidation, "Failed to read request body"), "")
+			}
+			if customerID == "" {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			result := r.limiter.Allow(ctx, ratelimit.CustomerBucket(ctx, customerID), r.limiter.CustomerRule())
+			if !result.Allowed {
+				return tooManyRequests(c, result, "Too many payment attempts for this customer")
+			}
+			return next(c)
+		}
+	}
+}
+
+// requestCustomerID 讀取 body 中的 customer_id；JSON 格式錯誤時回傳空值，交由 handler 回應
+func requestCustomerID(c echo.Context) (string, error) {
+	req := c.Request()
+	if !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		// 表單解析後保存在 req.Form，handler 的 Bind 不需要再讀取 body
+		return c.FormValue("customer_id"), nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		CustomerID string `json:"customer_id"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	return payload.CustomerID, nil
+}
+
+func tooManyRequests(c echo.Context, result ratelimit.Result, message string) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(result.RetryAfter)))
+	setRateLimitHeaders(c, result)
+	return handlers.RespondError(c, domainerr.New(domainerr.CodeRateLimited, message), "")
+}
+
+func setRateLimitHeaders(c echo.Context, result ratelimit.Result) {
+	if result.Limit == 0 {
+		return
+	}
+	c.Response().Header().Set(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+	c.Response().Header().Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+}
@@ -9,17 +9,39 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
+	"goflare.io/payment/config"
+	grpcserver "goflare.io/payment/grpc"
 	"goflare.io/payment/handlers"
+	"goflare.io/payment/metrics"
+	"goflare.io/payment/models"
+	"goflare.io/payment/tracing"
 )
 
+const defaultShutdownTimeout = 5 * time.Second
+
 type Server struct {
 	echo          *echo.Echo
+	config        config.ServerConfig
 	Customer      handlers.CustomerHandler
 	Product       handlers.ProductHandler
 	Price         handlers.PriceHandler
 	PaymentIntent handlers.PaymentIntentHandler
+	PaymentMethod handlers.PaymentMethodHandler
+	Subscription  handlers.SubscriptionHandler
+	Invoice       handlers.InvoiceHandler
+	Refund        handlers.RefundHandler
+	Connect       handlers.ConnectHandler
 	Webhook       handlers.WebhookHandler
+	Event         handlers.EventHandler
+	Health        handlers.HealthHandler
+	GRPC          *grpcserver.Server
+	Idempotency   *Idempotency
+	Auth          *Auth
+	Tenancy       *Tenancy
+	RateLimit     *RateLimit
+	Tracing       *tracing.Provider
 }
 
 func NewServer(
@@ -27,15 +49,48 @@ func NewServer(
 	Product handlers.ProductHandler,
 	Price handlers.PriceHandler,
 	PaymentIntent handlers.PaymentIntentHandler,
+	PaymentMethod handlers.PaymentMethodHandler,
+	Subscription handlers.SubscriptionHandler,
+	Invoice handlers.InvoiceHandler,
+	Refund handlers.RefundHandler,
+	Connect handlers.ConnectHandler,
 	Webhook handlers.WebhookHandler,
+	Event handlers.EventHandler,
+	Health handlers.HealthHandler,
+	GRPC *grpcserver.Server,
+	Idempotency *Idempotency,
+	Auth *Auth,
+	Tenancy *Tenancy,
+	RateLimit *RateLimit,
+	Tracing *tracing.Provider,
+	cfg *config.Config,
 ) *Server {
+	serverConfig := cfg.Server
+	if serverConfig.ShutdownTimeout <= 0 {
+		serverConfig.ShutdownTimeout = defaultShutdownTimeout
+	}
+
 	return &Server{
 		echo:          echo.New(),
+		config:        serverConfig,
 		Customer:      Customer,
 		Product:       Product,
 		Price:         Price,
 		Webhook:       Webhook,
 		PaymentIntent: PaymentIntent,
+		PaymentMethod: PaymentMethod,
+		Subscription:  Subscription,
+		Invoice:       Invoice,
+		Refund:        Refund,
+		Connect:       Connect,
+		Event:         Event,
+		Health:        Health,
+		GRPC:          GRPC,
+		Idempotency:   Idempotency,
+		Auth:          Auth,
+		Tenancy:       Tenancy,
+		RateLimit:     RateLimit,
+		Tracing:       Tracing,
 	}
 }
 
@@ -47,15 +102,21 @@ func (s *Server) Start(address string) error {
 	return s.echo.Start(address)
 }
 
-// Run starts the server by calling the Start method in a goroutine. If an error occurs, it
+// Run starts the HTTP server and the gRPC server on the configured addresses in separate goroutines. If an error occurs, it
 // logs the error and terminates the server. It then listens for an OS interrupt signal or a SIGTERM
-// signal to gracefully shut down the server. Once the signal is received, it creates a context with
-// a timeout of 5 seconds, cancels the context after the method returns, and returns the result of
-// shutting down the server.
-func (s *Server) Run(address string) error {
+// signal to gracefully shut down the servers. Once the signal is received, it stops the gRPC server,
+// creates a context with the configured shutdown timeout, cancels the context after the method returns, and
+// returns the result of shutting down the HTTP server.
+func (s *Server) Run() error {
 
 	go func() {
-		if err := s.Start(address); err != nil {
+		if err := s.Start(s.config.HTTPAddress); err != nil {
+			s.echo.Logger.Fatal(err)
+		}
+	}()
+
+	go func() {
+		if err := s.GRPC.Start(s.config.GRPCAddress); err != nil {
 			s.echo.Logger.Fatal(err)
 		}
 	}()
@@ -64,34 +125,87 @@ func (s *Server) Run(address string) error {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	s.GRPC.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
 	defer cancel()
 
-	return s.echo.Shutdown(ctx)
+	err := s.echo.Shutdown(ctx)
+	// 最後才停止 tracing，送出關閉期間完成的請求的 span
+	_ = s.Tracing.Shutdown(ctx)
+	return err
 }
 
 func (s *Server) registerMiddlewares() {
+	// 放在最外層，認證失敗或限流的請求也有 span，並從 traceparent header 接續呼叫端的 trace
+	s.echo.Use(otelecho.Middleware(s.Tracing.ServiceName()))
 	s.echo.Use(middleware.Recover())
+	s.echo.Use(s.Auth.Middleware())
+	s.echo.Use(s.Tenancy.Middleware())
+	s.echo.Use(s.Idempotency.Middleware())
 }
 
 func (s *Server) registerRoutes() {
 
-	s.echo.POST("/customer", s.Customer.CreateCustomer)
-	s.echo.GET("/customer/:id", s.Customer.GetCustomer)
-	s.echo.PUT("/customer/:id", s.Customer.UpdateCustomer)
-	s.echo.DELETE("/customer/:id", s.Customer.DeleteCustomer)
+	// 每個路由先檢查權限，再以權限的資源作為限流群組限制 API key 的請求速率
+	scope := func(scope models.APIKeyScope) echo.MiddlewareFunc {
+		requireScope, limit := s.Auth.RequireScope(scope), s.RateLimit.Middleware(scope.Resource())
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return requireScope(limit(next))
+		}
+	}
 
-	s.echo.POST("/product", s.Product.CreateProduct)
-	s.echo.GET("/product/:id", s.Product.GetProduct)
-	s.echo.PUT("/product/:id", s.Product.UpdateProduct)
-	s.echo.DELETE("/product/:id", s.Product.DeleteProduct)
-	s.echo.GET("/product", s.Product.ListProducts)
+	s.echo.POST("/customer", s.Customer.CreateCustomer, scope(models.ScopeCustomersWrite))
+	s.echo.GET("/customer/:id", s.Customer.GetCustomer, scope(models.ScopeCustomersRead))
+	s.echo.PUT("/customer/:id", s.Customer.UpdateCustomer, scope(models.ScopeCustomersWrite))
+	s.echo.DELETE("/customer/:id", s.Customer.DeleteCustomer, scope(models.ScopeCustomersWrite))
 
-	s.echo.POST("/price", s.Price.CreatePrice)
-	s.echo.DELETE("/price/:id", s.Price.DeletePrice)
+	s.echo.POST("/product", s.Product.CreateProduct, scope(models.ScopeProductsWrite))
+	s.echo.GET("/product/:id", s.Product.GetProduct, scope(models.ScopeProductsRead))
+	s.echo.PUT("/product/:id", s.Product.UpdateProduct, scope(models.ScopeProductsWrite))
+	s.echo.DELETE("/product/:id", s.Product.DeleteProduct, scope(models.ScopeProductsWrite))
+	s.echo.GET("/product", s.Product.ListProducts, scope(models.ScopeProductsRead))
 
-	s.echo.POST("/payment/intent", s.PaymentIntent.CreatePaymentIntent)
-	s.echo.POST("/payment/intent/confirm", s.PaymentIntent.ConfirmPaymentIntent)
+	s.echo.POST("/price", s.Price.CreatePrice, scope(models.ScopePricesWrite))
+	s.echo.DELETE("/price/:id", s.Price.DeletePrice, scope(models.ScopePricesWrite))
 
+	s.echo.POST("/payment/intent", s.PaymentIntent.CreatePaymentIntent, scope(models.ScopePaymentIntentsWrite), s.RateLimit.Customer())
+	s.echo.POST("/payment/intent/confirm", s.PaymentIntent.ConfirmPaymentIntent, scope(models.ScopePaymentIntentsWrite))
+	s.echo.GET("/payment/intent", s.PaymentIntent.ListPaymentIntents, scope(models.ScopePaymentIntentsRead))
+
+	// 支付方式屬於客戶，沿用客戶的權限
+	s.echo.GET("/payment/method", s.PaymentMethod.ListPaymentMethods, scope(models.ScopeCustomersRead))
+
+	s.echo.POST("/subscription", s.Subscription.CreateSubscription, scope(models.ScopeSubscriptionsWrite), s.RateLimit.Customer())
+	s.echo.GET("/subscription", s.Subscription.ListSubscriptions, scope(models.ScopeSubscriptionsRead))
+
+	s.echo.GET("/invoice", s.Invoice.ListInvoices, scope(models.ScopeInvoicesRead))
+
+	s.echo.POST("/refund", s.Refund.CreateRefund, scope(models.ScopeRefundsWrite))
+	s.echo.GET("/refund", s.Refund.ListRefunds, scope(models.ScopeRefundsRead))
+
+	s.echo.POST("/connect/account", s.Connect.CreateConnectedAccount, scope(models.ScopeConnectedAccountsWrite))
+	s.echo.GET("/connect/account/:id", s.Connect.GetConnectedAccount, scope(models.ScopeConnectedAccountsRead))
+	s.echo.POST("/connect/account/:id/link", s.Connect.CreateAccountLink, scope(models.ScopeConnectedAccountsWrite))
+	s.echo.GET("/connect/application_fee/:id", s.Connect.GetApplicationFee, scope(models.ScopeConnectedAccountsRead))
+
+	s.echo.POST("/transfer", s.Connect.CreateTransfer, scope(models.ScopeTransfersWrite))
+	s.echo.GET("/transfer/:id", s.Connect.GetTransfer, scope(models.ScopeTransfersRead))
+	s.echo.POST("/transfer/:id/reversal", s.Connect.ReverseTransfer, scope(models.ScopeTransfersWrite))
+
+	// Stripe 以簽章驗證，不經過 API key 認證；每個租戶的 Stripe 帳號設定各自的 endpoint，/webhook 屬於預設租戶
 	s.echo.POST("/webhook", s.Webhook.HandleWebhook)
+	s.echo.POST("/webhook/:tenant", s.Webhook.HandleWebhook)
+
+	s.echo.GET("/event/dead-letter", s.Event.ListDeadLetters, scope(models.ScopeEventsRead))
+	s.echo.POST("/event/dead-letter/:sequence/replay", s.Event.ReplayDeadLetter, scope(models.ScopeEventsWrite))
+	s.echo.GET("/event/workers", s.Event.WorkerStats, scope(models.ScopeEventsRead))
+	s.echo.GET("/event/failed", s.Event.ListFailedEvents, scope(models.ScopeEventsRead))
+
+	// 供負載平衡器與 Kubernetes 探測，不需要 API key
+	s.echo.GET("/healthz", s.Health.Liveness)
+	s.echo.GET("/readyz", s.Health.Readiness)
+
+	// Prometheus 以 authorization.credentials 帶入擁有 metrics:read 的 API key
+	s.echo.GET("/metrics", echo.WrapHandler(metrics.Handler()), scope(models.ScopeMetricsRead))
 }
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"goflare.io/payment"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/handlers"
+)
+
+// TenantHeader 讓平台 key 指定請求所屬的租戶
+const TenantHeader = "X-Tenant-ID"
+
+// Tenancy 決定每個 HTTP 請求所屬的租戶，並放進請求的 context 供資料庫與 Stripe 呼叫使用
+type Tenancy struct {
+	payment payment.Payment
+}
+
+func NewTenancy(payment payment.Payment) *Tenancy {
+	return &Tenancy{payment: payment}
+}
+
+// Middleware 依下列順序決定租戶，必須放在 Auth 之後
+//   - /webhook/:tenant：路徑中的租戶，/webhook 為預設租戶；租戶不存在時回傳 404
+//   - 屬於租戶的 API key：key 的租戶，X-Tenant-ID 指定其他租戶時回傳 403
+//   - 平台 API key：X-Tenant-ID 指定的租戶，未指定時為預設租戶
+//
+// 指定的租戶不在設定中時回傳 400
+func (t *Tenancy) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			if isWebhookPath(c.Path()) {
+				tenantID := c.Param("tenant")
+				if tenantID == "" {
+					tenantID = driver.DefaultTenantID
+				}
+				if !t.payment.HasTenant(tenantID) {
+					return handlers.RespondError(c, domainerr.New(domainerr.CodeNotFound, "Unknown webhook endpoint"), "")
+				}
+				c.SetRequest(req.WithContext(driver.WithTenant(req.Context(), tenantID)))
+				return next(c)
+			}
+
+			requested := strings.TrimSpace(req.Header.Get(TenantHeader))
+			tenantID := requested
+			if key := APIKeyFromContext(req.Context()); key != nil && key.TenantID != "" {
+				if requested != "" && requested != key.TenantID {
+					return handlers.RespondError(c, domainerr.New(domainerr.CodePermissionDenied, "API key cannot access tenant "+requested), "")
+				}
+				tenantID = key.TenantID
+			}
+			if tenantID == "" {
+				tenantID = driver.DefaultTenantID
+			}
+
+			if !t.payment.HasTenant(tenantID) {
+				return handlers.RespondError(c, domainerr.New(domainerr.CodeValidation, "Unknown tenant "+tenantID), "")
+			}
+
+			c.SetRequest(req.WithContext(driver.WithTenant(req.Context(), tenantID)))
+			return next(c)
+		}
+	}
+}
+
+// isWebhookPath 判斷路由是否為 Stripe webhook，webhook 以簽章驗證而非 API key
+func isWebhookPath(path string) bool {
+	return path == "/webhook" || strings.HasPrefix(path, "/webhook/")
+}
+
+// isHealthPath 判斷路由是否為 liveness 或 readiness 探測
+func isHealthPath(path string) bool {
+	return path == "/healthz" || path == "/readyz"
+}
@@ -10,10 +10,15 @@ import (
 )
 
 const deleteCheckOutSession = `-- name: DeleteCheckOutSession :exec
-DELETE FROM checkout_sessions WHERE id = $1
+DELETE FROM checkout_sessions WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteCheckOutSession(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteCheckOutSession, id)
+type DeleteCheckOutSessionParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteCheckOutSession(ctx context.Context, arg DeleteCheckOutSessionParams) error {
+	_, err := q.db.Exec(ctx, deleteCheckOutSession, arg.ID, arg.TenantID)
 	return err
 }
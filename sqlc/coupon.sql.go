@@ -18,7 +18,7 @@ INSERT INTO coupons (
 ) VALUES (
              $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
          )
-RETURNING id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by
+RETURNING id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by, tenant_id
 `
 
 type CreateCouponParams struct {
@@ -64,25 +64,22 @@ func (q *Queries) CreateCoupon(ctx context.Context, arg CreateCouponParams) (*Co
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.RedeemBy,
+		&i.TenantID,
 	)
 	return &i, err
 }
 
-const deleteCoupon = `-- name: DeleteCoupon :exec
-DELETE FROM coupons WHERE id = $1
+const getCouponByID = `-- name: GetCouponByID :one
+SELECT id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by, tenant_id FROM coupons WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1
 `
 
-func (q *Queries) DeleteCoupon(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteCoupon, id)
-	return err
+type GetCouponByIDParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
 }
 
-const getCouponByID = `-- name: GetCouponByID :one
-SELECT id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by FROM coupons WHERE id = $1 LIMIT 1
-`
-
-func (q *Queries) GetCouponByID(ctx context.Context, id string) (*Coupon, error) {
-	row := q.db.QueryRow(ctx, getCouponByID, id)
+func (q *Queries) GetCouponByID(ctx context.Context, arg GetCouponByIDParams) (*Coupon, error) {
+	row := q.db.QueryRow(ctx, getCouponByID, arg.ID, arg.TenantID)
 	var i Coupon
 	err := row.Scan(
 		&i.ID,
@@ -98,23 +95,26 @@ func (q *Queries) GetCouponByID(ctx context.Context, id string) (*Coupon, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.RedeemBy,
+		&i.TenantID,
 	)
 	return &i, err
 }
 
 const listCoupons = `-- name: ListCoupons :many
-SELECT id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by FROM coupons
+SELECT id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by, tenant_id FROM coupons
+WHERE tenant_id = $1 AND deleted_at IS NULL
 ORDER BY id
-LIMIT $1 OFFSET $2
+LIMIT $2 OFFSET $3
 `
 
 type ListCouponsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	TenantID string `json:"tenantId"`
+	Limit    int64  `json:"limit"`
+	Offset   int64  `json:"offset"`
 }
 
 func (q *Queries) ListCoupons(ctx context.Context, arg ListCouponsParams) ([]*Coupon, error) {
-	rows, err := q.db.Query(ctx, listCoupons, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listCoupons, arg.TenantID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +136,7 @@ func (q *Queries) ListCoupons(ctx context.Context, arg ListCouponsParams) ([]*Co
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.RedeemBy,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -160,8 +161,8 @@ SET name = $2,
     valid = $10,
     redeem_by = $11,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by
+WHERE id = $1 AND tenant_id = $12 AND deleted_at IS NULL
+RETURNING id, name, amount_off, percent_off, currency, duration, duration_in_months, max_redemptions, times_redeemed, valid, created_at, updated_at, redeem_by, tenant_id
 `
 
 type UpdateCouponParams struct {
@@ -176,6 +177,7 @@ type UpdateCouponParams struct {
 	TimesRedeemed    int32              `json:"timesRedeemed"`
 	Valid            bool               `json:"valid"`
 	RedeemBy         pgtype.Timestamptz `json:"redeemBy"`
+	TenantID         string             `json:"tenantId"`
 }
 
 func (q *Queries) UpdateCoupon(ctx context.Context, arg UpdateCouponParams) (*Coupon, error) {
@@ -191,6 +193,7 @@ func (q *Queries) UpdateCoupon(ctx context.Context, arg UpdateCouponParams) (*Co
 		arg.TimesRedeemed,
 		arg.Valid,
 		arg.RedeemBy,
+		arg.TenantID,
 	)
 	var i Coupon
 	err := row.Scan(
@@ -207,85 +210,7 @@ func (q *Queries) UpdateCoupon(ctx context.Context, arg UpdateCouponParams) (*Co
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.RedeemBy,
+		&i.TenantID,
 	)
 	return &i, err
 }
-
-const upsertCoupon = `-- name: UpsertCoupon :exec
-INSERT INTO coupons (
-    id,
-    name,
-    currency,
-    duration,
-    amount_off,
-    percent_off,
-    duration_in_months,
-    max_redemptions,
-    times_redeemed,
-    valid,
-    redeem_by,
-    created_at,
-    updated_at
-) VALUES (
-             $1,
-             $7,
-             $8,
-             $9,
-             $10,
-             $11,
-             $12,
-             $13,
-             $2,
-             $3,
-             $4,
-             $5,
-             $6
-         )
-ON CONFLICT (id) DO UPDATE SET
-                               name = COALESCE($7, coupons.name),
-                               currency = COALESCE($8, coupons.currency),
-                               duration = COALESCE($9, coupons.duration),
-                               amount_off = COALESCE($10, coupons.amount_off),
-                               percent_off = COALESCE($11, coupons.percent_off),
-                               duration_in_months = COALESCE($12, coupons.duration_in_months),
-                               max_redemptions = COALESCE($13, coupons.max_redemptions),
-                               times_redeemed = $2,
-                               valid = $3,
-                               redeem_by = $4,
-                               updated_at = $6
-`
-
-type UpsertCouponParams struct {
-	ID               string             `json:"id"`
-	TimesRedeemed    int32              `json:"timesRedeemed"`
-	Valid            bool               `json:"valid"`
-	RedeemBy         pgtype.Timestamptz `json:"redeemBy"`
-	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
-	Name             *string            `json:"name"`
-	Currency         NullCurrency       `json:"currency"`
-	Duration         NullCouponDuration `json:"duration"`
-	AmountOff        *int64             `json:"amountOff"`
-	PercentOff       float64            `json:"percentOff"`
-	DurationInMonths *int32             `json:"durationInMonths"`
-	MaxRedemptions   *int32             `json:"maxRedemptions"`
-}
-
-func (q *Queries) UpsertCoupon(ctx context.Context, arg UpsertCouponParams) error {
-	_, err := q.db.Exec(ctx, upsertCoupon,
-		arg.ID,
-		arg.TimesRedeemed,
-		arg.Valid,
-		arg.RedeemBy,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Name,
-		arg.Currency,
-		arg.Duration,
-		arg.AmountOff,
-		arg.PercentOff,
-		arg.DurationInMonths,
-		arg.MaxRedemptions,
-	)
-	return err
-}
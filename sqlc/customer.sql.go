@@ -42,20 +42,25 @@ func (q *Queries) CreateCustomer(ctx context.Context, arg CreateCustomerParams)
 }
 
 const deleteCustomer = `-- name: DeleteCustomer :exec
-DELETE FROM customers WHERE id = $1
+DELETE FROM customers WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteCustomer(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteCustomer, id)
+type DeleteCustomerParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteCustomer(ctx context.Context, arg DeleteCustomerParams) error {
+	_, err := q.db.Exec(ctx, deleteCustomer, arg.ID, arg.TenantID)
 	return err
 }
 
 const getCustomer = `-- name: GetCustomer :one
 SELECT c.id, c.balance, c.created_at, c.updated_at,
-       u.email, u.username as name
+       c.user_email as email, COALESCE(u.username, '')::varchar as name
 FROM customers c
-         JOIN users u ON c.user_email = u.email
-WHERE c.id = $1
+         LEFT JOIN users u ON c.user_email = u.email
+WHERE c.id = $1 AND c.tenant_id = $2 AND c.deleted_at IS NULL
 `
 
 type GetCustomerRow struct {
@@ -67,8 +72,13 @@ type GetCustomerRow struct {
 	Name      string             `json:"name"`
 }
 
-func (q *Queries) GetCustomer(ctx context.Context, dollar_1 *string) (*GetCustomerRow, error) {
-	row := q.db.QueryRow(ctx, getCustomer, dollar_1)
+type GetCustomerParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetCustomer(ctx context.Context, arg GetCustomerParams) (*GetCustomerRow, error) {
+	row := q.db.QueryRow(ctx, getCustomer, arg.ID, arg.TenantID)
 	var i GetCustomerRow
 	err := row.Scan(
 		&i.ID,
@@ -83,16 +93,18 @@ func (q *Queries) GetCustomer(ctx context.Context, dollar_1 *string) (*GetCustom
 
 const listCustomers = `-- name: ListCustomers :many
 SELECT c.id, c.user_email, c.balance, c.created_at, c.updated_at,
-    u.username as name
+    COALESCE(u.username, '')::varchar as name
 FROM customers c
-         JOIN users u ON c.user_email = u.email
+         LEFT JOIN users u ON c.user_email = u.email
+WHERE c.tenant_id = $3 AND c.deleted_at IS NULL
 ORDER BY c.created_at DESC
 LIMIT $1 OFFSET $2
 `
 
 type ListCustomersParams struct {
-	Column1 *int64 `json:"column1"`
-	Column2 *int64 `json:"column2"`
+	Column1  *int64 `json:"column1"`
+	Column2  *int64 `json:"column2"`
+	TenantID string `json:"tenantId"`
 }
 
 type ListCustomersRow struct {
@@ -105,7 +117,7 @@ type ListCustomersRow struct {
 }
 
 func (q *Queries) ListCustomers(ctx context.Context, arg ListCustomersParams) ([]*ListCustomersRow, error) {
-	rows, err := q.db.Query(ctx, listCustomers, arg.Column1, arg.Column2)
+	rows, err := q.db.Query(ctx, listCustomers, arg.Column1, arg.Column2, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -131,20 +143,58 @@ func (q *Queries) ListCustomers(ctx context.Context, arg ListCustomersParams) ([
 	return items, nil
 }
 
+const listCustomersByIDs = `-- name: ListCustomersByIDs :many
+SELECT id, user_email, balance, created_at, updated_at
+FROM customers
+WHERE id = ANY($1::varchar[]) AND tenant_id = $2
+`
+
+type ListCustomersByIDsParams struct {
+	Ids      []string `json:"ids"`
+	TenantID string   `json:"tenantId"`
+}
+
+func (q *Queries) ListCustomersByIDs(ctx context.Context, arg ListCustomersByIDsParams) ([]*Customer, error) {
+	rows, err := q.db.Query(ctx, listCustomersByIDs, arg.Ids, arg.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Customer{}
+	for rows.Next() {
+		var i Customer
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserEmail,
+			&i.Balance,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateCustomer = `-- name: UpdateCustomer :exec
 UPDATE customers
 SET balance = $2,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $3
 `
 
 type UpdateCustomerParams struct {
-	ID      string `json:"id"`
-	Balance int64  `json:"balance"`
+	ID       string `json:"id"`
+	Balance  int64  `json:"balance"`
+	TenantID string `json:"tenantId"`
 }
 
 func (q *Queries) UpdateCustomer(ctx context.Context, arg UpdateCustomerParams) error {
-	_, err := q.db.Exec(ctx, updateCustomer, arg.ID, arg.Balance)
+	_, err := q.db.Exec(ctx, updateCustomer, arg.ID, arg.Balance, arg.TenantID)
 	return err
 }
 
@@ -152,41 +202,16 @@ const updateCustomerBalance = `-- name: UpdateCustomerBalance :exec
 UPDATE customers
 SET balance = balance + $2,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $3
 `
 
 type UpdateCustomerBalanceParams struct {
-	ID      string `json:"id"`
-	Balance int64  `json:"balance"`
+	ID       string `json:"id"`
+	Balance  int64  `json:"balance"`
+	TenantID string `json:"tenantId"`
 }
 
 func (q *Queries) UpdateCustomerBalance(ctx context.Context, arg UpdateCustomerBalanceParams) error {
-	_, err := q.db.Exec(ctx, updateCustomerBalance, arg.ID, arg.Balance)
-	return err
-}
-
-const upsertCustomer = `-- name: UpsertCustomer :exec
-INSERT INTO customers (id, user_email, balance, updated_at)
-VALUES ($1, $2, $4, $3)
-ON CONFLICT (id) DO UPDATE SET
-                               user_email = COALESCE($2, customers.user_email),
-                               balance = COALESCE($4, customers.balance),
-                               updated_at = $3
-`
-
-type UpsertCustomerParams struct {
-	ID        string             `json:"id"`
-	UserEmail *string            `json:"userEmail"`
-	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
-	Balance   *int64             `json:"balance"`
-}
-
-func (q *Queries) UpsertCustomer(ctx context.Context, arg UpsertCustomerParams) error {
-	_, err := q.db.Exec(ctx, upsertCustomer,
-		arg.ID,
-		arg.UserEmail,
-		arg.UpdatedAt,
-		arg.Balance,
-	)
+	_, err := q.db.Exec(ctx, updateCustomerBalance, arg.ID, arg.Balance, arg.TenantID)
 	return err
 }
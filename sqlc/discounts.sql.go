@@ -17,7 +17,7 @@ INSERT INTO discounts (
 ) VALUES (
              $1, $2, $3, $4, $5
          )
-RETURNING id, customer_id, coupon_id, start, "end", created_at, updated_at
+RETURNING id, customer_id, coupon_id, start, "end", created_at, updated_at, tenant_id
 `
 
 type CreateDiscountParams struct {
@@ -45,27 +45,23 @@ func (q *Queries) CreateDiscount(ctx context.Context, arg CreateDiscountParams)
 		&i.End,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return &i, err
 }
 
-const deleteDiscount = `-- name: DeleteDiscount :exec
-DELETE FROM discounts
-WHERE id = $1
+const getDiscountByID = `-- name: GetDiscountByID :one
+SELECT id, customer_id, coupon_id, start, "end", created_at, updated_at, tenant_id FROM discounts
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1
 `
 
-func (q *Queries) DeleteDiscount(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteDiscount, id)
-	return err
+type GetDiscountByIDParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
 }
 
-const getDiscountByID = `-- name: GetDiscountByID :one
-SELECT id, customer_id, coupon_id, start, "end", created_at, updated_at FROM discounts
-WHERE id = $1 LIMIT 1
-`
-
-func (q *Queries) GetDiscountByID(ctx context.Context, id string) (*Discount, error) {
-	row := q.db.QueryRow(ctx, getDiscountByID, id)
+func (q *Queries) GetDiscountByID(ctx context.Context, arg GetDiscountByIDParams) (*Discount, error) {
+	row := q.db.QueryRow(ctx, getDiscountByID, arg.ID, arg.TenantID)
 	var i Discount
 	err := row.Scan(
 		&i.ID,
@@ -75,23 +71,26 @@ func (q *Queries) GetDiscountByID(ctx context.Context, id string) (*Discount, er
 		&i.End,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return &i, err
 }
 
 const listDiscounts = `-- name: ListDiscounts :many
-SELECT id, customer_id, coupon_id, start, "end", created_at, updated_at FROM discounts
+SELECT id, customer_id, coupon_id, start, "end", created_at, updated_at, tenant_id FROM discounts
+WHERE tenant_id = $1 AND deleted_at IS NULL
 ORDER BY id
-LIMIT $1 OFFSET $2
+LIMIT $2 OFFSET $3
 `
 
 type ListDiscountsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	TenantID string `json:"tenantId"`
+	Limit    int64  `json:"limit"`
+	Offset   int64  `json:"offset"`
 }
 
 func (q *Queries) ListDiscounts(ctx context.Context, arg ListDiscountsParams) ([]*Discount, error) {
-	rows, err := q.db.Query(ctx, listDiscounts, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listDiscounts, arg.TenantID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +106,7 @@ func (q *Queries) ListDiscounts(ctx context.Context, arg ListDiscountsParams) ([
 			&i.End,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -119,20 +119,21 @@ func (q *Queries) ListDiscounts(ctx context.Context, arg ListDiscountsParams) ([
 }
 
 const listDiscountsByCustomerID = `-- name: ListDiscountsByCustomerID :many
-SELECT id, customer_id, coupon_id, start, "end", created_at, updated_at FROM discounts
-WHERE customer_id = $1
+SELECT id, customer_id, coupon_id, start, "end", created_at, updated_at, tenant_id FROM discounts
+WHERE customer_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 ORDER BY id
-LIMIT $2 OFFSET $3
+LIMIT $3 OFFSET $4
 `
 
 type ListDiscountsByCustomerIDParams struct {
 	CustomerID string `json:"customerId"`
+	TenantID   string `json:"tenantId"`
 	Limit      int64  `json:"limit"`
 	Offset     int64  `json:"offset"`
 }
 
 func (q *Queries) ListDiscountsByCustomerID(ctx context.Context, arg ListDiscountsByCustomerIDParams) ([]*Discount, error) {
-	rows, err := q.db.Query(ctx, listDiscountsByCustomerID, arg.CustomerID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listDiscountsByCustomerID, arg.CustomerID, arg.TenantID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -148,6 +149,7 @@ func (q *Queries) ListDiscountsByCustomerID(ctx context.Context, arg ListDiscoun
 			&i.End,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -166,8 +168,8 @@ SET customer_id = $2,
     start = $4,
     "end" = $5,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, customer_id, coupon_id, start, "end", created_at, updated_at
+WHERE id = $1 AND tenant_id = $6 AND deleted_at IS NULL
+RETURNING id, customer_id, coupon_id, start, "end", created_at, updated_at, tenant_id
 `
 
 type UpdateDiscountParams struct {
@@ -176,6 +178,7 @@ type UpdateDiscountParams struct {
 	CouponID   string             `json:"couponId"`
 	Start      pgtype.Timestamptz `json:"start"`
 	End        pgtype.Timestamptz `json:"end"`
+	TenantID   string             `json:"tenantId"`
 }
 
 func (q *Queries) UpdateDiscount(ctx context.Context, arg UpdateDiscountParams) (*Discount, error) {
@@ -185,6 +188,7 @@ func (q *Queries) UpdateDiscount(ctx context.Context, arg UpdateDiscountParams)
 		arg.CouponID,
 		arg.Start,
 		arg.End,
+		arg.TenantID,
 	)
 	var i Discount
 	err := row.Scan(
@@ -195,40 +199,7 @@ func (q *Queries) UpdateDiscount(ctx context.Context, arg UpdateDiscountParams)
 		&i.End,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenantID,
 	)
 	return &i, err
 }
-
-const upsertDiscount = `-- name: UpsertDiscount :exec
-INSERT INTO discounts (
-    id, customer_id, coupon_id, start, "end"
-) VALUES (
-             $1, $2, $3, $4, $5
-         )
-ON CONFLICT (id)
-    DO UPDATE SET
-                  customer_id = EXCLUDED.customer_id,
-                  coupon_id = EXCLUDED.coupon_id,
-                  start = EXCLUDED.start,
-                  "end" = EXCLUDED."end",
-                  updated_at = NOW()
-`
-
-type UpsertDiscountParams struct {
-	ID         string             `json:"id"`
-	CustomerID string             `json:"customerId"`
-	CouponID   string             `json:"couponId"`
-	Start      pgtype.Timestamptz `json:"start"`
-	End        pgtype.Timestamptz `json:"end"`
-}
-
-func (q *Queries) UpsertDiscount(ctx context.Context, arg UpsertDiscountParams) error {
-	_, err := q.db.Exec(ctx, upsertDiscount,
-		arg.ID,
-		arg.CustomerID,
-		arg.CouponID,
-		arg.Start,
-		arg.End,
-	)
-	return err
-}
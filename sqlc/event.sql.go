@@ -11,20 +11,83 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimEventsForRetry = `-- name: ClaimEventsForRetry :many
+UPDATE events
+SET next_attempt_at = $1
+WHERE id IN (
+    SELECT e.id
+    FROM events e
+    WHERE e.processed = false
+      AND e.failed = false
+      AND e.payload IS NOT NULL
+      AND e.next_attempt_at <= $2
+    ORDER BY e.next_attempt_at
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
+`
+
+type ClaimEventsForRetryParams struct {
+	LeaseUntil pgtype.Timestamptz `json:"leaseUntil"`
+	Now        pgtype.Timestamptz `json:"now"`
+	Limit      int64              `json:"limit"`
+}
+
+func (q *Queries) ClaimEventsForRetry(ctx context.Context, arg ClaimEventsForRetryParams) ([]*Event, error) {
+	rows, err := q.db.Query(ctx, claimEventsForRetry, arg.LeaseUntil, arg.Now, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Processed,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Payload,
+			&i.ApiVersion,
+			&i.StripeCreatedAt,
+			&i.ObjectID,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.Failed,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createEvent = `-- name: CreateEvent :exec
 INSERT INTO events (
-    id, type, processed, created_at, updated_at
+    id, type, processed, payload, api_version, stripe_created_at, object_id, created_at, updated_at
 ) VALUES (
-             $1, $2, $3, $4, $5
+             $1, $2, $3, $4, $5, $6, $7, $8, $9
          )
+ON CONFLICT (id) DO NOTHING
 `
 
 type CreateEventParams struct {
-	ID        string             `json:"id"`
-	Type      EventType          `json:"type"`
-	Processed bool               `json:"processed"`
-	CreatedAt pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	ID              string             `json:"id"`
+	Type            EventType          `json:"type"`
+	Processed       bool               `json:"processed"`
+	Payload         []byte             `json:"payload"`
+	ApiVersion      *string            `json:"apiVersion"`
+	StripeCreatedAt pgtype.Timestamptz `json:"stripeCreatedAt"`
+	ObjectID        *string            `json:"objectId"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error {
@@ -32,6 +95,10 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error
 		arg.ID,
 		arg.Type,
 		arg.Processed,
+		arg.Payload,
+		arg.ApiVersion,
+		arg.StripeCreatedAt,
+		arg.ObjectID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -39,13 +106,18 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) error
 }
 
 const getEventByID = `-- name: GetEventByID :one
-SELECT id, type, processed, created_at, updated_at
+SELECT id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
 FROM events
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) GetEventByID(ctx context.Context, id string) (*Event, error) {
-	row := q.db.QueryRow(ctx, getEventByID, id)
+type GetEventByIDParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetEventByID(ctx context.Context, arg GetEventByIDParams) (*Event, error) {
+	row := q.db.QueryRow(ctx, getEventByID, arg.ID, arg.TenantID)
 	var i Event
 	err := row.Scan(
 		&i.ID,
@@ -53,22 +125,202 @@ func (q *Queries) GetEventByID(ctx context.Context, id string) (*Event, error) {
 		&i.Processed,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Payload,
+		&i.ApiVersion,
+		&i.StripeCreatedAt,
+		&i.ObjectID,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.Failed,
+		&i.TenantID,
 	)
 	return &i, err
 }
 
+const incrementEventAttempts = `-- name: IncrementEventAttempts :one
+UPDATE events
+SET attempts = attempts + 1, last_error = $2, updated_at = $3
+WHERE id = $1 AND tenant_id = $4
+RETURNING attempts
+`
+
+type IncrementEventAttemptsParams struct {
+	ID        string             `json:"id"`
+	LastError *string            `json:"lastError"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  string             `json:"tenantId"`
+}
+
+func (q *Queries) IncrementEventAttempts(ctx context.Context, arg IncrementEventAttemptsParams) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementEventAttempts, arg.ID, arg.LastError, arg.UpdatedAt, arg.TenantID)
+	var attempts int32
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const listEventsForReplay = `-- name: ListEventsForReplay :many
+SELECT id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
+FROM events
+WHERE payload IS NOT NULL
+  AND tenant_id = $5
+  AND ($1::event_type IS NULL OR type = $1::event_type)
+  AND ($2::varchar IS NULL OR object_id = $2::varchar)
+  AND ($3::timestamptz IS NULL OR stripe_created_at >= $3::timestamptz)
+  AND ($4::timestamptz IS NULL OR stripe_created_at < $4::timestamptz)
+ORDER BY stripe_created_at, id
+LIMIT $6
+`
+
+type ListEventsForReplayParams struct {
+	Type        NullEventType      `json:"type"`
+	ObjectID    *string            `json:"objectId"`
+	CreatedFrom pgtype.Timestamptz `json:"createdFrom"`
+	CreatedTo   pgtype.Timestamptz `json:"createdTo"`
+	TenantID    string             `json:"tenantId"`
+	Limit       int64              `json:"limit"`
+}
+
+func (q *Queries) ListEventsForReplay(ctx context.Context, arg ListEventsForReplayParams) ([]*Event, error) {
+	rows, err := q.db.Query(ctx, listEventsForReplay,
+		arg.Type,
+		arg.ObjectID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.TenantID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Processed,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Payload,
+			&i.ApiVersion,
+			&i.StripeCreatedAt,
+			&i.ObjectID,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.Failed,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFailedEvents = `-- name: ListFailedEvents :many
+SELECT id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
+FROM events
+WHERE failed = true
+  AND tenant_id = $1
+ORDER BY updated_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListFailedEventsParams struct {
+	TenantID string `json:"tenantId"`
+	Limit    int64  `json:"limit"`
+	Offset   int64  `json:"offset"`
+}
+
+func (q *Queries) ListFailedEvents(ctx context.Context, arg ListFailedEventsParams) ([]*Event, error) {
+	rows, err := q.db.Query(ctx, listFailedEvents, arg.TenantID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Processed,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Payload,
+			&i.ApiVersion,
+			&i.StripeCreatedAt,
+			&i.ObjectID,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.Failed,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEventAsFailed = `-- name: MarkEventAsFailed :exec
+UPDATE events
+SET failed = true, next_attempt_at = NULL, updated_at = $2
+WHERE id = $1 AND tenant_id = $3
+`
+
+type MarkEventAsFailedParams struct {
+	ID        string             `json:"id"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  string             `json:"tenantId"`
+}
+
+func (q *Queries) MarkEventAsFailed(ctx context.Context, arg MarkEventAsFailedParams) error {
+	_, err := q.db.Exec(ctx, markEventAsFailed, arg.ID, arg.UpdatedAt, arg.TenantID)
+	return err
+}
+
 const markEventAsProcessed = `-- name: MarkEventAsProcessed :exec
 UPDATE events
-SET processed = true, updated_at = $2
-WHERE id = $1
+SET processed = true, failed = false, next_attempt_at = NULL, updated_at = $2
+WHERE id = $1 AND tenant_id = $3
 `
 
 type MarkEventAsProcessedParams struct {
 	ID        string             `json:"id"`
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  string             `json:"tenantId"`
 }
 
 func (q *Queries) MarkEventAsProcessed(ctx context.Context, arg MarkEventAsProcessedParams) error {
-	_, err := q.db.Exec(ctx, markEventAsProcessed, arg.ID, arg.UpdatedAt)
+	_, err := q.db.Exec(ctx, markEventAsProcessed, arg.ID, arg.UpdatedAt, arg.TenantID)
+	return err
+}
+
+const scheduleEventRetry = `-- name: ScheduleEventRetry :exec
+UPDATE events
+SET next_attempt_at = $2, updated_at = $3
+WHERE id = $1 AND tenant_id = $4
+`
+
+type ScheduleEventRetryParams struct {
+	ID            string             `json:"id"`
+	NextAttemptAt pgtype.Timestamptz `json:"nextAttemptAt"`
+	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
+	TenantID      string             `json:"tenantId"`
+}
+
+func (q *Queries) ScheduleEventRetry(ctx context.Context, arg ScheduleEventRetryParams) error {
+	_, err := q.db.Exec(ctx, scheduleEventRetry, arg.ID, arg.NextAttemptAt, arg.UpdatedAt, arg.TenantID)
 	return err
 }
@@ -34,9 +34,9 @@ type CreateInvoiceParams struct {
 	SubscriptionID  *string            `json:"subscriptionId"`
 	Status          InvoiceStatus      `json:"status"`
 	Currency        Currency           `json:"currency"`
-	AmountDue       float64            `json:"amountDue"`
-	AmountPaid      float64            `json:"amountPaid"`
-	AmountRemaining float64            `json:"amountRemaining"`
+	AmountDue       int64              `json:"amountDue"`
+	AmountPaid      int64              `json:"amountPaid"`
+	AmountRemaining int64              `json:"amountRemaining"`
 	DueDate         pgtype.Timestamptz `json:"dueDate"`
 	PaidAt          pgtype.Timestamptz `json:"paidAt"`
 }
@@ -58,11 +58,16 @@ func (q *Queries) CreateInvoice(ctx context.Context, arg CreateInvoiceParams) er
 }
 
 const deleteInvoice = `-- name: DeleteInvoice :exec
-DELETE FROM invoices WHERE id = $1
+DELETE FROM invoices WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteInvoice(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteInvoice, id)
+type DeleteInvoiceParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteInvoice(ctx context.Context, arg DeleteInvoiceParams) error {
+	_, err := q.db.Exec(ctx, deleteInvoice, arg.ID, arg.TenantID)
 	return err
 }
 
@@ -70,12 +75,17 @@ const getInvoice = `-- name: GetInvoice :one
 
 SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
 FROM invoices
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1
 `
 
 // RETURNING id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, stripe_id, created_at, updated_at;
-func (q *Queries) GetInvoice(ctx context.Context, id string) (*Invoice, error) {
-	row := q.db.QueryRow(ctx, getInvoice, id)
+type GetInvoiceParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetInvoice(ctx context.Context, arg GetInvoiceParams) (*Invoice, error) {
+	row := q.db.QueryRow(ctx, getInvoice, arg.ID, arg.TenantID)
 	var i Invoice
 	err := row.Scan(
 		&i.ID,
@@ -95,13 +105,47 @@ func (q *Queries) GetInvoice(ctx context.Context, id string) (*Invoice, error) {
 }
 
 const listInvoices = `-- name: ListInvoices :many
+
 SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
 FROM invoices
-WHERE id = $1
+WHERE ($1::varchar IS NULL OR customer_id = $1::varchar)
+  AND ($2::invoice_status IS NULL OR status = $2::invoice_status)
+  AND ($3::currency IS NULL OR currency = $3::currency)
+  AND ($4::timestamptz IS NULL OR created_at >= $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+  AND ($6::timestamptz IS NULL
+       OR (created_at, id) < ($6::timestamptz, $7::varchar))
+  AND deleted_at IS NULL
+  AND tenant_id = $8
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
-func (q *Queries) ListInvoices(ctx context.Context, id string) ([]*Invoice, error) {
-	rows, err := q.db.Query(ctx, listInvoices, id)
+type ListInvoicesParams struct {
+	CustomerID      *string            `json:"customerId"`
+	Status          NullInvoiceStatus  `json:"status"`
+	Currency        NullCurrency       `json:"currency"`
+	CreatedFrom     pgtype.Timestamptz `json:"createdFrom"`
+	CreatedTo       pgtype.Timestamptz `json:"createdTo"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        *string            `json:"cursorId"`
+	TenantID        string             `json:"tenantId"`
+	Limit           int64              `json:"limit"`
+}
+
+// RETURNING id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, stripe_id, created_at, updated_at;
+func (q *Queries) ListInvoices(ctx context.Context, arg ListInvoicesParams) ([]*Invoice, error) {
+	rows, err := q.db.Query(ctx, listInvoices,
+		arg.CustomerID,
+		arg.Status,
+		arg.Currency,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.TenantID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -133,24 +177,19 @@ func (q *Queries) ListInvoices(ctx context.Context, id string) ([]*Invoice, erro
 	return items, nil
 }
 
-const listInvoicesByCustomerID = `-- name: ListInvoicesByCustomerID :many
-
+const listInvoicesByIDs = `-- name: ListInvoicesByIDs :many
 SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
 FROM invoices
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+WHERE id = ANY($1::varchar[]) AND tenant_id = $2
 `
 
-type ListInvoicesByCustomerIDParams struct {
-	CustomerID string `json:"customerId"`
-	Limit      int64  `json:"limit"`
-	Offset     int64  `json:"offset"`
+type ListInvoicesByIDsParams struct {
+	Ids      []string `json:"ids"`
+	TenantID string   `json:"tenantId"`
 }
 
-// RETURNING id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, stripe_id, created_at, updated_at;
-func (q *Queries) ListInvoicesByCustomerID(ctx context.Context, arg ListInvoicesByCustomerIDParams) ([]*Invoice, error) {
-	rows, err := q.db.Query(ctx, listInvoicesByCustomerID, arg.CustomerID, arg.Limit, arg.Offset)
+func (q *Queries) ListInvoicesByIDs(ctx context.Context, arg ListInvoicesByIDsParams) ([]*Invoice, error) {
+	rows, err := q.db.Query(ctx, listInvoicesByIDs, arg.Ids, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -189,15 +228,16 @@ SET status = $2,
     amount_remaining = $4,
     paid_at = $5,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $6
 `
 
 type UpdateInvoiceParams struct {
 	ID              string             `json:"id"`
 	Status          InvoiceStatus      `json:"status"`
-	AmountPaid      float64            `json:"amountPaid"`
-	AmountRemaining float64            `json:"amountRemaining"`
+	AmountPaid      int64              `json:"amountPaid"`
+	AmountRemaining int64              `json:"amountRemaining"`
 	PaidAt          pgtype.Timestamptz `json:"paidAt"`
+	TenantID        string             `json:"tenantId"`
 }
 
 func (q *Queries) UpdateInvoice(ctx context.Context, arg UpdateInvoiceParams) error {
@@ -207,55 +247,7 @@ func (q *Queries) UpdateInvoice(ctx context.Context, arg UpdateInvoiceParams) er
 		arg.AmountPaid,
 		arg.AmountRemaining,
 		arg.PaidAt,
-	)
-	return err
-}
-
-const upsertInvoice = `-- name: UpsertInvoice :exec
-INSERT INTO invoices (
-    id, customer_id, subscription_id, status, currency, amount_due, amount_paid,
-    amount_remaining, due_date, paid_at
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      customer_id = EXCLUDED.customer_id,
-                                      subscription_id = EXCLUDED.subscription_id,
-                                      status = EXCLUDED.status,
-                                      currency = EXCLUDED.currency,
-                                      amount_due = EXCLUDED.amount_due,
-                                      amount_paid = EXCLUDED.amount_paid,
-                                      amount_remaining = EXCLUDED.amount_remaining,
-                                      due_date = EXCLUDED.due_date,
-                                      paid_at = EXCLUDED.paid_at,
-                                      updated_at = NOW()
-`
-
-type UpsertInvoiceParams struct {
-	ID              string             `json:"id"`
-	CustomerID      string             `json:"customerId"`
-	SubscriptionID  *string            `json:"subscriptionId"`
-	Status          InvoiceStatus      `json:"status"`
-	Currency        Currency           `json:"currency"`
-	AmountDue       float64            `json:"amountDue"`
-	AmountPaid      float64            `json:"amountPaid"`
-	AmountRemaining float64            `json:"amountRemaining"`
-	DueDate         pgtype.Timestamptz `json:"dueDate"`
-	PaidAt          pgtype.Timestamptz `json:"paidAt"`
-}
-
-func (q *Queries) UpsertInvoice(ctx context.Context, arg UpsertInvoiceParams) error {
-	_, err := q.db.Exec(ctx, upsertInvoice,
-		arg.ID,
-		arg.CustomerID,
-		arg.SubscriptionID,
-		arg.Status,
-		arg.Currency,
-		arg.AmountDue,
-		arg.AmountPaid,
-		arg.AmountRemaining,
-		arg.DueDate,
-		arg.PaidAt,
+		arg.TenantID,
 	)
 	return err
 }
@@ -13,49 +13,67 @@ const createInvoiceItem = `-- name: CreateInvoiceItem :exec
 INSERT INTO invoice_items (
     invoice_id,
     amount,
+    currency,
     description
 ) VALUES (
-             $1, $2, $3
+             $1, $2, $3, $4
          )
 `
 
 type CreateInvoiceItemParams struct {
-	InvoiceID   string  `json:"invoiceId"`
-	Amount      float64 `json:"amount"`
-	Description *string `json:"description"`
+	InvoiceID   string   `json:"invoiceId"`
+	Amount      int64    `json:"amount"`
+	Currency    Currency `json:"currency"`
+	Description *string  `json:"description"`
 }
 
 func (q *Queries) CreateInvoiceItem(ctx context.Context, arg CreateInvoiceItemParams) error {
-	_, err := q.db.Exec(ctx, createInvoiceItem, arg.InvoiceID, arg.Amount, arg.Description)
+	_, err := q.db.Exec(ctx, createInvoiceItem,
+		arg.InvoiceID,
+		arg.Amount,
+		arg.Currency,
+		arg.Description,
+	)
 	return err
 }
 
 const deleteInvoiceItem = `-- name: DeleteInvoiceItem :exec
 
-DELETE FROM invoice_items WHERE id = $1
+DELETE FROM invoice_items WHERE id = $1 AND tenant_id = $2
 `
 
 // RETURNING id, invoice_id, amount, description, created_at, updated_at;
-func (q *Queries) DeleteInvoiceItem(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteInvoiceItem, id)
+type DeleteInvoiceItemParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteInvoiceItem(ctx context.Context, arg DeleteInvoiceItemParams) error {
+	_, err := q.db.Exec(ctx, deleteInvoiceItem, arg.ID, arg.TenantID)
 	return err
 }
 
 const getInvoiceItem = `-- name: GetInvoiceItem :one
 
-SELECT id, invoice_id, amount, description, created_at, updated_at
+SELECT id, invoice_id, amount, currency, description, created_at, updated_at
 FROM invoice_items
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND tenant_id = $2 LIMIT 1
 `
 
 // RETURNING id, invoice_id, amount, description, created_at, updated_at;
-func (q *Queries) GetInvoiceItem(ctx context.Context, id string) (*InvoiceItem, error) {
-	row := q.db.QueryRow(ctx, getInvoiceItem, id)
+type GetInvoiceItemParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetInvoiceItem(ctx context.Context, arg GetInvoiceItemParams) (*InvoiceItem, error) {
+	row := q.db.QueryRow(ctx, getInvoiceItem, arg.ID, arg.TenantID)
 	var i InvoiceItem
 	err := row.Scan(
 		&i.ID,
 		&i.InvoiceID,
 		&i.Amount,
+		&i.Currency,
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -64,14 +82,19 @@ func (q *Queries) GetInvoiceItem(ctx context.Context, id string) (*InvoiceItem,
 }
 
 const listInvoiceItems = `-- name: ListInvoiceItems :many
-SELECT id, invoice_id, amount, description, created_at, updated_at
+SELECT id, invoice_id, amount, currency, description, created_at, updated_at
 FROM invoice_items
-WHERE invoice_id = $1
+WHERE invoice_id = $1 AND tenant_id = $2
 ORDER BY created_at DESC
 `
 
-func (q *Queries) ListInvoiceItems(ctx context.Context, invoiceID string) ([]*InvoiceItem, error) {
-	rows, err := q.db.Query(ctx, listInvoiceItems, invoiceID)
+type ListInvoiceItemsParams struct {
+	InvoiceID string `json:"invoiceId"`
+	TenantID  string `json:"tenantId"`
+}
+
+func (q *Queries) ListInvoiceItems(ctx context.Context, arg ListInvoiceItemsParams) ([]*InvoiceItem, error) {
+	rows, err := q.db.Query(ctx, listInvoiceItems, arg.InvoiceID, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +106,7 @@ func (q *Queries) ListInvoiceItems(ctx context.Context, invoiceID string) ([]*In
 			&i.ID,
 			&i.InvoiceID,
 			&i.Amount,
+			&i.Currency,
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -102,16 +126,17 @@ UPDATE invoice_items
 SET amount = $2,
     description = $3,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $4
 `
 
 type UpdateInvoiceItemParams struct {
 	ID          string  `json:"id"`
-	Amount      float64 `json:"amount"`
+	Amount      int64   `json:"amount"`
 	Description *string `json:"description"`
+	TenantID    string  `json:"tenantId"`
 }
 
 func (q *Queries) UpdateInvoiceItem(ctx context.Context, arg UpdateInvoiceItemParams) error {
-	_, err := q.db.Exec(ctx, updateInvoiceItem, arg.ID, arg.Amount, arg.Description)
+	_, err := q.db.Exec(ctx, updateInvoiceItem, arg.ID, arg.Amount, arg.Description, arg.TenantID)
 	return err
 }
@@ -2069,11 +2069,56 @@ func (e SubscriptionStatus) Valid() bool {
 	return false
 }
 
+type ApiKey struct {
+	ID         int64              `json:"id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	Hash       string             `json:"hash"`
+	Scopes     []string           `json:"scopes"`
+	LastUsedAt pgtype.Timestamptz `json:"lastUsedAt"`
+	RevokedAt  pgtype.Timestamptz `json:"revokedAt"`
+	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
+	TenantID   *string            `json:"tenantId"`
+}
+
+type ApiKeyRequest struct {
+	ID        int64              `json:"id"`
+	ApiKeyID  int64              `json:"apiKeyId"`
+	Method    string             `json:"method"`
+	Path      string             `json:"path"`
+	Status    int32              `json:"status"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type ApplicationFee struct {
+	ID                       string             `json:"id"`
+	AccountID                string             `json:"accountId"`
+	ChargeID                 *string            `json:"chargeId"`
+	OriginatingTransactionID *string            `json:"originatingTransactionId"`
+	Amount                   int64              `json:"amount"`
+	AmountRefunded           int64              `json:"amountRefunded"`
+	Currency                 Currency           `json:"currency"`
+	Refunded                 bool               `json:"refunded"`
+	CreatedAt                pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt                pgtype.Timestamptz `json:"updatedAt"`
+	LastEventAt              pgtype.Timestamptz `json:"lastEventAt"`
+	TenantID                 string             `json:"tenantId"`
+}
+
+type BackfillCheckpoint struct {
+	Resource    string             `json:"resource"`
+	LastID      *string            `json:"lastId"`
+	Imported    int64              `json:"imported"`
+	CompletedAt pgtype.Timestamptz `json:"completedAt"`
+	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	TenantID    string             `json:"tenantId"`
+}
+
 type Charge struct {
 	ID              string             `json:"id"`
 	CustomerID      *string            `json:"customerId"`
 	PaymentIntentID *string            `json:"paymentIntentId"`
-	Amount          float64            `json:"amount"`
+	Amount          int64              `json:"amount"`
 	Currency        Currency           `json:"currency"`
 	Status          ChargeStatus       `json:"status"`
 	Paid            bool               `json:"paid"`
@@ -2082,6 +2127,7 @@ type Charge struct {
 	FailureMessage  *string            `json:"failureMessage"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	TenantID        string             `json:"tenantId"`
 }
 
 type CheckoutSession struct {
@@ -2096,6 +2142,25 @@ type CheckoutSession struct {
 	Currency        Currency              `json:"currency"`
 	CreatedAt       pgtype.Timestamptz    `json:"createdAt"`
 	UpdatedAt       pgtype.Timestamptz    `json:"updatedAt"`
+	TenantID        string                `json:"tenantId"`
+}
+
+type ConnectedAccount struct {
+	ID               string             `json:"id"`
+	Type             string             `json:"type"`
+	Email            *string            `json:"email"`
+	Country          *string            `json:"country"`
+	ChargesEnabled   bool               `json:"chargesEnabled"`
+	PayoutsEnabled   bool               `json:"payoutsEnabled"`
+	DetailsSubmitted bool               `json:"detailsSubmitted"`
+	Capabilities     []byte             `json:"capabilities"`
+	RequirementsDue  []string           `json:"requirementsDue"`
+	DisabledReason   *string            `json:"disabledReason"`
+	DeauthorizedAt   pgtype.Timestamptz `json:"deauthorizedAt"`
+	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
+	LastEventAt      pgtype.Timestamptz `json:"lastEventAt"`
+	TenantID         string             `json:"tenantId"`
 }
 
 type Coupon struct {
@@ -2112,6 +2177,7 @@ type Coupon struct {
 	CreatedAt        pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt        pgtype.Timestamptz `json:"updatedAt"`
 	RedeemBy         pgtype.Timestamptz `json:"redeemBy"`
+	TenantID         string             `json:"tenantId"`
 }
 
 type Customer struct {
@@ -2120,6 +2186,7 @@ type Customer struct {
 	Balance   int64              `json:"balance"`
 	CreatedAt pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  string             `json:"tenantId"`
 }
 
 type Discount struct {
@@ -2130,26 +2197,37 @@ type Discount struct {
 	End        pgtype.Timestamptz `json:"end"`
 	CreatedAt  pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt  pgtype.Timestamptz `json:"updatedAt"`
+	TenantID   string             `json:"tenantId"`
 }
 
 type Dispute struct {
 	ID            string             `json:"id"`
 	ChargeID      string             `json:"chargeId"`
-	Amount        float64            `json:"amount"`
+	Amount        int64              `json:"amount"`
 	Currency      Currency           `json:"currency"`
 	Status        DisputeStatus      `json:"status"`
 	Reason        DisputeReason      `json:"reason"`
 	EvidenceDueBy pgtype.Timestamptz `json:"evidenceDueBy"`
 	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt     pgtype.Timestamptz `json:"updatedAt"`
+	TenantID      string             `json:"tenantId"`
 }
 
 type Event struct {
-	ID        string             `json:"id"`
-	Type      EventType          `json:"type"`
-	Processed bool               `json:"processed"`
-	CreatedAt pgtype.Timestamptz `json:"createdAt"`
-	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	ID              string             `json:"id"`
+	Type            EventType          `json:"type"`
+	Processed       bool               `json:"processed"`
+	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	Payload         []byte             `json:"payload"`
+	ApiVersion      *string            `json:"apiVersion"`
+	StripeCreatedAt pgtype.Timestamptz `json:"stripeCreatedAt"`
+	ObjectID        *string            `json:"objectId"`
+	Attempts        int32              `json:"attempts"`
+	LastError       *string            `json:"lastError"`
+	NextAttemptAt   pgtype.Timestamptz `json:"nextAttemptAt"`
+	Failed          bool               `json:"failed"`
+	TenantID        string             `json:"tenantId"`
 }
 
 type Invoice struct {
@@ -2158,46 +2236,66 @@ type Invoice struct {
 	SubscriptionID  *string            `json:"subscriptionId"`
 	Status          InvoiceStatus      `json:"status"`
 	Currency        Currency           `json:"currency"`
-	AmountDue       float64            `json:"amountDue"`
-	AmountPaid      float64            `json:"amountPaid"`
-	AmountRemaining float64            `json:"amountRemaining"`
+	AmountDue       int64              `json:"amountDue"`
+	AmountPaid      int64              `json:"amountPaid"`
+	AmountRemaining int64              `json:"amountRemaining"`
 	DueDate         pgtype.Timestamptz `json:"dueDate"`
 	PaidAt          pgtype.Timestamptz `json:"paidAt"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	TenantID        string             `json:"tenantId"`
 }
 
 type InvoiceItem struct {
 	ID          string             `json:"id"`
 	InvoiceID   string             `json:"invoiceId"`
-	Amount      float64            `json:"amount"`
+	Amount      int64              `json:"amount"`
 	Description *string            `json:"description"`
 	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	Currency    Currency           `json:"currency"`
+	TenantID    string             `json:"tenantId"`
+}
+
+type OutboxMessage struct {
+	ID            string             `json:"id"`
+	Subject       string             `json:"subject"`
+	SchemaVersion int32              `json:"schemaVersion"`
+	Payload       []byte             `json:"payload"`
+	Attempts      int32              `json:"attempts"`
+	LastError     *string            `json:"lastError"`
+	PublishedAt   pgtype.Timestamptz `json:"publishedAt"`
+	CreatedAt     pgtype.Timestamptz `json:"createdAt"`
+	TenantID      string             `json:"tenantId"`
 }
 
 type PaymentIntent struct {
-	ID               string                            `json:"id"`
-	CustomerID       string                            `json:"customerId"`
-	Amount           float64                           `json:"amount"`
-	Currency         Currency                          `json:"currency"`
-	CaptureMethod    PaymentIntentCaptureMethod        `json:"captureMethod"`
-	Status           PaymentIntentStatus               `json:"status"`
-	PaymentMethodID  *string                           `json:"paymentMethodId"`
-	SetupFutureUsage NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
-	ClientSecret     string                            `json:"clientSecret"`
-	CreatedAt        pgtype.Timestamptz                `json:"createdAt"`
-	UpdatedAt        pgtype.Timestamptz                `json:"updatedAt"`
+	ID                   string                            `json:"id"`
+	CustomerID           string                            `json:"customerId"`
+	Amount               int64                             `json:"amount"`
+	Currency             Currency                          `json:"currency"`
+	CaptureMethod        PaymentIntentCaptureMethod        `json:"captureMethod"`
+	Status               PaymentIntentStatus               `json:"status"`
+	PaymentMethodID      *string                           `json:"paymentMethodId"`
+	SetupFutureUsage     NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
+	ClientSecret         string                            `json:"clientSecret"`
+	CreatedAt            pgtype.Timestamptz                `json:"createdAt"`
+	UpdatedAt            pgtype.Timestamptz                `json:"updatedAt"`
+	TenantID             string                            `json:"tenantId"`
+	OnBehalfOf           *string                           `json:"onBehalfOf"`
+	TransferDestination  *string                           `json:"transferDestination"`
+	ApplicationFeeAmount *int64                            `json:"applicationFeeAmount"`
 }
 
 type PaymentLink struct {
 	ID        string             `json:"id"`
 	Active    bool               `json:"active"`
 	Url       string             `json:"url"`
-	Amount    float64            `json:"amount"`
+	Amount    int64              `json:"amount"`
 	Currency  Currency           `json:"currency"`
 	CreatedAt pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	TenantID  string             `json:"tenantId"`
 }
 
 type PaymentMethod struct {
@@ -2213,6 +2311,7 @@ type PaymentMethod struct {
 	IsDefault           bool                       `json:"isDefault"`
 	CreatedAt           pgtype.Timestamptz         `json:"createdAt"`
 	UpdatedAt           pgtype.Timestamptz         `json:"updatedAt"`
+	TenantID            string                     `json:"tenantId"`
 }
 
 type Price struct {
@@ -2220,13 +2319,14 @@ type Price struct {
 	ProductID              string                     `json:"productId"`
 	Type                   PriceType                  `json:"type"`
 	Currency               Currency                   `json:"currency"`
-	UnitAmount             float64                    `json:"unitAmount"`
+	UnitAmount             int64                      `json:"unitAmount"`
 	RecurringInterval      NullPriceRecurringInterval `json:"recurringInterval"`
 	RecurringIntervalCount int32                      `json:"recurringIntervalCount"`
 	TrialPeriodDays        int32                      `json:"trialPeriodDays"`
 	Active                 bool                       `json:"active"`
 	CreatedAt              pgtype.Timestamptz         `json:"createdAt"`
 	UpdatedAt              pgtype.Timestamptz         `json:"updatedAt"`
+	TenantID               string                     `json:"tenantId"`
 }
 
 type Product struct {
@@ -2237,6 +2337,7 @@ type Product struct {
 	Metadata    []byte             `json:"metadata"`
 	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	TenantID    string             `json:"tenantId"`
 }
 
 type PromotionCode struct {
@@ -2250,6 +2351,7 @@ type PromotionCode struct {
 	ExpiresAt      pgtype.Timestamptz `json:"expiresAt"`
 	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt      pgtype.Timestamptz `json:"updatedAt"`
+	TenantID       string             `json:"tenantId"`
 }
 
 type Quote struct {
@@ -2263,16 +2365,19 @@ type Quote struct {
 	CanceledAt  pgtype.Timestamptz `json:"canceledAt"`
 	CreatedAt   pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt   pgtype.Timestamptz `json:"updatedAt"`
+	TenantID    string             `json:"tenantId"`
 }
 
 type Refund struct {
 	ID        string             `json:"id"`
 	ChargeID  string             `json:"chargeId"`
-	Amount    float64            `json:"amount"`
+	Amount    int64              `json:"amount"`
 	Status    RefundStatus       `json:"status"`
 	Reason    NullRefundReason   `json:"reason"`
 	CreatedAt pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+	Currency  Currency           `json:"currency"`
+	TenantID  string             `json:"tenantId"`
 }
 
 type Review struct {
@@ -2285,6 +2390,7 @@ type Review struct {
 	ClosedAt        pgtype.Timestamptz `json:"closedAt"`
 	CreatedAt       pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt       pgtype.Timestamptz `json:"updatedAt"`
+	TenantID        string             `json:"tenantId"`
 }
 
 type Subscription struct {
@@ -2300,6 +2406,7 @@ type Subscription struct {
 	TrialEnd           pgtype.Timestamptz `json:"trialEnd"`
 	CreatedAt          pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt          pgtype.Timestamptz `json:"updatedAt"`
+	TenantID           string             `json:"tenantId"`
 }
 
 type TaxRate struct {
@@ -2312,4 +2419,20 @@ type TaxRate struct {
 	Active       bool               `json:"active"`
 	CreatedAt    pgtype.Timestamptz `json:"createdAt"`
 	UpdatedAt    pgtype.Timestamptz `json:"updatedAt"`
+	TenantID     string             `json:"tenantId"`
+}
+
+type Transfer struct {
+	ID                   string             `json:"id"`
+	DestinationAccountID string             `json:"destinationAccountId"`
+	Amount               int64              `json:"amount"`
+	AmountReversed       int64              `json:"amountReversed"`
+	Currency             Currency           `json:"currency"`
+	Reversed             bool               `json:"reversed"`
+	SourceTransactionID  *string            `json:"sourceTransactionId"`
+	TransferGroup        *string            `json:"transferGroup"`
+	CreatedAt            pgtype.Timestamptz `json:"createdAt"`
+	UpdatedAt            pgtype.Timestamptz `json:"updatedAt"`
+	LastEventAt          pgtype.Timestamptz `json:"lastEventAt"`
+	TenantID             string             `json:"tenantId"`
 }
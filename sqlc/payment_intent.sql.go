@@ -30,7 +30,7 @@ INSERT INTO payment_intents (
 type CreatePaymentIntentParams struct {
 	ID               string                            `json:"id"`
 	CustomerID       string                            `json:"customerId"`
-	Amount           float64                           `json:"amount"`
+	Amount           int64                             `json:"amount"`
 	Currency         Currency                          `json:"currency"`
 	Status           PaymentIntentStatus               `json:"status"`
 	PaymentMethodID  *string                           `json:"paymentMethodId"`
@@ -56,28 +56,36 @@ func (q *Queries) CreatePaymentIntent(ctx context.Context, arg CreatePaymentInte
 
 const getPaymentIntent = `-- name: GetPaymentIntent :one
 
-SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret,capture_method, created_at, updated_at
+SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret,capture_method, created_at, updated_at, on_behalf_of, transfer_destination, application_fee_amount
 FROM payment_intents
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND tenant_id = $2 LIMIT 1
 `
 
 type GetPaymentIntentRow struct {
-	ID               string                            `json:"id"`
-	CustomerID       string                            `json:"customerId"`
-	Amount           float64                           `json:"amount"`
-	Currency         Currency                          `json:"currency"`
-	Status           PaymentIntentStatus               `json:"status"`
-	PaymentMethodID  *string                           `json:"paymentMethodId"`
-	SetupFutureUsage NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
-	ClientSecret     string                            `json:"clientSecret"`
-	CaptureMethod    PaymentIntentCaptureMethod        `json:"captureMethod"`
-	CreatedAt        pgtype.Timestamptz                `json:"createdAt"`
-	UpdatedAt        pgtype.Timestamptz                `json:"updatedAt"`
+	ID                   string                            `json:"id"`
+	CustomerID           string                            `json:"customerId"`
+	Amount               int64                             `json:"amount"`
+	Currency             Currency                          `json:"currency"`
+	Status               PaymentIntentStatus               `json:"status"`
+	PaymentMethodID      *string                           `json:"paymentMethodId"`
+	SetupFutureUsage     NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
+	ClientSecret         string                            `json:"clientSecret"`
+	CaptureMethod        PaymentIntentCaptureMethod        `json:"captureMethod"`
+	CreatedAt            pgtype.Timestamptz                `json:"createdAt"`
+	UpdatedAt            pgtype.Timestamptz                `json:"updatedAt"`
+	OnBehalfOf           *string                           `json:"onBehalfOf"`
+	TransferDestination  *string                           `json:"transferDestination"`
+	ApplicationFeeAmount *int64                            `json:"applicationFeeAmount"`
 }
 
 // RETURNING id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, stripe_id, client_secret, created_at, updated_at;
-func (q *Queries) GetPaymentIntent(ctx context.Context, id string) (*GetPaymentIntentRow, error) {
-	row := q.db.QueryRow(ctx, getPaymentIntent, id)
+type GetPaymentIntentParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetPaymentIntent(ctx context.Context, arg GetPaymentIntentParams) (*GetPaymentIntentRow, error) {
+	row := q.db.QueryRow(ctx, getPaymentIntent, arg.ID, arg.TenantID)
 	var i GetPaymentIntentRow
 	err := row.Scan(
 		&i.ID,
@@ -91,40 +99,71 @@ func (q *Queries) GetPaymentIntent(ctx context.Context, id string) (*GetPaymentI
 		&i.CaptureMethod,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OnBehalfOf,
+		&i.TransferDestination,
+		&i.ApplicationFeeAmount,
 	)
 	return &i, err
 }
 
 const listPaymentIntents = `-- name: ListPaymentIntents :many
 
-SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at
+SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at, on_behalf_of, transfer_destination, application_fee_amount
 FROM payment_intents
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+WHERE ($1::varchar IS NULL OR customer_id = $1::varchar)
+  AND ($2::payment_intent_status IS NULL OR status = $2::payment_intent_status)
+  AND ($3::currency IS NULL OR currency = $3::currency)
+  AND ($4::timestamptz IS NULL OR created_at >= $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+  AND ($6::timestamptz IS NULL
+       OR (created_at, id) < ($6::timestamptz, $7::varchar))
+  AND tenant_id = $8
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
 type ListPaymentIntentsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	CustomerID      *string                 `json:"customerId"`
+	Status          NullPaymentIntentStatus `json:"status"`
+	Currency        NullCurrency            `json:"currency"`
+	CreatedFrom     pgtype.Timestamptz      `json:"createdFrom"`
+	CreatedTo       pgtype.Timestamptz      `json:"createdTo"`
+	CursorCreatedAt pgtype.Timestamptz      `json:"cursorCreatedAt"`
+	CursorID        *string                 `json:"cursorId"`
+	TenantID        string                  `json:"tenantId"`
+	Limit           int64                   `json:"limit"`
 }
 
 type ListPaymentIntentsRow struct {
-	ID               string                            `json:"id"`
-	CustomerID       string                            `json:"customerId"`
-	Amount           float64                           `json:"amount"`
-	Currency         Currency                          `json:"currency"`
-	Status           PaymentIntentStatus               `json:"status"`
-	PaymentMethodID  *string                           `json:"paymentMethodId"`
-	SetupFutureUsage NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
-	ClientSecret     string                            `json:"clientSecret"`
-	CaptureMethod    PaymentIntentCaptureMethod        `json:"captureMethod"`
-	CreatedAt        pgtype.Timestamptz                `json:"createdAt"`
-	UpdatedAt        pgtype.Timestamptz                `json:"updatedAt"`
+	ID                   string                            `json:"id"`
+	CustomerID           string                            `json:"customerId"`
+	Amount               int64                             `json:"amount"`
+	Currency             Currency                          `json:"currency"`
+	Status               PaymentIntentStatus               `json:"status"`
+	PaymentMethodID      *string                           `json:"paymentMethodId"`
+	SetupFutureUsage     NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
+	ClientSecret         string                            `json:"clientSecret"`
+	CaptureMethod        PaymentIntentCaptureMethod        `json:"captureMethod"`
+	CreatedAt            pgtype.Timestamptz                `json:"createdAt"`
+	UpdatedAt            pgtype.Timestamptz                `json:"updatedAt"`
+	OnBehalfOf           *string                           `json:"onBehalfOf"`
+	TransferDestination  *string                           `json:"transferDestination"`
+	ApplicationFeeAmount *int64                            `json:"applicationFeeAmount"`
 }
 
 // RETURNING id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, stripe_id, client_secret, created_at, updated_at;
 func (q *Queries) ListPaymentIntents(ctx context.Context, arg ListPaymentIntentsParams) ([]*ListPaymentIntentsRow, error) {
-	rows, err := q.db.Query(ctx, listPaymentIntents, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listPaymentIntents,
+		arg.CustomerID,
+		arg.Status,
+		arg.Currency,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.TenantID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +183,9 @@ func (q *Queries) ListPaymentIntents(ctx context.Context, arg ListPaymentIntents
 			&i.CaptureMethod,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OnBehalfOf,
+			&i.TransferDestination,
+			&i.ApplicationFeeAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -155,55 +197,41 @@ func (q *Queries) ListPaymentIntents(ctx context.Context, arg ListPaymentIntents
 	return items, nil
 }
 
-const listPaymentIntentsByCustomer = `-- name: ListPaymentIntentsByCustomer :many
-SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at
+const listPaymentIntentsByIDs = `-- name: ListPaymentIntentsByIDs :many
+SELECT id, customer_id, amount, currency, capture_method, status, payment_method_id, setup_future_usage, client_secret, created_at, updated_at, on_behalf_of, transfer_destination, application_fee_amount
 FROM payment_intents
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+WHERE id = ANY($1::varchar[]) AND tenant_id = $2
 `
 
-type ListPaymentIntentsByCustomerParams struct {
-	CustomerID string `json:"customerId"`
-	Limit      int64  `json:"limit"`
-	Offset     int64  `json:"offset"`
-}
-
-type ListPaymentIntentsByCustomerRow struct {
-	ID               string                            `json:"id"`
-	CustomerID       string                            `json:"customerId"`
-	Amount           float64                           `json:"amount"`
-	Currency         Currency                          `json:"currency"`
-	Status           PaymentIntentStatus               `json:"status"`
-	PaymentMethodID  *string                           `json:"paymentMethodId"`
-	SetupFutureUsage NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
-	ClientSecret     string                            `json:"clientSecret"`
-	CaptureMethod    PaymentIntentCaptureMethod        `json:"captureMethod"`
-	CreatedAt        pgtype.Timestamptz                `json:"createdAt"`
-	UpdatedAt        pgtype.Timestamptz                `json:"updatedAt"`
+type ListPaymentIntentsByIDsParams struct {
+	Ids      []string `json:"ids"`
+	TenantID string   `json:"tenantId"`
 }
 
-func (q *Queries) ListPaymentIntentsByCustomer(ctx context.Context, arg ListPaymentIntentsByCustomerParams) ([]*ListPaymentIntentsByCustomerRow, error) {
-	rows, err := q.db.Query(ctx, listPaymentIntentsByCustomer, arg.CustomerID, arg.Limit, arg.Offset)
+func (q *Queries) ListPaymentIntentsByIDs(ctx context.Context, arg ListPaymentIntentsByIDsParams) ([]*PaymentIntent, error) {
+	rows, err := q.db.Query(ctx, listPaymentIntentsByIDs, arg.Ids, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []*ListPaymentIntentsByCustomerRow{}
+	items := []*PaymentIntent{}
 	for rows.Next() {
-		var i ListPaymentIntentsByCustomerRow
+		var i PaymentIntent
 		if err := rows.Scan(
 			&i.ID,
 			&i.CustomerID,
 			&i.Amount,
 			&i.Currency,
+			&i.CaptureMethod,
 			&i.Status,
 			&i.PaymentMethodID,
 			&i.SetupFutureUsage,
 			&i.ClientSecret,
-			&i.CaptureMethod,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OnBehalfOf,
+			&i.TransferDestination,
+			&i.ApplicationFeeAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -223,7 +251,7 @@ SET status = $2,
     client_secret = $5,
     capture_method = $6,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $7
 `
 
 type UpdatePaymentIntentParams struct {
@@ -233,6 +261,7 @@ type UpdatePaymentIntentParams struct {
 	SetupFutureUsage NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
 	ClientSecret     string                            `json:"clientSecret"`
 	CaptureMethod    PaymentIntentCaptureMethod        `json:"captureMethod"`
+	TenantID         string                            `json:"tenantId"`
 }
 
 func (q *Queries) UpdatePaymentIntent(ctx context.Context, arg UpdatePaymentIntentParams) error {
@@ -243,48 +272,7 @@ func (q *Queries) UpdatePaymentIntent(ctx context.Context, arg UpdatePaymentInte
 		arg.SetupFutureUsage,
 		arg.ClientSecret,
 		arg.CaptureMethod,
-	)
-	return err
-}
-
-const upsertPaymentIntent = `-- name: UpsertPaymentIntent :exec
-INSERT INTO payment_intents (
-    id,customer_id, amount, currency, status, payment_method_id, setup_future_usage,client_secret
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      customer_id = EXCLUDED.customer_id,
-                                      amount = EXCLUDED.amount,
-                                      currency = EXCLUDED.currency,
-                                      status = EXCLUDED.status,
-                                      payment_method_id = EXCLUDED.payment_method_id,
-                                      setup_future_usage = EXCLUDED.setup_future_usage,
-                                      client_secret = EXCLUDED.client_secret,
-                                      updated_at = NOW()
-`
-
-type UpsertPaymentIntentParams struct {
-	ID               string                            `json:"id"`
-	CustomerID       string                            `json:"customerId"`
-	Amount           float64                           `json:"amount"`
-	Currency         Currency                          `json:"currency"`
-	Status           PaymentIntentStatus               `json:"status"`
-	PaymentMethodID  *string                           `json:"paymentMethodId"`
-	SetupFutureUsage NullPaymentIntentSetupFutureUsage `json:"setupFutureUsage"`
-	ClientSecret     string                            `json:"clientSecret"`
-}
-
-func (q *Queries) UpsertPaymentIntent(ctx context.Context, arg UpsertPaymentIntentParams) error {
-	_, err := q.db.Exec(ctx, upsertPaymentIntent,
-		arg.ID,
-		arg.CustomerID,
-		arg.Amount,
-		arg.Currency,
-		arg.Status,
-		arg.PaymentMethodID,
-		arg.SetupFutureUsage,
-		arg.ClientSecret,
+		arg.TenantID,
 	)
 	return err
 }
@@ -10,10 +10,15 @@ import (
 )
 
 const deletePaymentLink = `-- name: DeletePaymentLink :exec
-DELETE FROM payment_links WHERE id = $1
+DELETE FROM payment_links WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeletePaymentLink(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deletePaymentLink, id)
+type DeletePaymentLinkParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeletePaymentLink(ctx context.Context, arg DeletePaymentLinkParams) error {
+	_, err := q.db.Exec(ctx, deletePaymentLink, arg.ID, arg.TenantID)
 	return err
 }
@@ -59,12 +59,20 @@ func (q *Queries) CreatePaymentMethod(ctx context.Context, arg CreatePaymentMeth
 
 const deletePaymentMethod = `-- name: DeletePaymentMethod :exec
 
-DELETE FROM payment_methods WHERE id = $1
+UPDATE payment_methods
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 `
 
 // RETURNING id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, stripe_id, created_at, updated_at;
-func (q *Queries) DeletePaymentMethod(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deletePaymentMethod, id)
+type DeletePaymentMethodParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeletePaymentMethod(ctx context.Context, arg DeletePaymentMethodParams) error {
+	_, err := q.db.Exec(ctx, deletePaymentMethod, arg.ID, arg.TenantID)
 	return err
 }
 
@@ -72,12 +80,17 @@ const getPaymentMethod = `-- name: GetPaymentMethod :one
 
 SELECT id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, created_at, updated_at
 FROM payment_methods
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 `
 
 // RETURNING id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, stripe_id, created_at, updated_at;
-func (q *Queries) GetPaymentMethod(ctx context.Context, id string) (*PaymentMethod, error) {
-	row := q.db.QueryRow(ctx, getPaymentMethod, id)
+type GetPaymentMethodParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetPaymentMethod(ctx context.Context, arg GetPaymentMethodParams) (*PaymentMethod, error) {
+	row := q.db.QueryRow(ctx, getPaymentMethod, arg.ID, arg.TenantID)
 	var i PaymentMethod
 	err := row.Scan(
 		&i.ID,
@@ -99,19 +112,37 @@ func (q *Queries) GetPaymentMethod(ctx context.Context, id string) (*PaymentMeth
 const listPaymentMethods = `-- name: ListPaymentMethods :many
 SELECT id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, created_at, updated_at
 FROM payment_methods
-WHERE customer_id = $1
-ORDER BY is_default DESC, created_at DESC
-LIMIT $2 OFFSET $3
+WHERE ($1::varchar IS NULL OR customer_id = $1::varchar)
+  AND ($2::timestamptz IS NULL OR created_at >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR created_at < $3::timestamptz)
+  AND ($4::timestamptz IS NULL
+       OR (created_at, id) < ($4::timestamptz, $5::varchar))
+  AND deleted_at IS NULL
+  AND tenant_id = $6
+ORDER BY created_at DESC, id DESC
+LIMIT $7
 `
 
 type ListPaymentMethodsParams struct {
-	CustomerID string `json:"customerId"`
-	Limit      int64  `json:"limit"`
-	Offset     int64  `json:"offset"`
+	CustomerID      *string            `json:"customerId"`
+	CreatedFrom     pgtype.Timestamptz `json:"createdFrom"`
+	CreatedTo       pgtype.Timestamptz `json:"createdTo"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        *string            `json:"cursorId"`
+	TenantID        string             `json:"tenantId"`
+	Limit           int64              `json:"limit"`
 }
 
 func (q *Queries) ListPaymentMethods(ctx context.Context, arg ListPaymentMethodsParams) ([]*PaymentMethod, error) {
-	rows, err := q.db.Query(ctx, listPaymentMethods, arg.CustomerID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listPaymentMethods,
+		arg.CustomerID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.TenantID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +188,7 @@ SET
     updated_at = NOW()
 WHERE
     id = $1
+  AND tenant_id = $11
   AND updated_at = $10
 `
 
@@ -171,6 +203,7 @@ type UpdatePaymentMethodParams struct {
 	BankAccountBankName *string                    `json:"bankAccountBankName"`
 	IsDefault           bool                       `json:"isDefault"`
 	UpdatedAt           pgtype.Timestamptz         `json:"updatedAt"`
+	TenantID            string                     `json:"tenantId"`
 }
 
 func (q *Queries) UpdatePaymentMethod(ctx context.Context, arg UpdatePaymentMethodParams) error {
@@ -185,64 +218,7 @@ func (q *Queries) UpdatePaymentMethod(ctx context.Context, arg UpdatePaymentMeth
 		arg.BankAccountBankName,
 		arg.IsDefault,
 		arg.UpdatedAt,
-	)
-	return err
-}
-
-const upsertPaymentMethod = `-- name: UpsertPaymentMethod :exec
-INSERT INTO payment_methods (
-    id,
-    customer_id,
-    type,
-    card_last4,
-    card_brand,
-    card_exp_month,
-    card_exp_year,
-    bank_account_last4,
-    bank_account_bank_name,
-    is_default
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-         )
-ON CONFLICT (id)
-    DO UPDATE SET
-                  customer_id = EXCLUDED.customer_id,
-                  type = EXCLUDED.type,
-                  card_last4 = EXCLUDED.card_last4,
-                  card_brand = EXCLUDED.card_brand,
-                  card_exp_month = EXCLUDED.card_exp_month,
-                  card_exp_year = EXCLUDED.card_exp_year,
-                  bank_account_last4 = EXCLUDED.bank_account_last4,
-                  bank_account_bank_name = EXCLUDED.bank_account_bank_name,
-                  is_default = EXCLUDED.is_default,
-                  updated_at = NOW()
-`
-
-type UpsertPaymentMethodParams struct {
-	ID                  string                     `json:"id"`
-	CustomerID          string                     `json:"customerId"`
-	Type                PaymentMethodType          `json:"type"`
-	CardLast4           *string                    `json:"cardLast4"`
-	CardBrand           NullPaymentMethodCardBrand `json:"cardBrand"`
-	CardExpMonth        *int32                     `json:"cardExpMonth"`
-	CardExpYear         *int32                     `json:"cardExpYear"`
-	BankAccountLast4    *string                    `json:"bankAccountLast4"`
-	BankAccountBankName *string                    `json:"bankAccountBankName"`
-	IsDefault           bool                       `json:"isDefault"`
-}
-
-func (q *Queries) UpsertPaymentMethod(ctx context.Context, arg UpsertPaymentMethodParams) error {
-	_, err := q.db.Exec(ctx, upsertPaymentMethod,
-		arg.ID,
-		arg.CustomerID,
-		arg.Type,
-		arg.CardLast4,
-		arg.CardBrand,
-		arg.CardExpMonth,
-		arg.CardExpYear,
-		arg.BankAccountLast4,
-		arg.BankAccountBankName,
-		arg.IsDefault,
+		arg.TenantID,
 	)
 	return err
 }
@@ -30,7 +30,7 @@ type CreatePriceParams struct {
 	ProductID              string                     `json:"productId"`
 	Type                   PriceType                  `json:"type"`
 	Currency               Currency                   `json:"currency"`
-	UnitAmount             float64                    `json:"unitAmount"`
+	UnitAmount             int64                      `json:"unitAmount"`
 	RecurringInterval      NullPriceRecurringInterval `json:"recurringInterval"`
 	RecurringIntervalCount int32                      `json:"recurringIntervalCount"`
 	TrialPeriodDays        int32                      `json:"trialPeriodDays"`
@@ -54,13 +54,18 @@ const deletePrice = `-- name: DeletePrice :one
 
 UPDATE prices
 SET active = false
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 RETURNING product_id
 `
 
 // RETURNING id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, stripe_id, created_at, updated_at;
-func (q *Queries) DeletePrice(ctx context.Context, id string) (string, error) {
-	row := q.db.QueryRow(ctx, deletePrice, id)
+type DeletePriceParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeletePrice(ctx context.Context, arg DeletePriceParams) (string, error) {
+	row := q.db.QueryRow(ctx, deletePrice, arg.ID, arg.TenantID)
 	var product_id string
 	err := row.Scan(&product_id)
 	return product_id, err
@@ -70,12 +75,17 @@ const getPrice = `-- name: GetPrice :one
 
 SELECT id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, created_at, updated_at
 FROM prices
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1
 `
 
 // RETURNING id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, stripe_id, created_at, updated_at;
-func (q *Queries) GetPrice(ctx context.Context, id string) (*Price, error) {
-	row := q.db.QueryRow(ctx, getPrice, id)
+type GetPriceParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetPrice(ctx context.Context, arg GetPriceParams) (*Price, error) {
+	row := q.db.QueryRow(ctx, getPrice, arg.ID, arg.TenantID)
 	var i Price
 	err := row.Scan(
 		&i.ID,
@@ -96,12 +106,17 @@ func (q *Queries) GetPrice(ctx context.Context, id string) (*Price, error) {
 const listActivePrices = `-- name: ListActivePrices :many
 SELECT id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, created_at, updated_at
 FROM prices
-WHERE product_id = $1 AND active = true
+WHERE product_id = $1 AND tenant_id = $2 AND active = true AND deleted_at IS NULL
 ORDER BY created_at DESC
 `
 
-func (q *Queries) ListActivePrices(ctx context.Context, productID string) ([]*Price, error) {
-	rows, err := q.db.Query(ctx, listActivePrices, productID)
+type ListActivePricesParams struct {
+	ProductID string `json:"productId"`
+	TenantID  string `json:"tenantId"`
+}
+
+func (q *Queries) ListActivePrices(ctx context.Context, arg ListActivePricesParams) ([]*Price, error) {
+	rows, err := q.db.Query(ctx, listActivePrices, arg.ProductID, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,12 +150,17 @@ func (q *Queries) ListActivePrices(ctx context.Context, productID string) ([]*Pr
 const listPrices = `-- name: ListPrices :many
 SELECT id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, created_at, updated_at
 FROM prices
-WHERE product_id = $1
+WHERE product_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 ORDER BY created_at DESC
 `
 
-func (q *Queries) ListPrices(ctx context.Context, productID string) ([]*Price, error) {
-	rows, err := q.db.Query(ctx, listPrices, productID)
+type ListPricesParams struct {
+	ProductID string `json:"productId"`
+	TenantID  string `json:"tenantId"`
+}
+
+func (q *Queries) ListPrices(ctx context.Context, arg ListPricesParams) ([]*Price, error) {
+	rows, err := q.db.Query(ctx, listPrices, arg.ProductID, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -182,7 +202,7 @@ SET product_id = $2,
     trial_period_days = $8,
     active = $9,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $10
 `
 
 type UpdatePriceParams struct {
@@ -190,11 +210,12 @@ type UpdatePriceParams struct {
 	ProductID              string                     `json:"productId"`
 	Type                   PriceType                  `json:"type"`
 	Currency               Currency                   `json:"currency"`
-	UnitAmount             float64                    `json:"unitAmount"`
+	UnitAmount             int64                      `json:"unitAmount"`
 	RecurringInterval      NullPriceRecurringInterval `json:"recurringInterval"`
 	RecurringIntervalCount int32                      `json:"recurringIntervalCount"`
 	TrialPeriodDays        int32                      `json:"trialPeriodDays"`
 	Active                 bool                       `json:"active"`
+	TenantID               string                     `json:"tenantId"`
 }
 
 func (q *Queries) UpdatePrice(ctx context.Context, arg UpdatePriceParams) error {
@@ -208,52 +229,7 @@ func (q *Queries) UpdatePrice(ctx context.Context, arg UpdatePriceParams) error
 		arg.RecurringIntervalCount,
 		arg.TrialPeriodDays,
 		arg.Active,
-	)
-	return err
-}
-
-const upsertPrice = `-- name: UpsertPrice :exec
-INSERT INTO prices (
-    id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count,
-    trial_period_days, active
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      product_id = EXCLUDED.product_id,
-                                      type = EXCLUDED.type,
-                                      currency = EXCLUDED.currency,
-                                      unit_amount = EXCLUDED.unit_amount,
-                                      recurring_interval = EXCLUDED.recurring_interval,
-                                      recurring_interval_count = EXCLUDED.recurring_interval_count,
-                                      trial_period_days = EXCLUDED.trial_period_days,
-                                      active = EXCLUDED.active,
-                                      updated_at = NOW()
-`
-
-type UpsertPriceParams struct {
-	ID                     string                     `json:"id"`
-	ProductID              string                     `json:"productId"`
-	Type                   PriceType                  `json:"type"`
-	Currency               Currency                   `json:"currency"`
-	UnitAmount             float64                    `json:"unitAmount"`
-	RecurringInterval      NullPriceRecurringInterval `json:"recurringInterval"`
-	RecurringIntervalCount int32                      `json:"recurringIntervalCount"`
-	TrialPeriodDays        int32                      `json:"trialPeriodDays"`
-	Active                 bool                       `json:"active"`
-}
-
-func (q *Queries) UpsertPrice(ctx context.Context, arg UpsertPriceParams) error {
-	_, err := q.db.Exec(ctx, upsertPrice,
-		arg.ID,
-		arg.ProductID,
-		arg.Type,
-		arg.Currency,
-		arg.UnitAmount,
-		arg.RecurringInterval,
-		arg.RecurringIntervalCount,
-		arg.TrialPeriodDays,
-		arg.Active,
+		arg.TenantID,
 	)
 	return err
 }
@@ -52,22 +52,32 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (*
 }
 
 const deleteProduct = `-- name: DeleteProduct :exec
-DELETE FROM products WHERE id = $1
+DELETE FROM products WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteProduct(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteProduct, id)
+type DeleteProductParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteProduct(ctx context.Context, arg DeleteProductParams) error {
+	_, err := q.db.Exec(ctx, deleteProduct, arg.ID, arg.TenantID)
 	return err
 }
 
 const getProduct = `-- name: GetProduct :one
 SELECT id, name, description, active, metadata, created_at, updated_at
 FROM products
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1
 `
 
-func (q *Queries) GetProduct(ctx context.Context, id string) (*Product, error) {
-	row := q.db.QueryRow(ctx, getProduct, id)
+type GetProductParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetProduct(ctx context.Context, arg GetProductParams) (*Product, error) {
+	row := q.db.QueryRow(ctx, getProduct, arg.ID, arg.TenantID)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -84,17 +94,37 @@ func (q *Queries) GetProduct(ctx context.Context, id string) (*Product, error) {
 const listProducts = `-- name: ListProducts :many
 SELECT id, name, description, active, metadata, created_at, updated_at
 FROM products
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2
+WHERE ($1::boolean IS NULL OR active = $1::boolean)
+  AND ($2::timestamptz IS NULL OR created_at >= $2::timestamptz)
+  AND ($3::timestamptz IS NULL OR created_at < $3::timestamptz)
+  AND ($4::timestamptz IS NULL
+       OR (created_at, id) < ($4::timestamptz, $5::varchar))
+  AND deleted_at IS NULL
+  AND tenant_id = $6
+ORDER BY created_at DESC, id DESC
+LIMIT $7
 `
 
 type ListProductsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	Active          *bool              `json:"active"`
+	CreatedFrom     pgtype.Timestamptz `json:"createdFrom"`
+	CreatedTo       pgtype.Timestamptz `json:"createdTo"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        *string            `json:"cursorId"`
+	TenantID        string             `json:"tenantId"`
+	Limit           int64              `json:"limit"`
 }
 
 func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]*Product, error) {
-	rows, err := q.db.Query(ctx, listProducts, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listProducts,
+		arg.Active,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.TenantID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +158,7 @@ SET name = $2,
     active = $4,
     metadata = $5,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $6
 RETURNING created_at, updated_at
 `
 
@@ -138,6 +168,7 @@ type UpdateProductParams struct {
 	Description *string `json:"description"`
 	Active      bool    `json:"active"`
 	Metadata    []byte  `json:"metadata"`
+	TenantID    string  `json:"tenantId"`
 }
 
 type UpdateProductRow struct {
@@ -152,41 +183,9 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (*
 		arg.Description,
 		arg.Active,
 		arg.Metadata,
+		arg.TenantID,
 	)
 	var i UpdateProductRow
 	err := row.Scan(&i.CreatedAt, &i.UpdatedAt)
 	return &i, err
 }
-
-const upsertProduct = `-- name: UpsertProduct :exec
-INSERT INTO products (
-    id, name, description, active, metadata
-) VALUES (
-             $1, $2, $3, $4, $5
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      name = EXCLUDED.name,
-                                      description = EXCLUDED.description,
-                                      active = EXCLUDED.active,
-                                      metadata = EXCLUDED.metadata,
-                                      updated_at = NOW()
-`
-
-type UpsertProductParams struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
-	Active      bool    `json:"active"`
-	Metadata    []byte  `json:"metadata"`
-}
-
-func (q *Queries) UpsertProduct(ctx context.Context, arg UpsertProductParams) error {
-	_, err := q.db.Exec(ctx, upsertProduct,
-		arg.ID,
-		arg.Name,
-		arg.Description,
-		arg.Active,
-		arg.Metadata,
-	)
-	return err
-}
@@ -10,10 +10,15 @@ import (
 )
 
 const deletePromotionCodes = `-- name: DeletePromotionCodes :exec
-DELETE FROM promotion_codes WHERE id = $1
+DELETE FROM promotion_codes WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeletePromotionCodes(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deletePromotionCodes, id)
+type DeletePromotionCodesParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeletePromotionCodes(ctx context.Context, arg DeletePromotionCodesParams) error {
+	_, err := q.db.Exec(ctx, deletePromotionCodes, arg.ID, arg.TenantID)
 	return err
 }
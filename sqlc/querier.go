@@ -10,8 +10,11 @@ import (
 
 type Querier interface {
 	// RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
-	CancelSubscription(ctx context.Context, id string) error
-	CloseDispute(ctx context.Context, arg CloseDisputeParams) error
+	CancelSubscription(ctx context.Context, arg CancelSubscriptionParams) error
+	ClaimEventsForRetry(ctx context.Context, arg ClaimEventsForRetryParams) ([]*Event, error)
+	ClaimPendingOutboxMessages(ctx context.Context, limit int64) ([]*OutboxMessage, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (*ApiKey, error)
+	CreateAPIKeyRequest(ctx context.Context, arg CreateAPIKeyRequestParams) error
 	CreateCoupon(ctx context.Context, arg CreateCouponParams) (*Coupon, error)
 	CreateCustomer(ctx context.Context, arg CreateCustomerParams) (*CreateCustomerRow, error)
 	CreateDiscount(ctx context.Context, arg CreateDiscountParams) (*Discount, error)
@@ -19,72 +22,89 @@ type Querier interface {
 	CreateEvent(ctx context.Context, arg CreateEventParams) error
 	CreateInvoice(ctx context.Context, arg CreateInvoiceParams) error
 	CreateInvoiceItem(ctx context.Context, arg CreateInvoiceItemParams) error
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) error
 	CreatePaymentIntent(ctx context.Context, arg CreatePaymentIntentParams) error
 	CreatePaymentMethod(ctx context.Context, arg CreatePaymentMethodParams) error
 	CreatePrice(ctx context.Context, arg CreatePriceParams) error
 	CreateProduct(ctx context.Context, arg CreateProductParams) (*CreateProductRow, error)
 	CreateRefund(ctx context.Context, arg CreateRefundParams) error
 	CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) error
-	DeleteCheckOutSession(ctx context.Context, id string) error
-	DeleteCoupon(ctx context.Context, id string) error
-	DeleteCustomer(ctx context.Context, id string) error
-	DeleteDiscount(ctx context.Context, id string) error
-	DeleteDispute(ctx context.Context, id string) error
-	DeleteInvoice(ctx context.Context, id string) error
+	DeauthorizeConnectedAccount(ctx context.Context, arg DeauthorizeConnectedAccountParams) error
+	DeleteBackfillCheckpoints(ctx context.Context, arg DeleteBackfillCheckpointsParams) error
+	DeleteCheckOutSession(ctx context.Context, arg DeleteCheckOutSessionParams) error
+	DeleteCustomer(ctx context.Context, arg DeleteCustomerParams) error
+	DeleteDispute(ctx context.Context, arg DeleteDisputeParams) error
+	DeleteInvoice(ctx context.Context, arg DeleteInvoiceParams) error
 	// RETURNING id, invoice_id, amount, description, created_at, updated_at;
-	DeleteInvoiceItem(ctx context.Context, id string) error
-	DeletePaymentLink(ctx context.Context, id string) error
+	DeleteInvoiceItem(ctx context.Context, arg DeleteInvoiceItemParams) error
+	DeletePaymentLink(ctx context.Context, arg DeletePaymentLinkParams) error
 	// RETURNING id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, stripe_id, created_at, updated_at;
-	DeletePaymentMethod(ctx context.Context, id string) error
+	DeletePaymentMethod(ctx context.Context, arg DeletePaymentMethodParams) error
 	// RETURNING id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, stripe_id, created_at, updated_at;
-	DeletePrice(ctx context.Context, id string) (string, error)
-	DeleteProduct(ctx context.Context, id string) error
-	DeletePromotionCodes(ctx context.Context, id string) error
-	DeleteQuote(ctx context.Context, id string) error
-	DeleteRefund(ctx context.Context, id string) error
-	DeleteReviews(ctx context.Context, id string) error
-	DeleteSubscription(ctx context.Context, id string) error
-	DeleteTaxRate(ctx context.Context, id string) error
-	GetCouponByID(ctx context.Context, id string) (*Coupon, error)
-	GetCustomer(ctx context.Context, dollar_1 *string) (*GetCustomerRow, error)
-	GetDiscountByID(ctx context.Context, id string) (*Discount, error)
-	GetDisputeByID(ctx context.Context, id string) (*Dispute, error)
-	GetEventByID(ctx context.Context, id string) (*Event, error)
+	DeletePrice(ctx context.Context, arg DeletePriceParams) (string, error)
+	DeleteProduct(ctx context.Context, arg DeleteProductParams) error
+	DeletePromotionCodes(ctx context.Context, arg DeletePromotionCodesParams) error
+	DeleteQuote(ctx context.Context, arg DeleteQuoteParams) error
+	DeleteRefund(ctx context.Context, arg DeleteRefundParams) error
+	DeleteReviews(ctx context.Context, arg DeleteReviewsParams) error
+	DeleteSubscription(ctx context.Context, arg DeleteSubscriptionParams) error
+	DeleteTaxRate(ctx context.Context, arg DeleteTaxRateParams) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*ApiKey, error)
+	GetApplicationFee(ctx context.Context, arg GetApplicationFeeParams) (*ApplicationFee, error)
+	GetBackfillCheckpoint(ctx context.Context, arg GetBackfillCheckpointParams) (*BackfillCheckpoint, error)
+	GetConnectedAccount(ctx context.Context, arg GetConnectedAccountParams) (*ConnectedAccount, error)
+	GetCouponByID(ctx context.Context, arg GetCouponByIDParams) (*Coupon, error)
+	GetCustomer(ctx context.Context, arg GetCustomerParams) (*GetCustomerRow, error)
+	GetDiscountByID(ctx context.Context, arg GetDiscountByIDParams) (*Discount, error)
+	GetDisputeByID(ctx context.Context, arg GetDisputeByIDParams) (*Dispute, error)
+	GetEventByID(ctx context.Context, arg GetEventByIDParams) (*Event, error)
 	GetExpiringSubscriptions(ctx context.Context, arg GetExpiringSubscriptionsParams) ([]*Subscription, error)
 	// RETURNING id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, stripe_id, created_at, updated_at;
-	GetInvoice(ctx context.Context, id string) (*Invoice, error)
+	GetInvoice(ctx context.Context, arg GetInvoiceParams) (*Invoice, error)
 	// RETURNING id, invoice_id, amount, description, created_at, updated_at;
-	GetInvoiceItem(ctx context.Context, id string) (*InvoiceItem, error)
+	GetInvoiceItem(ctx context.Context, arg GetInvoiceItemParams) (*InvoiceItem, error)
 	// RETURNING id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, stripe_id, client_secret, created_at, updated_at;
-	GetPaymentIntent(ctx context.Context, id string) (*GetPaymentIntentRow, error)
+	GetPaymentIntent(ctx context.Context, arg GetPaymentIntentParams) (*GetPaymentIntentRow, error)
 	// RETURNING id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, stripe_id, created_at, updated_at;
-	GetPaymentMethod(ctx context.Context, id string) (*PaymentMethod, error)
+	GetPaymentMethod(ctx context.Context, arg GetPaymentMethodParams) (*PaymentMethod, error)
 	// RETURNING id, product_id, type, currency, unit_amount, recurring_interval, recurring_interval_count, trial_period_days, active, stripe_id, created_at, updated_at;
-	GetPrice(ctx context.Context, id string) (*Price, error)
-	GetProduct(ctx context.Context, id string) (*Product, error)
-	GetRefund(ctx context.Context, id string) (*Refund, error)
+	GetPrice(ctx context.Context, arg GetPriceParams) (*Price, error)
+	GetProduct(ctx context.Context, arg GetProductParams) (*Product, error)
+	GetRefund(ctx context.Context, arg GetRefundParams) (*Refund, error)
 	// RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
-	GetSubscription(ctx context.Context, id string) (*Subscription, error)
-	ListActivePrices(ctx context.Context, productID string) ([]*Price, error)
-	ListByChargeID(ctx context.Context, chargeID string) ([]*Refund, error)
+	GetSubscription(ctx context.Context, arg GetSubscriptionParams) (*Subscription, error)
+	GetTransfer(ctx context.Context, arg GetTransferParams) (*Transfer, error)
+	IncrementEventAttempts(ctx context.Context, arg IncrementEventAttemptsParams) (int32, error)
+	ListActivePrices(ctx context.Context, arg ListActivePricesParams) ([]*Price, error)
 	ListCoupons(ctx context.Context, arg ListCouponsParams) ([]*Coupon, error)
 	ListCustomers(ctx context.Context, arg ListCustomersParams) ([]*ListCustomersRow, error)
+	ListCustomersByIDs(ctx context.Context, arg ListCustomersByIDsParams) ([]*Customer, error)
 	ListDiscounts(ctx context.Context, arg ListDiscountsParams) ([]*Discount, error)
 	ListDiscountsByCustomerID(ctx context.Context, arg ListDiscountsByCustomerIDParams) ([]*Discount, error)
-	ListInvoiceItems(ctx context.Context, invoiceID string) ([]*InvoiceItem, error)
-	ListInvoices(ctx context.Context, id string) ([]*Invoice, error)
+	ListEventsForReplay(ctx context.Context, arg ListEventsForReplayParams) ([]*Event, error)
+	ListFailedEvents(ctx context.Context, arg ListFailedEventsParams) ([]*Event, error)
+	ListInvoiceItems(ctx context.Context, arg ListInvoiceItemsParams) ([]*InvoiceItem, error)
 	// RETURNING id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, stripe_id, created_at, updated_at;
-	ListInvoicesByCustomerID(ctx context.Context, arg ListInvoicesByCustomerIDParams) ([]*Invoice, error)
+	ListInvoices(ctx context.Context, arg ListInvoicesParams) ([]*Invoice, error)
+	ListInvoicesByIDs(ctx context.Context, arg ListInvoicesByIDsParams) ([]*Invoice, error)
 	// RETURNING id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, stripe_id, client_secret, created_at, updated_at;
 	ListPaymentIntents(ctx context.Context, arg ListPaymentIntentsParams) ([]*ListPaymentIntentsRow, error)
-	ListPaymentIntentsByCustomer(ctx context.Context, arg ListPaymentIntentsByCustomerParams) ([]*ListPaymentIntentsByCustomerRow, error)
+	ListPaymentIntentsByIDs(ctx context.Context, arg ListPaymentIntentsByIDsParams) ([]*PaymentIntent, error)
 	ListPaymentMethods(ctx context.Context, arg ListPaymentMethodsParams) ([]*PaymentMethod, error)
-	ListPrices(ctx context.Context, productID string) ([]*Price, error)
+	ListPrices(ctx context.Context, arg ListPricesParams) ([]*Price, error)
 	ListProducts(ctx context.Context, arg ListProductsParams) ([]*Product, error)
 	ListRefunds(ctx context.Context, arg ListRefundsParams) ([]*Refund, error)
 	// RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
 	ListSubscriptions(ctx context.Context, arg ListSubscriptionsParams) ([]*Subscription, error)
+	ListSubscriptionsByIDs(ctx context.Context, arg ListSubscriptionsByIDsParams) ([]*Subscription, error)
+	MarkEventAsFailed(ctx context.Context, arg MarkEventAsFailedParams) error
 	MarkEventAsProcessed(ctx context.Context, arg MarkEventAsProcessedParams) error
+	MarkOutboxMessagePublished(ctx context.Context, arg MarkOutboxMessagePublishedParams) error
+	RecordOutboxMessageFailure(ctx context.Context, arg RecordOutboxMessageFailureParams) error
+	RevokeAPIKey(ctx context.Context, prefix string) (int64, error)
+	SaveBackfillCheckpoint(ctx context.Context, arg SaveBackfillCheckpointParams) error
+	ScheduleEventRetry(ctx context.Context, arg ScheduleEventRetryParams) error
+	TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error
 	UpdateCoupon(ctx context.Context, arg UpdateCouponParams) (*Coupon, error)
 	UpdateCustomer(ctx context.Context, arg UpdateCustomerParams) error
 	UpdateCustomerBalance(ctx context.Context, arg UpdateCustomerBalanceParams) error
@@ -98,18 +118,6 @@ type Querier interface {
 	UpdateProduct(ctx context.Context, arg UpdateProductParams) (*UpdateProductRow, error)
 	UpdateRefund(ctx context.Context, arg UpdateRefundParams) error
 	UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) error
-	UpsertCharge(ctx context.Context, arg UpsertChargeParams) error
-	UpsertCoupon(ctx context.Context, arg UpsertCouponParams) error
-	UpsertCustomer(ctx context.Context, arg UpsertCustomerParams) error
-	UpsertDiscount(ctx context.Context, arg UpsertDiscountParams) error
-	UpsertDispute(ctx context.Context, arg UpsertDisputeParams) error
-	UpsertInvoice(ctx context.Context, arg UpsertInvoiceParams) error
-	UpsertPaymentIntent(ctx context.Context, arg UpsertPaymentIntentParams) error
-	UpsertPaymentMethod(ctx context.Context, arg UpsertPaymentMethodParams) error
-	UpsertPrice(ctx context.Context, arg UpsertPriceParams) error
-	UpsertProduct(ctx context.Context, arg UpsertProductParams) error
-	UpsertRefund(ctx context.Context, arg UpsertRefundParams) error
-	UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,29 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    name, prefix, hash, scopes, tenant_id
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+RETURNING id, name, prefix, hash, scopes, last_used_at, revoked_at, created_at, tenant_id;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT id, name, prefix, hash, scopes, last_used_at, revoked_at, created_at, tenant_id
+FROM api_keys
+WHERE prefix = $1;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE prefix = $1 AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = $2
+WHERE id = $1;
+
+-- name: CreateAPIKeyRequest :exec
+INSERT INTO api_key_requests (
+    api_key_id, method, path, status, created_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         );
@@ -0,0 +1,4 @@
+-- name: GetApplicationFee :one
+SELECT id, account_id, charge_id, originating_transaction_id, amount, amount_refunded, currency, refunded, created_at, updated_at, last_event_at, tenant_id
+FROM application_fees
+WHERE id = $1 AND tenant_id = $2;
@@ -0,0 +1,21 @@
+-- name: GetBackfillCheckpoint :one
+SELECT resource, last_id, imported, completed_at, updated_at
+FROM backfill_checkpoints
+WHERE resource = $1 AND tenant_id = $2;
+
+-- name: SaveBackfillCheckpoint :exec
+INSERT INTO backfill_checkpoints (
+    resource, last_id, imported, completed_at, updated_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+ON CONFLICT (tenant_id, resource) DO UPDATE SET
+    last_id = EXCLUDED.last_id,
+    imported = EXCLUDED.imported,
+    completed_at = EXCLUDED.completed_at,
+    updated_at = EXCLUDED.updated_at
+WHERE backfill_checkpoints.tenant_id = $6;
+
+-- name: DeleteBackfillCheckpoints :exec
+DELETE FROM backfill_checkpoints
+WHERE resource = ANY($1::varchar[]) AND tenant_id = $2;
@@ -1,2 +1,2 @@
 -- name: DeleteCheckOutSession :exec
-DELETE FROM checkout_sessions WHERE id = $1;
\ No newline at end of file
+DELETE FROM checkout_sessions WHERE id = $1 AND tenant_id = $2;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- name: GetConnectedAccount :one
+SELECT id, type, email, country, charges_enabled, payouts_enabled, details_submitted, capabilities, requirements_due, disabled_reason, deauthorized_at, created_at, updated_at, last_event_at, tenant_id
+FROM connected_accounts
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: DeauthorizeConnectedAccount :exec
+UPDATE connected_accounts
+SET deauthorized_at = $2,
+    charges_enabled = FALSE,
+    payouts_enabled = FALSE,
+    updated_at = NOW()
+WHERE id = $1 AND tenant_id = $3;
@@ -10,25 +10,26 @@ RETURNING id, created_at, updated_at;
 
 -- name: GetCustomer :one
 SELECT c.id, c.balance, c.created_at, c.updated_at,
-       u.email, u.username as name
+       c.user_email as email, COALESCE(u.username, '')::varchar as name
 FROM customers c
-         JOIN users u ON c.user_email = u.email
-WHERE c.id = $1;
+         LEFT JOIN users u ON c.user_email = u.email
+WHERE c.id = $1 AND c.tenant_id = $2 AND c.deleted_at IS NULL;
 
 -- name: UpdateCustomer :exec
 UPDATE customers
 SET balance = $2,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $3;
 
 -- name: DeleteCustomer :exec
-DELETE FROM customers WHERE id = $1;
+DELETE FROM customers WHERE id = $1 AND tenant_id = $2;
 
 -- name: ListCustomers :many
 SELECT c.id, c.user_email, c.balance, c.created_at, c.updated_at,
-    u.username as name
+    COALESCE(u.username, '')::varchar as name
 FROM customers c
-         JOIN users u ON c.user_email = u.email
+         LEFT JOIN users u ON c.user_email = u.email
+WHERE c.tenant_id = $3 AND c.deleted_at IS NULL
 ORDER BY c.created_at DESC
 LIMIT $1 OFFSET $2;
 
@@ -36,12 +37,9 @@ LIMIT $1 OFFSET $2;
 UPDATE customers
 SET balance = balance + $2,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $3;
 
--- name: UpsertCustomer :exec
-INSERT INTO customers (id, user_email, balance, updated_at)
-VALUES ($1, sqlc.narg('user_email'), sqlc.narg('balance'), $3)
-ON CONFLICT (id) DO UPDATE SET
-                               user_email = COALESCE(sqlc.narg('user_email'), customers.user_email),
-                               balance = COALESCE(sqlc.narg('balance'), customers.balance),
-                               updated_at = $3;
\ No newline at end of file
+-- name: ListCustomersByIDs :many
+SELECT id, user_email, balance, created_at, updated_at
+FROM customers
+WHERE id = ANY(@ids::varchar[]) AND tenant_id = @tenant_id;
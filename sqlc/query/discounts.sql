@@ -8,18 +8,19 @@ RETURNING *;
 
 -- name: GetDiscountByID :one
 SELECT * FROM discounts
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1;
 
 -- name: ListDiscounts :many
 SELECT * FROM discounts
+WHERE tenant_id = $1 AND deleted_at IS NULL
 ORDER BY id
-LIMIT $1 OFFSET $2;
+LIMIT $2 OFFSET $3;
 
 -- name: ListDiscountsByCustomerID :many
 SELECT * FROM discounts
-WHERE customer_id = $1
+WHERE customer_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 ORDER BY id
-LIMIT $2 OFFSET $3;
+LIMIT $3 OFFSET $4;
 
 -- name: UpdateDiscount :one
 UPDATE discounts
@@ -28,23 +29,5 @@ SET customer_id = $2,
     start = $4,
     "end" = $5,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $6 AND deleted_at IS NULL
 RETURNING *;
-
--- name: DeleteDiscount :exec
-DELETE FROM discounts
-WHERE id = $1;
-
--- name: UpsertDiscount :exec
-INSERT INTO discounts (
-    id, customer_id, coupon_id, start, "end"
-) VALUES (
-             $1, $2, $3, $4, $5
-         )
-ON CONFLICT (id)
-    DO UPDATE SET
-                  customer_id = EXCLUDED.customer_id,
-                  coupon_id = EXCLUDED.coupon_id,
-                  start = EXCLUDED.start,
-                  "end" = EXCLUDED."end",
-                  updated_at = NOW();
\ No newline at end of file
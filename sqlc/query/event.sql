@@ -1,16 +1,69 @@
 -- name: CreateEvent :exec
 INSERT INTO events (
-    id, type, processed, created_at, updated_at
+    id, type, processed, payload, api_version, stripe_created_at, object_id, created_at, updated_at
 ) VALUES (
-             $1, $2, $3, $4, $5
-         );
+             $1, $2, $3, $4, $5, $6, $7, $8, $9
+         )
+ON CONFLICT (id) DO NOTHING;
 
 -- name: GetEventByID :one
-SELECT id, type, processed, created_at, updated_at
+SELECT id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
 FROM events
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2;
 
 -- name: MarkEventAsProcessed :exec
 UPDATE events
-SET processed = true, updated_at = $2
-WHERE id = $1;
\ No newline at end of file
+SET processed = true, failed = false, next_attempt_at = NULL, updated_at = $2
+WHERE id = $1 AND tenant_id = $3;
+
+-- name: ListEventsForReplay :many
+SELECT id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
+FROM events
+WHERE payload IS NOT NULL
+  AND tenant_id = sqlc.arg('tenant_id')
+  AND (sqlc.narg('type')::event_type IS NULL OR type = sqlc.narg('type')::event_type)
+  AND (sqlc.narg('object_id')::varchar IS NULL OR object_id = sqlc.narg('object_id')::varchar)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR stripe_created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR stripe_created_at < sqlc.narg('created_to')::timestamptz)
+ORDER BY stripe_created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: IncrementEventAttempts :one
+UPDATE events
+SET attempts = attempts + 1, last_error = $2, updated_at = $3
+WHERE id = $1 AND tenant_id = $4
+RETURNING attempts;
+
+-- name: ScheduleEventRetry :exec
+UPDATE events
+SET next_attempt_at = $2, updated_at = $3
+WHERE id = $1 AND tenant_id = $4;
+
+-- name: MarkEventAsFailed :exec
+UPDATE events
+SET failed = true, next_attempt_at = NULL, updated_at = $2
+WHERE id = $1 AND tenant_id = $3;
+
+-- name: ClaimEventsForRetry :many
+UPDATE events
+SET next_attempt_at = sqlc.arg('lease_until')
+WHERE id IN (
+    SELECT e.id
+    FROM events e
+    WHERE e.processed = false
+      AND e.failed = false
+      AND e.payload IS NOT NULL
+      AND e.next_attempt_at <= sqlc.arg('now')
+    ORDER BY e.next_attempt_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id;
+
+-- name: ListFailedEvents :many
+SELECT id, type, processed, created_at, updated_at, payload, api_version, stripe_created_at, object_id, attempts, last_error, next_attempt_at, failed, tenant_id
+FROM events
+WHERE failed = true
+  AND tenant_id = $1
+ORDER BY updated_at DESC
+LIMIT $2 OFFSET $3;
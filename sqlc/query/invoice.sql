@@ -18,7 +18,7 @@ INSERT INTO invoices (
 -- name: GetInvoice :one
 SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
 FROM invoices
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1;
 
 -- name: UpdateInvoice :exec
 UPDATE invoices
@@ -27,39 +27,28 @@ SET status = $2,
     amount_remaining = $4,
     paid_at = $5,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $6;
 -- RETURNING id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, stripe_id, created_at, updated_at;
 
--- name: ListInvoicesByCustomerID :many
-SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
-FROM invoices
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
-
 -- name: ListInvoices :many
 SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
 FROM invoices
-WHERE id = $1;
+WHERE (sqlc.narg('customer_id')::varchar IS NULL OR customer_id = sqlc.narg('customer_id')::varchar)
+  AND (sqlc.narg('status')::invoice_status IS NULL OR status = sqlc.narg('status')::invoice_status)
+  AND (sqlc.narg('currency')::currency IS NULL OR currency = sqlc.narg('currency')::currency)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::varchar))
+  AND deleted_at IS NULL
+  AND tenant_id = sqlc.arg('tenant_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: DeleteInvoice :exec
-DELETE FROM invoices WHERE id = $1;
+DELETE FROM invoices WHERE id = $1 AND tenant_id = $2;
 
--- name: UpsertInvoice :exec
-INSERT INTO invoices (
-    id, customer_id, subscription_id, status, currency, amount_due, amount_paid,
-    amount_remaining, due_date, paid_at
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      customer_id = EXCLUDED.customer_id,
-                                      subscription_id = EXCLUDED.subscription_id,
-                                      status = EXCLUDED.status,
-                                      currency = EXCLUDED.currency,
-                                      amount_due = EXCLUDED.amount_due,
-                                      amount_paid = EXCLUDED.amount_paid,
-                                      amount_remaining = EXCLUDED.amount_remaining,
-                                      due_date = EXCLUDED.due_date,
-                                      paid_at = EXCLUDED.paid_at,
-                                      updated_at = NOW();
+-- name: ListInvoicesByIDs :many
+SELECT id, customer_id, subscription_id, status, currency, amount_due, amount_paid, amount_remaining, due_date, paid_at, created_at, updated_at
+FROM invoices
+WHERE id = ANY(@ids::varchar[]) AND tenant_id = @tenant_id;
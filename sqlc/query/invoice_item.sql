@@ -2,30 +2,31 @@
 INSERT INTO invoice_items (
     invoice_id,
     amount,
+    currency,
     description
 ) VALUES (
-             $1, $2, $3
+             $1, $2, $3, $4
          );
 -- RETURNING id, invoice_id, amount, description, created_at, updated_at;
 
 -- name: GetInvoiceItem :one
-SELECT id, invoice_id, amount, description, created_at, updated_at
+SELECT id, invoice_id, amount, currency, description, created_at, updated_at
 FROM invoice_items
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND tenant_id = $2 LIMIT 1;
 
 -- name: UpdateInvoiceItem :exec
 UPDATE invoice_items
 SET amount = $2,
     description = $3,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $4;
 -- RETURNING id, invoice_id, amount, description, created_at, updated_at;
 
 -- name: DeleteInvoiceItem :exec
-DELETE FROM invoice_items WHERE id = $1;
+DELETE FROM invoice_items WHERE id = $1 AND tenant_id = $2;
 
 -- name: ListInvoiceItems :many
-SELECT id, invoice_id, amount, description, created_at, updated_at
+SELECT id, invoice_id, amount, currency, description, created_at, updated_at
 FROM invoice_items
-WHERE invoice_id = $1
+WHERE invoice_id = $1 AND tenant_id = $2
 ORDER BY created_at DESC;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- name: CreateOutboxMessage :exec
+INSERT INTO outbox_messages (
+    id, subject, schema_version, payload, created_at
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+ON CONFLICT (id) DO NOTHING;
+
+-- name: ClaimPendingOutboxMessages :many
+SELECT id, subject, schema_version, payload, attempts, last_error, published_at, created_at, tenant_id
+FROM outbox_messages
+WHERE published_at IS NULL
+ORDER BY created_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxMessagePublished :exec
+UPDATE outbox_messages
+SET published_at = $2, last_error = NULL
+WHERE id = $1;
+
+-- name: RecordOutboxMessageFailure :exec
+UPDATE outbox_messages
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1;
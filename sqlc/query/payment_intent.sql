@@ -15,9 +15,9 @@ INSERT INTO payment_intents (
 -- RETURNING id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, stripe_id, client_secret, created_at, updated_at;
 
 -- name: GetPaymentIntent :one
-SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret,capture_method, created_at, updated_at
+SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret,capture_method, created_at, updated_at, on_behalf_of, transfer_destination, application_fee_amount
 FROM payment_intents
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND tenant_id = $2 LIMIT 1;
 
 -- name: UpdatePaymentIntent :exec
 UPDATE payment_intents
@@ -27,35 +27,24 @@ SET status = $2,
     client_secret = $5,
     capture_method = $6,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $7;
 -- RETURNING id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, stripe_id, client_secret, created_at, updated_at;
 
 -- name: ListPaymentIntents :many
-SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at
+SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at, on_behalf_of, transfer_destination, application_fee_amount
 FROM payment_intents
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE (sqlc.narg('customer_id')::varchar IS NULL OR customer_id = sqlc.narg('customer_id')::varchar)
+  AND (sqlc.narg('status')::payment_intent_status IS NULL OR status = sqlc.narg('status')::payment_intent_status)
+  AND (sqlc.narg('currency')::currency IS NULL OR currency = sqlc.narg('currency')::currency)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::varchar))
+  AND tenant_id = sqlc.arg('tenant_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
-
--- name: ListPaymentIntentsByCustomer :many
-SELECT id, customer_id, amount, currency, status, payment_method_id, setup_future_usage, client_secret, capture_method, created_at, updated_at
+-- name: ListPaymentIntentsByIDs :many
+SELECT id, customer_id, amount, currency, capture_method, status, payment_method_id, setup_future_usage, client_secret, created_at, updated_at, on_behalf_of, transfer_destination, application_fee_amount
 FROM payment_intents
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
-
--- name: UpsertPaymentIntent :exec
-INSERT INTO payment_intents (
-    id,customer_id, amount, currency, status, payment_method_id, setup_future_usage,client_secret
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      customer_id = EXCLUDED.customer_id,
-                                      amount = EXCLUDED.amount,
-                                      currency = EXCLUDED.currency,
-                                      status = EXCLUDED.status,
-                                      payment_method_id = EXCLUDED.payment_method_id,
-                                      setup_future_usage = EXCLUDED.setup_future_usage,
-                                      client_secret = EXCLUDED.client_secret,
-                                      updated_at = NOW();
\ No newline at end of file
+WHERE id = ANY(@ids::varchar[]) AND tenant_id = @tenant_id;
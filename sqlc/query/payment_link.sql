@@ -1,2 +1,2 @@
 -- name: DeletePaymentLink :exec
-DELETE FROM payment_links WHERE id = $1;
\ No newline at end of file
+DELETE FROM payment_links WHERE id = $1 AND tenant_id = $2;
\ No newline at end of file
@@ -18,7 +18,7 @@ INSERT INTO payment_methods (
 -- name: GetPaymentMethod :one
 SELECT id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, created_at, updated_at
 FROM payment_methods
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL;
 
 -- name: UpdatePaymentMethod :exec
 UPDATE payment_methods
@@ -34,43 +34,25 @@ SET
     updated_at = NOW()
 WHERE
     id = $1
+  AND tenant_id = $11
   AND updated_at = $10;
 -- RETURNING id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, stripe_id, created_at, updated_at;
 
 -- name: DeletePaymentMethod :exec
-DELETE FROM payment_methods WHERE id = $1;
+UPDATE payment_methods
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL;
 
 -- name: ListPaymentMethods :many
 SELECT id, customer_id, type, card_last4, card_brand, card_exp_month, card_exp_year, bank_account_last4, bank_account_bank_name, is_default, created_at, updated_at
 FROM payment_methods
-WHERE customer_id = $1
-ORDER BY is_default DESC, created_at DESC
-LIMIT $2 OFFSET $3;
-
--- name: UpsertPaymentMethod :exec
-INSERT INTO payment_methods (
-    id,
-    customer_id,
-    type,
-    card_last4,
-    card_brand,
-    card_exp_month,
-    card_exp_year,
-    bank_account_last4,
-    bank_account_bank_name,
-    is_default
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-         )
-ON CONFLICT (id)
-    DO UPDATE SET
-                  customer_id = EXCLUDED.customer_id,
-                  type = EXCLUDED.type,
-                  card_last4 = EXCLUDED.card_last4,
-                  card_brand = EXCLUDED.card_brand,
-                  card_exp_month = EXCLUDED.card_exp_month,
-                  card_exp_year = EXCLUDED.card_exp_year,
-                  bank_account_last4 = EXCLUDED.bank_account_last4,
-                  bank_account_bank_name = EXCLUDED.bank_account_bank_name,
-                  is_default = EXCLUDED.is_default,
-                  updated_at = NOW();
\ No newline at end of file
+WHERE (sqlc.narg('customer_id')::varchar IS NULL OR customer_id = sqlc.narg('customer_id')::varchar)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::varchar))
+  AND deleted_at IS NULL
+  AND tenant_id = sqlc.arg('tenant_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -13,7 +13,7 @@ RETURNING id, created_at, updated_at;
 -- name: GetProduct :one
 SELECT id, name, description, active, metadata, created_at, updated_at
 FROM products
-WHERE id = $1 LIMIT 1;
+WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL LIMIT 1;
 
 -- name: UpdateProduct :one
 UPDATE products
@@ -22,20 +22,22 @@ SET name = $2,
     active = $4,
     metadata = $5,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $6
 RETURNING created_at, updated_at;
 
 -- name: DeleteProduct :exec
-DELETE FROM products WHERE id = $1;
+DELETE FROM products WHERE id = $1 AND tenant_id = $2;
 
 -- name: ListProducts :many
 SELECT id, name, description, active, metadata, created_at, updated_at
 FROM products
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE (sqlc.narg('active')::boolean IS NULL OR active = sqlc.narg('active')::boolean)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::varchar))
+  AND deleted_at IS NULL
+  AND tenant_id = sqlc.arg('tenant_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
--- name: GetProductsByCategory :many
-SELECT DISTINCT p.* FROM products p
-                             JOIN product_categories pc ON p.id = pc.product_id
-WHERE pc.category_id = $1
-LIMIT $2 OFFSET $3;
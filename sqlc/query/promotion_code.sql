@@ -1,2 +1,2 @@
 -- name: DeletePromotionCodes :exec
-DELETE FROM promotion_codes WHERE id = $1;
\ No newline at end of file
+DELETE FROM promotion_codes WHERE id = $1 AND tenant_id = $2;
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: DeleteQuote :exec
-DELETE FROM quotes WHERE id = $1;
\ No newline at end of file
+DELETE FROM quotes WHERE id = $1 AND tenant_id = $2;
\ No newline at end of file
@@ -3,10 +3,11 @@ INSERT INTO refunds (
     id,
     charge_id,
     amount,
+    currency,
     status,
     reason
 ) VALUES (
-             $1, $2, $3, $4, $5
+             $1, $2, $3, $4, $5, $6
          );
 
 -- name: GetRefund :one
@@ -14,12 +15,13 @@ SELECT
     id,
     charge_id,
     amount,
+    currency,
     status,
     reason,
     created_at,
     updated_at
 FROM refunds
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2;
 
 -- name: UpdateRefund :exec
 UPDATE refunds
@@ -27,51 +29,30 @@ SET
     status = $2,
     reason = $3,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $4;
 
 -- name: ListRefunds :many
 SELECT
     id,
     charge_id,
     amount,
+    currency,
     status,
     reason,
     created_at,
     updated_at
 FROM refunds
-WHERE charge_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
-
--- name: ListByChargeID :many
-SELECT
-    id,
-    charge_id,
-    amount,
-    status,
-    reason,
-    created_at,
-    updated_at
-FROM refunds
-WHERE charge_id = $1
-ORDER BY created_at DESC;
+WHERE (sqlc.narg('charge_id')::varchar IS NULL OR charge_id = sqlc.narg('charge_id')::varchar)
+  AND (sqlc.narg('status')::refund_status IS NULL OR status = sqlc.narg('status')::refund_status)
+  AND (sqlc.narg('currency')::currency IS NULL OR currency = sqlc.narg('currency')::currency)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::varchar))
+  AND tenant_id = sqlc.arg('tenant_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: DeleteRefund :exec
 DELETE FROM refunds
-WHERE id = $1;
-
-
--- name: UpsertRefund :exec
-INSERT INTO refunds (
-    id, charge_id, amount, status, reason
-) VALUES (
-             $1, $2, $3, $4, $5
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      charge_id = EXCLUDED.charge_id,
-                                      amount = EXCLUDED.amount,
-                                      status = EXCLUDED.status,
-                                      reason = EXCLUDED.reason,
-                                      updated_at = NOW();
-
-
+WHERE id = $1 AND tenant_id = $2;
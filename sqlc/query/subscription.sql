@@ -17,7 +17,7 @@ INSERT INTO subscriptions (
 -- name: GetSubscription :one
 SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
 FROM subscriptions
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2;
 
 -- name: UpdateSubscription :exec
 UPDATE subscriptions
@@ -30,7 +30,7 @@ SET price_id = $2,
     trial_start = $8,
     trial_end = $9,
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $10;
 -- RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
 
 -- name: CancelSubscription :exec
@@ -38,41 +38,33 @@ UPDATE subscriptions
 SET status = 'canceled',
     canceled_at = NOW(),
     updated_at = NOW()
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2;
 -- RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
 
 -- name: ListSubscriptions :many
 SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
 FROM subscriptions
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3;
+WHERE (sqlc.narg('customer_id')::varchar IS NULL OR customer_id = sqlc.narg('customer_id')::varchar)
+  AND (sqlc.narg('status')::subscription_status IS NULL OR status = sqlc.narg('status')::subscription_status)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::varchar))
+  AND tenant_id = sqlc.arg('tenant_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: DeleteSubscription :exec
 DELETE FROM subscriptions
-WHERE id = $1;
+WHERE id = $1 AND tenant_id = $2;
 
 -- name: GetExpiringSubscriptions :many
 SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
 FROM subscriptions
-WHERE current_period_end <= $1 AND status = $2
+WHERE current_period_end <= $1 AND status = $2 AND tenant_id = $3
 ORDER BY current_period_end;
 
--- name: UpsertSubscription :exec
-INSERT INTO subscriptions (
-    id, customer_id, price_id, status, current_period_start, current_period_end,
-    canceled_at, cancel_at_period_end, trial_start, trial_end
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      customer_id = EXCLUDED.customer_id,
-                                      price_id = EXCLUDED.price_id,
-                                      status = EXCLUDED.status,
-                                      current_period_start = EXCLUDED.current_period_start,
-                                      current_period_end = EXCLUDED.current_period_end,
-                                      canceled_at = EXCLUDED.canceled_at,
-                                      cancel_at_period_end = EXCLUDED.cancel_at_period_end,
-                                      trial_start = EXCLUDED.trial_start,
-                                      trial_end = EXCLUDED.trial_end,
-                                      updated_at = NOW();
\ No newline at end of file
+-- name: ListSubscriptionsByIDs :many
+SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
+FROM subscriptions
+WHERE id = ANY(@ids::varchar[]) AND tenant_id = @tenant_id;
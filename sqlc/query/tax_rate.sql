@@ -1,2 +1,2 @@
 -- name: DeleteTaxRate :exec
-DELETE FROM tax_rates WHERE id = $1;
\ No newline at end of file
+DELETE FROM tax_rates WHERE id = $1 AND tenant_id = $2;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetTransfer :one
+SELECT id, destination_account_id, amount, amount_reversed, currency, reversed, source_transaction_id, transfer_group, created_at, updated_at, last_event_at, tenant_id
+FROM transfers
+WHERE id = $1 AND tenant_id = $2;
@@ -10,10 +10,15 @@ import (
 )
 
 const deleteQuote = `-- name: DeleteQuote :exec
-DELETE FROM quotes WHERE id = $1
+DELETE FROM quotes WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteQuote(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteQuote, id)
+type DeleteQuoteParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteQuote(ctx context.Context, arg DeleteQuoteParams) error {
+	_, err := q.db.Exec(ctx, deleteQuote, arg.ID, arg.TenantID)
 	return err
 }
@@ -7,6 +7,8 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createRefund = `-- name: CreateRefund :exec
@@ -14,17 +16,19 @@ INSERT INTO refunds (
     id,
     charge_id,
     amount,
+    currency,
     status,
     reason
 ) VALUES (
-             $1, $2, $3, $4, $5
+             $1, $2, $3, $4, $5, $6
          )
 `
 
 type CreateRefundParams struct {
 	ID       string           `json:"id"`
 	ChargeID string           `json:"chargeId"`
-	Amount   float64          `json:"amount"`
+	Amount   int64            `json:"amount"`
+	Currency Currency         `json:"currency"`
 	Status   RefundStatus     `json:"status"`
 	Reason   NullRefundReason `json:"reason"`
 }
@@ -34,6 +38,7 @@ func (q *Queries) CreateRefund(ctx context.Context, arg CreateRefundParams) erro
 		arg.ID,
 		arg.ChargeID,
 		arg.Amount,
+		arg.Currency,
 		arg.Status,
 		arg.Reason,
 	)
@@ -42,11 +47,16 @@ func (q *Queries) CreateRefund(ctx context.Context, arg CreateRefundParams) erro
 
 const deleteRefund = `-- name: DeleteRefund :exec
 DELETE FROM refunds
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteRefund(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteRefund, id)
+type DeleteRefundParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteRefund(ctx context.Context, arg DeleteRefundParams) error {
+	_, err := q.db.Exec(ctx, deleteRefund, arg.ID, arg.TenantID)
 	return err
 }
 
@@ -55,21 +65,28 @@ SELECT
     id,
     charge_id,
     amount,
+    currency,
     status,
     reason,
     created_at,
     updated_at
 FROM refunds
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) GetRefund(ctx context.Context, id string) (*Refund, error) {
-	row := q.db.QueryRow(ctx, getRefund, id)
+type GetRefundParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetRefund(ctx context.Context, arg GetRefundParams) (*Refund, error) {
+	row := q.db.QueryRow(ctx, getRefund, arg.ID, arg.TenantID)
 	var i Refund
 	err := row.Scan(
 		&i.ID,
 		&i.ChargeID,
 		&i.Amount,
+		&i.Currency,
 		&i.Status,
 		&i.Reason,
 		&i.CreatedAt,
@@ -78,71 +95,53 @@ func (q *Queries) GetRefund(ctx context.Context, id string) (*Refund, error) {
 	return &i, err
 }
 
-const listByChargeID = `-- name: ListByChargeID :many
-SELECT
-    id,
-    charge_id,
-    amount,
-    status,
-    reason,
-    created_at,
-    updated_at
-FROM refunds
-WHERE charge_id = $1
-ORDER BY created_at DESC
-`
-
-func (q *Queries) ListByChargeID(ctx context.Context, chargeID string) ([]*Refund, error) {
-	rows, err := q.db.Query(ctx, listByChargeID, chargeID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []*Refund{}
-	for rows.Next() {
-		var i Refund
-		if err := rows.Scan(
-			&i.ID,
-			&i.ChargeID,
-			&i.Amount,
-			&i.Status,
-			&i.Reason,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, &i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const listRefunds = `-- name: ListRefunds :many
 SELECT
     id,
     charge_id,
     amount,
+    currency,
     status,
     reason,
     created_at,
     updated_at
 FROM refunds
-WHERE charge_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+WHERE ($1::varchar IS NULL OR charge_id = $1::varchar)
+  AND ($2::refund_status IS NULL OR status = $2::refund_status)
+  AND ($3::currency IS NULL OR currency = $3::currency)
+  AND ($4::timestamptz IS NULL OR created_at >= $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+  AND ($6::timestamptz IS NULL
+       OR (created_at, id) < ($6::timestamptz, $7::varchar))
+  AND tenant_id = $8
+ORDER BY created_at DESC, id DESC
+LIMIT $9
 `
 
 type ListRefundsParams struct {
-	ChargeID string `json:"chargeId"`
-	Limit    int64  `json:"limit"`
-	Offset   int64  `json:"offset"`
+	ChargeID        *string            `json:"chargeId"`
+	Status          NullRefundStatus   `json:"status"`
+	Currency        NullCurrency       `json:"currency"`
+	CreatedFrom     pgtype.Timestamptz `json:"createdFrom"`
+	CreatedTo       pgtype.Timestamptz `json:"createdTo"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursorCreatedAt"`
+	CursorID        *string            `json:"cursorId"`
+	TenantID        string             `json:"tenantId"`
+	Limit           int64              `json:"limit"`
 }
 
 func (q *Queries) ListRefunds(ctx context.Context, arg ListRefundsParams) ([]*Refund, error) {
-	rows, err := q.db.Query(ctx, listRefunds, arg.ChargeID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listRefunds,
+		arg.ChargeID,
+		arg.Status,
+		arg.Currency,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.TenantID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +153,7 @@ func (q *Queries) ListRefunds(ctx context.Context, arg ListRefundsParams) ([]*Re
 			&i.ID,
 			&i.ChargeID,
 			&i.Amount,
+			&i.Currency,
 			&i.Status,
 			&i.Reason,
 			&i.CreatedAt,
@@ -175,49 +175,17 @@ SET
     status = $2,
     reason = $3,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $4
 `
 
 type UpdateRefundParams struct {
-	ID     string           `json:"id"`
-	Status RefundStatus     `json:"status"`
-	Reason NullRefundReason `json:"reason"`
-}
-
-func (q *Queries) UpdateRefund(ctx context.Context, arg UpdateRefundParams) error {
-	_, err := q.db.Exec(ctx, updateRefund, arg.ID, arg.Status, arg.Reason)
-	return err
-}
-
-const upsertRefund = `-- name: UpsertRefund :exec
-INSERT INTO refunds (
-    id, charge_id, amount, status, reason
-) VALUES (
-             $1, $2, $3, $4, $5
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      charge_id = EXCLUDED.charge_id,
-                                      amount = EXCLUDED.amount,
-                                      status = EXCLUDED.status,
-                                      reason = EXCLUDED.reason,
-                                      updated_at = NOW()
-`
-
-type UpsertRefundParams struct {
 	ID       string           `json:"id"`
-	ChargeID string           `json:"chargeId"`
-	Amount   float64          `json:"amount"`
 	Status   RefundStatus     `json:"status"`
 	Reason   NullRefundReason `json:"reason"`
+	TenantID string           `json:"tenantId"`
 }
 
-func (q *Queries) UpsertRefund(ctx context.Context, arg UpsertRefundParams) error {
-	_, err := q.db.Exec(ctx, upsertRefund,
-		arg.ID,
-		arg.ChargeID,
-		arg.Amount,
-		arg.Status,
-		arg.Reason,
-	)
+func (q *Queries) UpdateRefund(ctx context.Context, arg UpdateRefundParams) error {
+	_, err := q.db.Exec(ctx, updateRefund, arg.ID, arg.Status, arg.Reason, arg.TenantID)
 	return err
 }
@@ -10,10 +10,15 @@ import (
 )
 
 const deleteReviews = `-- name: DeleteReviews :exec
-DELETE FROM reviews WHERE id = $1
+DELETE FROM reviews WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteReviews(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteReviews, id)
+type DeleteReviewsParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteReviews(ctx context.Context, arg DeleteReviewsParams) error {
+	_, err := q.db.Exec(ctx, deleteReviews, arg.ID, arg.TenantID)
 	return err
 }
@@ -17,12 +17,17 @@ UPDATE subscriptions
 SET status = 'canceled',
     canceled_at = NOW(),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
 // RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
-func (q *Queries) CancelSubscription(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, cancelSubscription, id)
+type CancelSubscriptionParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) CancelSubscription(ctx context.Context, arg CancelSubscriptionParams) error {
+	_, err := q.db.Exec(ctx, cancelSubscription, arg.ID, arg.TenantID)
 	return err
 }
 
@@ -71,28 +76,34 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 
 const deleteSubscription = `-- name: DeleteSubscription :exec
 DELETE FROM subscriptions
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteSubscription(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteSubscription, id)
+type DeleteSubscriptionParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteSubscription(ctx context.Context, arg DeleteSubscriptionParams) error {
+	_, err := q.db.Exec(ctx, deleteSubscription, arg.ID, arg.TenantID)
 	return err
 }
 
 const getExpiringSubscriptions = `-- name: GetExpiringSubscriptions :many
 SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
 FROM subscriptions
-WHERE current_period_end <= $1 AND status = $2
+WHERE current_period_end <= $1 AND status = $2 AND tenant_id = $3
 ORDER BY current_period_end
 `
 
 type GetExpiringSubscriptionsParams struct {
 	CurrentPeriodEnd pgtype.Timestamptz `json:"currentPeriodEnd"`
 	Status           SubscriptionStatus `json:"status"`
+	TenantID         string             `json:"tenantId"`
 }
 
 func (q *Queries) GetExpiringSubscriptions(ctx context.Context, arg GetExpiringSubscriptionsParams) ([]*Subscription, error) {
-	rows, err := q.db.Query(ctx, getExpiringSubscriptions, arg.CurrentPeriodEnd, arg.Status)
+	rows, err := q.db.Query(ctx, getExpiringSubscriptions, arg.CurrentPeriodEnd, arg.Status, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -128,12 +139,17 @@ const getSubscription = `-- name: GetSubscription :one
 
 SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
 FROM subscriptions
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $2
 `
 
 // RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
-func (q *Queries) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
-	row := q.db.QueryRow(ctx, getSubscription, id)
+type GetSubscriptionParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) GetSubscription(ctx context.Context, arg GetSubscriptionParams) (*Subscription, error) {
+	row := q.db.QueryRow(ctx, getSubscription, arg.ID, arg.TenantID)
 	var i Subscription
 	err := row.Scan(
 		&i.ID,
@@ -156,20 +172,84 @@ const listSubscriptions = `-- name: ListSubscriptions :many
 
 SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
 FROM subscriptions
-WHERE customer_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+WHERE ($1::varchar IS NULL OR customer_id = $1::varchar)
+  AND ($2::subscription_status IS NULL OR status = $2::subscription_status)
+  AND ($3::timestamptz IS NULL OR created_at >= $3::timestamptz)
+  AND ($4::timestamptz IS NULL OR created_at < $4::timestamptz)
+  AND ($5::timestamptz IS NULL
+       OR (created_at, id) < ($5::timestamptz, $6::varchar))
+  AND tenant_id = $7
+ORDER BY created_at DESC, id DESC
+LIMIT $8
 `
 
 type ListSubscriptionsParams struct {
-	CustomerID string `json:"customerId"`
-	Limit      int64  `json:"limit"`
-	Offset     int64  `json:"offset"`
+	CustomerID      *string                `json:"customerId"`
+	Status          NullSubscriptionStatus `json:"status"`
+	CreatedFrom     pgtype.Timestamptz     `json:"createdFrom"`
+	CreatedTo       pgtype.Timestamptz     `json:"createdTo"`
+	CursorCreatedAt pgtype.Timestamptz     `json:"cursorCreatedAt"`
+	CursorID        *string                `json:"cursorId"`
+	TenantID        string                 `json:"tenantId"`
+	Limit           int64                  `json:"limit"`
 }
 
 // RETURNING id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, stripe_id, created_at, updated_at;
 func (q *Queries) ListSubscriptions(ctx context.Context, arg ListSubscriptionsParams) ([]*Subscription, error) {
-	rows, err := q.db.Query(ctx, listSubscriptions, arg.CustomerID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listSubscriptions,
+		arg.CustomerID,
+		arg.Status,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.TenantID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*Subscription{}
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.CustomerID,
+			&i.PriceID,
+			&i.Status,
+			&i.CurrentPeriodStart,
+			&i.CurrentPeriodEnd,
+			&i.CanceledAt,
+			&i.CancelAtPeriodEnd,
+			&i.TrialStart,
+			&i.TrialEnd,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsByIDs = `-- name: ListSubscriptionsByIDs :many
+SELECT id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at
+FROM subscriptions
+WHERE id = ANY($1::varchar[]) AND tenant_id = $2
+`
+
+type ListSubscriptionsByIDsParams struct {
+	Ids      []string `json:"ids"`
+	TenantID string   `json:"tenantId"`
+}
+
+func (q *Queries) ListSubscriptionsByIDs(ctx context.Context, arg ListSubscriptionsByIDsParams) ([]*Subscription, error) {
+	rows, err := q.db.Query(ctx, listSubscriptionsByIDs, arg.Ids, arg.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -212,7 +292,7 @@ SET price_id = $2,
     trial_start = $8,
     trial_end = $9,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND tenant_id = $10
 `
 
 type UpdateSubscriptionParams struct {
@@ -225,6 +305,7 @@ type UpdateSubscriptionParams struct {
 	CancelAtPeriodEnd  bool               `json:"cancelAtPeriodEnd"`
 	TrialStart         pgtype.Timestamptz `json:"trialStart"`
 	TrialEnd           pgtype.Timestamptz `json:"trialEnd"`
+	TenantID           string             `json:"tenantId"`
 }
 
 func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) error {
@@ -238,55 +319,7 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		arg.CancelAtPeriodEnd,
 		arg.TrialStart,
 		arg.TrialEnd,
-	)
-	return err
-}
-
-const upsertSubscription = `-- name: UpsertSubscription :exec
-INSERT INTO subscriptions (
-    id, customer_id, price_id, status, current_period_start, current_period_end,
-    canceled_at, cancel_at_period_end, trial_start, trial_end
-) VALUES (
-             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-         )
-ON CONFLICT (id) DO UPDATE SET
-                                      customer_id = EXCLUDED.customer_id,
-                                      price_id = EXCLUDED.price_id,
-                                      status = EXCLUDED.status,
-                                      current_period_start = EXCLUDED.current_period_start,
-                                      current_period_end = EXCLUDED.current_period_end,
-                                      canceled_at = EXCLUDED.canceled_at,
-                                      cancel_at_period_end = EXCLUDED.cancel_at_period_end,
-                                      trial_start = EXCLUDED.trial_start,
-                                      trial_end = EXCLUDED.trial_end,
-                                      updated_at = NOW()
-`
-
-type UpsertSubscriptionParams struct {
-	ID                 string             `json:"id"`
-	CustomerID         string             `json:"customerId"`
-	PriceID            string             `json:"priceId"`
-	Status             SubscriptionStatus `json:"status"`
-	CurrentPeriodStart pgtype.Timestamptz `json:"currentPeriodStart"`
-	CurrentPeriodEnd   pgtype.Timestamptz `json:"currentPeriodEnd"`
-	CanceledAt         pgtype.Timestamptz `json:"canceledAt"`
-	CancelAtPeriodEnd  bool               `json:"cancelAtPeriodEnd"`
-	TrialStart         pgtype.Timestamptz `json:"trialStart"`
-	TrialEnd           pgtype.Timestamptz `json:"trialEnd"`
-}
-
-func (q *Queries) UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) error {
-	_, err := q.db.Exec(ctx, upsertSubscription,
-		arg.ID,
-		arg.CustomerID,
-		arg.PriceID,
-		arg.Status,
-		arg.CurrentPeriodStart,
-		arg.CurrentPeriodEnd,
-		arg.CanceledAt,
-		arg.CancelAtPeriodEnd,
-		arg.TrialStart,
-		arg.TrialEnd,
+		arg.TenantID,
 	)
 	return err
 }
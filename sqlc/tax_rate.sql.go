@@ -10,10 +10,15 @@ import (
 )
 
 const deleteTaxRate = `-- name: DeleteTaxRate :exec
-DELETE FROM tax_rates WHERE id = $1
+DELETE FROM tax_rates WHERE id = $1 AND tenant_id = $2
 `
 
-func (q *Queries) DeleteTaxRate(ctx context.Context, id string) error {
-	_, err := q.db.Exec(ctx, deleteTaxRate, id)
+type DeleteTaxRateParams struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+}
+
+func (q *Queries) DeleteTaxRate(ctx context.Context, arg DeleteTaxRateParams) error {
+	_, err := q.db.Exec(ctx, deleteTaxRate, arg.ID, arg.TenantID)
 	return err
 }
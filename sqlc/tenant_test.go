@@ -0,0 +1,65 @@
+package sqlc
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// tenantExempt 為不以 tenant_id 為條件的查詢：API key 在認證前還不知道租戶，其餘為跨租戶的系統工作或以主鍵寫入的 outbox 與事件
+var tenantExempt = map[string]bool{
+	"GetAPIKeyByPrefix":          true,
+	"RevokeAPIKey":               true,
+	"TouchAPIKey":                true,
+	"CreateEvent":                true,
+	"ClaimEventsForRetry":        true,
+	"CreateOutboxMessage":        true,
+	"ClaimPendingOutboxMessages": true,
+	"MarkOutboxMessagePublished": true,
+	"RecordOutboxMessageFailure": true,
+}
+
+var (
+	queryName       = regexp.MustCompile(`(?m)^-- name: (\w+) :\w+`)
+	tenantPredicate = regexp.MustCompile(`tenant_id\s*=\s*(\$\d+|sqlc\.arg\('tenant_id'\)|@tenant_id)`)
+)
+
+// TestQueriesScopedByTenant 確認讀取、更新、刪除與 upsert 都以 tenant_id 為條件，不依賴 row level security 隔離租戶
+// 單純的 INSERT 由資料表預設值寫入 app.tenant_id，不需要條件
+func TestQueriesScopedByTenant(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("query", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no query files: %v", err)
+	}
+
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+
+		src := string(raw)
+		locs := queryName.FindAllStringSubmatchIndex(src, -1)
+		for i, loc := range locs {
+			name := src[loc[2]:loc[3]]
+			end := len(src)
+			if i+1 < len(locs) {
+				end = locs[i+1][0]
+			}
+			body := strings.TrimSpace(src[loc[1]:end])
+
+			upper := strings.ToUpper(body)
+			if strings.HasPrefix(upper, "INSERT") && !strings.Contains(upper, "ON CONFLICT") {
+				continue
+			}
+			if tenantExempt[name] {
+				continue
+			}
+			if !tenantPredicate.MatchString(body) {
+				t.Errorf("%s: %s is not scoped by tenant_id", filepath.Base(file), name)
+			}
+		}
+	}
+}
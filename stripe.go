@@ -3,25 +3,33 @@ package payment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/stripe/stripe-go/v79"
-	"github.com/stripe/stripe-go/v79/client"
-	"github.com/stripe/stripe-go/v79/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"goflare.io/payment/application_fee"
+	"goflare.io/payment/backfill"
 	"goflare.io/payment/charge"
 	"goflare.io/payment/checkout_session"
 	"goflare.io/payment/config"
+	"goflare.io/payment/connected_account"
 	"goflare.io/payment/coupon"
 	"goflare.io/payment/customer"
 	"goflare.io/payment/discount"
 	"goflare.io/payment/disputes"
+	"goflare.io/payment/driver"
 	"goflare.io/payment/event"
 	"goflare.io/payment/invoice"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
+	"goflare.io/payment/outbox"
 	"goflare.io/payment/payment_intent"
 	"goflare.io/payment/payment_link"
 	"goflare.io/payment/payment_method"
@@ -33,37 +41,92 @@ import (
 	"goflare.io/payment/review"
 	"goflare.io/payment/subscription"
 	"goflare.io/payment/tax_rate"
+	"goflare.io/payment/transfer"
 )
 
 type StripePayment struct {
-	client       *client.API
-	natsConn     *nats.Conn
-	eventManager *EventManager
-	workerPool   *WorkerPool
-	logger       *zap.Logger
-
-	charge          charge.Service
-	checkoutSession checkout_session.Service
-	coupon          coupon.Service
-	customer        customer.Service
-	discount        discount.Service
-	dispute         disputes.Service
-	event           event.Service
-	invoice         invoice.Service
-	paymentIntent   payment_intent.Service
-	paymentLink     payment_link.Service
-	paymentMethod   payment_method.Service
-	price           price.Service
-	product         product.Service
-	promotionCode   promotion_code.Service
-	quote           quote.Service
-	refund          refund.Service
-	review          review.Service
-	subscription    subscription.Service
-	taxRate         tax_rate.Service
+	tenants        map[string]*tenant
+	natsConn       *nats.Conn
+	eventManager   *EventManager
+	workerPool     *WorkerPool
+	retryPolicy    event.RetryPolicy
+	retryScheduler *RetryScheduler
+	outboxRelay    *OutboxRelay
+	reconciler     *ReconcileScheduler
+	logger         *zap.Logger
+
+	// done 在 Close 時關閉，停止降級模式下的背景重試
+	done chan struct{}
+
+	transactionManager *driver.TransactionManager
+	outbox             outbox.Service
+	checkpoints        backfill.Service
+	backfillConfig     config.BackfillConfig
+
+	applicationFee   application_fee.Service
+	charge           charge.Service
+	checkoutSession  checkout_session.Service
+	connectedAccount connected_account.Service
+	coupon           coupon.Service
+	customer         customer.Service
+	discount         discount.Service
+	dispute          disputes.Service
+	event            event.Service
+	invoice          invoice.Service
+	paymentIntent    payment_intent.Service
+	paymentLink      payment_link.Service
+	paymentMethod    payment_method.Service
+	price            price.Service
+	product          product.Service
+	promotionCode    promotion_code.Service
+	quote            quote.Service
+	refund           refund.Service
+	review           review.Service
+	subscription     subscription.Service
+	taxRate          tax_rate.Service
+	transfer         transfer.Service
 }
 
-func NewStripePayment(config *config.Config,
+// defaultStripeTimeout 與 stripe-go 預設 HTTP client 的逾時相同
+const defaultStripeTimeout = 80 * time.Second
+
+// newStripeBackends 依設定的逾時建立 Stripe 後端，設定 APIBase 時讓所有 Stripe 請求改送到該位址
+func newStripeBackends(cfg config.StripeConfig) *stripe.Backends {
+	return stripe.NewBackendsWithConfig(newStripeBackendConfig(cfg, http.DefaultTransport))
+}
+
+func newStripeBackendConfig(cfg config.StripeConfig, transport http.RoundTripper) *stripe.BackendConfig {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultStripeTimeout
+	}
+
+	backendConfig := &stripe.BackendConfig{
+		HTTPClient: &http.Client{
+			Timeout:   timeout,
+			Transport: stripeTransport(&meteredTransport{base: transport}),
+		},
+	}
+	if cfg.APIBase != "" {
+		backendConfig.URL = stripe.String(cfg.APIBase)
+	}
+	return backendConfig
+}
+
+// stripeParams 將 ctx 與呼叫端提供的冪等鍵帶入 Stripe 請求，ctx 取消或逾時時 stripe-go 會中止 HTTP 呼叫與重試
+// 冪等鍵為空字串時不指定
+func stripeParams[P stripe.ParamsContainer](ctx context.Context, params P, idempotencyKey string) P {
+	p := params.GetParams()
+	p.Context = ctx
+	if idempotencyKey != "" {
+		p.SetIdempotencyKey(idempotencyKey)
+	}
+	return params
+}
+
+// NewEventProcessor 建立不連線 NATS 的 StripePayment，事件由呼叫端透過 ProcessEvent 同步處理
+// 供 paymentctl 等離線工具重新處理事件，避免與 API 服務的 consumer 搶奪訊息
+func NewEventProcessor(config *config.Config,
 	cs customer.Service,
 	charge charge.Service,
 	coupon coupon.Service,
@@ -83,70 +146,192 @@ func NewStripePayment(config *config.Config,
 	review review.Service,
 	taxRate tax_rate.Service,
 	quote quote.Service,
-	logger *zap.Logger) Payment {
+	connectedAccount connected_account.Service,
+	transfer transfer.Service,
+	applicationFee application_fee.Service,
+	ob outbox.Service,
+	bf backfill.Service,
+	tm *driver.TransactionManager,
+	logger *zap.Logger) *StripePayment {
 	sp := &StripePayment{
-		client:          client.New(config.Stripe.SecretKey, nil),
-		charge:          charge,
-		coupon:          coupon,
-		checkoutSession: checkoutSession,
-		customer:        cs,
-		discount:        discount,
-		dispute:         dispute,
-		event:           event,
-		product:         ps,
-		price:           prs,
-		subscription:    ss,
-		invoice:         is,
-		paymentMethod:   pms,
-		paymentLink:     paymentLink,
-		promotionCode:   pcs,
-		paymentIntent:   pis,
-		quote:           quote,
-		review:          review,
-		taxRate:         taxRate,
-		refund:          rs,
-		logger:          logger,
-	}
-	nc, err := nats.Connect(nats.DefaultURL)
+		tenants:          newTenants(config, logger),
+		charge:           charge,
+		coupon:           coupon,
+		checkoutSession:  checkoutSession,
+		customer:         cs,
+		discount:         discount,
+		dispute:          dispute,
+		event:            event,
+		product:          ps,
+		price:            prs,
+		subscription:     ss,
+		invoice:          is,
+		paymentMethod:    pms,
+		paymentLink:      paymentLink,
+		promotionCode:    pcs,
+		paymentIntent:    pis,
+		quote:            quote,
+		connectedAccount: connectedAccount,
+		transfer:         transfer,
+		applicationFee:   applicationFee,
+		review:           review,
+		taxRate:          taxRate,
+		refund:           rs,
+		outbox:           ob,
+		retryPolicy:      newRetryPolicy(config.Retry),
+		logger:           logger,
+
+		transactionManager: tm,
+		checkpoints:        bf,
+		backfillConfig:     config.Backfill,
+	}
+
+	// 不發佈也不訂閱事件，只需要 handler 對照表
+	sp.eventManager = &EventManager{
+		handlers: make(map[stripe.EventType]EventHandler),
+		logger:   logger,
+	}
+	sp.registerEventHandlers()
+
+	return sp
+}
+
+func NewStripePayment(config *config.Config,
+	cs customer.Service,
+	charge charge.Service,
+	coupon coupon.Service,
+	checkoutSession checkout_session.Service,
+	discount discount.Service,
+	dispute disputes.Service,
+	event event.Service,
+	ps product.Service,
+	prs price.Service,
+	ss subscription.Service,
+	is invoice.Service,
+	pms payment_method.Service,
+	paymentLink payment_link.Service,
+	pis payment_intent.Service,
+	pcs promotion_code.Service,
+	rs refund.Service,
+	review review.Service,
+	taxRate tax_rate.Service,
+	quote quote.Service,
+	connectedAccount connected_account.Service,
+	transfer transfer.Service,
+	applicationFee application_fee.Service,
+	ob outbox.Service,
+	bf backfill.Service,
+	tm *driver.TransactionManager,
+	logger *zap.Logger) (Payment, error) {
+	sp := NewEventProcessor(
+		config,
+		cs,
+		charge,
+		coupon,
+		checkoutSession,
+		discount,
+		dispute,
+		event,
+		ps,
+		prs,
+		ss,
+		is,
+		pms,
+		paymentLink,
+		pis,
+		pcs,
+		rs,
+		review,
+		taxRate,
+		quote,
+		connectedAccount,
+		transfer,
+		applicationFee,
+		ob,
+		bf,
+		tm,
+		logger,
+	)
+	for _, tc := range config.TenantConfigs() {
+		if len(tc.Webhook.Secrets) == 0 {
+			logger.Warn("no webhook secrets configured, all webhooks for the tenant will be rejected", zap.String("tenant", tc.ID))
+		}
+	}
+
+	// 執行期間斷線時持續重新連線，斷線期間由 readiness 回報；降級模式下啟動時無法連線也會在背景持續連線
+	natsOptions := []nats.Option{nats.MaxReconnects(-1)}
+	if config.Health.Degraded {
+		natsOptions = append(natsOptions, nats.RetryOnFailedConnect(true))
+	}
+	nc, err := nats.Connect(config.NATS.URL, natsOptions...)
 	if err != nil {
-		logger.Error("error connecting to nats", zap.Error(err))
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
 	}
 
 	sp.natsConn = nc
-	sp.eventManager = NewEventManager(nc, logger)
-	sp.workerPool = NewWorkerPool(10000, sp, logger)
+	sp.done = make(chan struct{})
+	sp.eventManager, err = NewEventManager(nc, config.NATS.JetStream, logger)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	sp.workerPool = NewWorkerPool(config.Workers, sp, logger)
 
 	// 註冊事件處理器
 	sp.registerEventHandlers()
 	if err = sp.eventManager.SubscribeToEvents(sp.workerPool); err != nil {
-		logger.Error("")
-		return nil
+		if !config.Health.Degraded {
+			sp.Close()
+			return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+		}
+		sp.retryInBackground("event subscription", err, func() error {
+			return sp.eventManager.SubscribeToEvents(sp.workerPool)
+		})
 	}
 
-	return sp
+	if !config.NATS.JetStream.Enabled {
+		sp.retryScheduler = NewRetryScheduler(event, sp.workerPool, config.Retry, logger)
+		sp.retryScheduler.Start()
+	}
+
+	sp.outboxRelay = NewOutboxRelay(ob, nc, sp.eventManager.js, config.Outbox, logger)
+	if err = sp.outboxRelay.Start(); err != nil {
+		if !config.Health.Degraded {
+			sp.Close()
+			return nil, fmt.Errorf("failed to start outbox relay: %w", err)
+		}
+		sp.retryInBackground("outbox relay", err, sp.outboxRelay.Start)
+	}
+
+	if config.Reconcile.Interval > 0 {
+		sp.reconciler = NewReconcileScheduler(sp, config.Reconcile, logger)
+		sp.reconciler.Start()
+	}
+
+	return sp, nil
 }
 
 // CreateCustomer creates a new customer in Stripe and in the local database
-func (sp *StripePayment) CreateCustomer(ctx context.Context, email, name string) error {
+func (sp *StripePayment) CreateCustomer(ctx context.Context, idempotencyKey, email, name string) (*models.Customer, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	params := &stripe.CustomerParams{
 		Email: stripe.String(email),
 		Name:  stripe.String(name),
 	}
-	stripeCustomer, err := sp.client.Customers.New(params)
+	stripeCustomer, err := sc.Customers.New(stripeParams(ctx, params, idempotencyKey))
 	if err != nil {
-		return fmt.Errorf("failed to create Stripe customer: %w", err)
+		return nil, fmt.Errorf("failed to create Stripe customer: %w", err)
 	}
 
-	customerModel := &models.Customer{
-		ID:    stripeCustomer.ID,
-		Name:  name,
-		Email: email,
-	}
-	if err = sp.customer.Create(ctx, customerModel); err != nil {
-		return fmt.Errorf("failed to create local customer record: %w", err)
+	if err = sp.customer.Upsert(ctx, partialCustomerFromStripe(stripeCustomer, objectCreatedAt(stripeCustomer.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local customer record: %w", err)
 	}
 
-	return nil
+	return sp.customer.GetByID(ctx, stripeCustomer.ID)
 }
 
 // GetCustomer retrieves a customer from the local database
@@ -155,13 +340,18 @@ func (sp *StripePayment) GetCustomer(ctx context.Context, customerID string) (*m
 }
 
 // UpdateCustomerBalance updates a customer in Stripe and in the local database
-func (sp *StripePayment) UpdateCustomerBalance(updateCustomer *models.Customer) error {
+func (sp *StripePayment) UpdateCustomerBalance(ctx context.Context, idempotencyKey string, updateCustomer *models.Customer) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	params := &stripe.CustomerParams{
 		Balance: &updateCustomer.Balance,
 	}
 
-	if _, err := sp.client.Customers.Update(updateCustomer.ID, params); err != nil {
+	if _, err := sc.Customers.Update(updateCustomer.ID, stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to update Stripe customer: %w", err)
 	}
 
@@ -169,26 +359,41 @@ func (sp *StripePayment) UpdateCustomerBalance(updateCustomer *models.Customer)
 }
 
 // DeleteCustomer deletes a customer from Stripe and from the local database
-func (sp *StripePayment) DeleteCustomer(customerID string) error {
-	if _, err := sp.client.Customers.Del(customerID, nil); err != nil {
+func (sp *StripePayment) DeleteCustomer(ctx context.Context, idempotencyKey, customerID string) error {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sc.Customers.Del(customerID, stripeParams(ctx, &stripe.CustomerParams{}, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to delete Stripe customer: %w", err)
 	}
 	return nil
 }
 
 // CreateProduct creates a new product in Stripe and in the local database
-func (sp *StripePayment) CreateProduct(req models.Product) error {
+func (sp *StripePayment) CreateProduct(ctx context.Context, idempotencyKey string, req models.Product) (*models.Product, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	productParams := &stripe.ProductParams{
 		Name:        stripe.String(req.Name),
 		Description: stripe.String(req.Description),
 		Active:      stripe.Bool(req.Active),
 		Metadata:    req.Metadata,
 	}
-	if _, err := sp.client.Products.New(productParams); err != nil {
-		return fmt.Errorf("failed to create Stripe product: %w", err)
+	stripeProduct, err := sc.Products.New(stripeParams(ctx, productParams, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe product: %w", err)
 	}
 
-	return nil
+	if err = sp.product.Upsert(ctx, partialProductFromStripe(stripeProduct, objectCreatedAt(stripeProduct.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local product record: %w", err)
+	}
+
+	return sp.product.GetByID(ctx, stripeProduct.ID)
 }
 
 func (sp *StripePayment) GetProductWithActivePrices(ctx context.Context, productID string) (*models.Product, error) {
@@ -223,7 +428,12 @@ func (sp *StripePayment) GetProductWithAllPrices(ctx context.Context, productID
 }
 
 // UpdateProduct updates a product in Stripe and in the local database
-func (sp *StripePayment) UpdateProduct(product *models.Product) error {
+func (sp *StripePayment) UpdateProduct(ctx context.Context, idempotencyKey string, product *models.Product) error {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
+
 	params := &stripe.ProductParams{
 		Name:        stripe.String(product.Name),
 		Description: stripe.String(product.Description),
@@ -231,7 +441,7 @@ func (sp *StripePayment) UpdateProduct(product *models.Product) error {
 		Metadata:    product.Metadata,
 	}
 
-	if _, err := sp.client.Products.Update(product.ID, params); err != nil {
+	if _, err := sc.Products.Update(product.ID, stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to update Stripe product: %w", err)
 	}
 
@@ -239,27 +449,37 @@ func (sp *StripePayment) UpdateProduct(product *models.Product) error {
 }
 
 // DeleteProduct deletes a product from Stripe and from the local database
-func (sp *StripePayment) DeleteProduct(productID string) error {
+func (sp *StripePayment) DeleteProduct(ctx context.Context, idempotencyKey, productID string) error {
 
-	if _, err := sp.client.Products.Del(productID, nil); err != nil {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sc.Products.Del(productID, stripeParams(ctx, &stripe.ProductParams{}, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to delete Stripe product: %w", err)
 	}
 
 	return nil
 }
 
-// ListProducts lists all products from the local database
-func (sp *StripePayment) ListProducts(ctx context.Context) ([]*models.Product, error) {
-	return sp.product.List(ctx, 1000, 0) // Assuming a large limit, you might want to implement pagination
+// ListProducts lists a page of products from the local database
+func (sp *StripePayment) ListProducts(ctx context.Context, params models.ListParams) (*models.Page[*models.Product], error) {
+	return sp.product.List(ctx, params)
 }
 
 // CreatePrice creates a new price in Stripe and in the local database
-func (sp *StripePayment) CreatePrice(price models.Price) error {
+func (sp *StripePayment) CreatePrice(ctx context.Context, idempotencyKey string, price models.Price) (*models.Price, error) {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	params := &stripe.PriceParams{
 		Product:    stripe.String(price.ProductID),
 		Currency:   stripe.String(string(price.Currency)),
-		UnitAmount: stripe.Int64(int64(price.UnitAmount * 100)),
+		UnitAmount: stripe.Int64(price.UnitAmount.Amount),
 	}
 
 	if price.Type == stripe.PriceTypeRecurring {
@@ -270,20 +490,29 @@ func (sp *StripePayment) CreatePrice(price models.Price) error {
 		}
 	}
 
-	_, err := sp.client.Prices.New(params)
+	stripePrice, err := sc.Prices.New(stripeParams(ctx, params, idempotencyKey))
 	if err != nil {
-		return fmt.Errorf("failed to create Stripe price: %w", err)
+		return nil, fmt.Errorf("failed to create Stripe price: %w", err)
 	}
 
-	return nil
+	if err = sp.price.Upsert(ctx, partialPriceFromStripe(stripePrice, objectCreatedAt(stripePrice.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local price record: %w", err)
+	}
+
+	return sp.price.GetByID(ctx, stripePrice.ID)
 }
 
 // DeletePrice deletes a price from Stripe and from the local database
-func (sp *StripePayment) DeletePrice(priceID string) error {
+func (sp *StripePayment) DeletePrice(ctx context.Context, idempotencyKey, priceID string) error {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
+
 	// In Stripe, you can't delete prices, you can only deactivate them
-	if _, err := sp.client.Prices.Update(priceID, &stripe.PriceParams{
+	if _, err := sc.Prices.Update(priceID, stripeParams(ctx, &stripe.PriceParams{
 		Active: stripe.Bool(false),
-	}); err != nil {
+	}, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to deactivate Stripe price: %w", err)
 	}
 
@@ -291,7 +520,12 @@ func (sp *StripePayment) DeletePrice(priceID string) error {
 }
 
 // CreateSubscription creates a new subscription in Stripe and in the local database
-func (sp *StripePayment) CreateSubscription(customerID, priceID string) error {
+func (sp *StripePayment) CreateSubscription(ctx context.Context, idempotencyKey, customerID, priceID string) (*models.Subscription, error) {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	params := &stripe.SubscriptionParams{
 		Customer: stripe.String(customerID),
@@ -302,11 +536,16 @@ func (sp *StripePayment) CreateSubscription(customerID, priceID string) error {
 		},
 	}
 
-	if _, err := sp.client.Subscriptions.New(params); err != nil {
-		return fmt.Errorf("failed to create Stripe subscription: %w", err)
+	stripeSubscription, err := sc.Subscriptions.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe subscription: %w", err)
+	}
+
+	if _, err = sp.subscription.Upsert(ctx, partialSubscriptionFromStripe(stripeSubscription, objectCreatedAt(stripeSubscription.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local subscription record: %w", err)
 	}
 
-	return nil
+	return sp.subscription.GetByID(ctx, stripeSubscription.ID)
 }
 
 // GetSubscription retrieves a subscription from the local database
@@ -315,45 +554,59 @@ func (sp *StripePayment) GetSubscription(ctx context.Context, subscriptionID str
 }
 
 // UpdateSubscription updates a subscription in Stripe and in the local database
-func (sp *StripePayment) UpdateSubscription(subscription *models.Subscription) error {
+func (sp *StripePayment) UpdateSubscription(ctx context.Context, idempotencyKey string, subscription *models.Subscription) error {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
+
 	params := &stripe.SubscriptionParams{
 		CancelAtPeriodEnd: stripe.Bool(subscription.CancelAtPeriodEnd),
 	}
-	if _, err := sp.client.Subscriptions.Update(subscription.ID, params); err != nil {
+	if _, err := sc.Subscriptions.Update(subscription.ID, stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to update Stripe subscription: %w", err)
 	}
 	return nil
 }
 
 // CancelSubscription cancels a subscription in Stripe and updates the local database
-func (sp *StripePayment) CancelSubscription(subscriptionID string, cancelAtPeriodEnd bool) error {
+func (sp *StripePayment) CancelSubscription(ctx context.Context, idempotencyKey, subscriptionID string, cancelAtPeriodEnd bool) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	params := &stripe.SubscriptionParams{
 		CancelAtPeriodEnd: stripe.Bool(cancelAtPeriodEnd),
 	}
 
-	if _, err := sp.client.Subscriptions.Update(subscriptionID, params); err != nil {
+	if _, err := sc.Subscriptions.Update(subscriptionID, stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to cancel Stripe subscription: %w", err)
 	}
 
 	return nil
 }
 
-// ListSubscriptions lists all subscriptions for a customer from the local database
-func (sp *StripePayment) ListSubscriptions(ctx context.Context, customerID string) ([]*models.Subscription, error) {
-	return sp.subscription.List(ctx, customerID, 1000, 0)
-	// Assuming a large limit, you might want to implement pagination
+// ListSubscriptions lists a page of subscriptions from the local database
+func (sp *StripePayment) ListSubscriptions(ctx context.Context, params models.ListParams) (*models.Page[*models.Subscription], error) {
+	return sp.subscription.List(ctx, params)
 }
 
 // CreateInvoice creates a new invoice in Stripe and in the local database
-func (sp *StripePayment) CreateInvoice(customerID, subscriptionID string) error {
+func (sp *StripePayment) CreateInvoice(ctx context.Context, idempotencyKey, customerID, subscriptionID string) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	params := &stripe.InvoiceParams{
 		Customer:     stripe.String(customerID),
 		Subscription: stripe.String(subscriptionID),
 	}
 
-	if _, err := sp.client.Invoices.New(params); err != nil {
+	if _, err := sc.Invoices.New(stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to create Stripe invoice: %w", err)
 	}
 
@@ -366,19 +619,23 @@ func (sp *StripePayment) GetInvoice(ctx context.Context, invoiceID string) (*mod
 }
 
 // PayInvoice pays an invoice in Stripe and updates the local database
-func (sp *StripePayment) PayInvoice(invoiceID string) error {
+func (sp *StripePayment) PayInvoice(ctx context.Context, idempotencyKey, invoiceID string) error {
 
-	if _, err := sp.client.Invoices.Pay(invoiceID, nil); err != nil {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := sc.Invoices.Pay(invoiceID, stripeParams(ctx, &stripe.InvoicePayParams{}, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to pay Stripe invoice: %w", err)
 	}
 
 	return nil
 }
 
-// ListInvoices lists all invoices for a customer from the local database
-func (sp *StripePayment) ListInvoices(ctx context.Context, customerID string) ([]*models.Invoice, error) {
-	return sp.invoice.List(ctx, customerID, 1000, 0)
-	// Assuming a large limit, you might want to implement pagination
+// ListInvoices lists a page of invoices from the local database
+func (sp *StripePayment) ListInvoices(ctx context.Context, params models.ListParams) (*models.Page[*models.Invoice], error) {
+	return sp.invoice.List(ctx, params)
 }
 
 // GetPaymentMethod retrieves a payment method from the local database
@@ -387,9 +644,14 @@ func (sp *StripePayment) GetPaymentMethod(ctx context.Context, paymentMethodID s
 }
 
 // DeletePaymentMethod deletes a payment method from Stripe and from the local database
-func (sp *StripePayment) DeletePaymentMethod(ctx context.Context, paymentMethodID string) error {
+func (sp *StripePayment) DeletePaymentMethod(ctx context.Context, idempotencyKey, paymentMethodID string) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	if _, err := sp.client.PaymentMethods.Detach(paymentMethodID, nil); err != nil {
+	if _, err := sc.PaymentMethods.Detach(paymentMethodID, stripeParams(ctx, &stripe.PaymentMethodDetachParams{}, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to detach Stripe payment method: %w", err)
 	}
 
@@ -400,27 +662,48 @@ func (sp *StripePayment) DeletePaymentMethod(ctx context.Context, paymentMethodI
 	return nil
 }
 
-// ListPaymentMethods lists all payment methods for a customer from the local database
-func (sp *StripePayment) ListPaymentMethods(ctx context.Context, customerID string) ([]*models.PaymentMethod, error) {
-	return sp.paymentMethod.List(ctx, customerID, 1000, 0)
-	// Assuming a large limit, you might want to implement pagination
+// ListPaymentMethods lists a page of payment methods from the local database
+func (sp *StripePayment) ListPaymentMethods(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentMethod], error) {
+	return sp.paymentMethod.List(ctx, params)
 }
 
 // CreatePaymentIntent creates a new payment intent in Stripe and in the local database
-func (sp *StripePayment) CreatePaymentIntent(customerID, paymentMethodID string, amount uint64, currency stripe.Currency) error {
+// connect 不為零值時建立 destination charge，款項在付款成功後轉給 TransferDestination
+func (sp *StripePayment) CreatePaymentIntent(ctx context.Context, idempotencyKey, customerID, paymentMethodID string, amount models.Money, connect models.PaymentIntentConnect) (*models.PaymentIntent, error) {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	params := &stripe.PaymentIntentParams{
-		Amount:        stripe.Int64(int64(amount)),
-		Currency:      stripe.String(string(currency)),
+		Amount:        stripe.Int64(amount.Amount),
+		Currency:      stripe.String(string(amount.Currency)),
 		Customer:      stripe.String(customerID),
 		PaymentMethod: stripe.String(paymentMethodID),
 	}
+	if connect.OnBehalfOf != "" {
+		params.OnBehalfOf = stripe.String(connect.OnBehalfOf)
+	}
+	if connect.TransferDestination != "" {
+		params.TransferData = &stripe.PaymentIntentTransferDataParams{
+			Destination: stripe.String(connect.TransferDestination),
+		}
+	}
+	if connect.ApplicationFeeAmount > 0 {
+		params.ApplicationFeeAmount = stripe.Int64(connect.ApplicationFeeAmount)
+	}
 
-	if _, err := sp.client.PaymentIntents.New(params); err != nil {
-		return fmt.Errorf("failed to create Stripe payment intent: %w", err)
+	stripePaymentIntent, err := sc.PaymentIntents.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe payment intent: %w", err)
 	}
 
-	return nil
+	if _, err = sp.paymentIntent.Upsert(ctx, partialPaymentIntentFromStripe(stripePaymentIntent, objectCreatedAt(stripePaymentIntent.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local payment intent record: %w", err)
+	}
+
+	return sp.paymentIntent.GetByID(ctx, stripePaymentIntent.ID)
 }
 
 // GetPaymentIntent retrieves a payment intent from the local database
@@ -429,13 +712,18 @@ func (sp *StripePayment) GetPaymentIntent(ctx context.Context, paymentIntentID s
 }
 
 // ConfirmPaymentIntent confirms a payment intent in Stripe and updates the local database
-func (sp *StripePayment) ConfirmPaymentIntent(paymentIntentID, paymentMethodID string) error {
+func (sp *StripePayment) ConfirmPaymentIntent(ctx context.Context, idempotencyKey, paymentIntentID, paymentMethodID string) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	params := &stripe.PaymentIntentConfirmParams{
 		PaymentMethod: stripe.String(paymentMethodID),
 	}
 
-	if _, err := sp.client.PaymentIntents.Confirm(paymentIntentID, params); err != nil {
+	if _, err := sc.PaymentIntents.Confirm(paymentIntentID, stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to confirm Stripe payment intent: %w", err)
 	}
 
@@ -443,35 +731,48 @@ func (sp *StripePayment) ConfirmPaymentIntent(paymentIntentID, paymentMethodID s
 }
 
 // CancelPaymentIntent cancels a payment intent in Stripe and updates the local database
-func (sp *StripePayment) CancelPaymentIntent(paymentIntentID string) error {
+func (sp *StripePayment) CancelPaymentIntent(ctx context.Context, idempotencyKey, paymentIntentID string) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	if _, err := sp.client.PaymentIntents.Cancel(paymentIntentID, nil); err != nil {
+	if _, err := sc.PaymentIntents.Cancel(paymentIntentID, stripeParams(ctx, &stripe.PaymentIntentCancelParams{}, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to cancel Stripe payment intent: %w", err)
 	}
 
 	return nil
 }
 
-func (sp *StripePayment) ListPaymentIntent(ctx context.Context, limit, offset uint64) ([]*models.PaymentIntent, error) {
-	return sp.paymentIntent.List(ctx, limit, offset)
+// ListPaymentIntents lists a page of payment intents from the local database
+func (sp *StripePayment) ListPaymentIntents(ctx context.Context, params models.ListParams) (*models.Page[*models.PaymentIntent], error) {
+	return sp.paymentIntent.List(ctx, params)
 }
 
-func (sp *StripePayment) ListPaymentIntentByCustomerID(ctx context.Context, customerID string, limit, offset uint64) ([]*models.PaymentIntent, error) {
-	return sp.paymentIntent.ListByCustomer(ctx, customerID, limit, offset)
-}
+// CreateRefund creates a refund in Stripe, amount is in the smallest unit of the payment intent currency
+func (sp *StripePayment) CreateRefund(ctx context.Context, idempotencyKey, paymentIntentID, reason string, amount int64) (*models.Refund, error) {
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-func (sp *StripePayment) CreateRefund(paymentIntentID, reason string, amount uint64) error {
 	params := &stripe.RefundParams{
 		PaymentIntent: stripe.String(paymentIntentID),
-		Amount:        stripe.Int64(int64(amount * 100)), // Convert to cents
+		Amount:        stripe.Int64(amount),
 		Reason:        stripe.String(reason),
 	}
 
-	if _, err := sp.client.Refunds.New(params); err != nil {
-		return fmt.Errorf("failed to create Stripe refund: %w", err)
+	stripeRefund, err := sc.Refunds.New(stripeParams(ctx, params, idempotencyKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe refund: %w", err)
 	}
 
-	return nil
+	if err = sp.refund.Upsert(ctx, partialRefundFromStripe(stripeRefund, objectCreatedAt(stripeRefund.Created))); err != nil {
+		return nil, fmt.Errorf("failed to create local refund record: %w", err)
+	}
+
+	return sp.refund.GetByID(ctx, stripeRefund.ID)
 }
 
 // GetRefund retrieves a refund from the local database
@@ -480,61 +781,92 @@ func (sp *StripePayment) GetRefund(ctx context.Context, refundID string) (*model
 }
 
 // UpdateRefund updates a refund in Stripe and in the local database
-func (sp *StripePayment) UpdateRefund(refundID, reason string) error {
+func (sp *StripePayment) UpdateRefund(ctx context.Context, idempotencyKey, refundID, reason string) error {
+
+	sc, err := sp.stripeClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	params := &stripe.RefundParams{
 		Reason: stripe.String(reason),
 	}
 
-	if _, err := sp.client.Refunds.Update(refundID, params); err != nil {
+	if _, err := sc.Refunds.Update(refundID, stripeParams(ctx, params, idempotencyKey)); err != nil {
 		return fmt.Errorf("failed to update Stripe refund: %w", err)
 	}
 
 	return nil
 }
 
-// ListRefunds lists all refunds for a payment intent from the local database
-func (sp *StripePayment) ListRefunds(ctx context.Context, chargeID string) ([]*models.Refund, error) {
-	return sp.refund.ListByChargeID(ctx, chargeID)
+// ListRefunds lists a page of refunds from the local database
+func (sp *StripePayment) ListRefunds(ctx context.Context, params models.ListParams) (*models.Page[*models.Refund], error) {
+	return sp.refund.List(ctx, params)
 }
 
 // HandleStripeWebhook handles Stripe webhook events
+// 以 ctx 所屬租戶的 endpoint secret 驗證簽章，事件與其處理結果都寫入該租戶
 func (sp *StripePayment) HandleStripeWebhook(ctx context.Context, payload []byte, signature string) error {
-	stripeEvent, err := webhook.ConstructEvent(payload, signature, "秘密")
+	t, err := sp.tenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	stripeEvent, err := t.webhookVerifier.ConstructEvent(payload, signature)
 	if err != nil {
-		return fmt.Errorf("failed to verify webhook signature: %w", err)
+		var signatureErr *WebhookSignatureError
+		if errors.As(err, &signatureErr) {
+			metrics.WebhookVerificationFailures.WithLabelValues(t.id).Inc()
+		}
+		return fmt.Errorf("failed to verify webhook: %w", err)
 	}
+	metrics.WebhooksReceived.WithLabelValues(t.id, string(stripeEvent.Type)).Inc()
 
+	// 查詢失敗時回傳錯誤讓 Stripe 稍後重送，不能當作未處理而重複發佈
 	processed, err := sp.event.IsEventProcessed(ctx, stripeEvent.ID)
+	if err != nil {
+		sp.logger.Error("Failed to check whether event is processed", zap.String("event_id", stripeEvent.ID), zap.Error(err))
+		return err
+	}
 	if processed {
 		sp.logger.Info("Event is already processed", zap.String("event_id", stripeEvent.ID))
 		return nil
 	}
 
-	// 使用 CreateWorkRequest 方法創建工作請求
-	if err = sp.eventManager.PublishEvent(&stripeEvent); err != nil {
-		return fmt.Errorf("failed to publish event to NATS: %w", err)
-	}
-
-	eventModel := &models.Event{
-		ID:        stripeEvent.ID,
-		Type:      stripeEvent.Type,
-		Processed: false,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err = sp.event.Create(ctx, eventModel); err != nil {
+	// 先保存原始 payload 再發佈，handler 修正後可以透過 paymentctl events replay 重新處理
+	if err = sp.event.Create(ctx, models.NewEventFromStripe(&stripeEvent, payload)); err != nil {
 		sp.logger.Error("Failed to create event", zap.Error(err))
 		return err
 	}
 
+	if err = sp.eventManager.PublishEvent(ctx, &stripeEvent); err != nil {
+		return fmt.Errorf("failed to publish event to NATS: %w", err)
+	}
+
 	return nil
 }
 
-func (sp *StripePayment) ProcessEvent(ctx context.Context, event *stripe.Event) error {
+func (sp *StripePayment) ProcessEvent(ctx context.Context, event *stripe.Event) (err error) {
+	ctx, span := tracer.Start(ctx, "process "+string(event.Type), trace.WithAttributes(
+		attribute.String("stripe.event_id", event.ID),
+		attribute.String("stripe.event_type", string(event.Type)),
+	))
+	start := time.Now()
+	defer func() {
+		endSpan(span, err)
+		result := "success"
+		if err != nil {
+			result = "error"
+			metrics.EventProcessingErrors.WithLabelValues(string(event.Type)).Inc()
+		}
+		metrics.EventProcessingDuration.WithLabelValues(string(event.Type), result).Observe(time.Since(start).Seconds())
+	}()
+
 	handler, exists := sp.eventManager.GetHandler(event.Type)
 	if !exists {
-		return fmt.Errorf("no handler registered for event type: %s", event.Type)
+		err := fmt.Errorf("no handler registered for event type: %s", event.Type)
+		sp.recordEventFailure(ctx, event, err)
+		return err
 	}
 
 	if err := handler(ctx, event); err != nil {
@@ -543,10 +875,12 @@ func (sp *StripePayment) ProcessEvent(ctx context.Context, event *stripe.Event)
 			zap.String("event_type", string(event.Type)),
 			zap.Error(err),
 		)
+		sp.recordEventFailure(ctx, event, err)
 		return err
 	}
 
-	if err := sp.event.MarkEventAsProcessed(context.Background(), event.ID); err != nil {
+	// 不受呼叫端取消影響，但保留 ctx 中的租戶
+	if err := sp.event.MarkEventAsProcessed(context.WithoutCancel(ctx), event.ID); err != nil {
 		sp.logger.Error("Failed to mark event as processed", zap.Error(err))
 		return err
 	}
@@ -556,6 +890,39 @@ func (sp *StripePayment) ProcessEvent(ctx context.Context, event *stripe.Event)
 	return nil
 }
 
+// recordEventFailure 記錄處理失敗的次數與錯誤，並依重試策略排定下次處理或標記為永久失敗
+func (sp *StripePayment) recordEventFailure(ctx context.Context, event *stripe.Event, processErr error) {
+	failed, err := sp.event.RecordFailure(context.WithoutCancel(ctx), event.ID, processErr, sp.retryPolicy)
+	if err != nil {
+		sp.logger.Error("Failed to record event failure", zap.Error(err), zap.String("event_id", event.ID))
+		return
+	}
+
+	if failed {
+		sp.logger.Error("Event permanently failed",
+			zap.String("event_id", event.ID),
+			zap.String("event_type", string(event.Type)),
+			zap.Error(processErr))
+	}
+}
+
+// objectCreatedAt 回傳 Stripe 物件的建立時間，作為呼叫 Stripe API 後寫入本地資料的 last_event_at
+// 與事件的建立時間同樣以秒為單位，同一秒內建立的事件（例如建立後隨即由 incomplete 轉為 active 的訂閱）不會被視為過期；
+// 不使用本機時間，避免時鐘誤差讓之後的事件被略過
+func objectCreatedAt(created int64) *time.Time {
+	if created <= 0 {
+		return nil
+	}
+	createdAt := time.Unix(created, 0)
+	return &createdAt
+}
+
+// eventCreatedAt 回傳事件在 Stripe 的建立時間，Upsert 以此略過比資料庫內容更舊的事件
+func eventCreatedAt(stripeEvent *stripe.Event) *time.Time {
+	createdAt := time.Unix(stripeEvent.Created, 0)
+	return &createdAt
+}
+
 func (sp *StripePayment) handleCustomerEvent(ctx context.Context, stripeEvent *stripe.Event) error {
 
 	sp.logger.Info("Stripe customer event", zap.String("event_id", stripeEvent.ID))
@@ -566,29 +933,16 @@ func (sp *StripePayment) handleCustomerEvent(ctx context.Context, stripeEvent *s
 		return err
 	}
 
-	partialCustomer := &models.PartialCustomer{
-		ID: customerModel.ID,
-	}
-
-	if customerModel.Email != "" {
-		partialCustomer.Email = &customerModel.Email
-	}
-
-	if customerModel.Balance != 0 {
-		balance := customerModel.Balance
-		partialCustomer.Balance = &balance
-	}
-	if customerModel.Created > 0 {
-		createdAt := time.Unix(customerModel.Created, 0)
-		partialCustomer.CreatedAt = &createdAt
-	}
+	partialCustomer := partialCustomerFromStripe(customerModel, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "customer.created", "customer.updated":
 		err = sp.customer.Upsert(ctx, partialCustomer)
 	case "customer.deleted":
-		err = sp.customer.Delete(ctx, customerModel.ID)
+		// 與訂閱相同，保留該列並記錄 deleted_at 作為 tombstone，較晚送達的舊事件無法讓已刪除的客戶復活
+		partialCustomer.DeletedAt = partialCustomer.LastEventAt
+		err = sp.customer.Upsert(ctx, partialCustomer)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected customer event type: %s", stripeEvent.Type))
 	}
@@ -612,45 +966,25 @@ func (sp *StripePayment) handleSubscriptionEvent(ctx context.Context, stripeEven
 		return err
 	}
 
-	partialSubscription := &models.PartialSubscription{
-		ID: subscriptionModel.ID,
-	}
-
-	if subscriptionModel.Customer != nil {
-		partialSubscription.CustomerID = &subscriptionModel.Customer.ID
-	}
-	if subscriptionModel.Status != "" {
-		partialSubscription.Status = &subscriptionModel.Status
-	}
-	if subscriptionModel.CurrentPeriodStart > 0 {
-		start := time.Unix(subscriptionModel.CurrentPeriodStart, 0)
-		partialSubscription.CurrentPeriodStart = &start
-	}
-	if subscriptionModel.CurrentPeriodEnd > 0 {
-		end := time.Unix(subscriptionModel.CurrentPeriodEnd, 0)
-		partialSubscription.CurrentPeriodEnd = &end
-	}
-	partialSubscription.CancelAtPeriodEnd = &subscriptionModel.CancelAtPeriodEnd
-	if subscriptionModel.CanceledAt > 0 {
-		canceledAt := time.Unix(subscriptionModel.CanceledAt, 0)
-		partialSubscription.CanceledAt = &canceledAt
-	}
+	partialSubscription := partialSubscriptionFromStripe(subscriptionModel, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "customer.subscription.created", "customer.subscription.updated",
 		"customer.subscription.trial_will_end", "customer.subscription.pending_update_applied",
 		"customer.subscription.pending_update_expired", "customer.subscription.paused",
-		"customer.subscription.resumed":
-		err = sp.subscription.Upsert(ctx, partialSubscription)
-	case "customer.subscription.deleted":
-		err = sp.subscription.Delete(ctx, subscriptionModel.ID)
+		"customer.subscription.resumed", "customer.subscription.deleted":
+		// deleted 事件的訂閱狀態為 canceled，保留該列與 last_event_at，較舊的 updated 事件才無法讓它復活
+		err = sp.writeWithDomainEvents(ctx, func(ctx context.Context) (bool, error) {
+			return sp.subscription.Upsert(ctx, partialSubscription)
+		}, subscriptionDomainEvents(stripeEvent, subscriptionModel)...)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected customer subscription event type: %s", stripeEvent.Type))
 	}
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert subscription event", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe subscription event processed", zap.String("event_id", stripeEvent.ID))
@@ -668,58 +1002,24 @@ func (sp *StripePayment) handleInvoiceEvent(ctx context.Context, stripeEvent *st
 		return err
 	}
 
-	partialInvoice := &models.PartialInvoice{
-		ID: invoiceModel.ID,
-	}
-
-	if invoiceModel.Customer != nil {
-		partialInvoice.CustomerID = &invoiceModel.Customer.ID
-	}
-	if invoiceModel.Subscription != nil {
-		partialInvoice.SubscriptionID = &invoiceModel.Subscription.ID
-	}
-	if invoiceModel.Status != "" {
-		partialInvoice.Status = &invoiceModel.Status
-	}
-	if invoiceModel.Currency != "" {
-		partialInvoice.Currency = &invoiceModel.Currency
-	}
-
-	amountDue := float64(invoiceModel.AmountDue) / 100
-	partialInvoice.AmountDue = &amountDue
-
-	amountPaid := float64(invoiceModel.AmountPaid) / 100
-	partialInvoice.AmountPaid = &amountPaid
-
-	amountRemaining := float64(invoiceModel.AmountRemaining) / 100
-	partialInvoice.AmountRemaining = &amountRemaining
-
-	if invoiceModel.DueDate > 0 {
-		dueDate := time.Unix(invoiceModel.DueDate, 0)
-		partialInvoice.DueDate = &dueDate
-	}
-	if invoiceModel.Created > 0 {
-		createdAt := time.Unix(invoiceModel.Created, 0)
-		partialInvoice.CreatedAt = &createdAt
-	}
-
-	if invoiceModel.Status == stripe.InvoiceStatusPaid {
-		now := time.Now()
-		partialInvoice.PaidAt = &now
-	}
+	partialInvoice := partialInvoiceFromStripe(invoiceModel, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "invoice.created", "invoice.updated", "invoice.finalized",
-		"invoice.payment_succeeded", "invoice.payment_failed", "invoice.sent", "invoice.paid":
-		err = sp.invoice.Upsert(ctx, partialInvoice)
+		"invoice.payment_succeeded", "invoice.payment_failed", "invoice.sent", "invoice.paid", "invoice.overdue":
+		err = sp.writeWithDomainEvents(ctx, func(ctx context.Context) (bool, error) {
+			return sp.invoice.Upsert(ctx, partialInvoice)
+		}, invoiceDomainEvents(stripeEvent, invoiceModel)...)
 	case "invoice.deleted":
-		err = sp.invoice.Delete(ctx, invoiceModel.ID)
+		partialInvoice.DeletedAt = partialInvoice.LastEventAt
+		_, err = sp.invoice.Upsert(ctx, partialInvoice)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected invoice event type: %s", stripeEvent.Type))
 	}
 	if err != nil {
 		sp.logger.Error("Failed to upsert invoice event", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe invoice event processed", zap.String("event_id", stripeEvent.ID))
@@ -736,44 +1036,15 @@ func (sp *StripePayment) handlePaymentIntentEvent(ctx context.Context, stripeEve
 		sp.logger.Error("Failed to unmarshal payment intent event", zap.Error(err))
 		return err
 	}
-	partialPaymentIntent := &models.PartialPaymentIntent{
-		ID: paymentIntent.ID,
-	}
-
-	if paymentIntent.Customer != nil {
-		partialPaymentIntent.CustomerID = &paymentIntent.Customer.ID
-	}
-	if paymentIntent.Amount > 0 {
-		amount := float64(paymentIntent.Amount) / 100
-		partialPaymentIntent.Amount = &amount
-	}
-	if paymentIntent.Currency != "" {
-		partialPaymentIntent.Currency = &paymentIntent.Currency
-	}
-	if paymentIntent.Status != "" {
-		partialPaymentIntent.Status = &paymentIntent.Status
-	}
-	if paymentIntent.PaymentMethod != nil {
-		partialPaymentIntent.PaymentMethodID = &paymentIntent.PaymentMethod.ID
-	}
-	if paymentIntent.SetupFutureUsage != "" {
-		partialPaymentIntent.SetupFutureUsage = &paymentIntent.SetupFutureUsage
-	}
-	if paymentIntent.ClientSecret != "" {
-		partialPaymentIntent.ClientSecret = &paymentIntent.ClientSecret
-	}
-	if paymentIntent.CaptureMethod != "" {
-		partialPaymentIntent.CaptureMethod = &paymentIntent.CaptureMethod
-	}
-	if paymentIntent.Created > 0 {
-		createdAt := time.Unix(paymentIntent.Created, 0)
-		partialPaymentIntent.CreatedAt = &createdAt
-	}
+	partialPaymentIntent := partialPaymentIntentFromStripe(paymentIntent, eventCreatedAt(stripeEvent))
 
-	if err := sp.paymentIntent.Upsert(ctx, partialPaymentIntent); err != nil {
+	if err := sp.writeWithDomainEvents(ctx, func(ctx context.Context) (bool, error) {
+		return sp.paymentIntent.Upsert(ctx, partialPaymentIntent)
+	}, paymentIntentDomainEvents(stripeEvent, paymentIntent)...); err != nil {
 		sp.logger.Error("Failed to upsert payment intent", zap.Error(err))
 		return err
 	}
+	observePaymentIntent(stripeEvent.Type, paymentIntent)
 
 	sp.logger.Info("Stripe payment intent event processed", zap.String("event_id", stripeEvent.ID))
 
@@ -790,41 +1061,11 @@ func (sp *StripePayment) handleChargeEvent(ctx context.Context, stripeEvent *str
 		return err
 	}
 
-	partialCharge := &models.PartialCharge{
-		ID: chargeModel.ID,
-	}
-
-	if chargeModel.Customer != nil {
-		partialCharge.CustomerID = &chargeModel.Customer.ID
-	}
-	if chargeModel.PaymentIntent != nil {
-		partialCharge.PaymentIntentID = &chargeModel.PaymentIntent.ID
-	}
-	if chargeModel.Amount > 0 {
-		amount := float64(chargeModel.Amount) / 100
-		partialCharge.Amount = &amount
-	}
-	if chargeModel.Currency != "" {
-		partialCharge.Currency = &chargeModel.Currency
-	}
-	if chargeModel.Status != "" {
-		partialCharge.Status = &chargeModel.Status
-	}
-	partialCharge.Paid = &chargeModel.Paid
-	partialCharge.Refunded = &chargeModel.Refunded
-	if chargeModel.FailureCode != "" {
-		partialCharge.FailureCode = &chargeModel.FailureCode
-	}
-	if chargeModel.FailureMessage != "" {
-		partialCharge.FailureMessage = &chargeModel.FailureMessage
-	}
-	if chargeModel.Created > 0 {
-		createdAt := time.Unix(chargeModel.Created, 0)
-		partialCharge.CreatedAt = &createdAt
-	}
+	partialCharge := partialChargeFromStripe(chargeModel, eventCreatedAt(stripeEvent))
 
 	if err := sp.charge.Upsert(ctx, partialCharge); err != nil {
 		sp.logger.Error("Failed to upsert charge", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe charge event processed", zap.String("event_id", stripeEvent.ID))
@@ -840,27 +1081,7 @@ func (sp *StripePayment) handleRefundEvent(ctx context.Context, stripeEvent *str
 		sp.logger.Error("Failed to unmarshal refund event", zap.Error(err))
 		return err
 	}
-	partialRefund := &models.PartialRefund{
-		ID: refundModel.ID,
-	}
-
-	if refundModel.Charge != nil {
-		partialRefund.ChargeID = &refundModel.Charge.ID
-	}
-	if refundModel.Amount > 0 {
-		amount := float64(refundModel.Amount)
-		partialRefund.Amount = &amount
-	}
-	if refundModel.Status != "" {
-		partialRefund.Status = &refundModel.Status
-	}
-	if refundModel.Reason != "" {
-		partialRefund.Reason = &refundModel.Reason
-	}
-	if refundModel.Created > 0 {
-		createdAt := time.Unix(refundModel.Created, 0)
-		partialRefund.CreatedAt = &createdAt
-	}
+	partialRefund := partialRefundFromStripe(refundModel, eventCreatedAt(stripeEvent))
 
 	if err := sp.refund.Upsert(ctx, partialRefund); err != nil {
 		sp.logger.Error(fmt.Sprintf("處理退款失敗: %s", err))
@@ -881,38 +1102,19 @@ func (sp *StripePayment) handleDisputeEvent(ctx context.Context, stripeEvent *st
 		sp.logger.Error("Failed to unmarshal dispute event", zap.Error(err))
 		return err
 	}
-	partialDispute := &models.PartialDispute{
-		ID: dispute.ID,
-	}
-
-	if dispute.Charge != nil {
-		partialDispute.ChargeID = &dispute.Charge.ID
-	}
-	if dispute.Amount > 0 {
-		partialDispute.Amount = &dispute.Amount
-	}
-	if dispute.Status != "" {
-		partialDispute.Status = &dispute.Status
-	}
-	if dispute.Reason != "" {
-		partialDispute.Reason = &dispute.Reason
-	}
-	if dispute.Created > 0 {
-		createdAt := time.Unix(dispute.Created, 0)
-		partialDispute.CreatedAt = &createdAt
-	}
+	partialDispute := partialDisputeFromStripe(dispute, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
-	case "charge.dispute.created", "charge.dispute.updated":
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed":
+		// closed 事件帶有最終的 won 或 lost 狀態，同樣經過 last_event_at 檢查，較早的 updated 晚到時不會覆蓋結果
 		err = sp.dispute.Upsert(ctx, partialDispute)
-	case "charge.dispute.closed":
-		err = sp.dispute.Close(ctx, dispute.ID)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected dispute event type: %s", stripeEvent.Type))
 	}
 	if err != nil {
 		sp.logger.Error("Failed to upsert dispute", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe dispute event processed", zap.String("event_id", stripeEvent.ID))
@@ -930,32 +1132,21 @@ func (sp *StripePayment) handleProductEvent(ctx context.Context, stripeEvent *st
 		return err
 	}
 
-	partialProduct := &models.PartialProduct{
-		ID: productModel.ID,
-	}
-
-	if productModel.Name != "" {
-		partialProduct.Name = &productModel.Name
-	}
-	if productModel.Description != "" {
-		partialProduct.Description = &productModel.Description
-	}
-	partialProduct.Active = &productModel.Active
-	if productModel.Metadata != nil {
-		partialProduct.Metadata = &productModel.Metadata
-	}
+	partialProduct := partialProductFromStripe(productModel, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "product.created", "product.updated":
 		err = sp.product.Upsert(ctx, partialProduct)
 	case "product.deleted":
-		err = sp.product.Delete(ctx, productModel.ID)
+		partialProduct.DeletedAt = partialProduct.LastEventAt
+		err = sp.product.Upsert(ctx, partialProduct)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected product event type: %s", stripeEvent.Type))
 	}
 	if err != nil {
 		sp.logger.Error("Failed to upsert product", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe product event processed", zap.String("event_id", stripeEvent.ID))
@@ -973,46 +1164,22 @@ func (sp *StripePayment) handlePriceEvent(ctx context.Context, stripeEvent *stri
 		return err
 	}
 
-	partialPrice := &models.PartialPrice{
-		ID: priceModel.ID,
-	}
-
-	if priceModel.Product != nil {
-		partialPrice.ProductID = &priceModel.Product.ID
-	}
-	partialPrice.Active = &priceModel.Active
-	if priceModel.Currency != "" {
-		partialPrice.Currency = &priceModel.Currency
-	}
-	if priceModel.UnitAmount > 0 {
-		unitAmount := float64(priceModel.UnitAmount) / 100
-		partialPrice.UnitAmount = &unitAmount
-	}
-	if priceModel.Type != "" {
-		partialPrice.Type = &priceModel.Type
-	}
-	if priceModel.Recurring != nil {
-		if priceModel.Recurring.Interval != "" {
-			partialPrice.RecurringInterval = &priceModel.Recurring.Interval
-		}
-		if priceModel.Recurring.IntervalCount > 0 {
-			intervalCount := int32(priceModel.Recurring.IntervalCount)
-			partialPrice.RecurringIntervalCount = &intervalCount
-		}
-	}
+	partialPrice := partialPriceFromStripe(priceModel, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "price.created", "price.updated":
 		err = sp.price.Upsert(ctx, partialPrice)
 	case "price.deleted":
-		err = sp.price.Delete(ctx, priceModel.ID)
+		partialPrice.DeletedAt = partialPrice.LastEventAt
+		err = sp.price.Upsert(ctx, partialPrice)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected price event type: %s", stripeEvent.Type))
 	}
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert price", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe price event processed", zap.String("event_id", stripeEvent.ID))
@@ -1030,65 +1197,24 @@ func (sp *StripePayment) handlePaymentMethodEvent(ctx context.Context, stripeEve
 		return err
 	}
 
-	partialPaymentMethod := &models.PartialPaymentMethod{
-		ID: paymentMethod.ID,
-	}
-
-	if paymentMethod.Customer != nil {
-		partialPaymentMethod.CustomerID = &paymentMethod.Customer.ID
-	}
-	if paymentMethod.Type != "" {
-		pmType := paymentMethod.Type
-		partialPaymentMethod.Type = &pmType
-	}
-	if paymentMethod.Created > 0 {
-		createdAt := time.Unix(paymentMethod.Created, 0)
-		partialPaymentMethod.CreatedAt = &createdAt
-	}
-
-	switch paymentMethod.Type {
-	case stripe.PaymentMethodTypeCard:
-		if paymentMethod.Card != nil {
-			if paymentMethod.Card.Last4 != "" {
-				partialPaymentMethod.CardLast4 = &paymentMethod.Card.Last4
-			}
-			if paymentMethod.Card.Brand != "" {
-				partialPaymentMethod.CardBrand = &paymentMethod.Card.Brand
-			}
-			if paymentMethod.Card.ExpMonth > 0 {
-				expMonth := int32(paymentMethod.Card.ExpMonth)
-				partialPaymentMethod.CardExpMonth = &expMonth
-			}
-			if paymentMethod.Card.ExpYear > 0 {
-				expYear := int32(paymentMethod.Card.ExpYear)
-				partialPaymentMethod.CardExpYear = &expYear
-			}
-		}
-	case stripe.PaymentMethodTypeUSBankAccount:
-		if paymentMethod.USBankAccount != nil {
-			if paymentMethod.USBankAccount.Last4 != "" {
-				partialPaymentMethod.BankAccountLast4 = &paymentMethod.USBankAccount.Last4
-			}
-			if paymentMethod.USBankAccount.BankName != "" {
-				partialPaymentMethod.BankAccountBankName = &paymentMethod.USBankAccount.BankName
-			}
-		}
-	}
+	partialPaymentMethod := partialPaymentMethodFromStripe(paymentMethod, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "payment_method.attached", "payment_method.updated":
 		err = sp.paymentMethod.Upsert(ctx, partialPaymentMethod)
 	case "payment_method.detached":
-		err = sp.paymentMethod.Delete(ctx, paymentMethod.ID)
+		// detached 事件的 customer 為空，Upsert 保留原本的 customer_id
+		partialPaymentMethod.DeletedAt = partialPaymentMethod.LastEventAt
+		err = sp.paymentMethod.Upsert(ctx, partialPaymentMethod)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected payment method event type: %s", stripeEvent.Type))
 	}
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert payment method", zap.Error(err))
+		return err
 	}
-	//
 
 	sp.logger.Info("Stripe payment method event processed", zap.String("event_id", stripeEvent.ID))
 
@@ -1104,58 +1230,23 @@ func (sp *StripePayment) handleCouponEvent(ctx context.Context, stripeEvent *str
 		sp.logger.Error("Failed to unmarshal coupon event", zap.Error(err))
 		return err
 	}
-	partialCoupon := &models.PartialCoupon{
-		ID: couponModel.ID,
-	}
-
-	if couponModel.Name != "" {
-		partialCoupon.Name = &couponModel.Name
-	}
-	if couponModel.Currency != "" {
-		partialCoupon.Currency = &couponModel.Currency
-	}
-	if couponModel.Duration != "" {
-		partialCoupon.Duration = &couponModel.Duration
-	}
-	timesRedeemed := int32(couponModel.TimesRedeemed)
-	partialCoupon.TimesRedeemed = &timesRedeemed
-	partialCoupon.Valid = &couponModel.Valid
-	if couponModel.Created > 0 {
-		createdAt := time.Unix(couponModel.Created, 0)
-		partialCoupon.CreatedAt = &createdAt
-	}
-
-	if couponModel.AmountOff > 0 {
-		partialCoupon.AmountOff = &couponModel.AmountOff
-	}
-	if couponModel.PercentOff > 0 {
-		partialCoupon.PercentOff = &couponModel.PercentOff
-	}
-	if couponModel.DurationInMonths > 0 {
-		durationInMonths := int(couponModel.DurationInMonths)
-		partialCoupon.DurationInMonths = &durationInMonths
-	}
-	if couponModel.MaxRedemptions > 0 {
-		maxRedemptions := int(couponModel.MaxRedemptions)
-		partialCoupon.MaxRedemptions = &maxRedemptions
-	}
-	if couponModel.RedeemBy > 0 {
-		redeemBy := time.Unix(couponModel.RedeemBy, 0)
-		partialCoupon.RedeemBy = &redeemBy
-	}
+	partialCoupon := partialCouponFromStripe(couponModel, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
 	case "coupon.created", "coupon.updated":
 		err = sp.coupon.Upsert(ctx, partialCoupon)
 	case "coupon.deleted":
-		err = sp.coupon.Delete(ctx, couponModel.ID)
+		// 保留該列作為 tombstone，刪除後才送達的舊 updated 事件不會重新建立優惠券
+		partialCoupon.DeletedAt = partialCoupon.LastEventAt
+		err = sp.coupon.Upsert(ctx, partialCoupon)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected coupon event type: %s", stripeEvent.Type))
 	}
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert coupon", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe coupon event processed", zap.String("event_id", stripeEvent.ID))
@@ -1174,7 +1265,8 @@ func (sp *StripePayment) handleDiscountEvent(ctx context.Context, stripeEvent *s
 	}
 
 	partialDiscount := &models.PartialDiscount{
-		ID: discountModel.ID,
+		ID:          discountModel.ID,
+		LastEventAt: eventCreatedAt(stripeEvent),
 	}
 
 	if discountModel.Customer != nil {
@@ -1198,13 +1290,15 @@ func (sp *StripePayment) handleDiscountEvent(ctx context.Context, stripeEvent *s
 	case "customer.discount.created", "customer.discount.updated":
 		err = sp.discount.Upsert(ctx, partialDiscount)
 	case "customer.discount.deleted":
-		err = sp.discount.Delete(ctx, discountModel.ID)
+		partialDiscount.DeletedAt = partialDiscount.LastEventAt
+		err = sp.discount.Upsert(ctx, partialDiscount)
 	default:
 		sp.logger.Error(fmt.Sprintf("unexpected discount event type: %s", stripeEvent.Type))
 	}
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert discount object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe discount event processed", zap.String("event_id", stripeEvent.ID))
@@ -1221,34 +1315,7 @@ func (sp *StripePayment) handlePromotionCodeEvent(ctx context.Context, stripeEve
 		sp.logger.Error("Failed to unmarshal promotion code event", zap.Error(err))
 		return err
 	}
-	partialPromotionCode := &models.PartialPromotionCode{
-		ID: promotionCode.ID,
-	}
-
-	if promotionCode.Code != "" {
-		partialPromotionCode.Code = &promotionCode.Code
-	}
-	if promotionCode.Coupon != nil {
-		partialPromotionCode.CouponID = &promotionCode.Coupon.ID
-	}
-	if promotionCode.Customer != nil {
-		partialPromotionCode.CustomerID = &promotionCode.Customer.ID
-	}
-	partialPromotionCode.Active = &promotionCode.Active
-	if promotionCode.MaxRedemptions > 0 {
-		maxRedemptions := int(promotionCode.MaxRedemptions)
-		partialPromotionCode.MaxRedemptions = &maxRedemptions
-	}
-	timesRedeemed := int(promotionCode.TimesRedeemed)
-	partialPromotionCode.TimesRedeemed = &timesRedeemed
-	if promotionCode.ExpiresAt > 0 {
-		expiresAt := time.Unix(promotionCode.ExpiresAt, 0)
-		partialPromotionCode.ExpiresAt = &expiresAt
-	}
-	if promotionCode.Created > 0 {
-		createdAt := time.Unix(promotionCode.Created, 0)
-		partialPromotionCode.CreatedAt = &createdAt
-	}
+	partialPromotionCode := partialPromotionCodeFromStripe(promotionCode, eventCreatedAt(stripeEvent))
 
 	var err error
 	switch stripeEvent.Type {
@@ -1259,6 +1326,7 @@ func (sp *StripePayment) handlePromotionCodeEvent(ctx context.Context, stripeEve
 	}
 	if err != nil {
 		sp.logger.Error("Failed to upsert promotion code object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe promotion code event processed", zap.String("event_id", stripeEvent.ID))
@@ -1276,7 +1344,8 @@ func (sp *StripePayment) handleCheckoutSessionEvent(ctx context.Context, stripeE
 		return err
 	}
 	partialSession := &models.PartialCheckoutSession{
-		ID: session.ID,
+		ID:          session.ID,
+		LastEventAt: eventCreatedAt(stripeEvent),
 	}
 
 	if session.Customer != nil {
@@ -1308,6 +1377,7 @@ func (sp *StripePayment) handleCheckoutSessionEvent(ctx context.Context, stripeE
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert checkout session object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe checkout session event processed", zap.String("event_id", stripeEvent.ID))
@@ -1326,7 +1396,8 @@ func (sp *StripePayment) handleQuoteEvent(ctx context.Context, stripeEvent *stri
 	}
 
 	partialQuote := &models.PartialQuote{
-		ID: quoteModel.ID,
+		ID:          quoteModel.ID,
+		LastEventAt: eventCreatedAt(stripeEvent),
 	}
 
 	if quoteModel.Customer != nil {
@@ -1372,6 +1443,7 @@ func (sp *StripePayment) handleQuoteEvent(ctx context.Context, stripeEvent *stri
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert quote object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe quote event processed", zap.String("event_id", stripeEvent.ID))
@@ -1390,7 +1462,8 @@ func (sp *StripePayment) handlePaymentLinkEvent(ctx context.Context, stripeEvent
 	}
 
 	partialPaymentLink := &models.PartialPaymentLink{
-		ID: paymentLink.ID,
+		ID:          paymentLink.ID,
+		LastEventAt: eventCreatedAt(stripeEvent),
 	}
 
 	partialPaymentLink.Active = &paymentLink.Active
@@ -1416,6 +1489,7 @@ func (sp *StripePayment) handlePaymentLinkEvent(ctx context.Context, stripeEvent
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert payment link object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe payment link event processed", zap.String("event_id", stripeEvent.ID))
@@ -1433,7 +1507,8 @@ func (sp *StripePayment) handleTaxRateEvent(ctx context.Context, stripeEvent *st
 		return err
 	}
 	partialTaxRate := &models.PartialTaxRate{
-		ID: taxRate.ID,
+		ID:          taxRate.ID,
+		LastEventAt: eventCreatedAt(stripeEvent),
 	}
 
 	partialTaxRate.DisplayName = &taxRate.DisplayName
@@ -1462,6 +1537,7 @@ func (sp *StripePayment) handleTaxRateEvent(ctx context.Context, stripeEvent *st
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert tax rate object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe tax rate event processed", zap.String("event_id", stripeEvent.ID))
@@ -1478,7 +1554,8 @@ func (sp *StripePayment) handleReviewEvent(ctx context.Context, stripeEvent *str
 		return err
 	}
 	partialReview := &models.PartialReview{
-		ID: reviewModel.ID,
+		ID:          reviewModel.ID,
+		LastEventAt: eventCreatedAt(stripeEvent),
 	}
 
 	if reviewModel.PaymentIntent != nil {
@@ -1523,6 +1600,7 @@ func (sp *StripePayment) handleReviewEvent(ctx context.Context, stripeEvent *str
 
 	if err != nil {
 		sp.logger.Error("Failed to upsert review object", zap.Error(err))
+		return err
 	}
 
 	sp.logger.Info("Stripe review event processed", zap.String("event_id", stripeEvent.ID))
@@ -1530,9 +1608,49 @@ func (sp *StripePayment) handleReviewEvent(ctx context.Context, stripeEvent *str
 	return nil
 }
 
+// ListDeadLetterEvents lists events that exhausted their redeliveries
+func (sp *StripePayment) ListDeadLetterEvents(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	return sp.eventManager.ListDeadLetters(ctx, limit)
+}
+
+// ReplayDeadLetterEvent publishes a dead-lettered event back onto the event stream
+func (sp *StripePayment) ReplayDeadLetterEvent(ctx context.Context, sequence uint64) error {
+	return sp.eventManager.ReplayDeadLetter(ctx, sequence)
+}
+
+// WorkerPoolStats reports the queue depth of each worker pool shard
+func (sp *StripePayment) WorkerPoolStats() []ShardStats {
+	if sp.workerPool == nil {
+		return nil
+	}
+	return sp.workerPool.Stats()
+}
+
+// ListFailedEvents lists events that exhausted their retry attempts
+func (sp *StripePayment) ListFailedEvents(ctx context.Context, limit, offset int) ([]*models.Event, error) {
+	return sp.event.ListFailed(ctx, limit, offset)
+}
+
 func (sp *StripePayment) Close() {
 	sp.logger.Info("Initiating graceful shutdown of workers and dispatcher")
-	sp.natsConn.Close()
-	sp.workerPool.Shutdown()
+	if sp.done != nil {
+		close(sp.done)
+	}
+	sp.eventManager.Stop()
+	if sp.retryScheduler != nil {
+		sp.retryScheduler.Stop()
+	}
+	if sp.reconciler != nil {
+		sp.reconciler.Stop()
+	}
+	if sp.workerPool != nil {
+		sp.workerPool.Shutdown()
+	}
+	if sp.outboxRelay != nil {
+		sp.outboxRelay.Stop()
+	}
+	if sp.natsConn != nil {
+		sp.natsConn.Close()
+	}
 	sp.logger.Info("StripePayment successfully shutdown")
 }
@@ -14,6 +14,7 @@ import (
 	"goflare.io/ember"
 	"goflare.io/ignite"
 	"goflare.io/payment/driver"
+	"goflare.io/payment/metrics"
 	"goflare.io/payment/models"
 	"goflare.io/payment/sqlc"
 )
@@ -24,9 +25,11 @@ type Repository interface {
 	Update(ctx context.Context, tx pgx.Tx, subscription *models.Subscription) error
 	Cancel(ctx context.Context, tx pgx.Tx, id string, cancelAtPeriodEnd bool) error
 	Delete(ctx context.Context, tx pgx.Tx, id string) error
-	List(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) ([]*models.Subscription, error)
+	List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Subscription], error)
 	GetExpiringSubscriptions(ctx context.Context, tx pgx.Tx, expirationDate time.Time) ([]*models.Subscription, error)
-	Upsert(ctx context.Context, tx pgx.Tx, subscription *models.PartialSubscription) error
+	Upsert(ctx context.Context, tx pgx.Tx, subscription *models.PartialSubscription) (bool, error)
+	UpsertBatch(ctx context.Context, tx pgx.Tx, subscriptions []*models.PartialSubscription) error
+	ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.Subscription, error)
 }
 
 type repository struct {
@@ -106,7 +109,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, subscription *models
 		return fmt.Errorf("failed to create subscription: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("subscription:%s", subscription.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("subscription:%s", subscription.ID))
 	if err := r.cache.Set(ctx, cacheKey, subscription); err != nil {
 		r.logger.Warn("Failed to cache new subscription", zap.Error(err), zap.String("id", subscription.ID))
 	}
@@ -115,7 +118,7 @@ func (r *repository) Create(ctx context.Context, tx pgx.Tx, subscription *models
 }
 
 func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Subscription, error) {
-	cacheKey := fmt.Sprintf("subscription:%s", id)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("subscription:%s", id))
 
 	subscription, release, err := r.getFromPool(ctx)
 	if err != nil {
@@ -124,13 +127,17 @@ func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models
 	defer release()
 
 	found, err := r.cache.Get(ctx, cacheKey, subscription)
+	metrics.ObserveCache("subscription", found, err)
 	if err != nil {
 		r.logger.Warn("Failed to get subscription from cache", zap.Error(err), zap.String("id", id))
 	} else if found {
 		return subscription, nil
 	}
 
-	sqlcSubscription, err := sqlc.New(r.conn).WithTx(tx).GetSubscription(ctx, id)
+	sqlcSubscription, err := sqlc.New(r.conn).WithTx(tx).GetSubscription(ctx, sqlc.GetSubscriptionParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 	if err != nil {
 		r.logger.Error("error getting subscription", zap.Error(err))
 		return nil, err
@@ -167,11 +174,12 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, subscription *models
 		CancelAtPeriodEnd:  subscription.CancelAtPeriodEnd,
 		TrialStart:         pgtype.Timestamptz{Time: trialStart, Valid: true},
 		TrialEnd:           pgtype.Timestamptz{Time: trialEnd, Valid: true},
+		TenantID:           driver.TenantID(ctx),
 	}); err != nil {
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
-	cacheKey := fmt.Sprintf("subscription:%s", subscription.ID)
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("subscription:%s", subscription.ID))
 	if err := r.cache.Set(ctx, cacheKey, subscription); err != nil {
 		r.logger.Warn("Failed to update subscription in cache", zap.Error(err), zap.String("id", subscription.ID))
 	}
@@ -180,7 +188,10 @@ func (r *repository) Update(ctx context.Context, tx pgx.Tx, subscription *models
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteSubscription(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeleteSubscription(ctx, sqlc.DeleteSubscriptionParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
 
 func (r *repository) Cancel(ctx context.Context, tx pgx.Tx, id string, cancelAtPeriodEnd bool) error {
@@ -197,50 +208,43 @@ func (r *repository) Cancel(ctx context.Context, tx pgx.Tx, id string, cancelAtP
 	return r.Update(ctx, tx, subscription)
 }
 
-func (r *repository) List(ctx context.Context, tx pgx.Tx, customerID string, limit, offset uint64) ([]*models.Subscription, error) {
-	cacheKey := fmt.Sprintf("subscriptions:customer:%s:limit:%d:offset:%d", customerID, limit, offset)
-	var cachedSubscriptions []*models.Subscription
-	found, err := r.cache.Get(ctx, cacheKey, &cachedSubscriptions)
-	if err != nil {
-		r.logger.Warn("Failed to get subscriptions from cache", zap.Error(err), zap.String("customerID", customerID))
-	} else if found {
-		return cachedSubscriptions, nil
+// List 依 (created_at, id) 遞減以 keyset 分頁列出訂閱，多查詢一筆判斷是否還有下一頁
+func (r *repository) List(ctx context.Context, tx pgx.Tx, params models.ListParams) (*models.Page[*models.Subscription], error) {
+	limit := params.PageSize()
+	arg := sqlc.ListSubscriptionsParams{
+		CreatedFrom: pgtype.Timestamptz{Time: params.CreatedFrom, Valid: !params.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: params.CreatedTo, Valid: !params.CreatedTo.IsZero()},
+		Limit:       int64(limit + 1),
+		TenantID:    driver.TenantID(ctx),
+	}
+	if params.CustomerID != "" {
+		arg.CustomerID = &params.CustomerID
+	}
+	if params.Status != "" {
+		status := sqlc.SubscriptionStatus(params.Status)
+		if !status.Valid() {
+			return nil, fmt.Errorf("%w: subscription status %q", models.ErrInvalidListFilter, params.Status)
+		}
+		arg.Status = sqlc.NullSubscriptionStatus{SubscriptionStatus: status, Valid: true}
+	}
+	if params.Cursor != nil {
+		arg.CursorCreatedAt = pgtype.Timestamptz{Time: params.Cursor.CreatedAt, Valid: true}
+		arg.CursorID = &params.Cursor.ID
 	}
 
-	sqlcSubscriptions, err := sqlc.New(r.conn).WithTx(tx).ListSubscriptions(ctx, sqlc.ListSubscriptionsParams{
-		CustomerID: customerID,
-		Limit:      int64(limit),
-		Offset:     int64(offset),
-	})
+	sqlcSubscriptions, err := sqlc.New(r.conn).WithTx(tx).ListSubscriptions(ctx, arg)
 	if err != nil {
-		r.logger.Error("error listing subscriptions", zap.Error(err))
-		return nil, err
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
 	}
 
 	subscriptions := make([]*models.Subscription, 0, len(sqlcSubscriptions))
 	for _, sqlcSubscription := range sqlcSubscriptions {
-		subscription, release, err := r.getFromPool(ctx)
-		if err != nil {
-			r.logger.Error("Failed to get subscription from pool", zap.Error(err))
-			continue
-		}
-
-		*subscription = *models.NewSubscription().ConvertFromSQLCSubscription(sqlcSubscription)
-		subscriptions = append(subscriptions, subscription)
-
-		singleCacheKey := fmt.Sprintf("subscription:%s", subscription.ID)
-		if err = r.cache.Set(ctx, singleCacheKey, subscription, 30*time.Minute); err != nil {
-			r.logger.Warn("Failed to cache single subscription", zap.Error(err), zap.String("id", subscription.ID))
-		}
-
-		release()
-	}
-
-	if err = r.cache.Set(ctx, cacheKey, subscriptions); err != nil {
-		r.logger.Warn("Failed to cache subscriptions list", zap.Error(err), zap.String("customerID", customerID))
+		subscriptions = append(subscriptions, models.NewSubscription().ConvertFromSQLCSubscription(sqlcSubscription))
 	}
 
-	return subscriptions, nil
+	return models.NewPage(subscriptions, limit, func(item *models.Subscription) models.Cursor {
+		return models.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+	}), nil
 }
 
 func (r *repository) GetExpiringSubscriptions(ctx context.Context, tx pgx.Tx, expirationDate time.Time) ([]*models.Subscription, error) {
@@ -248,6 +252,7 @@ func (r *repository) GetExpiringSubscriptions(ctx context.Context, tx pgx.Tx, ex
 	sqlcSubscriptions, err := sqlc.New(r.conn).WithTx(tx).GetExpiringSubscriptions(ctx, sqlc.GetExpiringSubscriptionsParams{
 		CurrentPeriodEnd: pgtype.Timestamptz{Time: expirationDate, Valid: true},
 		Status:           sqlc.SubscriptionStatus(stripe.SubscriptionStatusActive),
+		TenantID:         driver.TenantID(ctx),
 	})
 	if err != nil {
 		r.logger.Error("error getting expiring subscriptions", zap.Error(err))
@@ -276,10 +281,50 @@ func (r *repository) GetExpiringSubscriptions(ctx context.Context, tx pgx.Tx, ex
 	return subscriptions, nil
 }
 
-func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, subscription *models.PartialSubscription) error {
-	const query = `
-    INSERT INTO subscriptions (id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at)
-    VALUES (@id, @customer_id, @price_id, @status, @current_period_start, @current_period_end, @canceled_at, @cancel_at_period_end, @trial_start, @trial_end, COALESCE(@created_at, NOW()), @updated_at)
+// Upsert 回傳是否寫入了資料，事件比資料庫中的內容舊而被略過時為 false
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, subscription *models.PartialSubscription) (bool, error) {
+	tag, err := tx.Exec(ctx, upsertQuery, upsertArgs(ctx, subscription))
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert subscription: %w", err)
+	}
+
+	// 清除緩存，避免 GetByID 讀到 webhook 或對帳更新前的資料
+	cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("subscription:%s", subscription.ID))
+	if err := r.cache.Delete(ctx, cacheKey); err != nil {
+		r.logger.Warn("Failed to delete subscription from cache", zap.Error(err), zap.String("id", subscription.ID))
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpsertBatch 在同一個 batch 中寫入多筆資料，規則與 Upsert 相同
+func (r *repository) UpsertBatch(ctx context.Context, tx pgx.Tx, subscriptions []*models.PartialSubscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, subscription := range subscriptions {
+		batch.Queue(upsertQuery, upsertArgs(ctx, subscription))
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert subscriptions: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		cacheKey := driver.TenantCacheKey(ctx, fmt.Sprintf("subscription:%s", subscription.ID))
+		if err := r.cache.Delete(ctx, cacheKey); err != nil {
+			r.logger.Warn("Failed to delete subscription from cache", zap.Error(err), zap.String("id", subscription.ID))
+		}
+	}
+
+	return nil
+}
+
+const upsertQuery = `
+    INSERT INTO subscriptions (id, customer_id, price_id, status, current_period_start, current_period_end, canceled_at, cancel_at_period_end, trial_start, trial_end, created_at, updated_at, last_event_at)
+    VALUES (@id, @customer_id, @price_id, @status, @current_period_start, @current_period_end, @canceled_at, @cancel_at_period_end, @trial_start, @trial_end, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         customer_id = COALESCE(@customer_id, subscriptions.customer_id),
         price_id = COALESCE(@price_id, subscriptions.price_id),
@@ -290,12 +335,17 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, subscription *models
         cancel_at_period_end = COALESCE(@cancel_at_period_end, subscriptions.cancel_at_period_end),
         trial_start = COALESCE(@trial_start, subscriptions.trial_start),
         trial_end = COALESCE(@trial_end, subscriptions.trial_end),
+        last_event_at = COALESCE(@last_event_at, subscriptions.last_event_at),
         updated_at = @updated_at
     WHERE subscriptions.id = @id
+      AND subscriptions.tenant_id = @tenant_id
+      AND (subscriptions.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR subscriptions.last_event_at <= @last_event_at)
     `
 
+// upsertArgs 將部分欄位轉為 upsertQuery 的參數
+func upsertArgs(ctx context.Context, subscription *models.PartialSubscription) pgx.NamedArgs {
 	now := time.Now()
-	args := pgx.NamedArgs{
+	return pgx.NamedArgs{
 		"id":                   subscription.ID,
 		"customer_id":          subscription.CustomerID,
 		"price_id":             subscription.PriceID,
@@ -308,11 +358,25 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, subscription *models
 		"trial_end":            subscription.TrialEnd,
 		"created_at":           subscription.CreatedAt,
 		"updated_at":           now,
+		"last_event_at":        subscription.LastEventAt,
+		"tenant_id":            driver.TenantID(ctx),
 	}
+}
 
-	if _, err := tx.Exec(ctx, query, args); err != nil {
-		return fmt.Errorf("failed to upsert subscription: %w", err)
+// ListByIDs 直接從資料庫讀取指定 ID 的訂閱，不經過緩存，供對帳比對使用
+func (r *repository) ListByIDs(ctx context.Context, tx pgx.Tx, ids []string) ([]*models.Subscription, error) {
+	sqlcSubscriptions, err := sqlc.New(r.conn).WithTx(tx).ListSubscriptionsByIDs(ctx, sqlc.ListSubscriptionsByIDsParams{
+		Ids:      ids,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions by ids: %w", err)
 	}
 
-	return nil
+	subscriptions := make([]*models.Subscription, 0, len(sqlcSubscriptions))
+	for _, sqlcSubscription := range sqlcSubscriptions {
+		subscriptions = append(subscriptions, models.NewSubscription().ConvertFromSQLCSubscription(sqlcSubscription))
+	}
+
+	return subscriptions, nil
 }
@@ -20,10 +20,12 @@ type Service interface {
 	Delete(ctx context.Context, id string) error
 	Update(ctx context.Context, subscription *models.Subscription) error
 	Cancel(ctx context.Context, id string, cancelAtPeriodEnd bool) error
-	List(ctx context.Context, customerID string, limit, offset uint64) ([]*models.Subscription, error)
+	List(ctx context.Context, params models.ListParams) (*models.Page[*models.Subscription], error)
 	Renew(ctx context.Context, id string) error
 	HandleExpiringSubscriptions(ctx context.Context) error
-	Upsert(ctx context.Context, subscription *models.PartialSubscription) error
+	Upsert(ctx context.Context, subscription *models.PartialSubscription) (bool, error)
+	UpsertBatch(ctx context.Context, subscriptions []*models.PartialSubscription) error
+	ListByIDs(ctx context.Context, ids []string) ([]*models.Subscription, error)
 }
 
 type service struct {
@@ -99,14 +101,14 @@ func (s *service) Cancel(ctx context.Context, id string, cancelAtPeriodEnd bool)
 	})
 }
 
-func (s *service) List(ctx context.Context, customerID string, limit, offset uint64) ([]*models.Subscription, error) {
-	var subscriptions []*models.Subscription
+func (s *service) List(ctx context.Context, params models.ListParams) (*models.Page[*models.Subscription], error) {
+	var page *models.Page[*models.Subscription]
 	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
 		var err error
-		subscriptions, err = s.repo.List(ctx, tx, customerID, limit, offset)
+		page, err = s.repo.List(ctx, tx, params)
 		return err
 	})
-	return subscriptions, err
+	return page, err
 }
 
 func (s *service) Renew(ctx context.Context, id string) error {
@@ -188,8 +190,28 @@ func (s *service) HandleExpiringSubscriptions(ctx context.Context) error {
 	})
 }
 
-func (s *service) Upsert(ctx context.Context, subscription *models.PartialSubscription) error {
+func (s *service) Upsert(ctx context.Context, subscription *models.PartialSubscription) (bool, error) {
+	var applied bool
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		applied, err = s.repo.Upsert(ctx, tx, subscription)
+		return err
+	})
+	return applied, err
+}
+
+func (s *service) UpsertBatch(ctx context.Context, subscriptions []*models.PartialSubscription) error {
 	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
-		return s.repo.Upsert(ctx, tx, subscription)
+		return s.repo.UpsertBatch(ctx, tx, subscriptions)
 	})
 }
+
+func (s *service) ListByIDs(ctx context.Context, ids []string) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		subscriptions, err = s.repo.ListByIDs(ctx, tx, ids)
+		return err
+	})
+	return subscriptions, err
+}
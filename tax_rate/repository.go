@@ -27,8 +27,8 @@ func NewRepository(conn driver.PostgresPool) Repository {
 
 func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, taxRate *models.PartialTaxRate) error {
 	const query = `
-    INSERT INTO tax_rates (id, display_name, description, jurisdiction, percentage, inclusive, active, created_at, updated_at)
-    VALUES (@id, @display_name, @description, @jurisdiction, @percentage, @inclusive, @active, COALESCE(@created_at, NOW()), @updated_at)
+    INSERT INTO tax_rates (id, display_name, description, jurisdiction, percentage, inclusive, active, created_at, updated_at, last_event_at)
+    VALUES (@id, @display_name, @description, @jurisdiction, @percentage, @inclusive, @active, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
     ON CONFLICT (id) DO UPDATE SET
         display_name = COALESCE(@display_name, tax_rates.display_name),
         description = COALESCE(@description, tax_rates.description),
@@ -36,21 +36,26 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, taxRate *models.Part
         percentage = COALESCE(@percentage, tax_rates.percentage),
         inclusive = COALESCE(@inclusive, tax_rates.inclusive),
         active = COALESCE(@active, tax_rates.active),
+        last_event_at = COALESCE(@last_event_at, tax_rates.last_event_at),
         updated_at = @updated_at
     WHERE tax_rates.id = @id
+      AND tax_rates.tenant_id = @tenant_id
+      AND (tax_rates.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR tax_rates.last_event_at <= @last_event_at)
     `
 
 	now := time.Now()
 	args := pgx.NamedArgs{
-		"id":           taxRate.ID,
-		"display_name": taxRate.DisplayName,
-		"description":  taxRate.Description,
-		"jurisdiction": taxRate.Jurisdiction,
-		"percentage":   taxRate.Percentage,
-		"inclusive":    taxRate.Inclusive,
-		"active":       taxRate.Active,
-		"created_at":   taxRate.CreatedAt,
-		"updated_at":   now,
+		"id":            taxRate.ID,
+		"display_name":  taxRate.DisplayName,
+		"description":   taxRate.Description,
+		"jurisdiction":  taxRate.Jurisdiction,
+		"percentage":    taxRate.Percentage,
+		"inclusive":     taxRate.Inclusive,
+		"active":        taxRate.Active,
+		"created_at":    taxRate.CreatedAt,
+		"updated_at":    now,
+		"last_event_at": taxRate.LastEventAt,
+		"tenant_id":     driver.TenantID(ctx),
 	}
 
 	if _, err := tx.Exec(ctx, query, args); err != nil {
@@ -61,5 +66,8 @@ func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, taxRate *models.Part
 }
 
 func (r *repository) Delete(ctx context.Context, tx pgx.Tx, id string) error {
-	return sqlc.New(r.conn).WithTx(tx).DeleteReviews(ctx, id)
+	return sqlc.New(r.conn).WithTx(tx).DeleteReviews(ctx, sqlc.DeleteReviewsParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
 }
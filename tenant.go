@@ -0,0 +1,100 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stripe/stripe-go/v79/client"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/domainerr"
+	"goflare.io/payment/driver"
+)
+
+// headerTenantID 為 NATS 訊息所屬的租戶，consumer 以此切換資料庫與 Stripe 帳號
+const headerTenantID = "Tenant-Id"
+
+// ErrUnknownTenant 表示 ctx 沒有租戶，或租戶不在設定中
+var ErrUnknownTenant = domainerr.New(domainerr.CodeValidation, "unknown tenant")
+
+// tenant 為一個租戶的 Stripe 帳號，每個租戶以自己的 secret key 呼叫 Stripe 並以自己的 endpoint secret 驗證 webhook
+type tenant struct {
+	id              string
+	stripeConfig    config.StripeConfig
+	client          *client.API
+	webhookVerifier *WebhookVerifier
+}
+
+// newTenants 依設定建立所有租戶，ID 為空或重複的設定會被略過
+func newTenants(cfg *config.Config, logger *zap.Logger) map[string]*tenant {
+	tenants := make(map[string]*tenant)
+	for _, tc := range cfg.TenantConfigs() {
+		if tc.ID == "" {
+			logger.Warn("tenant without id in config, skipped")
+			continue
+		}
+		if _, exists := tenants[tc.ID]; exists {
+			logger.Warn("duplicate tenant in config, skipped", zap.String("tenant", tc.ID))
+			continue
+		}
+
+		tenants[tc.ID] = &tenant{
+			id:              tc.ID,
+			stripeConfig:    tc.Stripe,
+			client:          client.New(tc.Stripe.SecretKey, newStripeBackends(tc.Stripe)),
+			webhookVerifier: NewWebhookVerifier(tc.Webhook),
+		}
+	}
+	return tenants
+}
+
+// HasTenant 回傳租戶是否存在於設定中
+func (sp *StripePayment) HasTenant(tenantID string) bool {
+	_, ok := sp.tenants[tenantID]
+	return ok
+}
+
+// TenantIDs 依字母順序回傳所有租戶
+func (sp *StripePayment) TenantIDs() []string {
+	ids := make([]string, 0, len(sp.tenants))
+	for id := range sp.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// tenant 回傳 ctx 所屬的租戶，ctx 沒有租戶時不會退回預設租戶，避免以錯誤的 Stripe 帳號操作
+func (sp *StripePayment) tenant(ctx context.Context) (*tenant, error) {
+	tenantID, ok := driver.TenantFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: no tenant in context", ErrUnknownTenant)
+	}
+
+	t, ok := sp.tenants[tenantID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, tenantID)
+	}
+	return t, nil
+}
+
+// tenantContext 以訊息 header 中的租戶建立處理用的 ctx，加入多租戶之前發佈的訊息沒有 header，屬於預設租戶
+func tenantContext(ctx context.Context, header nats.Header) context.Context {
+	tenantID := header.Get(headerTenantID)
+	if tenantID == "" {
+		tenantID = driver.DefaultTenantID
+	}
+	return driver.WithTenant(ctx, tenantID)
+}
+
+// stripeClient 回傳 ctx 所屬租戶的 Stripe client
+func (sp *StripePayment) stripeClient(ctx context.Context) (*client.API, error) {
+	t, err := sp.tenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.client, nil
+}
@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("goflare.io/payment")
+
+// injectTraceContext 把 ctx 的 trace 寫入 NATS 訊息的 header，consumer 處理事件的 span 會接在發佈的 span 之下
+func injectTraceContext(ctx context.Context, header nats.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// extractTraceContext 從 NATS 訊息的 header 取出發佈端的 trace，沒有 trace 的訊息會開始新的 trace
+func extractTraceContext(ctx context.Context, header nats.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// startPublishSpan 建立發佈 NATS 訊息的 span，應在 injectTraceContext 之前呼叫
+func startPublishSpan(ctx context.Context, subject string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "publish "+subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", subject),
+		),
+	)
+}
+
+// endSpan 記錄錯誤並結束 span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// stripeTransport 為每個 Stripe HTTP 請求建立 span，stripe-go 重試時每次請求各有一個 span
+// 不把 trace header 送給 Stripe
+func stripeTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithPropagators(propagation.NewCompositeTextMapPropagator()),
+		otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+			return "stripe " + req.Method + " " + stripeResource(req.URL.Path)
+		}),
+	)
+}
+
+// stripeResource 回傳路徑中的 API 版本與資源（例如 /v1/payment_intents），不含物件 ID，避免 span 名稱過於分散
+func stripeResource(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return "/" + strings.Join(parts, "/")
+}
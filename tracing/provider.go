@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterMemory = "memory"
+
+	defaultServiceName = "payment"
+)
+
+// Provider 依設定建立 OpenTelemetry 的 TracerProvider 並設為全域，各模組以 otel.Tracer 取得的 tracer 都會使用它
+// 未設定 exporter 時仍設定 W3C trace context 的傳遞，上游的 trace 可以經過本服務繼續往下傳
+type Provider struct {
+	serviceName string
+	provider    *sdktrace.TracerProvider
+	memory      *tracetest.InMemoryExporter
+	logger      *zap.Logger
+}
+
+func NewProvider(cfg *config.Config, logger *zap.Logger) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	serviceName := cfg.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	p := &Provider{serviceName: serviceName, logger: logger}
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Tracing.Exporter {
+	case "":
+		return p, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if cfg.Tracing.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Tracing.Endpoint))
+		}
+		if cfg.Tracing.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		otlp, err := otlptracegrpc.New(context.Background(), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		exporter = otlp
+	case ExporterStdout:
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		exporter = stdout
+	case ExporterMemory:
+		p.memory = tracetest.NewInMemoryExporter()
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Tracing.Exporter)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if ratio := cfg.Tracing.SampleRatio; ratio > 0 && ratio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(ratio)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	}
+	if p.memory != nil {
+		// 同步匯出，span 結束後立即可以在 Spans 中檢查
+		opts = append(opts, sdktrace.WithSyncer(p.memory))
+	} else {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	p.provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(p.provider)
+
+	logger.Info("tracing enabled", zap.String("exporter", cfg.Tracing.Exporter), zap.String("service", serviceName))
+	return p, nil
+}
+
+// ServiceName 回傳 span 所屬的服務名稱
+func (p *Provider) ServiceName() string {
+	return p.serviceName
+}
+
+// NewInMemoryProvider 建立以 memory exporter 記錄所有 span 的 Provider，供測試檢查產生的 span
+func NewInMemoryProvider() *Provider {
+	p, _ := NewProvider(&config.Config{Tracing: config.TracingConfig{Exporter: ExporterMemory}}, zap.NewNop())
+	return p
+}
+
+// Spans 回傳 memory exporter 記錄的 span，其他 exporter 回傳 nil
+func (p *Provider) Spans() tracetest.SpanStubs {
+	if p.memory == nil {
+		return nil
+	}
+	return p.memory.GetSpans()
+}
+
+// Reset 清除 memory exporter 記錄的 span
+func (p *Provider) Reset() {
+	if p.memory != nil {
+		p.memory.Reset()
+	}
+}
+
+// Shutdown 送出尚未匯出的 span 並停止 exporter，應在服務結束前呼叫
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	if err := p.provider.Shutdown(ctx); err != nil {
+		p.logger.Warn("failed to shutdown tracer provider", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stripe/stripe-go/v79"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/tracing"
+)
+
+var (
+	testTracingOnce     sync.Once
+	testTracingProvider *tracing.Provider
+)
+
+// testTracing 回傳整個測試程式共用的 memory Provider
+// 套件的 tracer 只會接到第一個設為全域的 TracerProvider，因此不能每個測試各建一個
+func testTracing(t *testing.T) *tracing.Provider {
+	t.Helper()
+
+	testTracingOnce.Do(func() {
+		testTracingProvider = tracing.NewInMemoryProvider()
+	})
+	testTracingProvider.Reset()
+	return testTracingProvider
+}
+
+// waitForSpan 等待名稱為 name 的 span 結束，worker 在其他 goroutine 結束 span
+func waitForSpan(t *testing.T, provider *tracing.Provider, name string) tracetest.SpanStub {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, span := range provider.Spans() {
+			if span.Name == name {
+				return span
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("span %q not exported, got %v", name, spanNames(provider.Spans()))
+	return tracetest.SpanStub{}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, len(spans))
+	for i, span := range spans {
+		names[i] = span.Name
+	}
+	return names
+}
+
+// tracedProcessor 記錄處理事件時 ctx 中的 span 與租戶
+type tracedProcessor struct {
+	mu      sync.Mutex
+	spans   map[string]trace.SpanContext
+	tenants map[string]string
+	fail    string
+}
+
+func (p *tracedProcessor) ProcessEvent(ctx context.Context, event *stripe.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.spans[event.ID] = trace.SpanContextFromContext(ctx)
+	p.tenants[event.ID], _ = driver.TenantFromContext(ctx)
+	if event.ID == p.fail {
+		return errors.New("handler failed")
+	}
+	return nil
+}
+
+// TestTraceFollowsWebhookToWorker 確認 HTTP 請求、NATS 發佈與 worker 處理事件的 span 屬於同一個 trace
+func TestTraceFollowsWebhookToWorker(t *testing.T) {
+	provider := testTracing(t)
+
+	server := natsserver.RunRandClientPortServer()
+	t.Cleanup(server.Shutdown)
+	nc, err := nats.Connect(server.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect to nats: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	em, err := NewEventManager(nc, config.JetStreamConfig{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewEventManager: %v", err)
+	}
+	processor := &tracedProcessor{spans: map[string]trace.SpanContext{}, tenants: map[string]string{}, fail: "evt_failed"}
+	wp := NewWorkerPool(config.WorkerConfig{Shards: 1}, processor, zap.NewNop())
+	t.Cleanup(wp.Shutdown)
+	if err = em.SubscribeToEvents(wp); err != nil {
+		t.Fatalf("SubscribeToEvents: %v", err)
+	}
+	if err = nc.Flush(); err != nil {
+		t.Fatalf("failed to flush subscription: %v", err)
+	}
+
+	// 與 server.go 相同以 otelecho 建立請求的 span，handler 以請求的 ctx 發佈事件
+	e := echo.New()
+	e.Use(otelecho.Middleware(provider.ServiceName()))
+	e.POST("/webhook/:id", func(c echo.Context) error {
+		ctx := driver.WithTenant(c.Request().Context(), "brand-a")
+		event := &stripe.Event{ID: c.Param("id"), Type: "customer.updated", Data: &stripe.EventData{}}
+		if err := em.PublishEvent(ctx, event); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for i, id := range []string{"evt_ok", "evt_failed"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhook/"+id, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST /webhook/%s = %d", id, rec.Code)
+		}
+		waitForWorkerSpans(t, provider, i+1)
+	}
+
+	var requests, publishes, workers []tracetest.SpanStub
+	for _, span := range provider.Spans() {
+		switch span.Name {
+		case "/webhook/:id":
+			requests = append(requests, span)
+		case "publish stripe.event.customer.updated":
+			publishes = append(publishes, span)
+		case "worker task":
+			workers = append(workers, span)
+		}
+	}
+	if len(requests) != 2 || len(publishes) != 2 || len(workers) != 2 {
+		t.Fatalf("spans = %v", spanNames(provider.Spans()))
+	}
+
+	for i, request := range requests {
+		publish, worker := publishes[i], workers[i]
+		if publish.Parent.SpanID() != request.SpanContext.SpanID() {
+			t.Fatalf("publish span is not a child of the request span")
+		}
+		if publish.SpanKind != trace.SpanKindProducer || worker.SpanKind != trace.SpanKindConsumer {
+			t.Fatalf("span kinds = %s, %s", publish.SpanKind, worker.SpanKind)
+		}
+		// trace 經過 NATS header 傳到 consumer，worker 的 span 接在發佈的 span 之下
+		if worker.SpanContext.TraceID() != request.SpanContext.TraceID() || worker.Parent.SpanID() != publish.SpanContext.SpanID() {
+			t.Fatalf("worker span is not part of the webhook trace")
+		}
+	}
+
+	for id, want := range map[string]codes.Code{"evt_ok": codes.Unset, "evt_failed": codes.Error} {
+		worker := workerSpan(t, workers, id)
+		if worker.Status.Code != want {
+			t.Fatalf("%s: worker span status = %s, want %s", id, worker.Status.Code, want)
+		}
+		// 處理事件的 ctx 帶有 worker 的 span 與發佈時的租戶
+		processor.mu.Lock()
+		spanContext, tenantID := processor.spans[id], processor.tenants[id]
+		processor.mu.Unlock()
+		if spanContext.SpanID() != worker.SpanContext.SpanID() || tenantID != "brand-a" {
+			t.Fatalf("%s: processed in span %s tenant %q", id, spanContext.SpanID(), tenantID)
+		}
+	}
+}
+
+// waitForWorkerSpans 等待 n 個 worker task 的 span 結束，依序送出的事件依序產生 span
+func waitForWorkerSpans(t *testing.T, provider *tracing.Provider, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var count int
+		for _, span := range provider.Spans() {
+			if span.Name == "worker task" {
+				count++
+			}
+		}
+		if count >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("worker span not exported, got %v", spanNames(provider.Spans()))
+}
+
+func workerSpan(t *testing.T, workers []tracetest.SpanStub, eventID string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, worker := range workers {
+		for _, attr := range worker.Attributes {
+			if attr.Key == "stripe.event_id" && attr.Value.AsString() == eventID {
+				return worker
+			}
+		}
+	}
+	t.Fatalf("no worker span for %s", eventID)
+	return tracetest.SpanStub{}
+}
+
+// TestStripeCallsAreTraced 確認每個 Stripe 請求都有接在呼叫端之下的 span，且 trace header 不會送給 Stripe
+func TestStripeCallsAreTraced(t *testing.T) {
+	provider := testTracing(t)
+
+	var (
+		mu          sync.Mutex
+		traceparent []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceparent = append(traceparent, r.Header.Get("traceparent"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","url":"` + r.URL.Path + `","has_more":false,"data":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	sp := newReconcileTestPayment(server.URL, &fakeCustomers{local: map[string]*models.Customer{}}, &fakeSubscriptions{})
+	ctx, root := tracer.Start(driver.WithTenant(context.Background(), driver.DefaultTenantID), "reconcile")
+	_, err := sp.Reconcile(ctx, ReconcileOptions{Resources: []ReconcileResource{ReconcileCustomers, ReconcileSubscriptions}})
+	root.End()
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	for _, name := range []string{"stripe GET /v1/customers", "stripe GET /v1/subscriptions"} {
+		span := waitForSpan(t, provider, name)
+		if span.Parent.SpanID() != root.SpanContext().SpanID() || span.SpanKind != trace.SpanKindClient {
+			t.Fatalf("%s: parent %s kind %s", name, span.Parent.SpanID(), span.SpanKind)
+		}
+	}
+	for _, header := range traceparent {
+		if strings.TrimSpace(header) != "" {
+			t.Fatalf("trace context was sent to Stripe: %q", header)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+	"goflare.io/payment/sqlc"
+)
+
+type Repository interface {
+	GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Transfer, error)
+	Upsert(ctx context.Context, tx pgx.Tx, transfer *models.PartialTransfer) error
+}
+
+type repository struct {
+	conn driver.PostgresPool
+}
+
+func NewRepository(conn driver.PostgresPool) Repository {
+	return &repository{conn: conn}
+}
+
+func (r *repository) GetByID(ctx context.Context, tx pgx.Tx, id string) (*models.Transfer, error) {
+	sqlcTransfer, err := sqlc.New(r.conn).WithTx(tx).GetTransfer(ctx, sqlc.GetTransferParams{
+		ID:       id,
+		TenantID: driver.TenantID(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer: %w", err)
+	}
+
+	return models.NewTransfer().ConvertFromSQLCTransfer(sqlcTransfer), nil
+}
+
+func (r *repository) Upsert(ctx context.Context, tx pgx.Tx, transfer *models.PartialTransfer) error {
+	const query = `
+    INSERT INTO transfers (id, destination_account_id, amount, amount_reversed, currency, reversed, source_transaction_id, transfer_group, created_at, updated_at, last_event_at)
+    VALUES (@id, @destination_account_id, @amount, COALESCE(@amount_reversed, 0), @currency, COALESCE(@reversed, FALSE), @source_transaction_id, @transfer_group, COALESCE(@created_at, NOW()), @updated_at, @last_event_at)
+    ON CONFLICT (id) DO UPDATE SET
+        destination_account_id = COALESCE(@destination_account_id, transfers.destination_account_id),
+        amount = COALESCE(@amount, transfers.amount),
+        amount_reversed = COALESCE(@amount_reversed, transfers.amount_reversed),
+        currency = COALESCE(@currency, transfers.currency),
+        reversed = COALESCE(@reversed, transfers.reversed),
+        source_transaction_id = COALESCE(@source_transaction_id, transfers.source_transaction_id),
+        transfer_group = COALESCE(@transfer_group, transfers.transfer_group),
+        last_event_at = COALESCE(@last_event_at, transfers.last_event_at),
+        updated_at = @updated_at
+    WHERE transfers.id = @id
+      AND transfers.tenant_id = @tenant_id
+      AND (transfers.last_event_at IS NULL OR @last_event_at::timestamptz IS NULL OR transfers.last_event_at <= @last_event_at)
+    `
+
+	now := time.Now()
+	args := pgx.NamedArgs{
+		"id":                     transfer.ID,
+		"destination_account_id": transfer.DestinationAccountID,
+		"amount":                 transfer.Amount,
+		"amount_reversed":        transfer.AmountReversed,
+		"currency":               transfer.Currency,
+		"reversed":               transfer.Reversed,
+		"source_transaction_id":  transfer.SourceTransactionID,
+		"transfer_group":         transfer.TransferGroup,
+		"created_at":             transfer.CreatedAt,
+		"updated_at":             now,
+		"last_event_at":          transfer.LastEventAt,
+		"tenant_id":              driver.TenantID(ctx),
+	}
+
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("failed to upsert transfer: %w", err)
+	}
+
+	return nil
+}
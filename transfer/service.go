@@ -0,0 +1,43 @@
+package transfer
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"goflare.io/payment/driver"
+	"goflare.io/payment/models"
+)
+
+type Service interface {
+	GetByID(ctx context.Context, id string) (*models.Transfer, error)
+	Upsert(ctx context.Context, transfer *models.PartialTransfer) error
+}
+
+type service struct {
+	repo               Repository
+	transactionManager *driver.TransactionManager
+}
+
+func NewService(repo Repository, tm *driver.TransactionManager) Service {
+	return &service{
+		repo:               repo,
+		transactionManager: tm,
+	}
+}
+
+func (s *service) GetByID(ctx context.Context, id string) (*models.Transfer, error) {
+	var transfer *models.Transfer
+	err := s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		var err error
+		transfer, err = s.repo.GetByID(ctx, tx, id)
+		return err
+	})
+	return transfer, err
+}
+
+func (s *service) Upsert(ctx context.Context, transfer *models.PartialTransfer) error {
+	return s.transactionManager.ExecuteTransaction(ctx, func(tx pgx.Tx) error {
+		return s.repo.Upsert(ctx, tx, transfer)
+	})
+}
@@ -0,0 +1,71 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"github.com/stripe/stripe-go/v79/webhook"
+
+	"goflare.io/payment/config"
+)
+
+// WebhookSignatureError 表示 webhook 簽章驗證失敗，呼叫端應回覆 4xx 且 Stripe 不應重試
+type WebhookSignatureError struct {
+	Err error
+}
+
+func (e *WebhookSignatureError) Error() string {
+	return fmt.Sprintf("invalid webhook signature: %v", e.Err)
+}
+
+func (e *WebhookSignatureError) Unwrap() error {
+	return e.Err
+}
+
+// WebhookVerifier 以設定中的所有 endpoint secret 驗證 webhook，任一 secret 通過即可，輪替 secret 時不需停機
+type WebhookVerifier struct {
+	secrets   []string
+	tolerance time.Duration
+}
+
+func NewWebhookVerifier(cfg config.WebhookConfig) *WebhookVerifier {
+	tolerance := cfg.Tolerance
+	if tolerance <= 0 {
+		tolerance = webhook.DefaultTolerance
+	}
+
+	return &WebhookVerifier{
+		secrets:   cfg.Secrets,
+		tolerance: tolerance,
+	}
+}
+
+// ConstructEvent validates the Stripe-Signature header and parses the payload into a stripe.Event.
+// Signature problems are returned as *WebhookSignatureError; any other error is unexpected.
+func (v *WebhookVerifier) ConstructEvent(payload []byte, signature string) (stripe.Event, error) {
+	if len(v.secrets) == 0 {
+		return stripe.Event{}, errors.New("no webhook secrets configured")
+	}
+
+	for _, secret := range v.secrets {
+		err := webhook.ValidatePayloadWithTolerance(payload, signature, secret, v.tolerance)
+		switch {
+		case err == nil:
+			return webhook.ConstructEventWithOptions(payload, signature, secret, webhook.ConstructEventOptions{
+				Tolerance: v.tolerance,
+				// 簽章已驗證，事件的 API 版本與 stripe-go 不同時仍照常處理，避免 Stripe 帳戶升級版本後所有 webhook 被拒絕
+				IgnoreAPIVersionMismatch: true,
+			})
+		case errors.Is(err, webhook.ErrNoValidSignature):
+			// 此 secret 不符，嘗試下一組
+			continue
+		default:
+			// header 格式錯誤或時間戳超出容許範圍，與 secret 無關
+			return stripe.Event{}, &WebhookSignatureError{Err: err}
+		}
+	}
+
+	return stripe.Event{}, &WebhookSignatureError{Err: webhook.ErrNoValidSignature}
+}
@@ -0,0 +1,344 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+	"github.com/stripe/stripe-go/v79"
+	"github.com/stripe/stripe-go/v79/webhook"
+	"go.uber.org/zap"
+
+	"goflare.io/ember"
+	emberConfig "goflare.io/ember/config"
+	"goflare.io/ignite"
+	"goflare.io/payment/config"
+	"goflare.io/payment/driver"
+	"goflare.io/payment/event"
+	"goflare.io/payment/models"
+	"goflare.io/payment/outbox"
+	"goflare.io/payment/pgtest"
+	"goflare.io/payment/subscription"
+)
+
+// loadWebhookFixture 讀取 testdata/webhooks 中以 Stripe CLI 格式保存的事件
+func loadWebhookFixture(t *testing.T, name string) *stripe.Event {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "webhooks", name+".json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	stripeEvent := new(stripe.Event)
+	if err = json.Unmarshal(raw, stripeEvent); err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", name, err)
+	}
+	return stripeEvent
+}
+
+func TestSubscriptionFixturesCarryEventTime(t *testing.T) {
+	deleted := loadWebhookFixture(t, "customer.subscription.deleted")
+	stale := loadWebhookFixture(t, "customer.subscription.updated.stale")
+
+	// stale 在 deleted 之前產生，但在它之後送達
+	if stale.Created >= deleted.Created {
+		t.Fatalf("stale fixture created at %d, want before %d", stale.Created, deleted.Created)
+	}
+
+	for _, stripeEvent := range []*stripe.Event{deleted, stale} {
+		var sub stripe.Subscription
+		if err := json.Unmarshal(stripeEvent.Data.Raw, &sub); err != nil {
+			t.Fatalf("failed to parse subscription: %v", err)
+		}
+
+		partial := partialSubscriptionFromStripe(&sub, eventCreatedAt(stripeEvent))
+		if partial.LastEventAt == nil || partial.LastEventAt.Unix() != stripeEvent.Created {
+			t.Fatalf("%s: last_event_at = %v, want %d", stripeEvent.ID, partial.LastEventAt, stripeEvent.Created)
+		}
+		if partial.PriceID == nil || *partial.PriceID != "price_1PxOrdering0001" {
+			t.Fatalf("%s: price_id = %v", stripeEvent.ID, partial.PriceID)
+		}
+	}
+}
+
+// TestStaleSubscriptionUpdateDoesNotReviveCanceled 依序重播 created、deleted 與較晚送達的舊 updated 事件，訂閱應維持 canceled
+func TestStaleSubscriptionUpdateDoesNotReviveCanceled(t *testing.T) {
+	pool := pgtest.Migrate(t)
+	ctx := driver.WithTenant(context.Background(), driver.DefaultTenantID)
+
+	seed := []string{
+		"INSERT INTO customers (id, user_email) VALUES ('cus_QpOrdering0001', 'ordering@example.com')",
+		"INSERT INTO products (id, name) VALUES ('prod_QpOrdering0001', 'Ordering')",
+		"INSERT INTO prices (id, product_id, type, currency, unit_amount, recurring_interval) VALUES ('price_1PxOrdering0001', 'prod_QpOrdering0001', 'recurring', 'usd', 1999, 'month')",
+	}
+	for _, sql := range seed {
+		if _, err := pool.Exec(ctx, sql); err != nil {
+			t.Fatalf("failed to seed: %v", err)
+		}
+	}
+
+	sp := newWebhookTestPayment(t, pool)
+	for _, name := range []string{"customer.subscription.created", "customer.subscription.deleted", "customer.subscription.updated.stale"} {
+		if err := sp.handleSubscriptionEvent(ctx, loadWebhookFixture(t, name)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	var (
+		status      string
+		canceledAt  *time.Time
+		lastEventAt time.Time
+	)
+	err := pool.QueryRow(ctx, "SELECT status, canceled_at, last_event_at FROM subscriptions WHERE id = 'sub_1PxOrdering0001'").
+		Scan(&status, &canceledAt, &lastEventAt)
+	if err != nil {
+		t.Fatalf("failed to read subscription: %v", err)
+	}
+	if status != string(stripe.SubscriptionStatusCanceled) {
+		t.Fatalf("status = %s, want canceled", status)
+	}
+	if canceledAt == nil {
+		t.Fatal("canceled_at was cleared")
+	}
+	if lastEventAt.Unix() != 1726000200 {
+		t.Fatalf("last_event_at = %v, want the deleted event", lastEventAt)
+	}
+
+	// 被略過的事件不發佈領域事件，outbox 只有 created 與 canceled
+	var subjects []string
+	rows, err := pool.Query(ctx, "SELECT subject FROM outbox_messages ORDER BY created_at, id")
+	if err != nil {
+		t.Fatalf("failed to read outbox: %v", err)
+	}
+	for rows.Next() {
+		var subject string
+		if err = rows.Scan(&subject); err != nil {
+			t.Fatalf("failed to scan outbox: %v", err)
+		}
+		subjects = append(subjects, subject)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to read outbox: %v", err)
+	}
+	if len(subjects) != 2 {
+		t.Fatalf("outbox subjects = %v, want created and canceled only", subjects)
+	}
+}
+
+// newWebhookTestPayment 建立只有訂閱與 outbox 的 StripePayment，緩存使用 miniredis
+func newWebhookTestPayment(t *testing.T, pool driver.PostgresPool) *StripePayment {
+	t.Helper()
+
+	logger := zap.NewNop()
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() {
+		_ = rdb.Close()
+	})
+
+	cacheConfig := emberConfig.NewConfig()
+	cache, err := ember.NewMultiCache(context.Background(), &cacheConfig, rdb)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	repo, err := subscription.NewRepository(pool, logger, cache, ignite.NewManager())
+	if err != nil {
+		t.Fatalf("failed to create subscription repository: %v", err)
+	}
+
+	tm := driver.NewTransactionManager(pool, logger)
+	return &StripePayment{
+		logger:             logger,
+		transactionManager: tm,
+		outbox:             outbox.NewService(outbox.NewRepository(pool), tm),
+		subscription:       subscription.NewService(repo, tm, logger),
+	}
+}
+
+// fakeEventRepository 以 map 保存事件，找不到時與 sqlc 相同回傳 pgx.ErrNoRows
+type fakeEventRepository struct {
+	event.Repository
+
+	mu     sync.Mutex
+	events map[string]*models.Event
+	err    error
+}
+
+func (r *fakeEventRepository) Create(_ context.Context, e *models.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[e.ID] = e
+	return nil
+}
+
+func (r *fakeEventRepository) GetByID(_ context.Context, id string) (*models.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	e, ok := r.events[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return e, nil
+}
+
+func (r *fakeEventRepository) MarkAsProcessed(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[id].Processed = true
+	return nil
+}
+
+const testWebhookSecret = "whsec_test_current"
+
+// newWebhookHandlerTestPayment 建立以 testWebhookSecret 驗證 webhook 並發佈到內嵌 NATS 的 StripePayment，回傳收到發佈訊息的 channel
+func newWebhookHandlerTestPayment(t *testing.T, repo *fakeEventRepository) (*StripePayment, <-chan *nats.Msg) {
+	t.Helper()
+
+	server := natsserver.RunRandClientPortServer()
+	t.Cleanup(server.Shutdown)
+	nc, err := nats.Connect(server.ClientURL())
+	if err != nil {
+		t.Fatalf("failed to connect to nats: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	published := make(chan *nats.Msg, 8)
+	if _, err = nc.ChanSubscribe(eventSubjectPrefix+".>", published); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if err = nc.Flush(); err != nil {
+		t.Fatalf("failed to flush subscription: %v", err)
+	}
+
+	em, err := NewEventManager(nc, config.JetStreamConfig{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewEventManager: %v", err)
+	}
+
+	cfg := &config.Config{Webhook: config.WebhookConfig{Secrets: []string{testWebhookSecret}}}
+	return &StripePayment{
+		tenants:      newTenants(cfg, zap.NewNop()),
+		eventManager: em,
+		logger:       zap.NewNop(),
+		event:        event.NewService(repo),
+	}, published
+}
+
+func signWebhook(payload []byte, secret string, timestamp time.Time) string {
+	return webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   payload,
+		Secret:    secret,
+		Timestamp: timestamp,
+	}).Header
+}
+
+// TestHandleStripeWebhookStoresNewEventAndSkipsProcessed 確認第一次送達的事件會保存並發佈，已處理的事件重送時略過
+func TestHandleStripeWebhookStoresNewEventAndSkipsProcessed(t *testing.T) {
+	repo := &fakeEventRepository{events: map[string]*models.Event{}}
+	sp, published := newWebhookHandlerTestPayment(t, repo)
+	ctx := driver.WithTenant(context.Background(), driver.DefaultTenantID)
+
+	payload, err := os.ReadFile(filepath.Join("testdata", "webhooks", "customer.subscription.created.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	if err = sp.HandleStripeWebhook(ctx, payload, signWebhook(payload, testWebhookSecret, time.Now())); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	if _, ok := repo.events["evt_1PxCreated00001"]; !ok {
+		t.Fatal("first delivery was not stored")
+	}
+	select {
+	case msg := <-published:
+		if msg.Subject != eventSubjectPrefix+".customer.subscription.created" {
+			t.Fatalf("published to %s", msg.Subject)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first delivery was not published")
+	}
+
+	if err = repo.MarkAsProcessed(ctx, "evt_1PxCreated00001"); err != nil {
+		t.Fatalf("MarkAsProcessed: %v", err)
+	}
+	if err = sp.HandleStripeWebhook(ctx, payload, signWebhook(payload, testWebhookSecret, time.Now())); err != nil {
+		t.Fatalf("repeated delivery: %v", err)
+	}
+	select {
+	case msg := <-published:
+		t.Fatalf("processed event was published again to %s", msg.Subject)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// 其他查詢錯誤仍回傳給 Stripe 稍後重送，不能當作未處理
+	repo.err = errors.New("connection reset")
+	if err = sp.HandleStripeWebhook(ctx, payload, signWebhook(payload, testWebhookSecret, time.Now())); err == nil {
+		t.Fatal("expected lookup error")
+	}
+}
+
+// TestWebhookVerifierRotatesSecrets 確認輪替期間新舊 secret 的簽章都會通過，過期與未知 secret 的簽章以 WebhookSignatureError 拒絕
+func TestWebhookVerifierRotatesSecrets(t *testing.T) {
+	const previousSecret = "whsec_test_previous"
+	verifier := NewWebhookVerifier(config.WebhookConfig{Secrets: []string{testWebhookSecret, previousSecret}})
+
+	payload, err := os.ReadFile(filepath.Join("testdata", "webhooks", "customer.subscription.created.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   error
+	}{
+		{name: "current secret", signature: signWebhook(payload, testWebhookSecret, time.Now())},
+		{name: "previous secret", signature: signWebhook(payload, previousSecret, time.Now())},
+		{name: "expired timestamp", signature: signWebhook(payload, testWebhookSecret, time.Now().Add(-webhook.DefaultTolerance-time.Minute)), wantErr: webhook.ErrTooOld},
+		{name: "unknown secret", signature: signWebhook(payload, "whsec_test_unknown", time.Now()), wantErr: webhook.ErrNoValidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripeEvent, err := verifier.ConstructEvent(payload, tt.signature)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ConstructEvent: %v", err)
+				}
+				if stripeEvent.ID != "evt_1PxCreated00001" {
+					t.Fatalf("event id = %s", stripeEvent.ID)
+				}
+				return
+			}
+
+			var signatureErr *WebhookSignatureError
+			if !errors.As(err, &signatureErr) {
+				t.Fatalf("err = %v, want *WebhookSignatureError", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 未設定 secret 是設定錯誤而不是簽章錯誤
+	_, err = NewWebhookVerifier(config.WebhookConfig{}).ConstructEvent(payload, signWebhook(payload, testWebhookSecret, time.Now()))
+	var signatureErr *WebhookSignatureError
+	if err == nil || errors.As(err, &signatureErr) {
+		t.Fatalf("err without secrets = %v, want a configuration error", err)
+	}
+}
@@ -2,67 +2,213 @@ package payment
 
 import (
 	"context"
-	"github.com/stripe/stripe-go/v79"
+	"errors"
+	"hash/fnv"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/stripe/stripe-go/v79"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+	"goflare.io/payment/metrics"
+)
+
+const (
+	defaultWorkerShards   = 32
+	defaultShardQueueSize = 256
 )
 
+// ErrWorkerPoolClosed 表示 worker pool 已關閉，不再接受新的事件
+var ErrWorkerPoolClosed = errors.New("worker pool is closed")
+
 // EventProcessor 定義了處理事件的接口
 type EventProcessor interface {
 	ProcessEvent(ctx context.Context, event *stripe.Event) error
 }
 
-// WorkerPool 管理一組 worker 來處理事件
+// WorkerPool 管理一組 shard 來處理事件
+// 事件依 Data.Object 的 id 固定分派到同一個 shard，同一物件的事件依序處理，不同物件之間仍可並行
 type WorkerPool struct {
-	workers   chan struct{}
-	tasks     chan func()
+	shards    []*shard
 	wg        sync.WaitGroup
 	logger    *zap.Logger
 	processor EventProcessor
+
+	// done 關閉後不再接受提交，submitting 追蹤仍在送入佇列的呼叫，全部返回後才能關閉 shard 的佇列
+	mu         sync.RWMutex
+	closed     bool
+	done       chan struct{}
+	submitting sync.WaitGroup
 }
 
-// NewWorkerPool 創建一個新的 WorkerPool
-func NewWorkerPool(size int, processor EventProcessor, logger *zap.Logger) *WorkerPool {
+// shard 以單一 goroutine 依序執行有界佇列中的任務
+type shard struct {
+	tasks     chan func()
+	busy      atomic.Bool
+	processed atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// ShardStats 為單一 shard 的佇列狀態
+type ShardStats struct {
+	Shard     int    `json:"shard"`
+	Depth     int    `json:"depth"`
+	Capacity  int    `json:"capacity"`
+	Busy      bool   `json:"busy"`
+	Processed uint64 `json:"processed"`
+	Failed    uint64 `json:"failed"`
+}
+
+// NewWorkerPool 創建一個新的 WorkerPool，未設定的欄位使用預設值
+func NewWorkerPool(cfg config.WorkerConfig, processor EventProcessor, logger *zap.Logger) *WorkerPool {
+	if cfg.Shards <= 0 {
+		cfg.Shards = defaultWorkerShards
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultShardQueueSize
+	}
+
 	wp := &WorkerPool{
-		workers:   make(chan struct{}, size),
-		tasks:     make(chan func(), 1000), // 緩衝區大小可配置
+		shards:    make([]*shard, cfg.Shards),
 		logger:    logger,
 		processor: processor,
+		done:      make(chan struct{}),
 	}
 
-	for i := 0; i < size; i++ {
+	for i := range wp.shards {
+		wp.shards[i] = &shard{tasks: make(chan func(), cfg.QueueSize)}
 		wp.wg.Add(1)
-		go wp.worker()
+		go wp.worker(wp.shards[i])
 	}
 
 	return wp
 }
 
-// worker 是處理任務的 goroutine
-func (wp *WorkerPool) worker() {
+// worker 是處理單一 shard 任務的 goroutine
+func (wp *WorkerPool) worker(s *shard) {
 	defer wp.wg.Done()
-	for task := range wp.tasks {
-		wp.workers <- struct{}{}
+	for task := range s.tasks {
 		task()
-		<-wp.workers
 	}
 }
 
 // Submit 提交一個事件到 worker pool 進行處理
-func (wp *WorkerPool) Submit(ctx context.Context, event *stripe.Event) {
-	wp.tasks <- func() {
-		if err := wp.processor.ProcessEvent(ctx, event); err != nil {
+func (wp *WorkerPool) Submit(ctx context.Context, event *stripe.Event) error {
+	return wp.SubmitWithCallback(ctx, event, nil)
+}
+
+// SubmitWithCallback 提交事件並在處理完成後以處理結果呼叫 done，用於決定 ack 或重送
+// shard 佇列已滿時會阻塞直到有空間或 ctx 結束，藉此對上游形成背壓；pool 關閉後回傳 ErrWorkerPoolClosed
+func (wp *WorkerPool) SubmitWithCallback(ctx context.Context, event *stripe.Event, done func(error)) error {
+	wp.mu.RLock()
+	if wp.closed {
+		wp.mu.RUnlock()
+		return ErrWorkerPoolClosed
+	}
+	wp.submitting.Add(1)
+	wp.mu.RUnlock()
+	defer wp.submitting.Done()
+
+	index := wp.shardIndex(event)
+	s := wp.shards[index]
+	submitted := time.Now()
+
+	task := func() {
+		metrics.WorkerQueueDepth.WithLabelValues(strconv.Itoa(index)).Set(float64(len(s.tasks)))
+		metrics.WorkersBusy.Inc()
+		s.busy.Store(true)
+		defer func() {
+			s.busy.Store(false)
+			metrics.WorkersBusy.Dec()
+		}()
+
+		// span 從排入佇列開始計算，queue_wait 為等待 shard 空出的時間
+		ctx, span := tracer.Start(ctx, "worker task",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithTimestamp(submitted),
+			trace.WithAttributes(
+				attribute.Int("worker.shard", index),
+				attribute.Int64("worker.queue_wait_ms", time.Since(submitted).Milliseconds()),
+				attribute.String("stripe.event_id", event.ID),
+			),
+		)
+		err := wp.processor.ProcessEvent(ctx, event)
+		endSpan(span, err)
+		if err != nil {
+			s.failed.Add(1)
 			wp.logger.Error("Failed to process event",
 				zap.Error(err),
 				zap.String("event_type", string(event.Type)),
 				zap.String("event_id", event.ID))
+		} else {
+			s.processed.Add(1)
+		}
+		if done != nil {
+			done(err)
+		}
+	}
+
+	select {
+	case s.tasks <- task:
+		metrics.WorkerQueueDepth.WithLabelValues(strconv.Itoa(index)).Set(float64(len(s.tasks)))
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-wp.done:
+		return ErrWorkerPoolClosed
+	}
+}
+
+// shardIndex 以事件物件的 id 計算 shard，沒有物件 id 時退回使用事件 id
+func (wp *WorkerPool) shardIndex(event *stripe.Event) int {
+	key := event.ID
+	if event.Data != nil {
+		if id, ok := event.Data.Object["id"].(string); ok && id != "" {
+			key = id
 		}
 	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(wp.shards)))
 }
 
-// Shutdown 關閉 worker pool
+// Stats 回傳每個 shard 目前的佇列深度與處理數量
+func (wp *WorkerPool) Stats() []ShardStats {
+	stats := make([]ShardStats, len(wp.shards))
+	for i, s := range wp.shards {
+		stats[i] = ShardStats{
+			Shard:     i,
+			Depth:     len(s.tasks),
+			Capacity:  cap(s.tasks),
+			Busy:      s.busy.Load(),
+			Processed: s.processed.Load(),
+			Failed:    s.failed.Load(),
+		}
+	}
+	return stats
+}
+
+// Shutdown 關閉 worker pool，等待佇列中的事件處理完畢
+// 先拒絕新的提交並等候阻塞中的提交返回，才關閉佇列，避免對已關閉的 channel 送值
 func (wp *WorkerPool) Shutdown() {
-	close(wp.tasks)
+	wp.mu.Lock()
+	if wp.closed {
+		wp.mu.Unlock()
+		return
+	}
+	wp.closed = true
+	close(wp.done)
+	wp.mu.Unlock()
+
+	wp.submitting.Wait()
+	for _, s := range wp.shards {
+		close(s.tasks)
+	}
 	wp.wg.Wait()
 }
@@ -0,0 +1,80 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+	"go.uber.org/zap"
+
+	"goflare.io/payment/config"
+)
+
+// blockingProcessor 在 release 關閉前阻塞，讓佇列維持在滿的狀態
+type blockingProcessor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProcessor) ProcessEvent(context.Context, *stripe.Event) error {
+	p.started <- struct{}{}
+	<-p.release
+	return nil
+}
+
+// TestShutdownWithBlockedSubmitter 確認佇列已滿時關閉 pool，阻塞中的提交回傳 ErrWorkerPoolClosed 而不是對已關閉的佇列送值
+func TestShutdownWithBlockedSubmitter(t *testing.T) {
+	processor := &blockingProcessor{started: make(chan struct{}, 4), release: make(chan struct{})}
+	wp := NewWorkerPool(config.WorkerConfig{Shards: 1, QueueSize: 1}, processor, zap.NewNop())
+	ctx := context.Background()
+
+	processed := make(chan error, 2)
+	callback := func(err error) { processed <- err }
+
+	// 第一個事件佔住 worker，第二個事件填滿佇列
+	if err := wp.SubmitWithCallback(ctx, &stripe.Event{ID: "evt_running"}, callback); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-processor.started
+	if err := wp.SubmitWithCallback(ctx, &stripe.Event{ID: "evt_queued"}, callback); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- wp.Submit(ctx, &stripe.Event{ID: "evt_blocked"})
+	}()
+
+	shutdown := make(chan struct{})
+	go func() {
+		wp.Shutdown()
+		close(shutdown)
+	}()
+
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, ErrWorkerPoolClosed) {
+			t.Fatalf("blocked Submit = %v, want ErrWorkerPoolClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked Submit did not return after Shutdown")
+	}
+
+	// 已排入佇列的事件仍會處理完畢
+	close(processor.release)
+	select {
+	case <-shutdown:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown did not wait for queued events")
+	}
+	if len(processed) != 2 {
+		t.Fatalf("processed %d events, want 2", len(processed))
+	}
+
+	if err := wp.Submit(ctx, &stripe.Event{ID: "evt_late"}); !errors.Is(err, ErrWorkerPoolClosed) {
+		t.Fatalf("Submit after Shutdown = %v, want ErrWorkerPoolClosed", err)
+	}
+	wp.Shutdown()
+}